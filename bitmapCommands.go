@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"gopherkv/data"
+	"strconv"
+	"strings"
+)
+
+const (
+	bitcountUsage = "bitcount \"key\" [start end [byte|bit]]"
+	bitposUsage   = "bitpos \"key\" 0|1 [start [end [byte|bit]]]"
+	bitopUsage    = "bitop and|or|xor|not \"destkey\" \"key\" [\"key\" ...]"
+	bitfieldUsage = "bitfield \"key\" [get type offset] [set type offset value] [incrby type offset increment] [overflow wrap|sat|fail] ..."
+)
+
+// execBitMapCommand 执行位图相关命令
+// @param fields []string 拆分后的命令
+// @return bool 是否为位图命令
+func execBitMapCommand(fields []string) bool {
+	switch strings.ToLower(fields[0]) {
+	case "setbit":
+		if len(fields) != 4 {
+			usageError("setbit \"key\" offset 0|1")
+			return true
+		}
+		offset, err := data.ParseBitOffset(fields[2])
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		if fields[3] != "0" && fields[3] != "1" {
+			usageError("setbit \"key\" offset 0|1")
+			return true
+		}
+		if err := data.DataGkvBitMap.SetBit(fields[1], offset, fields[3] == "1"); err != nil {
+			fmt.Println(err)
+			return true
+		}
+		fmt.Println("OK")
+	case "getbit":
+		if len(fields) != 3 {
+			usageError("getbit \"key\" offset")
+			return true
+		}
+		offset, err := data.ParseBitOffset(fields[2])
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		fmt.Println(boolToInt(data.DataGkvBitMap.GetBit(fields[1], offset)))
+	case "bitcount":
+		if len(fields) != 2 && len(fields) != 4 && len(fields) != 5 {
+			usageError(bitcountUsage)
+			return true
+		}
+		r, ok := parseBitRange(fields[2:])
+		if !ok {
+			usageError(bitcountUsage)
+			return true
+		}
+		fmt.Println(data.DataGkvBitMap.BitCount(fields[1], r))
+	case "bitpos":
+		if len(fields) < 3 || len(fields) > 6 || (fields[2] != "0" && fields[2] != "1") {
+			usageError(bitposUsage)
+			return true
+		}
+		r, ok := parseBitRange(fields[3:])
+		if !ok {
+			usageError(bitposUsage)
+			return true
+		}
+		fmt.Println(data.DataGkvBitMap.BitPos(fields[1], fields[2] == "1", r))
+	case "bitop":
+		if len(fields) < 4 {
+			usageError(bitopUsage)
+			return true
+		}
+		var op data.BitOperation
+		switch strings.ToLower(fields[1]) {
+		case "and":
+			op = data.BitAnd
+		case "or":
+			op = data.BitOr
+		case "xor":
+			op = data.BitXor
+		case "not":
+			op = data.BitNot
+		default:
+			usageError(bitopUsage)
+			return true
+		}
+		n, err := data.DataGkvBitMap.BitOp(op, fields[2], fields[3:]...)
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		fmt.Println(n)
+	case "bitfield", "bitfield_ro":
+		name := strings.ToLower(fields[0])
+		if len(fields) < 2 {
+			usageError(bitfieldUsage)
+			return true
+		}
+		ops, err := parseBitFieldOps(fields[2:])
+		if err != nil {
+			fmt.Println(err)
+			usageError(bitfieldUsage)
+			return true
+		}
+		if name == "bitfield_ro" {
+			results, err := data.DataGkvBitMap.BitFieldRO(fields[1], ops)
+			if err != nil {
+				fmt.Println(err)
+				return true
+			}
+			for _, v := range results {
+				fmt.Println(v)
+			}
+			return true
+		}
+		results, valid := data.DataGkvBitMap.BitField(fields[1], ops)
+		for i, v := range results {
+			if valid[i] {
+				fmt.Println(v)
+			} else {
+				fmt.Println("(nil)")
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// parseBitRange 解析 [start [end [byte|bit]]] 形式的区间
+// @param args []string
+// @return *data.BitRange 未指定区间时为nil
+// @return bool 参数是否合法
+func parseBitRange(args []string) (*data.BitRange, bool) {
+	if len(args) == 0 {
+		return nil, true
+	}
+	r := &data.BitRange{}
+	var err error
+	if r.Start, err = strconv.Atoi(args[0]); err != nil {
+		return nil, false
+	}
+	if len(args) > 1 {
+		if r.End, err = strconv.Atoi(args[1]); err != nil {
+			return nil, false
+		}
+		r.HasEnd = true
+	}
+	if len(args) > 2 {
+		switch strings.ToLower(args[2]) {
+		case "byte":
+		case "bit":
+			r.Bit = true
+		default:
+			return nil, false
+		}
+	}
+	return r, true
+}
+
+// parseBitFieldOps 解析位域子命令列表, overflow 作用于其后的 set 与 incrby
+// @param args []string key 之后的参数
+// @return []data.BitFieldOp
+// @return error
+func parseBitFieldOps(args []string) ([]data.BitFieldOp, error) {
+	ops := []data.BitFieldOp{}
+	overflow := data.BitFieldWrap
+	for i := 0; i < len(args); {
+		sub := strings.ToLower(args[i])
+		if sub == "overflow" {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("overflow 需要指定 wrap|sat|fail")
+			}
+			switch strings.ToLower(args[i+1]) {
+			case "wrap":
+				overflow = data.BitFieldWrap
+			case "sat":
+				overflow = data.BitFieldSat
+			case "fail":
+				overflow = data.BitFieldFail
+			default:
+				return nil, fmt.Errorf("未知的溢出处理方式: %s", args[i+1])
+			}
+			i += 2
+			continue
+		}
+		op := data.BitFieldOp{Overflow: overflow}
+		argc := 3
+		switch sub {
+		case "get":
+			op.Kind = data.BitFieldGet
+		case "set":
+			op.Kind, argc = data.BitFieldSet, 4
+		case "incrby":
+			op.Kind, argc = data.BitFieldIncrBy, 4
+		default:
+			return nil, fmt.Errorf("未知子命令: %s", args[i])
+		}
+		if i+argc > len(args) {
+			return nil, fmt.Errorf("%s 参数不足", sub)
+		}
+		var err error
+		if op.Signed, op.Width, err = data.ParseBitFieldType(args[i+1]); err != nil {
+			return nil, err
+		}
+		if op.Offset, err = data.ParseBitFieldOffset(args[i+2], op.Width); err != nil {
+			return nil, err
+		}
+		if argc == 4 {
+			if op.Value, err = strconv.ParseInt(args[i+3], 10, 64); err != nil {
+				return nil, fmt.Errorf("%s 的值必须为整数", sub)
+			}
+		}
+		ops = append(ops, op)
+		i += argc
+	}
+	return ops, nil
+}
@@ -70,6 +70,64 @@ func parseFields(line string) []string {
 	return fields
 }
 
+// commandHandlers 各数据类型的命令处理函数
+// 处理函数返回false表示不认识该命令
+var commandHandlers = []func(fields []string) bool{
+	execSetCommand,
+	execZSetCommand,
+	execBitMapCommand,
+	execHLLCommand,
+	execGraphCommand,
+	execGeoCommand,
+	execStreamCommand,
+	execPubSubCommand,
+}
+
+// dispatchCommand 将命令分发给各数据类型的处理函数
+// @param fields []string 拆分后的命令
+// @return bool 命令是否被处理
+func dispatchCommand(fields []string) bool {
+	for _, handler := range commandHandlers {
+		if handler(fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// usageError 打印参数错误及正确用法
+// @param usage string 命令用法
+func usageError(usage string) {
+	fmt.Println("参数错误!")
+	fmt.Println("用法: " + usage)
+}
+
+// printList 打印列表, 每项以引号包裹并以空格分隔
+// @param items []string 列表内容
+func printList(items []string) {
+	if len(items) == 0 {
+		fmt.Println("(empty list or set)")
+		return
+	}
+	for i, item := range items {
+		if i > 0 {
+			fmt.Print(" ")
+		}
+		fmt.Printf("\"%s\"", item)
+	}
+	fmt.Println()
+}
+
+// boolToInt 将布尔值转换为 1/0 输出
+// @param b bool
+// @return int
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 // showWithPager 使用分页器显示内容
 // @author xuyang
 // @datetime 2025-6-24 7:00
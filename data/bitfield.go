@@ -0,0 +1,301 @@
+package data
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 位域中偏移最小的位是整数的最高位, 位的编号与 SetBit/GetBit 一致
+
+// BitFieldKind 位域子命令类型
+type BitFieldKind int
+
+const (
+	// BitFieldGet 读取整数
+	BitFieldGet BitFieldKind = iota
+	// BitFieldSet 写入整数, 返回旧值
+	BitFieldSet
+	// BitFieldIncrBy 整数加上增量, 返回新值
+	BitFieldIncrBy
+)
+
+// BitFieldOverflow 写入与自增溢出时的处理方式
+type BitFieldOverflow int
+
+const (
+	// BitFieldWrap 回绕, 只保留低位
+	BitFieldWrap BitFieldOverflow = iota
+	// BitFieldSat 饱和到最大值或最小值
+	BitFieldSat
+	// BitFieldFail 放弃本次操作并返回nil
+	BitFieldFail
+)
+
+// BitFieldOp 一个位域子命令
+type BitFieldOp struct {
+	Kind BitFieldKind
+	// 是否为有符号整数
+	Signed bool
+	// 整数位宽, 无符号 1-63, 有符号 1-64
+	Width int
+	// 整数最高位所在的位偏移
+	Offset int
+	// SET 的新值或 INCRBY 的增量
+	Value int64
+	// 溢出处理方式
+	Overflow BitFieldOverflow
+}
+
+// errBitFieldType 位域类型格式错误
+var errBitFieldType = errors.New("位域类型必须为 u1-u63 或 i1-i64")
+
+// errBitFieldOffset 位域偏移格式错误
+var errBitFieldOffset = errors.New("位域偏移必须为非负整数且整个位域不能超出位偏移 4294967295, 或以 # 开头表示按类型宽度计算")
+
+// errBitFieldReadOnly 只读位域命令中出现写操作
+var errBitFieldReadOnly = errors.New("bitfield_ro 只支持 get")
+
+// ParseBitFieldType 解析 u8、i16 形式的位域类型
+// @param s string
+// @return bool 是否为有符号整数
+// @return int 位宽
+// @return error
+func ParseBitFieldType(s string) (bool, int, error) {
+	if len(s) < 2 {
+		return false, 0, errBitFieldType
+	}
+	signed := false
+	switch s[0] {
+	case 'i', 'I':
+		signed = true
+	case 'u', 'U':
+	default:
+		return false, 0, errBitFieldType
+	}
+	width, err := strconv.Atoi(s[1:])
+	if err != nil || width < 1 || width > 64 || (!signed && width == 64) {
+		return false, 0, errBitFieldType
+	}
+	return signed, width, nil
+}
+
+// ParseBitFieldOffset 解析位域偏移, "#n" 表示第n个该宽度的整数, 即 n*width
+// @param s string
+// @param width int 位宽
+// @return int 位偏移
+// @return error 不是整数或位域的最后一位超出 MaxBitOffset 时返回错误
+func ParseBitFieldOffset(s string, width int) (int, error) {
+	multiply := strings.HasPrefix(s, "#")
+	if multiply {
+		s = s[1:]
+	}
+	offset, err := strconv.Atoi(s)
+	if err != nil || offset < 0 {
+		return 0, errBitFieldOffset
+	}
+	last := MaxBitOffset - width + 1
+	if multiply {
+		if offset > last/width {
+			return 0, errBitFieldOffset
+		}
+		offset *= width
+	}
+	if offset > last {
+		return 0, errBitFieldOffset
+	}
+	return offset, nil
+}
+
+// inRange 位域的每一位是否都在 [0, MaxBitOffset] 内
+// @return bool
+func (op *BitFieldOp) inRange() bool {
+	return op.Width >= 1 && op.Width <= 64 && op.Offset >= 0 && op.Offset <= MaxBitOffset-op.Width+1
+}
+
+// bitfieldLimits 位域整数的取值范围
+// @param signed bool
+// @param width int
+// @return int64 最小值
+// @return int64 最大值
+func bitfieldLimits(signed bool, width int) (int64, int64) {
+	if !signed {
+		return 0, int64(uint64(1)<<width - 1)
+	}
+	if width == 64 {
+		return math.MinInt64, math.MaxInt64
+	}
+	return -(int64(1) << (width - 1)), int64(1)<<(width-1) - 1
+}
+
+// wrapBitfield 将运算结果截断为位宽内的整数, 有符号整数做符号扩展
+// @param signed bool
+// @param width int
+// @param v uint64 运算结果的补码
+// @return int64
+func wrapBitfield(signed bool, width int, v uint64) int64 {
+	if width == 64 {
+		return int64(v)
+	}
+	if signed {
+		shift := 64 - width
+		return int64(v<<shift) >> shift
+	}
+	return int64(v & (uint64(1)<<width - 1))
+}
+
+// overflowBitfield 按溢出处理方式得到最终结果
+// @param op *BitFieldOp
+// @param dir int 溢出方向, 1为上溢, -1为下溢, 0为未溢出
+// @param wrapped int64 回绕后的结果
+// @return int64 最终结果
+// @return bool 是否写入(FAIL 溢出时为false)
+func overflowBitfield(op *BitFieldOp, dir int, wrapped int64) (int64, bool) {
+	if dir == 0 {
+		return wrapped, true
+	}
+	minV, maxV := bitfieldLimits(op.Signed, op.Width)
+	switch op.Overflow {
+	case BitFieldSat:
+		if dir > 0 {
+			return maxV, true
+		}
+		return minV, true
+	case BitFieldFail:
+		return 0, false
+	}
+	return wrapped, true
+}
+
+// setResult 计算 SET 写入的值
+// @param op *BitFieldOp
+// @return int64
+// @return bool 是否写入
+func (op *BitFieldOp) setResult() (int64, bool) {
+	minV, maxV := bitfieldLimits(op.Signed, op.Width)
+	dir := 0
+	if op.Value > maxV {
+		dir = 1
+	} else if op.Value < minV {
+		dir = -1
+	}
+	return overflowBitfield(op, dir, wrapBitfield(op.Signed, op.Width, uint64(op.Value)))
+}
+
+// incrResult 计算 INCRBY 之后的值
+// @param op *BitFieldOp
+// @param old int64 当前值
+// @return int64
+// @return bool 是否写入
+func (op *BitFieldOp) incrResult(old int64) (int64, bool) {
+	minV, maxV := bitfieldLimits(op.Signed, op.Width)
+	incr := op.Value
+	dir := 0
+	if incr > 0 && old > maxV-incr {
+		dir = 1
+	} else if incr < 0 && uint64(old)-uint64(minV) < -uint64(incr) {
+		// 在 uint64 中比较 old-minV 与 |incr|, incr 为 math.MinInt64 时也不会溢出
+		dir = -1
+	}
+	return overflowBitfield(op, dir, wrapBitfield(op.Signed, op.Width, uint64(old)+uint64(incr)))
+}
+
+// readBitfield 读取位域整数, 超出位图的位视为0
+// @param b *bitmapObject
+// @param offset int 最高位的位偏移
+// @param signed bool
+// @param width int
+// @return int64
+func readBitfield(b *bitmapObject, offset int, signed bool, width int) int64 {
+	var v uint64
+	for i := 0; i < width; i++ {
+		v <<= 1
+		if b.getBit(offset + i) {
+			v |= 1
+		}
+	}
+	return wrapBitfield(signed, width, v)
+}
+
+// writeBitfield 写入位域整数, 位图长度不足时自动增长
+// @param b *bitmapObject
+// @param offset int 最高位的位偏移
+// @param width int
+// @param value int64
+func writeBitfield(b *bitmapObject, offset int, width int, value int64) {
+	v := uint64(value)
+	for i := width - 1; i >= 0; i-- {
+		b.setBit(offset+i, v&1 != 0)
+		v >>= 1
+	}
+}
+
+// BitField 在一个key上原子地执行一组位域子命令
+// @param key string
+// @param ops []BitFieldOp 子命令
+// @return []int64 每个子命令的结果: GET 为当前值, SET 为旧值, INCRBY 为新值
+// @return []bool 每个结果是否有效, FAIL 溢出或位域超出 MaxBitOffset 时为false
+func (bm *GkvBitMap) BitField(key string, ops []BitFieldOp) ([]int64, []bool) {
+	bm.keyLock.WLockRow(key)
+	defer bm.keyLock.WUnLockRow(key)
+	b := bm.liveBitmap(key)
+	results := make([]int64, len(ops))
+	valid := make([]bool, len(ops))
+	written := false
+	for i := range ops {
+		op := &ops[i]
+		if !op.inRange() {
+			continue
+		}
+		old := readBitfield(b, op.Offset, op.Signed, op.Width)
+		var value int64
+		var ok bool
+		switch op.Kind {
+		case BitFieldGet:
+			results[i], valid[i] = old, true
+			continue
+		case BitFieldSet:
+			value, ok = op.setResult()
+			results[i], valid[i] = old, ok
+		case BitFieldIncrBy:
+			value, ok = op.incrResult(old)
+			results[i], valid[i] = value, ok
+		}
+		if !ok {
+			continue
+		}
+		b = bm.writableBitmap(key)
+		writeBitfield(b, op.Offset, op.Width, value)
+		delete(bm.expireTimes, key)
+		written = true
+	}
+	if written {
+		notifyKeyspaceEvent(NotifyString, "setbit", key)
+	}
+	return results, valid
+}
+
+// BitFieldRO 只读地执行一组位域 GET 子命令
+// @param key string
+// @param ops []BitFieldOp 子命令, 只能为 GET
+// @return []int64 每个子命令读取到的值
+// @return error 包含写操作或位域超出 MaxBitOffset 时返回错误
+func (bm *GkvBitMap) BitFieldRO(key string, ops []BitFieldOp) ([]int64, error) {
+	for _, op := range ops {
+		if op.Kind != BitFieldGet {
+			return nil, errBitFieldReadOnly
+		}
+		if !op.inRange() {
+			return nil, errBitFieldOffset
+		}
+	}
+	bm.keyLock.RLockRow(key)
+	defer bm.keyLock.RUnLockRow(key)
+	b := bm.liveBitmap(key)
+	results := make([]int64, len(ops))
+	for i, op := range ops {
+		results[i] = readBitfield(b, op.Offset, op.Signed, op.Width)
+	}
+	return results, nil
+}
@@ -0,0 +1,207 @@
+package data
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// newTestBitMap 创建独立于全局实例的位图存储
+// @return *GkvBitMap
+func newTestBitMap() *GkvBitMap {
+	return &GkvBitMap{
+		data:        make(map[string]*bitmapObject),
+		expireTimes: make(map[string]time.Time),
+		keyLock:     NewKeyLock(),
+	}
+}
+
+// TestParseBitFieldType 无符号位宽 1-63, 有符号位宽 1-64
+func TestParseBitFieldType(t *testing.T) {
+	tests := []struct {
+		in     string
+		signed bool
+		width  int
+		isErr  bool
+	}{
+		{"u1", false, 1, false},
+		{"U8", false, 8, false},
+		{"u63", false, 63, false},
+		{"i1", true, 1, false},
+		{"I16", true, 16, false},
+		{"i64", true, 64, false},
+		{"u64", false, 0, true},
+		{"i65", false, 0, true},
+		{"u0", false, 0, true},
+		{"i-1", false, 0, true},
+		{"u", false, 0, true},
+		{"x8", false, 0, true},
+		{"", false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			signed, width, err := ParseBitFieldType(tt.in)
+			if tt.isErr {
+				if !errors.Is(err, errBitFieldType) {
+					t.Fatalf("期望 errBitFieldType, 得到 %v", err)
+				}
+				return
+			}
+			if err != nil || signed != tt.signed || width != tt.width {
+				t.Fatalf("得到 %v %d %v, 期望 %v %d", signed, width, err, tt.signed, tt.width)
+			}
+		})
+	}
+}
+
+// TestParseBitFieldOffset "#n" 按位宽换算, 位域的最后一位不能超出 MaxBitOffset
+func TestParseBitFieldOffset(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  int
+		isErr bool
+	}{
+		{"0", 8, 0, false},
+		{"#3", 8, 24, false},
+		{"4294967295", 1, 4294967295, false},
+		{"4294967295", 2, 0, true},
+		{"4294967288", 8, 4294967288, false},
+		{"4294967289", 8, 0, true},
+		{"#536870911", 8, 4294967288, false},
+		{"#536870912", 8, 0, true},
+		{"#67108863", 64, 4294967232, false},
+		{"#67108864", 64, 0, true},
+		{"-1", 8, 0, true},
+		{"#-1", 8, 0, true},
+		{"#", 8, 0, true},
+		{"abc", 8, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseBitFieldOffset(tt.in, tt.width)
+			if tt.isErr {
+				if !errors.Is(err, errBitFieldOffset) {
+					t.Fatalf("期望 errBitFieldOffset, 得到 %d %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("得到 %d %v, 期望 %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestBitFieldIncrResult 增量为 math.MinInt64 等边界值时正确判断溢出方向
+func TestBitFieldIncrResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		signed   bool
+		width    int
+		old      int64
+		incr     int64
+		overflow BitFieldOverflow
+		want     int64
+		ok       bool
+	}{
+		{"i64加最小值", true, 64, 5, math.MinInt64, BitFieldFail, 5 + math.MinInt64, true},
+		{"i64零加最小值", true, 64, 0, math.MinInt64, BitFieldFail, math.MinInt64, true},
+		{"i64负数加最小值下溢", true, 64, -1, math.MinInt64, BitFieldFail, 0, false},
+		{"i64负数加最小值饱和", true, 64, -1, math.MinInt64, BitFieldSat, math.MinInt64, true},
+		{"i64负数加最小值回绕", true, 64, -1, math.MinInt64, BitFieldWrap, math.MaxInt64, true},
+		{"i64最大值加1上溢", true, 64, math.MaxInt64, 1, BitFieldFail, 0, false},
+		{"i8加最小值下溢", true, 8, 100, math.MinInt64, BitFieldFail, 0, false},
+		{"i8加最小值饱和", true, 8, 100, math.MinInt64, BitFieldSat, -128, true},
+		{"i8恰好到最小值", true, 8, -100, -28, BitFieldFail, -128, true},
+		{"i8低于最小值", true, 8, -100, -29, BitFieldFail, 0, false},
+		{"i8低于最小值回绕", true, 8, -100, -29, BitFieldWrap, 127, true},
+		{"u8恰好到0", false, 8, 3, -3, BitFieldFail, 0, true},
+		{"u8低于0", false, 8, 3, -4, BitFieldFail, 0, false},
+		{"u8低于0饱和", false, 8, 3, -4, BitFieldSat, 0, true},
+		{"u8低于0回绕", false, 8, 3, -4, BitFieldWrap, 255, true},
+		{"u8加最小值", false, 8, 3, math.MinInt64, BitFieldFail, 0, false},
+		{"u8上溢饱和", false, 8, 200, 100, BitFieldSat, 255, true},
+		{"u63上溢回绕", false, 63, math.MaxInt64, 1, BitFieldWrap, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := BitFieldOp{Kind: BitFieldIncrBy, Signed: tt.signed, Width: tt.width, Value: tt.incr, Overflow: tt.overflow}
+			got, ok := op.incrResult(tt.old)
+			if ok != tt.ok || (ok && got != tt.want) {
+				t.Fatalf("得到 %d %v, 期望 %d %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// TestBitField 一组子命令按顺序执行, 每个子命令看到前一个子命令的结果
+func TestBitField(t *testing.T) {
+	bm := newTestBitMap()
+	tests := []struct {
+		name string
+		op   BitFieldOp
+		want int64
+		ok   bool
+	}{
+		{"SET返回旧值", BitFieldOp{Kind: BitFieldSet, Width: 8, Value: 200}, 0, true},
+		{"无符号GET", BitFieldOp{Kind: BitFieldGet, Width: 8}, 200, true},
+		{"有符号GET", BitFieldOp{Kind: BitFieldGet, Signed: true, Width: 8}, -56, true},
+		{"回绕", BitFieldOp{Kind: BitFieldIncrBy, Width: 8, Value: 100}, 44, true},
+		{"饱和", BitFieldOp{Kind: BitFieldIncrBy, Width: 8, Value: 250, Overflow: BitFieldSat}, 255, true},
+		{"FAIL不写入", BitFieldOp{Kind: BitFieldIncrBy, Width: 8, Value: 1, Overflow: BitFieldFail}, 0, false},
+		{"有符号回绕", BitFieldOp{Kind: BitFieldIncrBy, Signed: true, Width: 4, Offset: 100, Value: 9}, -7, true},
+		{"有符号饱和", BitFieldOp{Kind: BitFieldIncrBy, Signed: true, Width: 4, Offset: 100, Value: -20, Overflow: BitFieldSat}, -8, true},
+		{"i64 SET", BitFieldOp{Kind: BitFieldSet, Signed: true, Width: 64, Offset: 200, Value: -5}, 0, true},
+		{"i64下溢FAIL", BitFieldOp{Kind: BitFieldIncrBy, Signed: true, Width: 64, Offset: 200, Value: -math.MaxInt64, Overflow: BitFieldFail}, 0, false},
+		{"FAIL后值不变", BitFieldOp{Kind: BitFieldGet, Signed: true, Width: 64, Offset: 200}, -5, true},
+		{"u63 SET负数饱和", BitFieldOp{Kind: BitFieldSet, Width: 63, Offset: 300, Value: -1, Overflow: BitFieldSat}, 0, true},
+		{"饱和到0", BitFieldOp{Kind: BitFieldGet, Width: 63, Offset: 300}, 0, true},
+		{"位域末尾在最大偏移", BitFieldOp{Kind: BitFieldSet, Width: 8, Offset: MaxBitOffset - 7, Value: 1}, 0, true},
+		{"位域超出最大偏移", BitFieldOp{Kind: BitFieldSet, Width: 8, Offset: MaxBitOffset - 6, Value: 1}, 0, false},
+	}
+	ops := make([]BitFieldOp, len(tests))
+	for i, tt := range tests {
+		ops[i] = tt.op
+	}
+	results, valid := bm.BitField("k", ops)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if valid[i] != tt.ok || results[i] != tt.want {
+				t.Fatalf("得到 %d %v, 期望 %d %v", results[i], valid[i], tt.want, tt.ok)
+			}
+		})
+	}
+	if !bm.GetBit("k", MaxBitOffset) {
+		t.Fatal("最大偏移处的位应为1")
+	}
+}
+
+// TestBitFieldOutOfRange 超出 MaxBitOffset 的位域不执行, 只读命令只接受 GET
+func TestBitFieldOutOfRange(t *testing.T) {
+	bm := newTestBitMap()
+	outOfRange := []BitFieldOp{
+		{Kind: BitFieldSet, Width: 2, Offset: MaxBitOffset, Value: 1},
+		{Kind: BitFieldIncrBy, Signed: true, Width: 64, Offset: MaxBitOffset - 62, Value: 1},
+		{Kind: BitFieldGet, Width: 8, Offset: -1},
+	}
+	if _, valid := bm.BitField("k", outOfRange); valid[0] || valid[1] || valid[2] {
+		t.Fatalf("超出范围的子命令应无效, 得到 %v", valid)
+	}
+	if _, exists := bm.data["k"]; exists {
+		t.Fatal("没有执行任何写入时不应创建key")
+	}
+	if _, err := bm.BitFieldRO("k", []BitFieldOp{{Kind: BitFieldGet, Width: 2, Offset: MaxBitOffset}}); !errors.Is(err, errBitFieldOffset) {
+		t.Fatalf("期望 errBitFieldOffset, 得到 %v", err)
+	}
+	if _, err := bm.BitFieldRO("k", []BitFieldOp{{Kind: BitFieldGet, Width: 1}, {Kind: BitFieldSet, Width: 1}}); !errors.Is(err, errBitFieldReadOnly) {
+		t.Fatalf("期望 errBitFieldReadOnly, 得到 %v", err)
+	}
+
+	bm.SetBit("k", 0, true)
+	results, err := bm.BitFieldRO("k", []BitFieldOp{{Kind: BitFieldGet, Width: 1}, {Kind: BitFieldGet, Width: 4}, {Kind: BitFieldGet, Signed: true, Width: 4}})
+	if err != nil || results[0] != 1 || results[1] != 8 || results[2] != -8 {
+		t.Fatalf("得到 %v %v", results, err)
+	}
+}
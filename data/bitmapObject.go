@@ -0,0 +1,141 @@
+package data
+
+// bitmapObject 单个位图的值
+// 默认使用连续字节编码; 长度超过阈值且足够稀疏时转换为压缩位图编码, 转换后不会再转回
+type bitmapObject struct {
+	// 连续字节编码, 第 n 位位于第 n/8 个字节的第 n%8 位
+	dense []byte
+	// 压缩位图编码, 非nil时不使用 dense
+	sparse *roaring
+}
+
+// encoding 当前编码名称
+// @return string
+func (b *bitmapObject) encoding() string {
+	if b.sparse != nil {
+		return "roaring"
+	}
+	return "raw"
+}
+
+// len 逻辑字节长度
+// @return int
+func (b *bitmapObject) len() int {
+	if b == nil {
+		return 0
+	}
+	if b.sparse != nil {
+		return b.sparse.length
+	}
+	return len(b.dense)
+}
+
+// getBit 获取某一位
+// @param offset int
+// @return bool
+func (b *bitmapObject) getBit(offset int) bool {
+	if b == nil {
+		return false
+	}
+	if b.sparse != nil {
+		return b.sparse.getBit(offset)
+	}
+	return offset/8 < len(b.dense) && b.dense[offset/8]&(1<<(offset%8)) != 0
+}
+
+// setBit 设置某一位, 连续字节编码需要大幅增长且位图稀疏时先转换为压缩位图
+// @param offset int
+// @param value bool
+func (b *bitmapObject) setBit(offset int, value bool) {
+	need := offset/8 + 1
+	if b.sparse == nil && need > len(b.dense) && b.shouldCompress(need, value) {
+		b.sparse = newRoaringFromBytes(b.dense)
+		b.dense = nil
+	}
+	if b.sparse != nil {
+		b.sparse.setBit(offset, value)
+		return
+	}
+	if need > len(b.dense) {
+		b.dense = append(b.dense, make([]byte, need-len(b.dense))...)
+	}
+	if value {
+		b.dense[offset/8] |= 1 << (offset % 8)
+	} else {
+		b.dense[offset/8] &^= 1 << (offset % 8)
+	}
+}
+
+// shouldCompress 连续字节编码增长到need字节时是否应转换为压缩位图
+// 只在长度超过阈值且至少翻倍时统计, 顺序写入的均摊代价为 O(1);
+// 压缩位图中稀疏块每个1占2字节, 即每16位中不到1个为1时更省空间
+// @param need int 增长后的字节数
+// @param value bool 写入的值
+// @return bool
+func (b *bitmapObject) shouldCompress(need int, value bool) bool {
+	if need <= encodingLimits.BitmapSparseMinBytes || need < 2*len(b.dense) {
+		return false
+	}
+	ones := popcount(b.dense)
+	if value {
+		ones++
+	}
+	return ones*16 < need*8
+}
+
+// count 统计位区间 [startBit, endBit] 内为1的位数, 调用方需保证区间位于位图内
+// @param startBit int
+// @param endBit int
+// @return int
+func (b *bitmapObject) count(startBit, endBit int) int {
+	if b.sparse != nil {
+		return b.sparse.count(startBit, endBit)
+	}
+	return bitCountRange(b.dense, startBit, endBit)
+}
+
+// pos 在位区间 [startBit, endBit] 内查找第一个值为bit的位, 调用方需保证区间位于位图内
+// @param bit bool
+// @param startBit int
+// @param endBit int
+// @return int 未找到为-1
+func (b *bitmapObject) pos(bit bool, startBit, endBit int) int {
+	if b.sparse != nil {
+		return b.sparse.pos(bit, startBit, endBit)
+	}
+	return bitPosRange(b.dense, bit, startBit, endBit)
+}
+
+// toRoaring 获取压缩位图形式, 连续字节编码时转换出一份新的压缩位图
+// @return *roaring
+func (b *bitmapObject) toRoaring() *roaring {
+	if b == nil {
+		return &roaring{}
+	}
+	if b.sparse != nil {
+		return b.sparse
+	}
+	return newRoaringFromBytes(b.dense)
+}
+
+// bitmapBitOp 对若干位图执行按位运算
+// 全部来源为连续字节编码时直接按字节运算, 否则在压缩位图上按块运算
+// @param op BitOperation
+// @param srcs []*bitmapObject 来源, 不存在的key为nil
+// @return *bitmapObject 结果
+func bitmapBitOp(op BitOperation, srcs []*bitmapObject) *bitmapObject {
+	dense := make([][]byte, len(srcs))
+	for i, src := range srcs {
+		if src != nil && src.sparse != nil {
+			roarings := make([]*roaring, len(srcs))
+			for j, s := range srcs {
+				roarings[j] = s.toRoaring()
+			}
+			return &bitmapObject{sparse: roaringBitOp(op, roarings)}
+		}
+		if src != nil {
+			dense[i] = src.dense
+		}
+	}
+	return &bitmapObject{dense: bitOp(op, dense)}
+}
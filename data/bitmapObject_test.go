@@ -0,0 +1,53 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestSetBitOffsetLimit 超出 MaxBitOffset 的偏移被拒绝
+func TestSetBitOffsetLimit(t *testing.T) {
+	bm := &GkvBitMap{
+		data:        make(map[string]*bitmapObject),
+		expireTimes: make(map[string]time.Time),
+		keyLock:     NewKeyLock(),
+	}
+	for _, offset := range []int{-1, MaxBitOffset + 1, 1 << 62} {
+		if err := bm.SetBit("k", offset, true); !errors.Is(err, errBitOffset) {
+			t.Fatalf("偏移 %d: 期望 errBitOffset, 得到 %v", offset, err)
+		}
+	}
+	if _, exists := bm.data["k"]; exists {
+		t.Fatal("被拒绝的写入不应创建key")
+	}
+}
+
+// TestRoaringBitNotAtMaxOffset 最大偏移处的稀疏位图取反后块数有界
+func TestRoaringBitNotAtMaxOffset(t *testing.T) {
+	bm := &GkvBitMap{
+		data:        make(map[string]*bitmapObject),
+		expireTimes: make(map[string]time.Time),
+		keyLock:     NewKeyLock(),
+	}
+	if err := bm.SetBit("src", MaxBitOffset, true); err != nil {
+		t.Fatal(err)
+	}
+	if enc, _ := bm.Encoding("src"); enc != "roaring" {
+		t.Fatalf("编码为 %s, 期望 roaring", enc)
+	}
+	n, err := bm.BitOp(BitNot, "dst", "src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != (MaxBitOffset+1)/8 {
+		t.Fatalf("结果长度 %d", n)
+	}
+	dst := bm.data["dst"].sparse
+	if dst == nil || len(dst.keys) > (MaxBitOffset+1)>>roaringChunkBits {
+		t.Fatal("取反结果应为块数有界的压缩位图")
+	}
+	if bm.GetBit("dst", MaxBitOffset) || !bm.GetBit("dst", 0) || bm.Count("dst") != MaxBitOffset {
+		t.Fatal("取反结果错误")
+	}
+}
@@ -0,0 +1,143 @@
+package data
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// 位图中第 n 位位于第 n/8 个字节的第 n%8 位(低位在前), 与 SetBit/GetBit 一致
+
+// BitOperation BITOP 的运算类型
+type BitOperation int
+
+const (
+	// BitAnd 按位与
+	BitAnd BitOperation = iota
+	// BitOr 按位或
+	BitOr
+	// BitXor 按位异或
+	BitXor
+	// BitNot 按位取反, 只接受一个来源
+	BitNot
+)
+
+// errBitNot NOT 只能作用于一个key
+var errBitNot = errors.New("bitop not 只能指定一个来源key")
+
+// popcount 统计字节切片中为1的位数, 每次处理8个字节
+// @param b []byte
+// @return int
+func popcount(b []byte) int {
+	count := 0
+	for len(b) >= 8 {
+		count += bits.OnesCount64(binary.LittleEndian.Uint64(b))
+		b = b[8:]
+	}
+	for _, v := range b {
+		count += bits.OnesCount8(v)
+	}
+	return count
+}
+
+// normalizeBitRange 按 Redis 规则规范化区间, 负数表示倒数
+// @param start int 起始位置(含)
+// @param end int 结束位置(含)
+// @param length int 总长度(字节数或位数)
+// @return int 规范化后的起始位置
+// @return int 规范化后的结束位置
+// @return bool 区间是否非空
+func normalizeBitRange(start, end, length int) (int, int, bool) {
+	if start < 0 {
+		start += length
+	}
+	if end < 0 {
+		end += length
+	}
+	start = max(start, 0)
+	end = max(end, 0)
+	end = min(end, length-1)
+	if length == 0 || start > end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// bitCountRange 统计位区间 [startBit, endBit] 内为1的位数, 调用方需保证区间位于切片内
+// @param b []byte
+// @param startBit int
+// @param endBit int
+// @return int
+func bitCountRange(b []byte, startBit, endBit int) int {
+	first, last := startBit/8, endBit/8
+	headMask := byte(0xFF) << (startBit % 8)
+	tailMask := byte(0xFF) >> (7 - endBit%8)
+	if first == last {
+		return bits.OnesCount8(b[first] & headMask & tailMask)
+	}
+	return bits.OnesCount8(b[first]&headMask) + popcount(b[first+1:last]) + bits.OnesCount8(b[last]&tailMask)
+}
+
+// bitPosRange 在位区间 [startBit, endBit] 内查找第一个值为bit的位, 调用方需保证区间位于切片内
+// @param b []byte
+// @param bit bool 查找1还是0
+// @param startBit int
+// @param endBit int
+// @return int 位置, 未找到为-1
+func bitPosRange(b []byte, bit bool, startBit, endBit int) int {
+	for i := startBit / 8; i <= endBit/8; i++ {
+		v := b[i]
+		if !bit {
+			v = ^v
+		}
+		if i == startBit/8 {
+			v &= byte(0xFF) << (startBit % 8)
+		}
+		if i == endBit/8 {
+			v &= byte(0xFF) >> (7 - endBit%8)
+		}
+		if v != 0 {
+			return i*8 + bits.TrailingZeros8(v)
+		}
+	}
+	return -1
+}
+
+// bitOp 对多个字节切片执行按位运算, 较短的来源视为以0补齐
+// @param op BitOperation
+// @param srcs [][]byte
+// @return []byte 结果, 长度为最长来源的长度
+func bitOp(op BitOperation, srcs [][]byte) []byte {
+	length := 0
+	for _, src := range srcs {
+		length = max(length, len(src))
+	}
+	result := make([]byte, length)
+	if len(srcs) == 0 {
+		return result
+	}
+	if op == BitNot {
+		for i := range result {
+			result[i] = ^srcs[0][i]
+		}
+		return result
+	}
+	copy(result, srcs[0])
+	for _, src := range srcs[1:] {
+		for i := range result {
+			var v byte
+			if i < len(src) {
+				v = src[i]
+			}
+			switch op {
+			case BitAnd:
+				result[i] &= v
+			case BitOr:
+				result[i] |= v
+			case BitXor:
+				result[i] ^= v
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package data
+
+// EncodingLimits 紧凑编码的阈值配置
+// 超过阈值的key会自动转换为哈希表等常规结构, 转换后不会再转回
+type EncodingLimits struct {
+	// 整数集合编码的最大成员数
+	SetMaxIntsetEntries int `json:"set_max_intset_entries"`
+	// 映射紧凑编码的最大字段数
+	HashMaxListpackEntries int `json:"hash_max_listpack_entries"`
+	// 映射紧凑编码中字段或值的最大字节数
+	HashMaxListpackValue int `json:"hash_max_listpack_value"`
+	// 有序集合紧凑编码的最大成员数
+	ZSetMaxListpackEntries int `json:"zset_max_listpack_entries"`
+	// 有序集合紧凑编码中成员的最大字节数
+	ZSetMaxListpackValue int `json:"zset_max_listpack_value"`
+	// 位图长度超过该字节数且足够稀疏时转换为压缩位图编码
+	BitmapSparseMinBytes int `json:"bitmap_sparse_min_bytes"`
+	// HyperLogLog 稀疏编码的最大字节数(每个非0寄存器4字节), 超过后转换为稠密编码
+	HLLSparseMaxBytes int `json:"hll_sparse_max_bytes"`
+}
+
+// encodingLimits 当前生效的阈值, 默认值与 Redis 一致
+var encodingLimits = EncodingLimits{
+	SetMaxIntsetEntries:    512,
+	HashMaxListpackEntries: 128,
+	HashMaxListpackValue:   64,
+	ZSetMaxListpackEntries: 128,
+	ZSetMaxListpackValue:   64,
+	BitmapSparseMinBytes:   4096,
+	HLLSparseMaxBytes:      3000,
+}
+
+// SetEncodingLimits 设置紧凑编码阈值, 未设置(<=0)的字段保留原值
+// 仅影响之后写入的key, 应在启动时调用
+// @param limits EncodingLimits 阈值配置
+func SetEncodingLimits(limits EncodingLimits) {
+	if limits.SetMaxIntsetEntries > 0 {
+		encodingLimits.SetMaxIntsetEntries = limits.SetMaxIntsetEntries
+	}
+	if limits.HashMaxListpackEntries > 0 {
+		encodingLimits.HashMaxListpackEntries = limits.HashMaxListpackEntries
+	}
+	if limits.HashMaxListpackValue > 0 {
+		encodingLimits.HashMaxListpackValue = limits.HashMaxListpackValue
+	}
+	if limits.ZSetMaxListpackEntries > 0 {
+		encodingLimits.ZSetMaxListpackEntries = limits.ZSetMaxListpackEntries
+	}
+	if limits.ZSetMaxListpackValue > 0 {
+		encodingLimits.ZSetMaxListpackValue = limits.ZSetMaxListpackValue
+	}
+	if limits.BitmapSparseMinBytes > 0 {
+		encodingLimits.BitmapSparseMinBytes = limits.BitmapSparseMinBytes
+	}
+	if limits.HLLSparseMaxBytes > 0 {
+		encodingLimits.HLLSparseMaxBytes = limits.HLLSparseMaxBytes
+	}
+}
+
+// ObjectEncoding 查询key当前使用的内部编码
+// 集合: intset / hashtable; 映射: listpack / hashtable; 有序集合: listpack / skiplist; 位图: raw / roaring; HyperLogLog: sparse / dense
+// @param key string 键
+// @return string 编码名称
+// @return bool key是否存在于支持多种编码的类型中
+func ObjectEncoding(key string) (string, bool) {
+	if enc, ok := DataGkvSet.Encoding(key); ok {
+		return enc, true
+	}
+	if enc, ok := DataGkvMap.Encoding(key); ok {
+		return enc, true
+	}
+	if enc, ok := DataGkvZSet.Encoding(key); ok {
+		return enc, true
+	}
+	if enc, ok := DataGkvBitMap.Encoding(key); ok {
+		return enc, true
+	}
+	if enc, ok := DataGkvHyperLoglog.Encoding(key); ok {
+		return enc, true
+	}
+	return "", false
+}
@@ -1,45 +1,61 @@
 package data
 
 import (
+	"errors"
+	"strconv"
 	"time"
 )
 
+// MaxBitOffset 位偏移的上限, 与 Redis 相同位图最大为 512MB
+const MaxBitOffset = 1<<32 - 1
+
+// errBitOffset 位偏移格式错误或超出范围
+var errBitOffset = errors.New("位偏移必须为 0 到 4294967295 之间的整数")
+
+// ParseBitOffset 解析 SETBIT/GETBIT 的位偏移
+// @param s string
+// @return int
+// @return error 不是整数或超出 [0, MaxBitOffset] 时返回错误
+func ParseBitOffset(s string) (int, error) {
+	offset, err := strconv.Atoi(s)
+	if err != nil || offset < 0 || offset > MaxBitOffset {
+		return 0, errBitOffset
+	}
+	return offset, nil
+}
+
 // GkvBitMap 位图结构
 // @author xuyang
 // @datetime 2025-7-16 21:00
 type GkvBitMap struct {
-	data        map[string][]byte
+	data        map[string]*bitmapObject
 	expireTimes map[string]time.Time
 	keyLock     *KeyLock
 }
 
 // DataGkvBitMap 全局数据实例
 var DataGkvBitMap = &GkvBitMap{
-	data:        make(map[string][]byte),
+	data:        make(map[string]*bitmapObject),
 	expireTimes: make(map[string]time.Time),
 	keyLock:     NewKeyLock(),
 }
 
 // SetBit 设置某一位
+// 偏移有上限, 因此位图的逻辑长度不超过 512MB, 压缩位图上的 NOT 最多产生 65536 个块
 // @param key string
-// @param offset int
+// @param offset int 0 到 MaxBitOffset
 // @param value bool
-func (bm *GkvBitMap) SetBit(key string, offset int, value bool) {
+// @return error 偏移超出范围时返回错误
+func (bm *GkvBitMap) SetBit(key string, offset int, value bool) error {
+	if offset < 0 || offset > MaxBitOffset {
+		return errBitOffset
+	}
 	bm.keyLock.WLockRow(key)
 	defer bm.keyLock.WUnLockRow(key)
-	byteIdx := offset / 8
-	bitIdx := offset % 8
-	if len(bm.data[key]) <= byteIdx {
-		newBytes := make([]byte, byteIdx+1)
-		copy(newBytes, bm.data[key])
-		bm.data[key] = newBytes
-	}
-	if value {
-		bm.data[key][byteIdx] |= 1 << bitIdx
-	} else {
-		bm.data[key][byteIdx] &^= 1 << bitIdx
-	}
+	bm.writableBitmap(key).setBit(offset, value)
 	delete(bm.expireTimes, key)
+	notifyKeyspaceEvent(NotifyString, "setbit", key)
+	return nil
 }
 
 // GetBit 获取某一位
@@ -49,12 +65,7 @@ func (bm *GkvBitMap) SetBit(key string, offset int, value bool) {
 func (bm *GkvBitMap) GetBit(key string, offset int) bool {
 	bm.keyLock.RLockRow(key)
 	defer bm.keyLock.RUnLockRow(key)
-	byteIdx := offset / 8
-	bitIdx := offset % 8
-	if len(bm.data[key]) <= byteIdx {
-		return false
-	}
-	return bm.data[key][byteIdx]&(1<<bitIdx) != 0
+	return bm.liveBitmap(key).getBit(offset)
 }
 
 // Count 统计位图中为1的位数
@@ -63,19 +74,153 @@ func (bm *GkvBitMap) GetBit(key string, offset int) bool {
 func (bm *GkvBitMap) Count(key string) int {
 	bm.keyLock.RLockRow(key)
 	defer bm.keyLock.RUnLockRow(key)
-	data, exists := bm.data[key]
-	if !exists {
+	b := bm.liveBitmap(key)
+	if b.len() == 0 {
+		return 0
+	}
+	return b.count(0, b.len()*8-1)
+}
+
+// BitRange BITCOUNT/BITPOS 的区间, 负数表示倒数
+type BitRange struct {
+	Start, End int
+	// 是否指定了结束位置, 未指定时到末尾为止
+	HasEnd bool
+	// 区间以位而不是字节为单位
+	Bit bool
+}
+
+// bitBounds 将区间换算为位区间
+// @param length int 位图字节数
+// @return int 起始位(含)
+// @return int 结束位(含)
+// @return bool 区间是否非空
+func (r *BitRange) bitBounds(length int) (int, int, bool) {
+	if r == nil {
+		return 0, length*8 - 1, length > 0
+	}
+	end := r.End
+	if !r.HasEnd {
+		end = -1
+	}
+	if r.Bit {
+		return normalizeBitRange(r.Start, end, length*8)
+	}
+	start, end, ok := normalizeBitRange(r.Start, end, length)
+	return start * 8, end*8 + 7, ok
+}
+
+// BitCount 统计区间内为1的位数
+// @param key string
+// @param r *BitRange 区间, nil表示整个位图
+// @return int
+func (bm *GkvBitMap) BitCount(key string, r *BitRange) int {
+	bm.keyLock.RLockRow(key)
+	defer bm.keyLock.RUnLockRow(key)
+	b := bm.liveBitmap(key)
+	start, end, ok := r.bitBounds(b.len())
+	if !ok {
+		return 0
+	}
+	return b.count(start, end)
+}
+
+// BitPos 查找区间内第一个值为bit的位
+// 查找0且未指定结束位置时, 若区间内全为1, 返回位图末尾之后的第一位, 与 Redis 一致
+// @param key string
+// @param bit bool 查找1还是0
+// @param r *BitRange 区间, nil表示整个位图
+// @return int 位置, 未找到为-1
+func (bm *GkvBitMap) BitPos(key string, bit bool, r *BitRange) int {
+	bm.keyLock.RLockRow(key)
+	defer bm.keyLock.RUnLockRow(key)
+	b := bm.liveBitmap(key)
+	if b.len() == 0 {
+		if bit {
+			return -1
+		}
 		return 0
 	}
-	count := 0
-	for _, b := range data {
-		for i := 0; i < 8; i++ {
-			if b&(1<<i) != 0 {
-				count++
-			}
+	start, end, ok := r.bitBounds(b.len())
+	if !ok {
+		return -1
+	}
+	pos := b.pos(bit, start, end)
+	if pos < 0 && !bit && (r == nil || !r.HasEnd) {
+		return b.len() * 8
+	}
+	return pos
+}
+
+// BitOp 对若干位图执行按位运算并写入目标key, 较短的位图视为以0补齐
+// 结果为空时删除目标key
+// @param op BitOperation 运算类型
+// @param dst string 目标key
+// @param keys ...string 来源key, NOT 只能有一个
+// @return int 目标位图的字节数
+// @return error
+func (bm *GkvBitMap) BitOp(op BitOperation, dst string, keys ...string) (int, error) {
+	if op == BitNot && len(keys) != 1 {
+		return 0, errBitNot
+	}
+	locked := append([]string{dst}, keys...)
+	bm.keyLock.WLockRows(locked...)
+	defer bm.keyLock.WUnLockRows(locked...)
+	srcs := make([]*bitmapObject, len(keys))
+	for i, key := range keys {
+		srcs[i] = bm.liveBitmap(key)
+	}
+	result := bitmapBitOp(op, srcs)
+	delete(bm.expireTimes, dst)
+	if result.len() == 0 {
+		if _, exists := bm.data[dst]; exists {
+			delete(bm.data, dst)
+			notifyKeyspaceEvent(NotifyGeneric, "del", dst)
 		}
+		return 0, nil
+	}
+	bm.data[dst] = result
+	notifyKeyspaceEvent(NotifyString, "set", dst)
+	return result.len(), nil
+}
+
+// liveBitmap 获取未过期的位图, 调用方需持有key的锁
+// @param key string
+// @return *bitmapObject 不存在或已过期时为nil
+func (bm *GkvBitMap) liveBitmap(key string) *bitmapObject {
+	if expireTime, exists := bm.expireTimes[key]; exists && time.Now().After(expireTime) {
+		return nil
+	}
+	return bm.data[key]
+}
+
+// writableBitmap 获取用于写入的位图, 不存在或已过期时创建空位图, 调用方需持有key的写锁
+// @param key string
+// @return *bitmapObject
+func (bm *GkvBitMap) writableBitmap(key string) *bitmapObject {
+	if expireTime, exists := bm.expireTimes[key]; exists && time.Now().After(expireTime) {
+		delete(bm.data, key)
+		delete(bm.expireTimes, key)
+		notifyKeyspaceEvent(NotifyExpired, "expired", key)
+	}
+	if _, exists := bm.data[key]; !exists {
+		bm.data[key] = &bitmapObject{}
+	}
+	return bm.data[key]
+}
+
+// Encoding 获取位图当前使用的编码
+// @param key string
+// @return string 编码名称: raw / roaring
+// @return bool 位图是否存在
+func (bm *GkvBitMap) Encoding(key string) (string, bool) {
+	bm.keyLock.RLockRow(key)
+	defer bm.keyLock.RUnLockRow(key)
+	b := bm.liveBitmap(key)
+	if b == nil {
+		return "", false
 	}
-	return count
+	return b.encoding(), true
 }
 
 // SetTime 设置过期时间(毫秒为单位)
@@ -85,6 +230,7 @@ func (bm *GkvBitMap) SetTime(key string, timeMs int) bool {
 	if _, exists := bm.data[key]; exists {
 		expireTime := time.Now().Add(time.Duration(timeMs) * time.Millisecond)
 		bm.expireTimes[key] = expireTime
+		notifyKeyspaceEvent(NotifyGeneric, "expire", key)
 		return true
 	}
 	return false
@@ -107,3 +253,22 @@ func (bm *GkvBitMap) GetTTL(key string) int64 {
 	}
 	return int64(remaining.Milliseconds())
 }
+
+// liveKeys 获取所有未过期的key
+// @return []string
+func (bm *GkvBitMap) liveKeys() []string {
+	return collectLiveKeys(bm.keyLock, bm.data, bm.expireTimes)
+}
+
+// deleteKey 删除整个key
+// @param key string
+// @return bool key是否存在
+func (bm *GkvBitMap) deleteKey(key string) bool {
+	return removeKey(bm.keyLock, bm.data, bm.expireTimes, key)
+}
+
+// removeExpired 删除所有已过期的key
+// @return []string 被删除的key
+func (bm *GkvBitMap) removeExpired() []string {
+	return removeExpiredKeys(bm.keyLock, bm.data, bm.expireTimes)
+}
@@ -1,104 +1,487 @@
 package data
 
 import (
-	"math"
-	"container/list"
+	"encoding/gob"
+	"io"
+	"maps"
+	"slices"
+	"time"
 )
 
 // GkvGraph 图结构
 // @author xuyang
 // @datetime 2025-7-16 21:00
 type GkvGraph struct {
-	// 节点坐标信息：节点名 -> (x, y)
-	nodes map[string][2]float64
-	// 邻接表：节点名 -> 邻居节点集合
-	edges map[string]map[string]struct{}
+	data        map[string]*graphObject
+	expireTimes map[string]time.Time
+	keyLock     *KeyLock
+	maxExplored int // 单次最短路径查询最多展开的节点数
 }
 
-// DataGkvGraph 全局图实例
+// DataGkvGraph 全局数据实例
 var DataGkvGraph = &GkvGraph{
-	nodes: make(map[string][2]float64),
-	edges: make(map[string]map[string]struct{}),
+	data:        make(map[string]*graphObject),
+	expireTimes: make(map[string]time.Time),
+	keyLock:     NewKeyLock(),
+	maxExplored: graphDefaultMaxExplored,
 }
 
-// AddNode 添加节点及其坐标
+// AddNode 添加节点及其坐标, 图不存在时创建
+// @param key string 图名
 // @param name string 节点名
 // @param x, y float64 坐标
-func (g *GkvGraph) AddNode(name string, x, y float64) {
-	g.nodes[name] = [2]float64{x, y}
-	if _, exists := g.edges[name]; !exists {
-		g.edges[name] = make(map[string]struct{})
+func (g *GkvGraph) AddNode(key, name string, x, y float64) {
+	g.keyLock.WLockRow(key)
+	defer g.keyLock.WUnLockRow(key)
+	g.writableGraph(key).addNode(name, x, y)
+	delete(g.expireTimes, key)
+	notifyKeyspaceEvent(NotifyGraph, "graph.addnode", key)
+}
+
+// AddEdge 添加边, 图或节点不存在时创建, 已存在的边被替换
+// @param key string 图名
+// @param edge GraphEdge 边, Directed 为false时添加无向边
+func (g *GkvGraph) AddEdge(key string, edge GraphEdge) {
+	g.keyLock.WLockRow(key)
+	defer g.keyLock.WUnLockRow(key)
+	g.writableGraph(key).addEdge(edge)
+	delete(g.expireTimes, key)
+	notifyKeyspaceEvent(NotifyGraph, "graph.addedge", key)
+}
+
+// RemoveEdge 删除边, 无向边的两个方向同时删除
+// @param key string 图名
+// @param from, to string 节点名
+// @return bool 边是否存在
+func (g *GkvGraph) RemoveEdge(key, from, to string) bool {
+	g.keyLock.WLockRow(key)
+	defer g.keyLock.WUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil || !graph.removeEdge(from, to) {
+		return false
+	}
+	delete(g.expireTimes, key)
+	notifyKeyspaceEvent(NotifyGraph, "graph.remedge", key)
+	return true
+}
+
+// RemoveNode 删除节点及与其相连的全部出边和入边, 图中没有节点时删除整个图
+// @param key string 图名
+// @param name string 节点名
+// @return bool 节点是否存在
+func (g *GkvGraph) RemoveNode(key, name string) bool {
+	g.keyLock.WLockRow(key)
+	defer g.keyLock.WUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil || !graph.removeNode(name) {
+		return false
+	}
+	delete(g.expireTimes, key)
+	notifyKeyspaceEvent(NotifyGraph, "graph.remnode", key)
+	if graph.empty() {
+		delete(g.data, key)
+		notifyKeyspaceEvent(NotifyGeneric, "del", key)
+	}
+	return true
+}
+
+// AddLabels 为节点添加标签, 图或节点不存在时创建
+// @param key string 图名
+// @param name string 节点名
+// @param labels ...string 标签
+// @return int 新增的标签数
+func (g *GkvGraph) AddLabels(key, name string, labels ...string) int {
+	g.keyLock.WLockRow(key)
+	defer g.keyLock.WUnLockRow(key)
+	graph := g.writableGraph(key)
+	added := 0
+	for _, label := range labels {
+		if graph.addLabel(name, label) {
+			added++
+		}
+	}
+	delete(g.expireTimes, key)
+	if added > 0 {
+		notifyKeyspaceEvent(NotifyGraph, "graph.addlabel", key)
+	}
+	return added
+}
+
+// RemoveLabels 删除节点的标签
+// @param key string 图名
+// @param name string 节点名
+// @param labels ...string 标签
+// @return int 删除的标签数
+func (g *GkvGraph) RemoveLabels(key, name string, labels ...string) int {
+	g.keyLock.WLockRow(key)
+	defer g.keyLock.WUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return 0
+	}
+	removed := 0
+	for _, label := range labels {
+		if graph.removeLabel(name, label) {
+			removed++
+		}
+	}
+	if removed > 0 {
+		delete(g.expireTimes, key)
+		notifyKeyspaceEvent(NotifyGraph, "graph.remlabel", key)
+	}
+	return removed
+}
+
+// SetNodeProps 设置节点属性, 图或节点不存在时创建
+// @param key string 图名
+// @param name string 节点名
+// @param props map[string]string 属性名 -> 属性值
+func (g *GkvGraph) SetNodeProps(key, name string, props map[string]string) {
+	g.keyLock.WLockRow(key)
+	defer g.keyLock.WUnLockRow(key)
+	graph := g.writableGraph(key)
+	graph.ensureNode(name)
+	for prop, value := range props {
+		graph.setProp(name, prop, value)
+	}
+	delete(g.expireTimes, key)
+	notifyKeyspaceEvent(NotifyGraph, "graph.setprop", key)
+}
+
+// RemoveNodeProps 删除节点属性
+// @param key string 图名
+// @param name string 节点名
+// @param props ...string 属性名
+// @return int 删除的属性数
+func (g *GkvGraph) RemoveNodeProps(key, name string, props ...string) int {
+	g.keyLock.WLockRow(key)
+	defer g.keyLock.WUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return 0
+	}
+	removed := 0
+	for _, prop := range props {
+		if graph.removeProp(name, prop) {
+			removed++
+		}
+	}
+	if removed > 0 {
+		delete(g.expireTimes, key)
+		notifyKeyspaceEvent(NotifyGraph, "graph.remprop", key)
+	}
+	return removed
+}
+
+// Node 获取节点的坐标、标签与属性
+// @param key string 图名
+// @param name string 节点名
+// @return GraphNode
+// @return bool 节点是否存在
+func (g *GkvGraph) Node(key, name string) (GraphNode, bool) {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return GraphNode{}, false
 	}
+	return graph.node(name)
 }
 
-// AddEdge 添加无向边
+// Nodes 获取全部节点名
+// @param key string 图名
+// @return []string 按字典序排列
+func (g *GkvGraph) Nodes(key string) []string {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []string{}
+	}
+	return graph.nodeNames()
+}
+
+// Edges 获取全部边, 无向边只列出一次
+// @param key string 图名
+// @return []GraphEdge 按起点、终点字典序排列
+func (g *GkvGraph) Edges(key string) []GraphEdge {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []GraphEdge{}
+	}
+	return graph.edges()
+}
+
+// Edge 获取从 from 到 to 的边
+// @param key string 图名
 // @param from, to string 节点名
-func (g *GkvGraph) AddEdge(from, to string) {
-	if _, exists := g.edges[from]; !exists {
-		g.edges[from] = make(map[string]struct{})
+// @return GraphEdge
+// @return bool 边是否存在
+func (g *GkvGraph) Edge(key, from, to string) (GraphEdge, bool) {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return GraphEdge{}, false
 	}
-	if _, exists := g.edges[to]; !exists {
-		g.edges[to] = make(map[string]struct{})
+	return graph.edge(from, to)
+}
+
+// Degree 获取节点的入度与出度, 无向边同时计入两者
+// @param key string 图名
+// @param name string 节点名
+// @return int 入度
+// @return int 出度
+// @return bool 节点是否存在
+func (g *GkvGraph) Degree(key, name string) (int, int, bool) {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return 0, 0, false
 	}
-	g.edges[from][to] = struct{}{}
-	g.edges[to][from] = struct{}{}
+	return graph.degree(name)
 }
 
 // EuclideanDistance 计算两节点的欧氏距离
+// @param key string 图名
 // @param a, b string 节点名
 // @return float64 距离, bool 是否存在
-func (g *GkvGraph) EuclideanDistance(a, b string) (float64, bool) {
-	na, oka := g.nodes[a]
-	nb, okb := g.nodes[b]
-	if !oka || !okb {
+func (g *GkvGraph) EuclideanDistance(key, a, b string) (float64, bool) {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
 		return 0, false
 	}
-	dx := na[0] - nb[0]
-	dy := na[1] - nb[1]
-	return math.Sqrt(dx*dx + dy*dy), true
+	return graph.distance(a, b)
 }
 
 // DFS 深度优先搜索，返回遍历顺序
+// @param key string 图名
 // @param start string 起点
 // @return []string 遍历顺序
-func (g *GkvGraph) DFS(start string) []string {
-	visited := make(map[string]bool)
-	result := []string{}
-	var dfs func(string)
-	dfs = func(node string) {
-		if visited[node] {
-			return
-		}
-		visited[node] = true
-		result = append(result, node)
-		for neighbor := range g.edges[node] {
-			dfs(neighbor)
-		}
+func (g *GkvGraph) DFS(key, start string) []string {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []string{}
 	}
-	dfs(start)
-	return result
+	return graph.dfs(start)
 }
 
 // BFS 广度优先搜索，返回遍历顺序
+// @param key string 图名
 // @param start string 起点
 // @return []string 遍历顺序
-func (g *GkvGraph) BFS(start string) []string {
-	visited := make(map[string]bool)
-	result := []string{}
-	q := list.New()
-	q.PushBack(start)
-	visited[start] = true
-	for q.Len() > 0 {
-		e := q.Front()
-		node := e.Value.(string)
-		q.Remove(e)
-		result = append(result, node)
-		for neighbor := range g.edges[node] {
-			if !visited[neighbor] {
-				visited[neighbor] = true
-				q.PushBack(neighbor)
+func (g *GkvGraph) BFS(key, start string) []string {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []string{}
+	}
+	return graph.bfs(start)
+}
+
+// Delete 删除整个图
+// @param key string 图名
+// @return bool 图是否存在
+func (g *GkvGraph) Delete(key string) bool {
+	return g.deleteKey(key)
+}
+
+// liveGraph 获取未过期的图, 调用方需持有key的锁
+// @param key string
+// @return *graphObject 不存在或已过期时为nil
+func (g *GkvGraph) liveGraph(key string) *graphObject {
+	if expireTime, exists := g.expireTimes[key]; exists && time.Now().After(expireTime) {
+		return nil
+	}
+	return g.data[key]
+}
+
+// writableGraph 获取用于写入的图, 不存在或已过期时创建空图, 调用方需持有key的写锁
+// @param key string
+// @return *graphObject
+func (g *GkvGraph) writableGraph(key string) *graphObject {
+	if expireTime, exists := g.expireTimes[key]; exists && time.Now().After(expireTime) {
+		delete(g.data, key)
+		delete(g.expireTimes, key)
+		notifyKeyspaceEvent(NotifyExpired, "expired", key)
+	}
+	if _, exists := g.data[key]; !exists {
+		g.data[key] = newGraphObject()
+	}
+	return g.data[key]
+}
+
+// SetTime 设置过期时间(毫秒为单位)
+// @param key string 图名
+// @param timeMs int 过期时间(毫秒数)
+// @return bool 是否设置成功
+func (g *GkvGraph) SetTime(key string, timeMs int) bool {
+	g.keyLock.WLockRow(key)
+	defer g.keyLock.WUnLockRow(key)
+	if _, exists := g.data[key]; exists {
+		expireTime := time.Now().Add(time.Duration(timeMs) * time.Millisecond)
+		g.expireTimes[key] = expireTime
+		notifyKeyspaceEvent(NotifyGeneric, "expire", key)
+		return true
+	}
+	return false
+}
+
+// GetTTL 获取key的剩余生存时间(毫秒数)
+// @param key string 图名
+// @return int64 剩余生存时间
+// @return 0 键已过期
+// @return -1 键不存在
+// @return -2 键没有设置过期时间
+func (g *GkvGraph) GetTTL(key string) int64 {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	if _, exists := g.data[key]; !exists {
+		return -1
+	}
+	expireTime, exists := g.expireTimes[key]
+	if !exists {
+		return -2
+	}
+	remaining := time.Until(expireTime)
+	if remaining <= 0 {
+		return 0
+	}
+	return int64(remaining.Milliseconds())
+}
+
+// liveKeys 获取所有未过期的key
+// @return []string
+func (g *GkvGraph) liveKeys() []string {
+	return collectLiveKeys(g.keyLock, g.data, g.expireTimes)
+}
+
+// deleteKey 删除整个key
+// @param key string
+// @return bool key是否存在
+func (g *GkvGraph) deleteKey(key string) bool {
+	return removeKey(g.keyLock, g.data, g.expireTimes, key)
+}
+
+// removeExpired 删除所有已过期的key
+// @return []string 被删除的key
+func (g *GkvGraph) removeExpired() []string {
+	return removeExpiredKeys(g.keyLock, g.data, g.expireTimes)
+}
+
+// graphSnapshot 单个图的快照形式
+type graphSnapshot struct {
+	// 全部节点名, 包含没有坐标的节点
+	Names []string
+	// 有坐标的节点
+	Nodes map[string][2]float64
+	// 全部边, 无向边只记录一次
+	Edges []GraphEdge
+	// 节点标签
+	Labels map[string][]string
+	// 节点属性
+	Props map[string]map[string]string
+}
+
+// graphsSnapshot 全部图的快照形式
+type graphsSnapshot struct {
+	Graphs      map[string]graphSnapshot
+	ExpireTimes map[string]time.Time
+}
+
+// snapshot 生成快照
+// @return graphSnapshot
+func (g *graphObject) snapshot() graphSnapshot {
+	s := graphSnapshot{
+		Names:  g.nodeNames(),
+		Nodes:  maps.Clone(g.nodes),
+		Edges:  g.edges(),
+		Labels: make(map[string][]string, len(g.labels)),
+		Props:  make(map[string]map[string]string, len(g.props)),
+	}
+	for name, labels := range g.labels {
+		s.Labels[name] = slices.Sorted(maps.Keys(labels))
+	}
+	for name, props := range g.props {
+		s.Props[name] = maps.Clone(props)
+	}
+	return s
+}
+
+// graphFromSnapshot 由快照还原图
+// @param s graphSnapshot
+// @return *graphObject
+func graphFromSnapshot(s graphSnapshot) *graphObject {
+	graph := newGraphObject()
+	for _, name := range s.Names {
+		graph.ensureNode(name)
+	}
+	for name, pos := range s.Nodes {
+		graph.addNode(name, pos[0], pos[1])
+	}
+	for _, e := range s.Edges {
+		graph.addEdge(e)
+	}
+	for name, labels := range s.Labels {
+		for _, label := range labels {
+			graph.addLabel(name, label)
+		}
+	}
+	for name, props := range s.Props {
+		for prop, value := range props {
+			graph.setProp(name, prop, value)
+		}
+	}
+	return graph
+}
+
+// SaveSnapshot 将全部未过期的图写入快照
+// @param w io.Writer
+// @return error
+func (g *GkvGraph) SaveSnapshot(w io.Writer) error {
+	tmp := graphsSnapshot{
+		Graphs:      make(map[string]graphSnapshot),
+		ExpireTimes: make(map[string]time.Time),
+	}
+	for _, key := range g.liveKeys() {
+		g.keyLock.RLockRow(key)
+		if graph := g.liveGraph(key); graph != nil {
+			tmp.Graphs[key] = graph.snapshot()
+			if expireTime, exists := g.expireTimes[key]; exists {
+				tmp.ExpireTimes[key] = expireTime
 			}
 		}
+		g.keyLock.RUnLockRow(key)
+	}
+	return gob.NewEncoder(w).Encode(tmp)
+}
+
+// LoadSnapshot 从快照加载图, 替换当前全部的图
+// @param r io.Reader
+// @return error
+func (g *GkvGraph) LoadSnapshot(r io.Reader) error {
+	tmp := graphsSnapshot{}
+	if err := gob.NewDecoder(r).Decode(&tmp); err != nil {
+		return err
+	}
+	dataMap := make(map[string]*graphObject, len(tmp.Graphs))
+	for key, s := range tmp.Graphs {
+		dataMap[key] = graphFromSnapshot(s)
+	}
+	if tmp.ExpireTimes == nil {
+		tmp.ExpireTimes = make(map[string]time.Time)
 	}
-	return result
+	g.keyLock.LockTable()
+	defer g.keyLock.UnLockTable()
+	g.data = dataMap
+	g.expireTimes = tmp.ExpireTimes
+	return nil
 }
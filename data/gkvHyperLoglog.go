@@ -1,7 +1,6 @@
 package data
 
 import (
-	"hash/fnv"
 	"time"
 )
 
@@ -9,93 +8,159 @@ import (
 // @author xuyang
 // @datetime 2025-7-16 21:00
 type GkvHyperLoglog struct {
-	data        map[string][]uint8 // key -> register array
+	data        map[string]*hllObject // key -> 寄存器
 	expireTimes map[string]time.Time
 	keyLock     *KeyLock
-	precision   uint8 // 桶数量为 2^precision
+	precision   uint8 // 新建key的桶数量为 2^precision
 }
 
 // DataGkvHyperLoglog 全局数据实例
 var DataGkvHyperLoglog = &GkvHyperLoglog{
-	data:        make(map[string][]uint8),
+	data:        make(map[string]*hllObject),
 	expireTimes: make(map[string]time.Time),
 	keyLock:     NewKeyLock(),
-	precision:   14, // 16384 桶
+	precision:   hllDefaultPrecision, // 16384 桶
+}
+
+// SetPrecision 设置新建key使用的精度, 已存在的key保持原精度
+// 精度越高误差越小(标准误差约 1.04/sqrt(2^p)), 稠密编码占用 2^p 字节
+// @param p uint8 精度, 4 到 18
+// @return error
+func (hll *GkvHyperLoglog) SetPrecision(p uint8) error {
+	if p < hllMinPrecision || p > hllMaxPrecision {
+		return errHLLPrecision
+	}
+	hll.precision = p
+	return nil
 }
 
 // Add 添加元素
 // @author xuyang
 // @datetime 2025-7-20 23:00
 // @param key string
-// @param element string
-func (hll *GkvHyperLoglog) Add(key, element string) {
+// @param elements ...string 若干元素, 为空时只创建key
+// @return bool key是否新建或有寄存器改变
+func (hll *GkvHyperLoglog) Add(key string, elements ...string) bool {
 	hll.keyLock.WLockRow(key)
 	defer hll.keyLock.WUnLockRow(key)
-	if _, exists := hll.data[key]; !exists {
-		hll.data[key] = make([]uint8, 1<<hll.precision)
-	}
-	// 哈希值计算
-	h := fnv.New64a()
-	h.Write([]byte(element))
-	hash := h.Sum64()
-	idx := hash & ((1 << hll.precision) - 1)
-	w := hash >> hll.precision
-	zeros := uint8(1)
-	for w != 0 && (w&1) == 0 {
-		zeros++
-		w >>= 1
-	}
-	if hll.data[key][idx] < zeros {
-		hll.data[key][idx] = zeros
+	changed := hll.liveHLL(key) == nil
+	h := hll.writableHLL(key)
+	for _, element := range elements {
+		if h.add(element) {
+			changed = true
+		}
 	}
 	delete(hll.expireTimes, key)
+	if changed {
+		notifyKeyspaceEvent(NotifyString, "pfadd", key)
+	}
+	return changed
 }
 
-// Count 估算基数
+// Count 估算基数, 指定多个key时估算它们并集的基数
+// 多个key在临时寄存器中合并, 不会修改任何key
 // @author xuyang
 // @datetime 2025-7-20 23:00
-// @param key string 键
-// @return uint64 估算得到的基数(误差0.81%)
-func (hll *GkvHyperLoglog) Count(key string) uint64 {
-	hll.keyLock.RLockRow(key)
-	defer hll.keyLock.RUnLockRow(key)
-	registers, exists := hll.data[key]
-	if !exists {
-		return 0
+// @param keys ...string 键
+// @return uint64 估算得到的基数(精度14时误差0.81%)
+func (hll *GkvHyperLoglog) Count(keys ...string) uint64 {
+	hll.keyLock.RLockRows(keys...)
+	defer hll.keyLock.RUnLockRows(keys...)
+	objs := []*hllObject{}
+	for _, key := range keys {
+		if h := hll.liveHLL(key); h != nil {
+			objs = append(objs, h)
+		}
 	}
-	m := float64(len(registers))
-	sum := 0.0
-	for _, v := range registers {
-		sum += 1.0 / float64(uint64(1)<<v)
+	switch len(objs) {
+	case 0:
+		return 0
+	case 1:
+		return objs[0].count()
 	}
-	alpha := 0.7213 / (1 + 1.079/m)
-	est := alpha * m * m / sum
-	return uint64(est)
+	return mergeHLL(objs...).count()
 }
 
 // Merge 合并多个 HyperLogLog
+// 精度不同时按其中最低的精度合并, 目标key的精度随之降低
 // @param dest string 目标HLL
 // @param srcs ...string 要被合并的若干个HLL
 func (hll *GkvHyperLoglog) Merge(dest string, srcs ...string) {
-	hll.keyLock.WLockRow(dest)
-	if _, exists := hll.data[dest]; !exists && len(srcs) > 0 {
-		hll.data[dest] = make([]uint8, 1<<hll.precision)
-	}
-	for _, src := range srcs {
-		hll.keyLock.RLockRow(src)
-		srcReg, exists := hll.data[src]
-		hll.keyLock.RUnLockRow(src)
-		if !exists {
-			continue
+	// 目标与来源可能共用同一把行锁, 统一加锁避免重入死锁
+	locked := append([]string{dest}, srcs...)
+	hll.keyLock.WLockRows(locked...)
+	defer hll.keyLock.WUnLockRows(locked...)
+	objs := []*hllObject{}
+	for _, key := range locked {
+		if h := hll.liveHLL(key); h != nil {
+			objs = append(objs, h)
 		}
-		for i, v := range srcReg {
-			if hll.data[dest][i] < v {
-				hll.data[dest][i] = v
-			}
+	}
+	if len(objs) == 0 {
+		if len(srcs) > 0 {
+			hll.data[dest] = newHLLObject(hll.precision)
+			delete(hll.expireTimes, dest)
+			notifyKeyspaceEvent(NotifyString, "pfadd", dest)
 		}
+		return
 	}
+	hll.data[dest] = mergeHLL(objs...)
 	delete(hll.expireTimes, dest)
-	hll.keyLock.WUnLockRow(dest)
+	notifyKeyspaceEvent(NotifyString, "pfadd", dest)
+}
+
+// mergeHLL 将若干 HyperLogLog 合并到一份新的稠密寄存器中, 不修改来源
+// @param objs ...*hllObject 至少一个
+// @return *hllObject 精度为来源中最低的精度
+func mergeHLL(objs ...*hllObject) *hllObject {
+	p := objs[0].p
+	for _, h := range objs[1:] {
+		p = min(p, h.p)
+	}
+	merged := &hllObject{p: p, dense: make([]uint8, 1<<p)}
+	for _, h := range objs {
+		h.foldInto(merged.dense, p)
+	}
+	return merged
+}
+
+// liveHLL 获取未过期的 HyperLogLog, 调用方需持有key的锁
+// @param key string
+// @return *hllObject 不存在或已过期时为nil
+func (hll *GkvHyperLoglog) liveHLL(key string) *hllObject {
+	if expireTime, exists := hll.expireTimes[key]; exists && time.Now().After(expireTime) {
+		return nil
+	}
+	return hll.data[key]
+}
+
+// writableHLL 获取用于写入的 HyperLogLog, 不存在或已过期时按当前精度创建, 调用方需持有key的写锁
+// @param key string
+// @return *hllObject
+func (hll *GkvHyperLoglog) writableHLL(key string) *hllObject {
+	if expireTime, exists := hll.expireTimes[key]; exists && time.Now().After(expireTime) {
+		delete(hll.data, key)
+		delete(hll.expireTimes, key)
+		notifyKeyspaceEvent(NotifyExpired, "expired", key)
+	}
+	if _, exists := hll.data[key]; !exists {
+		hll.data[key] = newHLLObject(hll.precision)
+	}
+	return hll.data[key]
+}
+
+// Encoding 获取 HyperLogLog 当前使用的编码
+// @param key string
+// @return string 编码名称: sparse / dense
+// @return bool 是否存在
+func (hll *GkvHyperLoglog) Encoding(key string) (string, bool) {
+	hll.keyLock.RLockRow(key)
+	defer hll.keyLock.RUnLockRow(key)
+	h := hll.liveHLL(key)
+	if h == nil {
+		return "", false
+	}
+	return h.encoding(), true
 }
 
 // HSetTime 设置过期时间(毫秒为单位)
@@ -110,6 +175,7 @@ func (hll *GkvHyperLoglog) HSetTime(key string, timeMs int) bool {
 	if _, exists := hll.data[key]; exists {
 		expireTime := time.Now().Add(time.Duration(timeMs) * time.Millisecond)
 		hll.expireTimes[key] = expireTime
+		notifyKeyspaceEvent(NotifyGeneric, "expire", key)
 		return true
 	}
 	return false
@@ -139,3 +205,22 @@ func (gkv *GkvHyperLoglog) HGetTTL(key string) int64 {
 	}
 	return int64(remaining.Milliseconds())
 }
+
+// liveKeys 获取所有未过期的key
+// @return []string
+func (hll *GkvHyperLoglog) liveKeys() []string {
+	return collectLiveKeys(hll.keyLock, hll.data, hll.expireTimes)
+}
+
+// deleteKey 删除整个key
+// @param key string
+// @return bool key是否存在
+func (hll *GkvHyperLoglog) deleteKey(key string) bool {
+	return removeKey(hll.keyLock, hll.data, hll.expireTimes, key)
+}
+
+// removeExpired 删除所有已过期的key
+// @return []string 被删除的key
+func (hll *GkvHyperLoglog) removeExpired() []string {
+	return removeExpiredKeys(hll.keyLock, hll.data, hll.expireTimes)
+}
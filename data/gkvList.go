@@ -31,6 +31,7 @@ func (gkvList *GkvList) LRPush(key string, value []string) {
 	gkvList.keyLock.WLockRow(key)
 	defer gkvList.keyLock.WUnLockRow(key)
 	gkvList.date[key] = append(gkvList.data[key], value)
+	notifyKeyspaceEvent(NotifyList, "rpush", key)
 }
 
 // LLPush 从左侧推入数据
@@ -42,6 +43,7 @@ func (gkvList *GkvList) LLPush(key string, value []string) {
 	gkvList.keyLock.WLockRow(key)
 	defer gkvList.keyLock.WUnLockRow(key)
 	gkvList.date[key] = append(value, gkvList.data[key])
+	notifyKeyspaceEvent(NotifyList, "lpush", key)
 }
 
 // LRPop 从右侧弹出数据
@@ -58,6 +60,7 @@ func (gkvList *GkvList) LRPop(key string) (string, bool) {
 	}
 	element := gkvList.data[key][n-1]
 	gkvList.data[key] := gkvList.data[key][:n]
+	notifyKeyspaceEvent(NotifyList, "rpop", key)
 	return element, true
 }
 
@@ -74,6 +77,7 @@ func (gkvList *GkvList) LLPop(key string) (string, bool) {
 	}
 	element := gkvList.data[key][0]
 	gkvList.data[key] = gkvList.data[key][1:]
+	notifyKeyspaceEvent(NotifyList, "lpop", key)
 	return element, true
 }
 
@@ -112,8 +116,8 @@ func (gkvList *GkvList) LLTop(key string) (string, bool) {
 // @author xuyang
 // @datetime 2025-6-24 6:00
 func (gkvList *GkvList) GetAllKeys() []string {
-	gkvList.keyLock.tableLock.Lock()
-	defer gkvList.keyLock.tableLock.Unlock()
+	gkvList.keyLock.RLockTable()
+	defer gkvList.keyLock.RUnLockTable()
 	keys := make([]string, 0, len(gkvList.data))
 	for key := range gkvList.data {
 		keys = append(keys, key)
@@ -129,6 +133,7 @@ func (gkvList *GkvList) LSetTime(key string, timeMs int) bool {
 	if _, exists := gkvList.data[key]; exists {
 		expireTime := time.Now().Add(time.Duration(timeMs) * time.Millisecond)
 		gkvList.expireTimes[key] = expireTime
+		notifyKeyspaceEvent(NotifyGeneric, "expire", key)
 		return true
 	} else {
 		return false
@@ -159,3 +164,22 @@ func (gkv *GkvList) GetTTL(key string) int64 {
 	}
 	return int64(remaining.Milliseconds())
 }
+
+// liveKeys 获取所有未过期的key
+// @return []string
+func (gkvList *GkvList) liveKeys() []string {
+	return collectLiveKeys(gkvList.keyLock, gkvList.data, gkvList.expireTimes)
+}
+
+// deleteKey 删除整个key
+// @param key string
+// @return bool key是否存在
+func (gkvList *GkvList) deleteKey(key string) bool {
+	return removeKey(gkvList.keyLock, gkvList.data, gkvList.expireTimes, key)
+}
+
+// removeExpired 删除所有已过期的key
+// @return []string 被删除的key
+func (gkvList *GkvList) removeExpired() []string {
+	return removeExpiredKeys(gkvList.keyLock, gkvList.data, gkvList.expireTimes)
+}
@@ -9,7 +9,7 @@ import (
 // @datetime 2025-7-16 21:00
 type GkvMap struct {
 	// 全部数据 key - filed - value
-	data        map[string]map[string]string
+	data        map[string]*hashObject
 	// 全部数据的过期时间
 	expireTimes map[string]time.Time
 	// 锁实例
@@ -20,7 +20,7 @@ type GkvMap struct {
 // @author xuyang
 // @datetime 2025-7-16 21:00
 var DataGkvMap = &GkvMap{
-	data:        make(map[string]map[string]string),
+	data:        make(map[string]*hashObject),
 	expireTimes: make(map[string]time.Time),
 	keyLock:     NewKeyLock(),
 }
@@ -36,10 +36,11 @@ func (gkvMap *GkvMap) MSet(key, field, value string) bool {
 	gkvMap.keyLock.WLockRow(key)
 	defer gkvMap.keyLock.WUnLockRow(key)
 	if _, exists := gkvMap.data[key]; !exists {
-		gkvMap.data[key] = make(map[string]string)
+		gkvMap.data[key] = newHashObject()
 	}
-	gkvMap.data[key][field] = value
+	gkvMap.data[key].set(field, value)
 	delete(gkvMap.expireTimes, key)
+	notifyKeyspaceEvent(NotifyHash, "hset", key)
 	return true
 }
 
@@ -62,8 +63,7 @@ func (gkvMap *GkvMap) MGet(key, field string) (value string, ok bool) {
 	if !exists {
 		return "", false
 	}
-	value, ok = fields[field]
-	return
+	return fields.get(field)
 }
 
 // Delete 删除某个key或field
@@ -72,11 +72,12 @@ func (gkvMap *GkvMap) MGet(key, field string) (value string, ok bool) {
 func (gkvMap *GkvMap) Delete(key, field string) {
 	gkvMap.keyLock.WLockRow(key)
 	defer gkvMap.keyLock.WUnLockRow(key)
-	if fields, exists := gkvMap.data[key]; exists {
-		delete(fields, field)
-		if len(fields) == 0 {
+	if fields, exists := gkvMap.data[key]; exists && fields.del(field) {
+		notifyKeyspaceEvent(NotifyHash, "hdel", key)
+		if fields.len() == 0 {
 			delete(gkvMap.data, key)
 			delete(gkvMap.expireTimes, key)
+			notifyKeyspaceEvent(NotifyGeneric, "del", key)
 		}
 	}
 }
@@ -96,10 +97,11 @@ func (gkvMap *GkvMap) GetAllFields(key string) []string {
 	if !exists {
 		return nil
 	}
-	result := make([]string, 0, len(fields))
-	for f := range fields {
+	result := make([]string, 0, fields.len())
+	fields.each(func(f, _ string) bool {
 		result = append(result, f)
-	}
+		return true
+	})
 	return result
 }
 
@@ -113,6 +115,7 @@ func (gkvMap *GkvMap) SetTime(key string, timeMs int) bool {
 	if _, exists := gkvMap.data[key]; exists {
 		expireTime := time.Now().Add(time.Duration(timeMs) * time.Millisecond)
 		gkvMap.expireTimes[key] = expireTime
+		notifyKeyspaceEvent(NotifyGeneric, "expire", key)
 		return true
 	}
 	return false
@@ -139,4 +142,40 @@ func (gkvMap *GkvMap) GetTTL(key string) int64 {
 		return 0
 	}
 	return int64(remaining.Milliseconds())
-}
\ No newline at end of file
+}
+
+// Encoding 获取映射当前使用的编码
+// @param key string
+// @return string 编码名称
+// @return bool key是否存在
+func (gkvMap *GkvMap) Encoding(key string) (string, bool) {
+	gkvMap.keyLock.RLockRow(key)
+	defer gkvMap.keyLock.RUnLockRow(key)
+	if expireTime, exists := gkvMap.expireTimes[key]; exists && time.Now().After(expireTime) {
+		return "", false
+	}
+	fields, exists := gkvMap.data[key]
+	if !exists {
+		return "", false
+	}
+	return fields.encoding(), true
+}
+
+// liveKeys 获取所有未过期的key
+// @return []string
+func (gkvMap *GkvMap) liveKeys() []string {
+	return collectLiveKeys(gkvMap.keyLock, gkvMap.data, gkvMap.expireTimes)
+}
+
+// deleteKey 删除整个key
+// @param key string
+// @return bool key是否存在
+func (gkvMap *GkvMap) deleteKey(key string) bool {
+	return removeKey(gkvMap.keyLock, gkvMap.data, gkvMap.expireTimes, key)
+}
+
+// removeExpired 删除所有已过期的key
+// @return []string 被删除的key
+func (gkvMap *GkvMap) removeExpired() []string {
+	return removeExpiredKeys(gkvMap.keyLock, gkvMap.data, gkvMap.expireTimes)
+}
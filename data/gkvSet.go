@@ -1,15 +1,23 @@
 package data
 
 import (
+	"errors"
+	"math/rand/v2"
 	"time"
 )
 
+// setRandMemberMaxRepeat 可重复随机成员的最大数量
+const setRandMemberMaxRepeat = 1 << 20
+
+// errRandMemberCount 可重复随机成员的数量过大
+var errRandMemberCount = errors.New("count 为负数时绝对值不能超过 1048576")
+
 // GkvSet 集合结构
 // @author xuyang
 // @datetime 2025-7-16 21:00
 type GkvSet struct {
 	// 全部数据 key -> set成员集合
-	data        map[string]map[string]struct{}
+	data        map[string]*setObject
 	// 全部数据的过期时间
 	expireTimes map[string]time.Time
 	// 锁实例
@@ -20,37 +28,103 @@ type GkvSet struct {
 // @author xuyang
 // @datetime 2025-7-16 21:00
 var DataGkvSet = &GkvSet{
-	data:        make(map[string]map[string]struct{}),
+	data:        make(map[string]*setObject),
 	expireTimes: make(map[string]time.Time),
 	keyLock:     NewKeyLock(),
 }
 
 // Add 向集合添加成员
 // @param key string 集合名
-// @param member string 成员
-func (gkvSet *GkvSet) Add(key, member string) {
+// @param members ...string 成员
+// @return int 新增的成员数量
+func (gkvSet *GkvSet) Add(key string, members ...string) int {
 	gkvSet.keyLock.WLockRow(key)
 	defer gkvSet.keyLock.WUnLockRow(key)
+	added := gkvSet.addLocked(key, members...)
+	if added > 0 {
+		notifyKeyspaceEvent(NotifySet, "sadd", key)
+	}
+	return added
+}
+
+// addLocked 向集合添加成员, 调用方需持有key的写锁
+// @param key string 集合名
+// @param members ...string 成员
+// @return int 新增的成员数量
+func (gkvSet *GkvSet) addLocked(key string, members ...string) int {
+	gkvSet.dropExpired(key)
 	if _, exists := gkvSet.data[key]; !exists {
-		gkvSet.data[key] = make(map[string]struct{})
+		gkvSet.data[key] = newSetObject(nil)
+	}
+	added := 0
+	for _, member := range members {
+		if gkvSet.data[key].add(member) {
+			added++
+		}
+	}
+	if gkvSet.data[key].len() == 0 {
+		delete(gkvSet.data, key)
 	}
-	gkvSet.data[key][member] = struct{}{}
 	delete(gkvSet.expireTimes, key)
+	return added
 }
 
 // Remove 从集合移除成员
 // @param key string 集合名
-// @param member string 成员
-func (gkvSet *GkvSet) Remove(key, member string) {
+// @param members ...string 成员
+// @return int 被移除的成员数量
+func (gkvSet *GkvSet) Remove(key string, members ...string) int {
 	gkvSet.keyLock.WLockRow(key)
 	defer gkvSet.keyLock.WUnLockRow(key)
-	if members, exists := gkvSet.data[key]; exists {
-		delete(members, member)
-		if len(members) == 0 {
-			delete(gkvSet.data, key)
-			delete(gkvSet.expireTimes, key)
+	return gkvSet.removeLocked(key, "srem", members...)
+}
+
+// removeLocked 从集合移除成员, 集合为空时删除key, 调用方需持有key的写锁
+// @param key string 集合名
+// @param event string 移除了成员时发出的通知事件名
+// @param members ...string 成员
+// @return int 被移除的成员数量
+func (gkvSet *GkvSet) removeLocked(key, event string, members ...string) int {
+	gkvSet.dropExpired(key)
+	set, exists := gkvSet.data[key]
+	if !exists {
+		return 0
+	}
+	removed := 0
+	for _, member := range members {
+		if set.remove(member) {
+			removed++
 		}
 	}
+	if removed > 0 {
+		notifyKeyspaceEvent(NotifySet, event, key)
+	}
+	if set.len() == 0 {
+		delete(gkvSet.data, key)
+		delete(gkvSet.expireTimes, key)
+		notifyKeyspaceEvent(NotifyGeneric, "del", key)
+	}
+	return removed
+}
+
+// dropExpired 删除已过期的key, 调用方需持有key的写锁
+// @param key string 集合名
+func (gkvSet *GkvSet) dropExpired(key string) {
+	if expireTime, exists := gkvSet.expireTimes[key]; exists && time.Now().After(expireTime) {
+		delete(gkvSet.data, key)
+		delete(gkvSet.expireTimes, key)
+		notifyKeyspaceEvent(NotifyExpired, "expired", key)
+	}
+}
+
+// liveMembers 获取未过期集合的成员表, 调用方需持有key的锁
+// @param key string 集合名
+// @return *setObject 集合, 不存在或已过期时为nil
+func (gkvSet *GkvSet) liveMembers(key string) *setObject {
+	if expireTime, exists := gkvSet.expireTimes[key]; exists && time.Now().After(expireTime) {
+		return nil
+	}
+	return gkvSet.data[key]
 }
 
 // IsMember 判断成员是否存在
@@ -65,12 +139,7 @@ func (gkvSet *GkvSet) IsMember(key, member string) bool {
 			return false
 		}
 	}
-	members, exists := gkvSet.data[key]
-	if !exists {
-		return false
-	}
-	_, ok := members[member]
-	return ok
+	return gkvSet.data[key].has(member)
 }
 
 // GetAllMembers 获取集合所有成员
@@ -88,11 +157,7 @@ func (gkvSet *GkvSet) GetAllMembers(key string) []string {
 	if !exists {
 		return nil
 	}
-	result := make([]string, 0, len(members))
-	for m := range members {
-		result = append(result, m)
-	}
-	return result
+	return members.members()
 }
 
 // SetTime 设置过期时间(毫秒为单位)
@@ -105,6 +170,7 @@ func (gkvSet *GkvSet) SetTime(key string, timeMs int) bool {
 	if _, exists := gkvSet.data[key]; exists {
 		expireTime := time.Now().Add(time.Duration(timeMs) * time.Millisecond)
 		gkvSet.expireTimes[key] = expireTime
+		notifyKeyspaceEvent(NotifyGeneric, "expire", key)
 		return true
 	}
 	return false
@@ -137,90 +203,93 @@ func (gkvSet *GkvSet) GetTTL(key string) int64 {
 // @param keys ...string
 // @return []string 交集成员
 func (gkvSet *GkvSet) Inter(keys ...string) []string {
+	gkvSet.keyLock.RLockRows(keys...)
+	defer gkvSet.keyLock.RUnLockRows(keys...)
+	return memberSlice(gkvSet.interLocked(keys))
+}
+
+// interLocked 计算交集, 调用方需持有全部key的锁
+// @param keys []string
+// @return map[string]struct{} 交集成员
+func (gkvSet *GkvSet) interLocked(keys []string) map[string]struct{} {
+	result := make(map[string]struct{})
 	if len(keys) == 0 {
-		return nil
-	}
-	gkvSet.keyLock.RLockRow(keys[0])
-	base, exists := gkvSet.data[keys[0]]
-	gkvSet.keyLock.RUnLockRow(keys[0])
-	if !exists {
-		return nil
+		return result
 	}
-	result := make(map[string]struct{})
-	for m := range base {
+	gkvSet.liveMembers(keys[0]).each(func(m string) bool {
 		result[m] = struct{}{}
-	}
+		return true
+	})
 	for _, key := range keys[1:] {
-		gkvSet.keyLock.RLockRow(key)
-		members, exists := gkvSet.data[key]
-		gkvSet.keyLock.RUnLockRow(key)
-		if !exists {
-			return nil
-		}
+		members := gkvSet.liveMembers(key)
 		for m := range result {
-			if _, ok := members[m]; !ok {
+			if !members.has(m) {
 				delete(result, m)
 			}
 		}
 	}
-	arr := make([]string, 0, len(result))
-	for m := range result {
-		arr = append(arr, m)
-	}
-	return arr
+	return result
 }
 
 // Union 计算多个集合的并集
 // @param keys ...string
 // @return []string 并集成员
 func (gkvSet *GkvSet) Union(keys ...string) []string {
+	gkvSet.keyLock.RLockRows(keys...)
+	defer gkvSet.keyLock.RUnLockRows(keys...)
+	return memberSlice(gkvSet.unionLocked(keys))
+}
+
+// unionLocked 计算并集, 调用方需持有全部key的锁
+// @param keys []string
+// @return map[string]struct{} 并集成员
+func (gkvSet *GkvSet) unionLocked(keys []string) map[string]struct{} {
 	result := make(map[string]struct{})
 	for _, key := range keys {
-		gkvSet.keyLock.RLockRow(key)
-		members, exists := gkvSet.data[key]
-		gkvSet.keyLock.RUnLockRow(key)
-		if exists {
-			for m := range members {
-				result[m] = struct{}{}
-			}
-		}
+		gkvSet.liveMembers(key).each(func(m string) bool {
+			result[m] = struct{}{}
+			return true
+		})
 	}
-	arr := make([]string, 0, len(result))
-	for m := range result {
-		arr = append(arr, m)
-	}
-	return arr
+	return result
 }
 
 // Diff 计算第一个集合与后续集合的差集
 // @param keys ...string
 // @return []string 差集成员
 func (gkvSet *GkvSet) Diff(keys ...string) []string {
+	gkvSet.keyLock.RLockRows(keys...)
+	defer gkvSet.keyLock.RUnLockRows(keys...)
+	return memberSlice(gkvSet.diffLocked(keys))
+}
+
+// diffLocked 计算差集, 调用方需持有全部key的锁
+// @param keys []string
+// @return map[string]struct{} 差集成员
+func (gkvSet *GkvSet) diffLocked(keys []string) map[string]struct{} {
+	result := make(map[string]struct{})
 	if len(keys) == 0 {
-		return nil
-	}
-	gkvSet.keyLock.RLockRow(keys[0])
-	base, exists := gkvSet.data[keys[0]]
-	gkvSet.keyLock.RUnLockRow(keys[0])
-	if !exists {
-		return nil
+		return result
 	}
-	result := make(map[string]struct{})
-	for m := range base {
+	gkvSet.liveMembers(keys[0]).each(func(m string) bool {
 		result[m] = struct{}{}
-	}
+		return true
+	})
 	for _, key := range keys[1:] {
-		gkvSet.keyLock.RLockRow(key)
-		members, exists := gkvSet.data[key]
-		gkvSet.keyLock.RUnLockRow(key)
-		if exists {
-			for m := range members {
-				delete(result, m)
-			}
-		}
+		gkvSet.liveMembers(key).each(func(m string) bool {
+			delete(result, m)
+			return true
+		})
 	}
-	arr := make([]string, 0, len(result))
-	for m := range result {
+	return result
+}
+
+// memberSlice 将成员表转为切片
+// @param members map[string]struct{}
+// @return []string
+func memberSlice(members map[string]struct{}) []string {
+	arr := make([]string, 0, len(members))
+	for m := range members {
 		arr = append(arr, m)
 	}
 	return arr
@@ -232,11 +301,196 @@ func (gkvSet *GkvSet) Diff(keys ...string) []string {
 func (gkvSet *GkvSet) Cardinality(key string) int {
 	gkvSet.keyLock.RLockRow(key)
 	defer gkvSet.keyLock.RUnLockRow(key)
-	members, exists := gkvSet.data[key]
-	if !exists {
+	return gkvSet.data[key].len()
+}
+
+// Pop 随机弹出成员
+// @param key string 集合名
+// @param count int 弹出数量
+// @return []string 被弹出的成员
+func (gkvSet *GkvSet) Pop(key string, count int) []string {
+	gkvSet.keyLock.WLockRow(key)
+	defer gkvSet.keyLock.WUnLockRow(key)
+	popped := randomMembers(gkvSet.liveMembers(key), count)
+	gkvSet.removeLocked(key, "spop", popped...)
+	return popped
+}
+
+// RandMember 随机获取成员但不移除
+// count为正数时返回不重复的成员, 为负数时返回|count|个可能重复的成员
+// @param key string 集合名
+// @param count int 数量
+// @return []string 成员
+// @return error count为负数且绝对值超过 setRandMemberMaxRepeat 时返回错误
+func (gkvSet *GkvSet) RandMember(key string, count int) ([]string, error) {
+	if count < -setRandMemberMaxRepeat {
+		return nil, errRandMemberCount
+	}
+	gkvSet.keyLock.RLockRow(key)
+	defer gkvSet.keyLock.RUnLockRow(key)
+	members := gkvSet.liveMembers(key)
+	if count >= 0 {
+		return randomMembers(members, count), nil
+	}
+	if members.len() == 0 {
+		return []string{}, nil
+	}
+	all := members.members()
+	result := make([]string, -count)
+	for i := range result {
+		result[i] = all[rand.IntN(len(all))]
+	}
+	return result, nil
+}
+
+// randomMembers 随机选出至多count个不重复成员
+// count小于集合大小时用蓄水池抽样, 只保留count个成员, 不复制与打乱整个集合
+// @param members *setObject 集合
+// @param count int 数量
+// @return []string 成员
+func randomMembers(members *setObject, count int) []string {
+	if count <= 0 || members.len() == 0 {
+		return []string{}
+	}
+	if count >= members.len() {
+		return members.members()
+	}
+	sample := make([]string, 0, count)
+	seen := 0
+	members.each(func(m string) bool {
+		if seen < count {
+			sample = append(sample, m)
+		} else if j := rand.IntN(seen + 1); j < count {
+			sample[j] = m
+		}
+		seen++
+		return true
+	})
+	rand.Shuffle(len(sample), func(i, j int) {
+		sample[i], sample[j] = sample[j], sample[i]
+	})
+	return sample
+}
+
+// Move 将成员从一个集合原子地移动到另一个集合
+// @param src string 源集合
+// @param dst string 目标集合
+// @param member string 成员
+// @return bool 源集合中是否存在该成员
+func (gkvSet *GkvSet) Move(src, dst, member string) bool {
+	gkvSet.keyLock.WLockRows(src, dst)
+	defer gkvSet.keyLock.WUnLockRows(src, dst)
+	if !gkvSet.liveMembers(src).has(member) {
+		return false
+	}
+	if src == dst {
+		return true
+	}
+	gkvSet.removeLocked(src, "srem", member)
+	if gkvSet.addLocked(dst, member) > 0 {
+		notifyKeyspaceEvent(NotifySet, "sadd", dst)
+	}
+	return true
+}
+
+// MIsMember 批量判断成员是否存在
+// @param key string 集合名
+// @param members ...string 成员
+// @return []bool 每个成员是否存在
+func (gkvSet *GkvSet) MIsMember(key string, members ...string) []bool {
+	gkvSet.keyLock.RLockRow(key)
+	defer gkvSet.keyLock.RUnLockRow(key)
+	set := gkvSet.liveMembers(key)
+	result := make([]bool, len(members))
+	for i, member := range members {
+		result[i] = set.has(member)
+	}
+	return result
+}
+
+// InterCard 计算交集的成员数量
+// @param limit int 计数上限, 达到后提前返回, 0表示不限制
+// @param keys ...string
+// @return int 交集成员数量
+func (gkvSet *GkvSet) InterCard(limit int, keys ...string) int {
+	gkvSet.keyLock.RLockRows(keys...)
+	defer gkvSet.keyLock.RUnLockRows(keys...)
+	if len(keys) == 0 {
+		return 0
+	}
+	count := 0
+	gkvSet.liveMembers(keys[0]).each(func(m string) bool {
+		for _, key := range keys[1:] {
+			if !gkvSet.liveMembers(key).has(m) {
+				return true
+			}
+		}
+		count++
+		return limit <= 0 || count < limit
+	})
+	return count
+}
+
+// InterStore 计算交集并写入目标集合
+// @param dst string 目标集合
+// @param keys ...string
+// @return int 结果集合的成员数量
+func (gkvSet *GkvSet) InterStore(dst string, keys ...string) int {
+	return gkvSet.store(dst, "sinterstore", keys, gkvSet.interLocked)
+}
+
+// UnionStore 计算并集并写入目标集合
+// @param dst string 目标集合
+// @param keys ...string
+// @return int 结果集合的成员数量
+func (gkvSet *GkvSet) UnionStore(dst string, keys ...string) int {
+	return gkvSet.store(dst, "sunionstore", keys, gkvSet.unionLocked)
+}
+
+// DiffStore 计算差集并写入目标集合
+// @param dst string 目标集合
+// @param keys ...string
+// @return int 结果集合的成员数量
+func (gkvSet *GkvSet) DiffStore(dst string, keys ...string) int {
+	return gkvSet.store(dst, "sdiffstore", keys, gkvSet.diffLocked)
+}
+
+// store 在持有全部相关key写锁的情况下计算结果并覆盖目标集合, 结果为空时删除目标集合
+// @param dst string 目标集合
+// @param event string 写入目标集合时发出的通知事件名
+// @param keys []string 参与计算的集合
+// @param op func 集合运算
+// @return int 结果集合的成员数量
+func (gkvSet *GkvSet) store(dst, event string, keys []string, op func([]string) map[string]struct{}) int {
+	locked := append([]string{dst}, keys...)
+	gkvSet.keyLock.WLockRows(locked...)
+	defer gkvSet.keyLock.WUnLockRows(locked...)
+	result := op(keys)
+	delete(gkvSet.expireTimes, dst)
+	if len(result) == 0 {
+		if _, exists := gkvSet.data[dst]; exists {
+			delete(gkvSet.data, dst)
+			notifyKeyspaceEvent(NotifyGeneric, "del", dst)
+		}
 		return 0
 	}
-	return len(members)
+	gkvSet.data[dst] = newSetObject(memberSlice(result))
+	notifyKeyspaceEvent(NotifySet, event, dst)
+	return len(result)
+}
+
+// Encoding 获取集合当前使用的编码
+// @param key string 集合名
+// @return string 编码名称
+// @return bool 集合是否存在
+func (gkvSet *GkvSet) Encoding(key string) (string, bool) {
+	gkvSet.keyLock.RLockRow(key)
+	defer gkvSet.keyLock.RUnLockRow(key)
+	set := gkvSet.liveMembers(key)
+	if set == nil {
+		return "", false
+	}
+	return set.encoding(), true
 }
 
 // Clear 清空集合
@@ -244,6 +498,29 @@ func (gkvSet *GkvSet) Cardinality(key string) int {
 func (gkvSet *GkvSet) Clear(key string) {
 	gkvSet.keyLock.WLockRow(key)
 	defer gkvSet.keyLock.WUnLockRow(key)
+	_, exists := gkvSet.data[key]
 	delete(gkvSet.data, key)
 	delete(gkvSet.expireTimes, key)
+	if exists {
+		notifyKeyspaceEvent(NotifyGeneric, "del", key)
+	}
+}
+
+// liveKeys 获取所有未过期的key
+// @return []string
+func (gkvSet *GkvSet) liveKeys() []string {
+	return collectLiveKeys(gkvSet.keyLock, gkvSet.data, gkvSet.expireTimes)
+}
+
+// deleteKey 删除整个key
+// @param key string
+// @return bool key是否存在
+func (gkvSet *GkvSet) deleteKey(key string) bool {
+	return removeKey(gkvSet.keyLock, gkvSet.data, gkvSet.expireTimes, key)
+}
+
+// removeExpired 删除所有已过期的key
+// @return []string 被删除的key
+func (gkvSet *GkvSet) removeExpired() []string {
+	return removeExpiredKeys(gkvSet.keyLock, gkvSet.data, gkvSet.expireTimes)
 }
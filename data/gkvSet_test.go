@@ -0,0 +1,152 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestSet 创建独立于全局实例的集合存储
+// @return *GkvSet
+func newTestSet() *GkvSet {
+	return &GkvSet{
+		data:        make(map[string]*setObject),
+		expireTimes: make(map[string]time.Time),
+		keyLock:     NewKeyLock(),
+	}
+}
+
+// setMembers 生成 n 个成员, numeric 为true时为整数(intset编码), 否则为字符串
+// @param n int
+// @param numeric bool
+// @return []string
+func setMembers(n int, numeric bool) []string {
+	members := make([]string, n)
+	for i := range members {
+		members[i] = fmt.Sprint(i)
+		if !numeric {
+			members[i] = "m" + members[i]
+		}
+	}
+	return members
+}
+
+// TestRandomMembers 抽样结果不重复、属于集合且数量为 min(count, 集合大小)
+func TestRandomMembers(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		numeric bool
+		count   int
+		want    int
+	}{
+		{"空集合", 0, false, 3, 0},
+		{"count为0", 10, false, 0, 0},
+		{"count为负数", 10, false, -1, 0},
+		{"少于集合大小", 10, false, 3, 3},
+		{"整数集合", 10, true, 3, 3},
+		{"等于集合大小", 10, false, 10, 10},
+		{"超过集合大小", 10, true, 100, 10},
+		{"大集合中抽取少量成员", 5000, false, 7, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := setMembers(tt.size, tt.numeric)
+			set := newSetObject(members)
+			got := randomMembers(set, tt.count)
+			if len(got) != tt.want {
+				t.Fatalf("得到 %d 个成员, 期望 %d 个", len(got), tt.want)
+			}
+			sorted := slices.Clone(got)
+			slices.Sort(sorted)
+			if len(slices.Compact(sorted)) != len(got) {
+				t.Fatalf("成员重复: %v", got)
+			}
+			for _, m := range got {
+				if !set.has(m) {
+					t.Fatalf("%s 不在集合中", m)
+				}
+			}
+		})
+	}
+}
+
+// TestSetPopSampling SPOP 只删除返回的成员, 且每个成员被弹出的概率相同
+func TestSetPopSampling(t *testing.T) {
+	s := newTestSet()
+	members := setMembers(10, false)
+	counts := map[string]int{}
+	const trials = 3000
+	for range trials {
+		s.Clear("k")
+		s.Add("k", members...)
+		popped := s.Pop("k", 3)
+		if len(popped) != 3 || s.Cardinality("k") != 7 {
+			t.Fatalf("弹出 %v, 剩余 %d 个", popped, s.Cardinality("k"))
+		}
+		for _, m := range popped {
+			if s.IsMember("k", m) {
+				t.Fatalf("%s 被弹出后仍在集合中", m)
+			}
+			counts[m]++
+		}
+	}
+	// 每个成员被弹出的次数服从 B(3000, 0.3), 均值 900, 标准差约 25
+	for _, m := range members {
+		if counts[m] < 750 || counts[m] > 1050 {
+			t.Fatalf("弹出次数分布不均匀: %v", counts)
+		}
+	}
+	if popped := s.Pop("k", 100); len(popped) != 7 || s.Cardinality("k") != 0 {
+		t.Fatalf("count 超过集合大小时应弹出全部成员, 得到 %v", popped)
+	}
+	if popped := s.Pop("missing", 1); len(popped) != 0 {
+		t.Fatalf("集合不存在时得到 %v", popped)
+	}
+}
+
+// TestSetRandMember 正数返回不重复的成员, 负数返回可重复的成员且数量有上限
+func TestSetRandMember(t *testing.T) {
+	s := newTestSet()
+	s.Add("k", "a", "b", "c")
+	tests := []struct {
+		name     string
+		key      string
+		count    int
+		want     int
+		distinct bool
+		err      error
+	}{
+		{"正数", "k", 2, 2, true, nil},
+		{"正数超过集合大小", "k", 10, 3, true, nil},
+		{"负数可重复", "k", -10, 10, false, nil},
+		{"负数的绝对值为上限", "k", -setRandMemberMaxRepeat, setRandMemberMaxRepeat, false, nil},
+		{"负数的绝对值超过上限", "k", -setRandMemberMaxRepeat - 1, 0, false, errRandMemberCount},
+		{"最小的整数", "k", math.MinInt, 0, false, errRandMemberCount},
+		{"集合不存在时负数", "missing", -5, 0, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.RandMember(tt.key, tt.count)
+			if !errors.Is(err, tt.err) || len(got) != tt.want {
+				t.Fatalf("得到 %d 个成员 %v, 期望 %d 个 %v", len(got), err, tt.want, tt.err)
+			}
+			for _, m := range got {
+				if !s.IsMember(tt.key, m) {
+					t.Fatalf("%s 不在集合中", m)
+				}
+			}
+			sorted := slices.Clone(got)
+			slices.Sort(sorted)
+			if tt.distinct && len(slices.Compact(sorted)) != len(got) {
+				t.Fatalf("成员重复: %v", got)
+			}
+		})
+	}
+	if s.Cardinality("k") != 3 {
+		t.Fatal("SRANDMEMBER 不应修改集合")
+	}
+}
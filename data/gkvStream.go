@@ -0,0 +1,768 @@
+package data
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// errStreamFields 条目的字段为空或不成对
+var errStreamFields = errors.New("条目的字段必须为非空的 字段名 字段值 对")
+
+// errStreamKeysIDs 读取时流与ID的数量不一致
+var errStreamKeysIDs = errors.New("流的数量必须与ID的数量相同")
+
+// errAutoClaimCount xautoclaim 的 count 超出范围
+var errAutoClaimCount = errors.New("count 必须大于0且不能过大")
+
+// GkvStream 流结构: 只追加的条目日志, 支持区间读取、阻塞读取与消费者组
+type GkvStream struct {
+	data        map[string]*streamObject
+	expireTimes map[string]time.Time
+	keyLock     *KeyLock
+	blocked     *streamBlocking
+}
+
+// DataGkvStream 全局数据实例
+var DataGkvStream = &GkvStream{
+	data:        make(map[string]*streamObject),
+	expireTimes: make(map[string]time.Time),
+	keyLock:     NewKeyLock(),
+	blocked:     newStreamBlocking(),
+}
+
+// StreamAddOptions XADD 的可选参数
+type StreamAddOptions struct {
+	// 流不存在时不创建
+	NoMkStream bool
+	// 追加后按该参数裁剪
+	Trim StreamTrim
+}
+
+// StreamReadResult 从一个流中读到的条目
+type StreamReadResult struct {
+	Key     string
+	Entries []StreamEntry
+}
+
+// Add 追加条目, 流不存在时创建
+// @param key string 流名
+// @param idSpec string "*"、"毫秒-*" 或完整的ID
+// @param fields []string 字段名与字段值交替排列
+// @param opts StreamAddOptions
+// @return StreamID 新条目的ID
+// @return bool 是否追加, 指定 NoMkStream 且流不存在时为false
+// @return error
+func (gkvStream *GkvStream) Add(key, idSpec string, fields []string, opts StreamAddOptions) (StreamID, bool, error) {
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		return StreamID{}, false, errStreamFields
+	}
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	gkvStream.expireIfNeeded(key)
+	s := gkvStream.liveStream(key)
+	if s == nil {
+		if opts.NoMkStream {
+			return StreamID{}, false, nil
+		}
+		s = newStreamObject()
+	}
+	id, err := s.nextID(idSpec, time.Now())
+	if err != nil {
+		return id, false, err
+	}
+	gkvStream.data[key] = s
+	s.append(id, fields)
+	delete(gkvStream.expireTimes, key)
+	gkvStream.blocked.signal(key)
+	notifyKeyspaceEvent(NotifyStream, "xadd", key)
+	if s.trim(opts.Trim) > 0 {
+		notifyKeyspaceEvent(NotifyStream, "xtrim", key)
+	}
+	return id, true, nil
+}
+
+// Len 获取流中的条目数
+// @param key string 流名
+// @return int
+func (gkvStream *GkvStream) Len(key string) int {
+	gkvStream.keyLock.RLockRow(key)
+	defer gkvStream.keyLock.RUnLockRow(key)
+	s := gkvStream.liveStream(key)
+	if s == nil {
+		return 0
+	}
+	return len(s.entries)
+}
+
+// Range 获取闭区间内的条目
+// @param key string 流名
+// @param start, end StreamID 区间端点, 倒序时仍然 start 为较小的一端
+// @param count int 最多返回的数量, 小于等于0表示不限制
+// @param rev bool 是否从 end 向 start 倒序返回
+// @return []StreamEntry
+func (gkvStream *GkvStream) Range(key string, start, end StreamID, count int, rev bool) []StreamEntry {
+	gkvStream.keyLock.RLockRow(key)
+	defer gkvStream.keyLock.RUnLockRow(key)
+	s := gkvStream.liveStream(key)
+	if s == nil {
+		return []StreamEntry{}
+	}
+	return s.rangeEntries(start, end, count, rev)
+}
+
+// Trim 裁剪流
+// @param key string 流名
+// @param t StreamTrim
+// @return int 删除的条目数
+func (gkvStream *GkvStream) Trim(key string, t StreamTrim) int {
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	s := gkvStream.liveStream(key)
+	if s == nil {
+		return 0
+	}
+	n := s.trim(t)
+	if n > 0 {
+		delete(gkvStream.expireTimes, key)
+		notifyKeyspaceEvent(NotifyStream, "xtrim", key)
+	}
+	return n
+}
+
+// Remove 删除指定ID的条目, 消费者组待确认列表中的条目保留, 读取时字段为nil
+// @param key string 流名
+// @param ids ...StreamID
+// @return int 删除的条目数
+func (gkvStream *GkvStream) Remove(key string, ids ...StreamID) int {
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	s := gkvStream.liveStream(key)
+	if s == nil {
+		return 0
+	}
+	n := s.remove(ids)
+	if n > 0 {
+		delete(gkvStream.expireTimes, key)
+		notifyKeyspaceEvent(NotifyStream, "xdel", key)
+	}
+	return n
+}
+
+// resolveReadIDs 解析 XREAD 的起始ID, "$" 表示流中当前最大的ID, 调用方需持有全部key的锁
+// @param keys []string
+// @param ids []string
+// @return []StreamID
+// @return error
+func (gkvStream *GkvStream) resolveReadIDs(keys, ids []string) ([]StreamID, error) {
+	if len(keys) == 0 || len(keys) != len(ids) {
+		return nil, errStreamKeysIDs
+	}
+	resolved := make([]StreamID, len(ids))
+	for i, spec := range ids {
+		if spec == "$" {
+			if s := gkvStream.liveStream(keys[i]); s != nil {
+				resolved[i] = s.lastID
+			}
+			continue
+		}
+		id, err := ParseStreamID(spec)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = id
+	}
+	return resolved, nil
+}
+
+// readLocked 读取每个流中ID大于起始ID的条目, 调用方需持有全部key的锁
+// @param keys []string
+// @param ids []StreamID
+// @param count int 每个流最多返回的数量, 小于等于0表示不限制
+// @return []StreamReadResult 只包含读到条目的流
+func (gkvStream *GkvStream) readLocked(keys []string, ids []StreamID, count int) []StreamReadResult {
+	results := []StreamReadResult{}
+	for i, key := range keys {
+		s := gkvStream.liveStream(key)
+		if s == nil {
+			continue
+		}
+		if entries := s.after(ids[i], count); len(entries) > 0 {
+			results = append(results, StreamReadResult{Key: key, Entries: entries})
+		}
+	}
+	return results
+}
+
+// Read 从多个流中读取ID大于对应起始ID的条目
+// @param keys []string 流名
+// @param ids []string 起始ID, "$" 表示只读取之后追加的条目
+// @param count int 每个流最多返回的数量, 小于等于0表示不限制
+// @return []StreamReadResult 只包含读到条目的流
+// @return error
+func (gkvStream *GkvStream) Read(keys, ids []string, count int) ([]StreamReadResult, error) {
+	gkvStream.keyLock.RLockRows(keys...)
+	defer gkvStream.keyLock.RUnLockRows(keys...)
+	resolved, err := gkvStream.resolveReadIDs(keys, ids)
+	if err != nil {
+		return nil, err
+	}
+	return gkvStream.readLocked(keys, resolved, count), nil
+}
+
+// BlockingRead 与 Read 相同, 但没有读到任何条目时阻塞等待新条目
+// "$" 在开始阻塞前解析一次, 之后只返回阻塞期间追加的条目
+// @param keys []string 流名
+// @param ids []string 起始ID
+// @param count int 每个流最多返回的数量, 小于等于0表示不限制
+// @param timeout time.Duration 最长等待时间, 0表示一直等待
+// @return []StreamReadResult 超时为空
+// @return error
+func (gkvStream *GkvStream) BlockingRead(keys, ids []string, count int, timeout time.Duration) ([]StreamReadResult, error) {
+	var resolved []StreamID
+	results := []StreamReadResult{}
+	_, err := gkvStream.blocked.blockUntil(keys, timeout,
+		func() { gkvStream.keyLock.RLockRows(keys...) },
+		func() { gkvStream.keyLock.RUnLockRows(keys...) },
+		func() (bool, error) {
+			if resolved == nil {
+				var err error
+				if resolved, err = gkvStream.resolveReadIDs(keys, ids); err != nil {
+					return false, err
+				}
+			}
+			results = gkvStream.readLocked(keys, resolved, count)
+			return len(results) > 0, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// groupID 解析消费者组的起始ID, "$" 表示流中当前最大的ID
+// @param s *streamObject
+// @param spec string
+// @return StreamID
+// @return error
+func groupID(s *streamObject, spec string) (StreamID, error) {
+	if spec == "$" {
+		return s.lastID, nil
+	}
+	return ParseStreamID(spec)
+}
+
+// CreateGroup 创建消费者组
+// @param key string 流名
+// @param group string 组名
+// @param id string 从该ID之后开始投递, "$" 表示只投递之后追加的条目
+// @param mkStream bool 流不存在时创建空流
+// @return error 流不存在或组已存在时返回错误
+func (gkvStream *GkvStream) CreateGroup(key, group, id string, mkStream bool) error {
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	gkvStream.expireIfNeeded(key)
+	s := gkvStream.liveStream(key)
+	if s == nil {
+		if !mkStream {
+			return fmt.Errorf("流 %s 不存在, 可使用 mkstream 创建", key)
+		}
+		s = newStreamObject()
+	}
+	if _, exists := s.groups[group]; exists {
+		return fmt.Errorf("消费者组 %s 已存在", group)
+	}
+	start, err := groupID(s, id)
+	if err != nil {
+		return err
+	}
+	gkvStream.data[key] = s
+	s.groups[group] = newStreamGroup(start)
+	delete(gkvStream.expireTimes, key)
+	notifyKeyspaceEvent(NotifyStream, "xgroup-create", key)
+	return nil
+}
+
+// DestroyGroup 删除消费者组
+// @param key string 流名
+// @param group string 组名
+// @return bool 组是否存在
+func (gkvStream *GkvStream) DestroyGroup(key, group string) bool {
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	s := gkvStream.liveStream(key)
+	if s == nil {
+		return false
+	}
+	if _, exists := s.groups[group]; !exists {
+		return false
+	}
+	delete(s.groups, group)
+	delete(gkvStream.expireTimes, key)
+	// 唤醒在该组上阻塞的读取, 使其返回组不存在的错误
+	gkvStream.blocked.signal(key)
+	notifyKeyspaceEvent(NotifyStream, "xgroup-destroy", key)
+	return true
+}
+
+// SetGroupID 修改消费者组的最后投递ID
+// @param key string 流名
+// @param group string 组名
+// @param id string 新的ID, "$" 表示流中当前最大的ID
+// @return error
+func (gkvStream *GkvStream) SetGroupID(key, group, id string) error {
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	g, s, err := gkvStream.liveGroup(key, group)
+	if err != nil {
+		return err
+	}
+	start, err := groupID(s, id)
+	if err != nil {
+		return err
+	}
+	g.lastDelivered = start
+	delete(gkvStream.expireTimes, key)
+	notifyKeyspaceEvent(NotifyStream, "xgroup-setid", key)
+	return nil
+}
+
+// CreateConsumer 在消费者组中创建消费者
+// @param key string 流名
+// @param group string 组名
+// @param consumer string 消费者名
+// @return bool 是否新建
+// @return error 流或组不存在时返回错误
+func (gkvStream *GkvStream) CreateConsumer(key, group, consumer string) (bool, error) {
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	g, _, err := gkvStream.liveGroup(key, group)
+	if err != nil {
+		return false, err
+	}
+	if _, exists := g.consumers[consumer]; exists {
+		return false, nil
+	}
+	g.consumer(consumer, time.Now())
+	delete(gkvStream.expireTimes, key)
+	notifyKeyspaceEvent(NotifyStream, "xgroup-createconsumer", key)
+	return true, nil
+}
+
+// DeleteConsumer 删除消费者及其待确认的条目
+// @param key string 流名
+// @param group string 组名
+// @param consumer string 消费者名
+// @return int 被删除的待确认条目数
+// @return error 流或组不存在时返回错误
+func (gkvStream *GkvStream) DeleteConsumer(key, group, consumer string) (int, error) {
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	g, _, err := gkvStream.liveGroup(key, group)
+	if err != nil {
+		return 0, err
+	}
+	if _, exists := g.consumers[consumer]; !exists {
+		return 0, nil
+	}
+	n := g.deleteConsumer(consumer)
+	delete(gkvStream.expireTimes, key)
+	notifyKeyspaceEvent(NotifyStream, "xgroup-delconsumer", key)
+	return n, nil
+}
+
+// readGroupLocked 以消费者组的身份读取, 调用方需持有全部key的写锁
+// ">" 读取从未投递给组内任何消费者的新条目, 其他ID读取该消费者自己待确认的条目
+// @param group, consumer string
+// @param keys, ids []string
+// @param count int
+// @param noAck bool
+// @return []StreamReadResult ">" 只包含读到条目的流, 其他ID的流总会出现在结果中
+// @return error
+func (gkvStream *GkvStream) readGroupLocked(group, consumer string, keys, ids []string, count int, noAck bool) ([]StreamReadResult, error) {
+	if len(keys) == 0 || len(keys) != len(ids) {
+		return nil, errStreamKeysIDs
+	}
+	history := make([]StreamID, len(ids))
+	for i, spec := range ids {
+		if spec == ">" {
+			continue
+		}
+		id, err := ParseStreamID(spec)
+		if err != nil {
+			return nil, err
+		}
+		history[i] = id
+	}
+	groups := make([]*streamGroup, len(keys))
+	streams := make([]*streamObject, len(keys))
+	for i, key := range keys {
+		g, s, err := gkvStream.liveGroup(key, group)
+		if err != nil {
+			return nil, err
+		}
+		groups[i], streams[i] = g, s
+	}
+	now := time.Now()
+	results := []StreamReadResult{}
+	for i, key := range keys {
+		if ids[i] != ">" {
+			entries := groups[i].readHistory(streams[i], consumer, history[i], count, now)
+			results = append(results, StreamReadResult{Key: key, Entries: entries})
+			continue
+		}
+		if entries := groups[i].readNew(streams[i], consumer, count, noAck, now); len(entries) > 0 {
+			results = append(results, StreamReadResult{Key: key, Entries: entries})
+			notifyKeyspaceEvent(NotifyStream, "xreadgroup", key)
+		}
+	}
+	for _, key := range keys {
+		delete(gkvStream.expireTimes, key)
+	}
+	return results, nil
+}
+
+// ReadGroup 以消费者组的身份读取, 消费者不存在时创建
+// ">" 读取新条目并加入该消费者的待确认列表, 其他ID重新读取该消费者自己待确认的条目
+// @param group string 组名
+// @param consumer string 消费者名
+// @param keys []string 流名
+// @param ids []string ">" 或起始ID
+// @param count int 每个流最多返回的数量, 小于等于0表示不限制
+// @param noAck bool 为true时新条目不加入待确认列表
+// @return []StreamReadResult
+// @return error 流或组不存在时返回错误
+func (gkvStream *GkvStream) ReadGroup(group, consumer string, keys, ids []string, count int, noAck bool) ([]StreamReadResult, error) {
+	gkvStream.keyLock.WLockRows(keys...)
+	defer gkvStream.keyLock.WUnLockRows(keys...)
+	return gkvStream.readGroupLocked(group, consumer, keys, ids, count, noAck)
+}
+
+// BlockingReadGroup 与 ReadGroup 相同, 但全部ID都为 ">" 且没有新条目时阻塞等待
+// @param group string 组名
+// @param consumer string 消费者名
+// @param keys []string 流名
+// @param ids []string ">" 或起始ID
+// @param count int 每个流最多返回的数量, 小于等于0表示不限制
+// @param noAck bool 为true时新条目不加入待确认列表
+// @param timeout time.Duration 最长等待时间, 0表示一直等待
+// @return []StreamReadResult 超时为空
+// @return error 流或组不存在(包括阻塞期间被删除)时返回错误
+func (gkvStream *GkvStream) BlockingReadGroup(group, consumer string, keys, ids []string, count int, noAck bool, timeout time.Duration) ([]StreamReadResult, error) {
+	for _, id := range ids {
+		if id != ">" {
+			return gkvStream.ReadGroup(group, consumer, keys, ids, count, noAck)
+		}
+	}
+	results := []StreamReadResult{}
+	_, err := gkvStream.blocked.blockUntil(keys, timeout,
+		func() { gkvStream.keyLock.WLockRows(keys...) },
+		func() { gkvStream.keyLock.WUnLockRows(keys...) },
+		func() (bool, error) {
+			var err error
+			results, err = gkvStream.readGroupLocked(group, consumer, keys, ids, count, noAck)
+			return len(results) > 0, err
+		})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Ack 确认条目, 将其移出消费者组的待确认列表
+// @param key string 流名
+// @param group string 组名
+// @param ids ...StreamID
+// @return int 确认的条目数, 流或组不存在时为0
+func (gkvStream *GkvStream) Ack(key, group string, ids ...StreamID) int {
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	g, _, err := gkvStream.liveGroup(key, group)
+	if err != nil {
+		return 0
+	}
+	acked := 0
+	for _, id := range ids {
+		if g.ack(id) {
+			acked++
+		}
+	}
+	if acked > 0 {
+		delete(gkvStream.expireTimes, key)
+		notifyKeyspaceEvent(NotifyStream, "xack", key)
+	}
+	return acked
+}
+
+// Pending 获取消费者组待确认列表的概要
+// @param key string 流名
+// @param group string 组名
+// @return StreamPendingSummary
+// @return error 流或组不存在时返回错误
+func (gkvStream *GkvStream) Pending(key, group string) (StreamPendingSummary, error) {
+	gkvStream.keyLock.RLockRow(key)
+	defer gkvStream.keyLock.RUnLockRow(key)
+	g, _, err := gkvStream.liveGroup(key, group)
+	if err != nil {
+		return StreamPendingSummary{}, err
+	}
+	return g.summary(), nil
+}
+
+// PendingRange 按区间查询消费者组待确认的条目
+// @param key string 流名
+// @param group string 组名
+// @param opts StreamPendingOptions
+// @return []StreamPendingEntry
+// @return error 流或组不存在时返回错误
+func (gkvStream *GkvStream) PendingRange(key, group string, opts StreamPendingOptions) ([]StreamPendingEntry, error) {
+	gkvStream.keyLock.RLockRow(key)
+	defer gkvStream.keyLock.RUnLockRow(key)
+	g, _, err := gkvStream.liveGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+	return g.pendingRange(opts, time.Now()), nil
+}
+
+// Claim 将空闲时间不小于 minIdle 的待确认条目转移给消费者
+// @param key string 流名
+// @param group string 组名
+// @param consumer string 消费者名
+// @param minIdle time.Duration 最小空闲时间
+// @param ids []StreamID
+// @param opts StreamClaimOptions
+// @return []StreamEntry 被认领的条目
+// @return error 流或组不存在时返回错误
+func (gkvStream *GkvStream) Claim(key, group, consumer string, minIdle time.Duration, ids []StreamID, opts StreamClaimOptions) ([]StreamEntry, error) {
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	g, s, err := gkvStream.liveGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+	delete(gkvStream.expireTimes, key)
+	claimed := g.claim(s, consumer, minIdle, ids, opts, time.Now())
+	notifyKeyspaceEvent(NotifyStream, "xclaim", key)
+	return claimed, nil
+}
+
+// AutoClaim 从 start 开始扫描待确认列表, 将空闲时间不小于 minIdle 的条目转移给消费者
+// @param key string 流名
+// @param group string 组名
+// @param consumer string 消费者名
+// @param minIdle time.Duration 最小空闲时间
+// @param start StreamID 扫描起点
+// @param count int 最多认领的数量
+// @param justID bool 为true时不增加投递次数
+// @return StreamID 下次扫描的起点, 扫描完整个列表时为 0-0
+// @return []StreamEntry 被认领的条目
+// @return []StreamID 已从流中删除而被移出待确认列表的ID
+// @return error 流或组不存在, 或 count 超出范围时返回错误
+func (gkvStream *GkvStream) AutoClaim(key, group, consumer string, minIdle time.Duration, start StreamID, count int, justID bool) (StreamID, []StreamEntry, []StreamID, error) {
+	if count <= 0 || count > MaxAutoClaimCount {
+		return StreamID{}, nil, nil, errAutoClaimCount
+	}
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	g, s, err := gkvStream.liveGroup(key, group)
+	if err != nil {
+		return StreamID{}, nil, nil, err
+	}
+	delete(gkvStream.expireTimes, key)
+	next, claimed, deleted := g.autoClaim(s, consumer, minIdle, start, count, justID, time.Now())
+	notifyKeyspaceEvent(NotifyStream, "xautoclaim", key)
+	return next, claimed, deleted, nil
+}
+
+// Delete 删除整个流
+// @param key string 流名
+// @return bool 流是否存在
+func (gkvStream *GkvStream) Delete(key string) bool {
+	return gkvStream.deleteKey(key)
+}
+
+// liveStream 获取未过期的流, 调用方需持有key的锁
+// @param key string
+// @return *streamObject 不存在或已过期时为nil
+func (gkvStream *GkvStream) liveStream(key string) *streamObject {
+	if expireTime, exists := gkvStream.expireTimes[key]; exists && time.Now().After(expireTime) {
+		return nil
+	}
+	return gkvStream.data[key]
+}
+
+// expireIfNeeded 删除已过期的流并发出 expired 通知, 调用方需持有key的写锁
+// @param key string
+func (gkvStream *GkvStream) expireIfNeeded(key string) {
+	if expireTime, exists := gkvStream.expireTimes[key]; exists && time.Now().After(expireTime) {
+		delete(gkvStream.data, key)
+		delete(gkvStream.expireTimes, key)
+		notifyKeyspaceEvent(NotifyExpired, "expired", key)
+	}
+}
+
+// liveGroup 获取未过期的流及其消费者组, 调用方需持有key的锁
+// @param key string
+// @param group string
+// @return *streamGroup
+// @return *streamObject
+// @return error 流或组不存在时返回错误
+func (gkvStream *GkvStream) liveGroup(key, group string) (*streamGroup, *streamObject, error) {
+	s := gkvStream.liveStream(key)
+	if s == nil {
+		return nil, nil, fmt.Errorf("流 %s 不存在", key)
+	}
+	g, err := s.group(group)
+	return g, s, err
+}
+
+// SetTime 设置过期时间(毫秒为单位)
+// @param key string 流名
+// @param timeMs int 过期时间(毫秒数)
+// @return bool 是否设置成功
+func (gkvStream *GkvStream) SetTime(key string, timeMs int) bool {
+	gkvStream.keyLock.WLockRow(key)
+	defer gkvStream.keyLock.WUnLockRow(key)
+	if _, exists := gkvStream.data[key]; exists {
+		expireTime := time.Now().Add(time.Duration(timeMs) * time.Millisecond)
+		gkvStream.expireTimes[key] = expireTime
+		notifyKeyspaceEvent(NotifyGeneric, "expire", key)
+		return true
+	}
+	return false
+}
+
+// GetTTL 获取key的剩余生存时间(毫秒数)
+// @param key string 流名
+// @return int64 剩余生存时间
+// @return 0 键已过期
+// @return -1 键不存在
+// @return -2 键没有设置过期时间
+func (gkvStream *GkvStream) GetTTL(key string) int64 {
+	gkvStream.keyLock.RLockRow(key)
+	defer gkvStream.keyLock.RUnLockRow(key)
+	if _, exists := gkvStream.data[key]; !exists {
+		return -1
+	}
+	expireTime, exists := gkvStream.expireTimes[key]
+	if !exists {
+		return -2
+	}
+	remaining := time.Until(expireTime)
+	if remaining <= 0 {
+		return 0
+	}
+	return int64(remaining.Milliseconds())
+}
+
+// liveKeys 获取所有未过期的key
+// @return []string
+func (gkvStream *GkvStream) liveKeys() []string {
+	return collectLiveKeys(gkvStream.keyLock, gkvStream.data, gkvStream.expireTimes)
+}
+
+// deleteKey 删除整个key, 并唤醒在该key上阻塞的消费者组读取
+// @param key string
+// @return bool key是否存在
+func (gkvStream *GkvStream) deleteKey(key string) bool {
+	if !removeKey(gkvStream.keyLock, gkvStream.data, gkvStream.expireTimes, key) {
+		return false
+	}
+	gkvStream.blocked.signal(key)
+	return true
+}
+
+// removeExpired 删除所有已过期的key
+// @return []string 被删除的key
+func (gkvStream *GkvStream) removeExpired() []string {
+	return removeExpiredKeys(gkvStream.keyLock, gkvStream.data, gkvStream.expireTimes)
+}
+
+// streamSnapshot 单个流的快照形式
+type streamSnapshot struct {
+	Entries []StreamEntry
+	LastID  StreamID
+	Added   uint64
+	Groups  map[string]streamGroupSnapshot
+}
+
+// streamsSnapshot 全部流的快照形式
+type streamsSnapshot struct {
+	Streams     map[string]streamSnapshot
+	ExpireTimes map[string]time.Time
+}
+
+// snapshot 生成快照
+// @return streamSnapshot
+func (s *streamObject) snapshot() streamSnapshot {
+	tmp := streamSnapshot{
+		Entries: append([]StreamEntry{}, s.entries...),
+		LastID:  s.lastID,
+		Added:   s.added,
+		Groups:  make(map[string]streamGroupSnapshot, len(s.groups)),
+	}
+	for name, g := range s.groups {
+		tmp.Groups[name] = g.snapshot()
+	}
+	return tmp
+}
+
+// streamFromSnapshot 由快照还原流
+// @param tmp streamSnapshot
+// @return *streamObject
+func streamFromSnapshot(tmp streamSnapshot) *streamObject {
+	s := newStreamObject()
+	s.entries = tmp.Entries
+	s.lastID = tmp.LastID
+	s.added = tmp.Added
+	for name, g := range tmp.Groups {
+		s.groups[name] = streamGroupFromSnapshot(g)
+	}
+	return s
+}
+
+// SaveSnapshot 将全部未过期的流写入快照
+// @param w io.Writer
+// @return error
+func (gkvStream *GkvStream) SaveSnapshot(w io.Writer) error {
+	tmp := streamsSnapshot{
+		Streams:     make(map[string]streamSnapshot),
+		ExpireTimes: make(map[string]time.Time),
+	}
+	for _, key := range gkvStream.liveKeys() {
+		gkvStream.keyLock.RLockRow(key)
+		if s := gkvStream.liveStream(key); s != nil {
+			tmp.Streams[key] = s.snapshot()
+			if expireTime, exists := gkvStream.expireTimes[key]; exists {
+				tmp.ExpireTimes[key] = expireTime
+			}
+		}
+		gkvStream.keyLock.RUnLockRow(key)
+	}
+	return gob.NewEncoder(w).Encode(tmp)
+}
+
+// LoadSnapshot 从快照加载流, 替换当前全部的流
+// @param r io.Reader
+// @return error
+func (gkvStream *GkvStream) LoadSnapshot(r io.Reader) error {
+	tmp := streamsSnapshot{}
+	if err := gob.NewDecoder(r).Decode(&tmp); err != nil {
+		return err
+	}
+	dataMap := make(map[string]*streamObject, len(tmp.Streams))
+	for key, s := range tmp.Streams {
+		dataMap[key] = streamFromSnapshot(s)
+	}
+	if tmp.ExpireTimes == nil {
+		tmp.ExpireTimes = make(map[string]time.Time)
+	}
+	gkvStream.keyLock.LockTable()
+	defer gkvStream.keyLock.UnLockTable()
+	gkvStream.data = dataMap
+	gkvStream.expireTimes = tmp.ExpireTimes
+	return nil
+}
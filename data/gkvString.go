@@ -36,6 +36,7 @@ func (gkvString *GkvString) Set(key string, value []byte) {
 	gkvString.data[key] = value
 	// 清除旧的过期时间
 	delete(gkvString.expireTimes, key)
+	notifyKeyspaceEvent(NotifyString, "set", key)
 }
 
 // Get 获取某个键对应的值
@@ -53,8 +54,12 @@ func (gkvString *GkvString) Get(key string) (val []byte, ok bool) {
 			gkvString.keyLock.RUnLockRow(key)
 			gkvString.keyLock.WLockRow(key)
 			defer gkvString.keyLock.WUnLockRow(key)
-			delete(gkvString.data, key)
-			delete(gkvString.expireTimes, key)
+			// 重新加锁期间键可能已被删除或重新写入
+			if expireTime, exists := gkvString.expireTimes[key]; exists && time.Now().After(expireTime) {
+				delete(gkvString.data, key)
+				delete(gkvString.expireTimes, key)
+				notifyKeyspaceEvent(NotifyExpired, "expired", key)
+			}
 			return nil, false
 		}
 	}
@@ -71,8 +76,12 @@ func (gkvString *GkvString) Get(key string) (val []byte, ok bool) {
 func (gkvString *GkvString) Delete(key string) {
 	gkvString.keyLock.WLockRow(key)
 	defer gkvString.keyLock.WUnLockRow(key)
+	_, exists := gkvString.data[key]
 	delete(gkvString.data, key)
 	delete(gkvString.expireTimes, key)
+	if exists {
+		notifyKeyspaceEvent(NotifyGeneric, "del", key)
+	}
 }
 
 // GetAllKeys 获取所有key
@@ -80,8 +89,8 @@ func (gkvString *GkvString) Delete(key string) {
 // @datetime 2025-6-24 6:00
 // @return []string 所有的key
 func (gkvString *GkvString) GetAllKeys() []string {
-	gkvString.keyLock.tableLock.Lock()
-	defer gkvString.keyLock.tableLock.Unlock()
+	gkvString.keyLock.RLockTable()
+	defer gkvString.keyLock.RUnLockTable()
 	keys := make([]string, 0, len(gkvString.data))
 	for key := range gkvString.data {
 		keys = append(keys, key)
@@ -94,8 +103,8 @@ func (gkvString *GkvString) GetAllKeys() []string {
 // @datetime 2025-7-16 21:00
 // @return map[string]string 所有的键值对数据
 func (gkvString *GkvString) GetAllKVs() (result map[string]string) {
-	gkvString.keyLock.tableLock.Lock()
-	defer gkvString.keyLock.tableLock.Unlock()
+	gkvString.keyLock.RLockTable()
+	defer gkvString.keyLock.RUnLockTable()
 	result = make(map[string]string)
 	for s, bs := range gkvString.data {
 		result[s] = string(bs)
@@ -115,6 +124,7 @@ func (gkvString *GkvString) SetTime(key string, timeMs int) bool {
 	if _, exists := gkvString.data[key]; exists {
 		expireTime := time.Now().Add(time.Duration(timeMs) * time.Millisecond)
 		gkvString.expireTimes[key] = expireTime
+		notifyKeyspaceEvent(NotifyGeneric, "expire", key)
 		return true
 	} else {
 		return false
@@ -135,6 +145,7 @@ func (gkvString *GkvString) SetNX(key string, value []byte) bool {
 	}
 	gkvString.data[key] = value
 	delete(gkvString.expireTimes, key)
+	notifyKeyspaceEvent(NotifyString, "set", key)
 	return true
 }
 
@@ -152,6 +163,7 @@ func (gkvString *GkvString) SetXX(key string, value []byte) bool {
 	}
 	gkvString.data[key] = value
 	delete(gkvString.expireTimes, key)
+	notifyKeyspaceEvent(NotifyString, "set", key)
 	return true
 }
 
@@ -179,3 +191,22 @@ func (gkv *GkvString) GetTTL(key string) int64 {
 	}
 	return int64(remaining.Milliseconds())
 }
+
+// liveKeys 获取所有未过期的key
+// @return []string
+func (gkvString *GkvString) liveKeys() []string {
+	return collectLiveKeys(gkvString.keyLock, gkvString.data, gkvString.expireTimes)
+}
+
+// deleteKey 删除整个key
+// @param key string
+// @return bool key是否存在
+func (gkvString *GkvString) deleteKey(key string) bool {
+	return removeKey(gkvString.keyLock, gkvString.data, gkvString.expireTimes, key)
+}
+
+// removeExpired 删除所有已过期的key
+// @return []string 被删除的key
+func (gkvString *GkvString) removeExpired() []string {
+	return removeExpiredKeys(gkvString.keyLock, gkvString.data, gkvString.expireTimes)
+}
@@ -1,8 +1,11 @@
 package data
 
 import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
 	"time"
-	"sort"
 )
 
 // GkvZSet 有序集合结构
@@ -10,20 +13,23 @@ import (
 // @datetime 2025-7-16 21:00
 type GkvZSet struct {
 	// 全部数据 key -> member -> score
-	data        map[string]map[string]float64
+	data        map[string]*zsetObject
 	// 全部数据的过期时间
 	expireTimes map[string]time.Time
 	// 锁实例
 	keyLock     *KeyLock
+	// 阻塞弹出的等待表
+	blocked *zsetBlocking
 }
 
 // DataGkvZSet 全局数据实例
 // @author xuyang
 // @datetime 2025-7-16 21:00
 var DataGkvZSet = &GkvZSet{
-	data:        make(map[string]map[string]float64),
+	data:        make(map[string]*zsetObject),
 	expireTimes: make(map[string]time.Time),
 	keyLock:     NewKeyLock(),
+	blocked:     newZSetBlocking(),
 }
 
 // Add 添加成员及分数
@@ -33,26 +39,301 @@ var DataGkvZSet = &GkvZSet{
 func (gkvZSet *GkvZSet) Add(key, member string, score float64) {
 	gkvZSet.keyLock.WLockRow(key)
 	defer gkvZSet.keyLock.WUnLockRow(key)
+	gkvZSet.dropExpired(key)
 	if _, exists := gkvZSet.data[key]; !exists {
-		gkvZSet.data[key] = make(map[string]float64)
+		gkvZSet.data[key] = newZSetObject()
 	}
-	gkvZSet.data[key][member] = score
+	gkvZSet.data[key].add(member, score)
 	delete(gkvZSet.expireTimes, key)
+	notifyKeyspaceEvent(NotifyZSet, "zadd", key)
+	gkvZSet.serveBlockedLocked(key)
 }
 
-// Remove 移除成员
+// ZAddFlags ZADD 的条件选项
+type ZAddFlags struct {
+	// NX 只添加新成员, 不更新已有成员
+	NX bool
+	// XX 只更新已有成员, 不添加新成员
+	XX bool
+	// GT 仅当新分数大于当前分数时更新
+	GT bool
+	// LT 仅当新分数小于当前分数时更新
+	LT bool
+	// CH 返回值统计被修改(新增或分数变化)的成员, 而不仅是新增的成员
+	CH bool
+}
+
+// errZAddFlags ZADD 选项冲突
+var errZAddFlags = errors.New("nx 与 xx 不能同时使用, gt、lt 与 nx 两两不能同时使用")
+
+// errZSetNaN 计算结果不是数字
+var errZSetNaN = errors.New("计算结果不是数字")
+
+// validate 检查选项是否冲突
+// @return error
+func (flags ZAddFlags) validate() error {
+	if (flags.NX && flags.XX) || (flags.GT && flags.LT) || (flags.NX && (flags.GT || flags.LT)) {
+		return errZAddFlags
+	}
+	return nil
+}
+
+// zaddLocked 按条件添加或更新一个成员, 调用方需持有key的写锁且key已存在
+// @param z *zsetObject 有序集合
+// @param flags ZAddFlags 条件选项
+// @param member string 成员
+// @param score float64 分数(incr为true时为增量)
+// @param incr bool 是否为增量
+// @return float64 成员的最终分数
+// @return int 结果: 0未修改, 1新增, 2更新
+// @return error 增量结果不是数字
+func zaddLocked(z *zsetObject, flags ZAddFlags, member string, score float64, incr bool) (float64, int, error) {
+	cur, exists := z.score(member)
+	if !exists {
+		if flags.XX {
+			return 0, 0, nil
+		}
+		if math.IsNaN(score) {
+			return 0, 0, errZSetNaN
+		}
+		z.add(member, score)
+		return score, 1, nil
+	}
+	if flags.NX {
+		return cur, 0, nil
+	}
+	if incr {
+		score += cur
+		if math.IsNaN(score) {
+			return cur, 0, errZSetNaN
+		}
+	}
+	if (flags.GT && score <= cur) || (flags.LT && score >= cur) || score == cur {
+		return cur, 0, nil
+	}
+	z.add(member, score)
+	return score, 2, nil
+}
+
+// AddWithFlags 按条件批量添加或更新成员, 整个操作在key的写锁内原子完成
+// @param key string 集合名
+// @param flags ZAddFlags 条件选项
+// @param entries ...ZSetEntry 成员及分数
+// @return int 新增的成员数量, 指定CH时为新增与分数变化的成员数量
+// @return error 选项冲突
+func (gkvZSet *GkvZSet) AddWithFlags(key string, flags ZAddFlags, entries ...ZSetEntry) (int, error) {
+	if err := flags.validate(); err != nil {
+		return 0, err
+	}
+	gkvZSet.keyLock.WLockRow(key)
+	defer gkvZSet.keyLock.WUnLockRow(key)
+	z := gkvZSet.writableZSet(key)
+	added, updated := 0, 0
+	for _, e := range entries {
+		_, result, _ := zaddLocked(z, flags, e.Member, e.Score, false)
+		switch result {
+		case 1:
+			added++
+		case 2:
+			updated++
+		}
+	}
+	if added+updated > 0 {
+		notifyKeyspaceEvent(NotifyZSet, "zadd", key)
+	}
+	gkvZSet.finishWrite(key)
+	if flags.CH {
+		return added + updated, nil
+	}
+	return added, nil
+}
+
+// IncrBy 按条件为成员的分数加上增量, 成员不存在时以增量作为分数添加
 // @param key string 集合名
 // @param member string 成员
-func (gkvZSet *GkvZSet) Remove(key, member string) {
+// @param delta float64 增量
+// @param flags ZAddFlags 条件选项, CH 不影响结果
+// @return float64 成员的最终分数
+// @return bool 是否执行了修改(受 NX/XX/GT/LT 限制时为false)
+// @return error 选项冲突或结果不是数字
+func (gkvZSet *GkvZSet) IncrBy(key, member string, delta float64, flags ZAddFlags) (float64, bool, error) {
+	if err := flags.validate(); err != nil {
+		return 0, false, err
+	}
+	gkvZSet.keyLock.WLockRow(key)
+	defer gkvZSet.keyLock.WUnLockRow(key)
+	z := gkvZSet.writableZSet(key)
+	score, result, err := zaddLocked(z, flags, member, delta, true)
+	if result != 0 {
+		notifyKeyspaceEvent(NotifyZSet, "zincr", key)
+	}
+	gkvZSet.finishWrite(key)
+	return score, result != 0, err
+}
+
+// MScore 批量获取成员分数
+// @param key string 集合名
+// @param members ...string 成员
+// @return []float64 分数
+// @return []bool 每个成员是否存在
+func (gkvZSet *GkvZSet) MScore(key string, members ...string) ([]float64, []bool) {
+	gkvZSet.keyLock.RLockRow(key)
+	defer gkvZSet.keyLock.RUnLockRow(key)
+	z := gkvZSet.liveZSet(key)
+	scores := make([]float64, len(members))
+	found := make([]bool, len(members))
+	for i, m := range members {
+		scores[i], found[i] = z.score(m)
+	}
+	return scores, found
+}
+
+// RandMember 随机获取成员但不移除
+// count为正数时返回不重复的成员, 为负数时返回|count|个可能重复的成员
+// @param key string 集合名
+// @param count int 数量
+// @return []ZSetEntry 成员及分数
+// @return error count为负数且绝对值超过 setRandMemberMaxRepeat 时返回错误
+func (gkvZSet *GkvZSet) RandMember(key string, count int) ([]ZSetEntry, error) {
+	if count < -setRandMemberMaxRepeat {
+		return nil, errRandMemberCount
+	}
+	gkvZSet.keyLock.RLockRow(key)
+	defer gkvZSet.keyLock.RUnLockRow(key)
+	z := gkvZSet.liveZSet(key)
+	n := z.len()
+	result := []ZSetEntry{}
+	if n == 0 || count == 0 {
+		return result, nil
+	}
+	if count < 0 {
+		for range -count {
+			rank := rand.IntN(n)
+			result = append(result, z.rangeByRank(rank, rank)[0])
+		}
+		return result, nil
+	}
+	if count >= n {
+		result = z.sorted()
+		rand.Shuffle(len(result), func(i, j int) {
+			result[i], result[j] = result[j], result[i]
+		})
+		return result, nil
+	}
+	picked := make(map[int]struct{}, count)
+	for len(picked) < count {
+		rank := rand.IntN(n)
+		if _, ok := picked[rank]; ok {
+			continue
+		}
+		picked[rank] = struct{}{}
+		result = append(result, z.rangeByRank(rank, rank)[0])
+	}
+	return result, nil
+}
+
+// Pop 弹出分数最低或最高的若干成员
+// @param key string 集合名
+// @param count int 弹出数量
+// @param max bool 为true时弹出分数最高的成员
+// @return []ZSetEntry 被弹出的成员, max为true时按分数从高到低排列
+func (gkvZSet *GkvZSet) Pop(key string, count int, max bool) []ZSetEntry {
+	gkvZSet.keyLock.WLockRow(key)
+	defer gkvZSet.keyLock.WUnLockRow(key)
+	return gkvZSet.popLocked(key, count, max)
+}
+
+// popLocked 弹出成员, 调用方需持有key的写锁
+// @param key string 集合名
+// @param count int 弹出数量
+// @param max bool 为true时弹出分数最高的成员
+// @return []ZSetEntry 被弹出的成员
+func (gkvZSet *GkvZSet) popLocked(key string, count int, max bool) []ZSetEntry {
+	z := gkvZSet.liveZSet(key)
+	n := z.len()
+	if n == 0 || count <= 0 {
+		return []ZSetEntry{}
+	}
+	count = min(count, n)
+	start, stop := 0, count-1
+	if max {
+		start, stop = n-count, n-1
+	}
+	popped := z.rangeByRank(start, stop)
+	z.deleteRangeByRank(start, stop)
+	event := "zpopmin"
+	if max {
+		slices.Reverse(popped)
+		event = "zpopmax"
+	}
+	notifyKeyspaceEvent(NotifyZSet, event, key)
+	if z.len() == 0 {
+		delete(gkvZSet.data, key)
+		delete(gkvZSet.expireTimes, key)
+		notifyKeyspaceEvent(NotifyGeneric, "del", key)
+	}
+	return popped
+}
+
+// dropExpired 删除已过期的key, 调用方需持有key的写锁
+// @param key string 集合名
+func (gkvZSet *GkvZSet) dropExpired(key string) {
+	if expireTime, exists := gkvZSet.expireTimes[key]; exists && time.Now().After(expireTime) {
+		delete(gkvZSet.data, key)
+		delete(gkvZSet.expireTimes, key)
+		notifyKeyspaceEvent(NotifyExpired, "expired", key)
+	}
+}
+
+// writableZSet 获取用于写入的有序集合, 不存在或已过期时创建空集合, 调用方需持有key的写锁
+// 写入完成后需调用 finishWrite
+// @param key string 集合名
+// @return *zsetObject
+func (gkvZSet *GkvZSet) writableZSet(key string) *zsetObject {
+	gkvZSet.dropExpired(key)
+	if _, exists := gkvZSet.data[key]; !exists {
+		gkvZSet.data[key] = newZSetObject()
+	}
+	return gkvZSet.data[key]
+}
+
+// finishWrite 结束写入: 清除过期时间, 集合为空时删除key, 否则服务阻塞等待的请求
+// @param key string 集合名
+func (gkvZSet *GkvZSet) finishWrite(key string) {
+	delete(gkvZSet.expireTimes, key)
+	if gkvZSet.data[key].len() == 0 {
+		delete(gkvZSet.data, key)
+		return
+	}
+	gkvZSet.serveBlockedLocked(key)
+}
+
+// Remove 原子地移除若干成员
+// @param key string 集合名
+// @param members ...string 成员
+// @return int 被移除的成员数量
+func (gkvZSet *GkvZSet) Remove(key string, members ...string) int {
 	gkvZSet.keyLock.WLockRow(key)
 	defer gkvZSet.keyLock.WUnLockRow(key)
-	if members, exists := gkvZSet.data[key]; exists {
-		delete(members, member)
-		if len(members) == 0 {
-			delete(gkvZSet.data, key)
-			delete(gkvZSet.expireTimes, key)
+	z := gkvZSet.liveZSet(key)
+	if z == nil {
+		return 0
+	}
+	removed := 0
+	for _, member := range members {
+		if z.remove(member) {
+			removed++
 		}
 	}
+	if removed > 0 {
+		notifyKeyspaceEvent(NotifyZSet, "zrem", key)
+	}
+	if z.len() == 0 {
+		delete(gkvZSet.data, key)
+		delete(gkvZSet.expireTimes, key)
+		notifyKeyspaceEvent(NotifyGeneric, "del", key)
+	}
+	return removed
 }
 
 // Score 获取成员分数
@@ -72,8 +353,7 @@ func (gkvZSet *GkvZSet) Score(key, member string) (float64, bool) {
 	if !exists {
 		return 0, false
 	}
-	score, ok := members[member]
-	return score, ok
+	return members.score(member)
 }
 
 // RangeByScore 按分数区间获取成员（升序）
@@ -92,26 +372,170 @@ func (gkvZSet *GkvZSet) RangeByScore(key string, min, max float64) []string {
 	if !exists {
 		return nil
 	}
-	type kv struct {
-		member string
-		score  float64
+	return entryMembers(members.query(&ZRangeOptions{
+		By:  ZRangeByScore,
+		Min: ScoreBound{Value: min},
+		Max: ScoreBound{Value: max},
+	}))
+}
+
+// Range 按排名、分数或字典序区间获取成员及分数
+// @param key string 集合名
+// @param opts ZRangeOptions 查询参数
+// @return []ZSetEntry 成员及分数
+func (gkvZSet *GkvZSet) Range(key string, opts ZRangeOptions) []ZSetEntry {
+	gkvZSet.keyLock.RLockRow(key)
+	defer gkvZSet.keyLock.RUnLockRow(key)
+	members := gkvZSet.liveZSet(key)
+	if members == nil {
+		return []ZSetEntry{}
+	}
+	return members.query(&opts)
+}
+
+// RangeStore 将区间查询结果原子地写入目标集合, 结果为空时删除目标集合
+// @param dst string 目标集合
+// @param src string 源集合
+// @param opts ZRangeOptions 查询参数
+// @return int 结果集合的成员数量
+func (gkvZSet *GkvZSet) RangeStore(dst, src string, opts ZRangeOptions) int {
+	gkvZSet.keyLock.WLockRows(dst, src)
+	defer gkvZSet.keyLock.WUnLockRows(dst, src)
+	entries := []ZSetEntry{}
+	if members := gkvZSet.liveZSet(src); members != nil {
+		entries = members.query(&opts)
 	}
-	var arr []kv
-	for m, s := range members {
-		if s >= min && s <= max {
-			arr = append(arr, kv{m, s})
+	gkvZSet.storeLocked(dst, "zrangestore", entries)
+	return len(entries)
+}
+
+// storeLocked 用给定成员覆盖目标集合并服务阻塞等待的请求, 调用方需持有dst的写锁
+// @param dst string 目标集合
+// @param event string 写入目标集合时发出的通知事件名
+// @param entries []ZSetEntry 成员及分数
+func (gkvZSet *GkvZSet) storeLocked(dst, event string, entries []ZSetEntry) {
+	delete(gkvZSet.expireTimes, dst)
+	if len(entries) == 0 {
+		if _, exists := gkvZSet.data[dst]; exists {
+			delete(gkvZSet.data, dst)
+			notifyKeyspaceEvent(NotifyGeneric, "del", dst)
 		}
+		return
 	}
-	sort.Slice(arr, func(i, j int) bool {
-		return arr[i].score < arr[j].score
-	})
-	result := make([]string, len(arr))
-	for i, v := range arr {
-		result[i] = v.member
+	result := newZSetObject()
+	for _, e := range entries {
+		result.add(e.Member, e.Score)
+	}
+	gkvZSet.data[dst] = result
+	notifyKeyspaceEvent(NotifyZSet, event, dst)
+	gkvZSet.serveBlockedLocked(dst)
+}
+
+// Count 统计分数或字典序区间内的成员数量
+// @param key string 集合名
+// @param opts ZRangeOptions 查询参数, By 为 ZRangeByScore 或 ZRangeByLex
+// @return int 成员数量
+func (gkvZSet *GkvZSet) Count(key string, opts ZRangeOptions) int {
+	gkvZSet.keyLock.RLockRow(key)
+	defer gkvZSet.keyLock.RUnLockRow(key)
+	members := gkvZSet.liveZSet(key)
+	if opts.By == ZRangeByRank {
+		start, stop, ok := normalizeRankRange(opts.Start, opts.Stop, members.len())
+		if !ok {
+			return 0
+		}
+		return stop - start + 1
+	}
+	return members.countRange(opts.bound())
+}
+
+// RemoveRange 删除排名、分数或字典序区间内的成员, 集合为空时删除key
+// @param key string 集合名
+// @param opts ZRangeOptions 查询参数, 忽略 Rev 与 Limit
+// @return int 删除的数量
+func (gkvZSet *GkvZSet) RemoveRange(key string, opts ZRangeOptions) int {
+	gkvZSet.keyLock.WLockRow(key)
+	defer gkvZSet.keyLock.WUnLockRow(key)
+	members := gkvZSet.liveZSet(key)
+	if members == nil {
+		return 0
+	}
+	removed := members.deleteByOptions(&opts)
+	if removed > 0 {
+		notifyKeyspaceEvent(NotifyZSet, zremRangeEvents[opts.By], key)
+	}
+	if members.len() == 0 {
+		delete(gkvZSet.data, key)
+		delete(gkvZSet.expireTimes, key)
+		notifyKeyspaceEvent(NotifyGeneric, "del", key)
+	}
+	return removed
+}
+
+// zremRangeEvents 按区间删除成员时各区间类型对应的通知事件名
+var zremRangeEvents = map[ZRangeBy]string{
+	ZRangeByRank:  "zremrangebyrank",
+	ZRangeByScore: "zremrangebyscore",
+	ZRangeByLex:   "zremrangebylex",
+}
+
+// RangeByRank 按排名区间获取成员（升序）
+// 下标从0开始, 负数表示从末尾倒数, 区间两端均包含
+// @param key string 集合名
+// @param start, stop int 排名区间
+// @return []string 成员
+func (gkvZSet *GkvZSet) RangeByRank(key string, start, stop int) []string {
+	gkvZSet.keyLock.RLockRow(key)
+	defer gkvZSet.keyLock.RUnLockRow(key)
+	members := gkvZSet.liveZSet(key)
+	start, stop, ok := normalizeRankRange(start, stop, members.len())
+	if !ok {
+		return []string{}
+	}
+	return entryMembers(members.rangeByRank(start, stop))
+}
+
+// normalizeRankRange 将可能为负数的排名区间转换为合法的非负区间
+// @param start, stop int 排名区间
+// @param length int 成员数量
+// @return int, int 转换后的区间
+// @return bool 区间是否非空
+func normalizeRankRange(start, stop, length int) (int, int, bool) {
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	start = max(start, 0)
+	stop = min(stop, length-1)
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+	return start, stop, true
+}
+
+// entryMembers 提取成员名
+// @param entries []ZSetEntry
+// @return []string
+func entryMembers(entries []ZSetEntry) []string {
+	result := make([]string, len(entries))
+	for i, e := range entries {
+		result[i] = e.Member
 	}
 	return result
 }
 
+// liveZSet 获取未过期的有序集合, 调用方需持有key的锁
+// @param key string 集合名
+// @return *zsetObject 不存在或已过期时为nil
+func (gkvZSet *GkvZSet) liveZSet(key string) *zsetObject {
+	if expireTime, exists := gkvZSet.expireTimes[key]; exists && time.Now().After(expireTime) {
+		return nil
+	}
+	return gkvZSet.data[key]
+}
+
 // SetTime 设置过期时间(毫秒为单位)
 // @param key string 集合名
 // @param timeMs int 毫秒
@@ -122,6 +546,7 @@ func (gkvZSet *GkvZSet) SetTime(key string, timeMs int) bool {
 	if _, exists := gkvZSet.data[key]; exists {
 		expireTime := time.Now().Add(time.Duration(timeMs) * time.Millisecond)
 		gkvZSet.expireTimes[key] = expireTime
+		notifyKeyspaceEvent(NotifyGeneric, "expire", key)
 		return true
 	}
 	return false
@@ -157,27 +582,11 @@ func (gkvZSet *GkvZSet) GetTTL(key string) int64 {
 func (gkvZSet *GkvZSet) Rank(key, member string) int {
 	gkvZSet.keyLock.RLockRow(key)
 	defer gkvZSet.keyLock.RUnLockRow(key)
-	members, exists := gkvZSet.data[key]
-	if !exists {
+	rank, ok := gkvZSet.liveZSet(key).rank(member)
+	if !ok {
 		return -1
 	}
-	type kv struct {
-		member string
-		score  float64
-	}
-	arr := make([]kv, 0, len(members))
-	for m, s := range members {
-		arr = append(arr, kv{m, s})
-	}
-	sort.Slice(arr, func(i, j int) bool {
-		return arr[i].score < arr[j].score
-	})
-	for i, v := range arr {
-		if v.member == member {
-			return i
-		}
-	}
-	return -1
+	return rank
 }
 
 // RevRank 获取成员的倒序排名（分数高的排前面）
@@ -187,48 +596,24 @@ func (gkvZSet *GkvZSet) Rank(key, member string) int {
 func (gkvZSet *GkvZSet) RevRank(key, member string) int {
 	gkvZSet.keyLock.RLockRow(key)
 	defer gkvZSet.keyLock.RUnLockRow(key)
-	members, exists := gkvZSet.data[key]
-	if !exists {
+	members := gkvZSet.liveZSet(key)
+	rank, ok := members.rank(member)
+	if !ok {
 		return -1
 	}
-	type kv struct {
-		member string
-		score  float64
-	}
-	arr := make([]kv, 0, len(members))
-	for m, s := range members {
-		arr = append(arr, kv{m, s})
-	}
-	sort.Slice(arr, func(i, j int) bool {
-		return arr[i].score > arr[j].score
-	})
-	for i, v := range arr {
-		if v.member == member {
-			return i
-		}
-	}
-	return -1
+	return members.len() - 1 - rank
 }
 
 // RemoveRangeByScore 删除分数区间的成员
 // @param key string
 // @param min, max float64
-func (gkvZSet *GkvZSet) RemoveRangeByScore(key string, min, max float64) {
-	gkvZSet.keyLock.WLockRow(key)
-	defer gkvZSet.keyLock.WUnLockRow(key)
-	members, exists := gkvZSet.data[key]
-	if !exists {
-		return
-	}
-	for m, s := range members {
-		if s >= min && s <= max {
-			delete(members, m)
-		}
-	}
-	if len(members) == 0 {
-		delete(gkvZSet.data, key)
-		delete(gkvZSet.expireTimes, key)
-	}
+// @return int 删除的数量
+func (gkvZSet *GkvZSet) RemoveRangeByScore(key string, min, max float64) int {
+	return gkvZSet.RemoveRange(key, ZRangeOptions{
+		By:  ZRangeByScore,
+		Min: ScoreBound{Value: min},
+		Max: ScoreBound{Value: max},
+	})
 }
 
 // Cardinality 获取有序集合成员数量
@@ -237,11 +622,24 @@ func (gkvZSet *GkvZSet) RemoveRangeByScore(key string, min, max float64) {
 func (gkvZSet *GkvZSet) Cardinality(key string) int {
 	gkvZSet.keyLock.RLockRow(key)
 	defer gkvZSet.keyLock.RUnLockRow(key)
+	return gkvZSet.data[key].len()
+}
+
+// Encoding 获取有序集合当前使用的编码
+// @param key string
+// @return string 编码名称
+// @return bool key是否存在
+func (gkvZSet *GkvZSet) Encoding(key string) (string, bool) {
+	gkvZSet.keyLock.RLockRow(key)
+	defer gkvZSet.keyLock.RUnLockRow(key)
+	if expireTime, exists := gkvZSet.expireTimes[key]; exists && time.Now().After(expireTime) {
+		return "", false
+	}
 	members, exists := gkvZSet.data[key]
 	if !exists {
-		return 0
+		return "", false
 	}
-	return len(members)
+	return members.encoding(), true
 }
 
 // Clear 清空有序集合
@@ -249,6 +647,29 @@ func (gkvZSet *GkvZSet) Cardinality(key string) int {
 func (gkvZSet *GkvZSet) Clear(key string) {
 	gkvZSet.keyLock.WLockRow(key)
 	defer gkvZSet.keyLock.WUnLockRow(key)
+	_, exists := gkvZSet.data[key]
 	delete(gkvZSet.data, key)
 	delete(gkvZSet.expireTimes, key)
+	if exists {
+		notifyKeyspaceEvent(NotifyGeneric, "del", key)
+	}
+}
+
+// liveKeys 获取所有未过期的key
+// @return []string
+func (gkvZSet *GkvZSet) liveKeys() []string {
+	return collectLiveKeys(gkvZSet.keyLock, gkvZSet.data, gkvZSet.expireTimes)
+}
+
+// deleteKey 删除整个key
+// @param key string
+// @return bool key是否存在
+func (gkvZSet *GkvZSet) deleteKey(key string) bool {
+	return removeKey(gkvZSet.keyLock, gkvZSet.data, gkvZSet.expireTimes, key)
+}
+
+// removeExpired 删除所有已过期的key
+// @return []string 被删除的key
+func (gkvZSet *GkvZSet) removeExpired() []string {
+	return removeExpiredKeys(gkvZSet.keyLock, gkvZSet.data, gkvZSet.expireTimes)
 }
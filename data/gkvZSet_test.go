@@ -0,0 +1,133 @@
+package data
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestZAddFlags NX/XX/GT/LT/CH 组合下的返回值与最终分数, 初始集合为 a=1 b=2
+func TestZAddFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   ZAddFlags
+		entries []ZSetEntry
+		want    int
+		// 操作后的全部成员及分数
+		scores map[string]float64
+	}{
+		{"无选项只统计新增", ZAddFlags{}, []ZSetEntry{{"a", 5}, {"c", 3}}, 1, map[string]float64{"a": 5, "b": 2, "c": 3}},
+		{"CH 统计新增与修改", ZAddFlags{CH: true}, []ZSetEntry{{"a", 5}, {"b", 2}, {"c", 3}}, 2, map[string]float64{"a": 5, "b": 2, "c": 3}},
+		{"NX 不修改已存在的成员", ZAddFlags{NX: true}, []ZSetEntry{{"a", 5}, {"c", 3}}, 1, map[string]float64{"a": 1, "b": 2, "c": 3}},
+		{"XX 不新增成员", ZAddFlags{XX: true, CH: true}, []ZSetEntry{{"a", 5}, {"c", 3}}, 1, map[string]float64{"a": 5, "b": 2}},
+		{"GT 只提高分数", ZAddFlags{GT: true, CH: true}, []ZSetEntry{{"a", 0}, {"b", 3}, {"c", 1}}, 2, map[string]float64{"a": 1, "b": 3, "c": 1}},
+		{"LT 只降低分数", ZAddFlags{LT: true, CH: true}, []ZSetEntry{{"a", 0}, {"b", 3}}, 1, map[string]float64{"a": 0, "b": 2}},
+		{"GT 与 XX", ZAddFlags{GT: true, XX: true}, []ZSetEntry{{"a", 9}, {"c", 1}}, 0, map[string]float64{"a": 9, "b": 2}},
+		{"同一成员多次出现以最后一次为准", ZAddFlags{}, []ZSetEntry{{"c", 1}, {"c", 7}}, 1, map[string]float64{"a": 1, "b": 2, "c": 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			z := newTestZSet()
+			z.Add("k", "a", 1)
+			z.Add("k", "b", 2)
+			n, err := z.AddWithFlags("k", tt.flags, tt.entries...)
+			if err != nil || n != tt.want {
+				t.Fatalf("返回 %d %v, 期望 %d", n, err, tt.want)
+			}
+			if z.Cardinality("k") != len(tt.scores) {
+				t.Fatalf("成员数 %d, 期望 %d", z.Cardinality("k"), len(tt.scores))
+			}
+			for m, want := range tt.scores {
+				if s, ok := z.Score("k", m); !ok || s != want {
+					t.Fatalf("%s 的分数为 %g, 期望 %g", m, s, want)
+				}
+			}
+		})
+	}
+	for _, flags := range []ZAddFlags{{NX: true, XX: true}, {GT: true, LT: true}, {NX: true, GT: true}, {NX: true, LT: true}} {
+		z := newTestZSet()
+		if _, err := z.AddWithFlags("k", flags, ZSetEntry{"a", 1}); !errors.Is(err, errZAddFlags) {
+			t.Fatalf("%+v: 期望 errZAddFlags, 得到 %v", flags, err)
+		}
+		if z.Cardinality("k") != 0 {
+			t.Fatalf("%+v: 选项冲突时不应写入", flags)
+		}
+	}
+}
+
+// TestZIncrBy 增量的条件选项与 NaN 结果, 初始集合为 a=1 inf=+Inf
+func TestZIncrBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		member  string
+		delta   float64
+		flags   ZAddFlags
+		want    float64
+		changed bool
+		err     error
+	}{
+		{"已存在的成员", "a", 2, ZAddFlags{}, 3, true, nil},
+		{"不存在的成员以增量为分数", "b", -2, ZAddFlags{}, -2, true, nil},
+		{"GT 拒绝减小", "a", -1, ZAddFlags{GT: true}, 1, false, nil},
+		{"LT 接受减小", "a", -1, ZAddFlags{LT: true}, 0, true, nil},
+		{"XX 不新增", "b", 1, ZAddFlags{XX: true}, 0, false, nil},
+		{"NX 不修改", "a", 1, ZAddFlags{NX: true}, 1, false, nil},
+		{"新成员的增量为 NaN", "b", math.NaN(), ZAddFlags{}, 0, false, errZSetNaN},
+		{"结果为 NaN", "inf", math.Inf(-1), ZAddFlags{}, math.Inf(1), false, errZSetNaN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			z := newTestZSet()
+			z.Add("k", "a", 1)
+			z.Add("k", "inf", math.Inf(1))
+			score, changed, err := z.IncrBy("k", tt.member, tt.delta, tt.flags)
+			if !errors.Is(err, tt.err) || changed != tt.changed || score != tt.want {
+				t.Fatalf("返回 %g %v %v, 期望 %g %v %v", score, changed, err, tt.want, tt.changed, tt.err)
+			}
+			if _, exists := z.Score("k", "b"); exists != (tt.member == "b" && tt.changed) {
+				t.Fatal("成员 b 的存在性错误")
+			}
+		})
+	}
+}
+
+// TestZRandMember 正数返回不重复成员, 负数返回可重复成员且绝对值有上限
+func TestZRandMember(t *testing.T) {
+	for _, big := range []bool{false, true} {
+		z := newTestZSet()
+		fillZSet(z, "k", big)
+		n := z.Cardinality("k")
+		tests := []struct {
+			count, want int
+			distinct    bool
+			err         error
+		}{
+			{0, 0, true, nil},
+			{3, 3, true, nil},
+			{n, n, true, nil},
+			{n + 10, n, true, nil},
+			{-(n + 10), n + 10, false, nil},
+			{-setRandMemberMaxRepeat - 1, 0, false, errRandMemberCount},
+			{math.MinInt, 0, false, errRandMemberCount},
+		}
+		for _, tt := range tests {
+			entries, err := z.RandMember("k", tt.count)
+			if !errors.Is(err, tt.err) || len(entries) != tt.want {
+				t.Fatalf("跳表=%v count=%d: 得到 %d 个 %v, 期望 %d 个 %v", big, tt.count, len(entries), err, tt.want, tt.err)
+			}
+			seen := map[string]bool{}
+			for _, e := range entries {
+				if s, ok := z.Score("k", e.Member); !ok || s != e.Score {
+					t.Fatalf("跳表=%v count=%d: 返回了错误的成员 %+v", big, tt.count, e)
+				}
+				if tt.distinct && seen[e.Member] {
+					t.Fatalf("跳表=%v count=%d: 成员 %s 重复", big, tt.count, e.Member)
+				}
+				seen[e.Member] = true
+			}
+		}
+	}
+	if entries, err := newTestZSet().RandMember("missing", -5); err != nil || len(entries) != 0 {
+		t.Fatal("不存在的集合应返回空结果")
+	}
+}
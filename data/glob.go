@@ -0,0 +1,126 @@
+package data
+
+// GlobMatch 判断字符串是否匹配 Redis 风格的 glob 模式
+// 支持 `*` 任意长度字符, `?` 单个字符, `[abc]` `[a-z]` `[^x]` 字符类, `\` 转义
+// 未闭合的 `[` 视为延伸到模式末尾的字符类, 与 Redis 保持一致
+// @param pattern string 模式
+// @param str string 待匹配字符串
+// @param nocase bool 是否忽略大小写
+// @return bool 是否匹配
+func GlobMatch(pattern, str string, nocase bool) bool {
+	p, s := 0, 0
+	// 最近一个 `*` 之后的模式位置及其对应的字符串位置, 用于回溯
+	starP, starS := -1, 0
+	for s < len(str) {
+		if p < len(pattern) {
+			if pattern[p] == '*' {
+				for p < len(pattern) && pattern[p] == '*' {
+					p++
+				}
+				if p == len(pattern) {
+					return true
+				}
+				starP, starS = p, s
+				continue
+			}
+			if width, ok := globMatchOne(pattern[p:], str[s], nocase); ok {
+				p += width
+				s++
+				continue
+			}
+		}
+		// 失配: 让最近的 `*` 多吞一个字符后重试
+		if starP < 0 {
+			return false
+		}
+		starS++
+		p, s = starP, starS
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// globMatchOne 用模式开头的单个匹配单元匹配一个字符
+// @param pattern string 剩余模式(非空且不以 `*` 开头)
+// @param c byte 待匹配字符
+// @param nocase bool 是否忽略大小写
+// @return int 匹配单元占用的模式长度
+// @return bool 是否匹配
+func globMatchOne(pattern string, c byte, nocase bool) (int, bool) {
+	switch pattern[0] {
+	case '?':
+		return 1, true
+	case '[':
+		return globMatchClass(pattern, c, nocase)
+	case '\\':
+		if len(pattern) >= 2 {
+			return 2, globByteEqual(pattern[1], c, nocase)
+		}
+	}
+	return 1, globByteEqual(pattern[0], c, nocase)
+}
+
+// globMatchClass 匹配 `[...]` 字符类
+// @param pattern string 以 `[` 开头的模式
+// @param c byte 待匹配字符
+// @param nocase bool 是否忽略大小写
+// @return int 字符类占用的模式长度
+// @return bool 是否匹配
+func globMatchClass(pattern string, c byte, nocase bool) (int, bool) {
+	i := 1
+	not := i < len(pattern) && pattern[i] == '^'
+	if not {
+		i++
+	}
+	match := false
+	for i < len(pattern) {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			if globByteEqual(pattern[i+1], c, nocase) {
+				match = true
+			}
+			i += 2
+		case pattern[i] == ']':
+			i++
+			return i, match != not
+		case i+2 < len(pattern) && pattern[i+1] == '-':
+			start, end := pattern[i], pattern[i+2]
+			if start > end {
+				start, end = end, start
+			}
+			lc := c
+			if nocase {
+				start, end, lc = globLower(start), globLower(end), globLower(c)
+			}
+			if lc >= start && lc <= end {
+				match = true
+			}
+			i += 3
+		default:
+			if globByteEqual(pattern[i], c, nocase) {
+				match = true
+			}
+			i++
+		}
+	}
+	// 未闭合的字符类延伸到模式末尾
+	return i, match != not
+}
+
+// globByteEqual 比较两个字符
+func globByteEqual(a, b byte, nocase bool) bool {
+	if nocase {
+		return globLower(a) == globLower(b)
+	}
+	return a == b
+}
+
+// globLower 将 ASCII 大写字母转为小写
+func globLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,443 @@
+package data
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// errGraphNotDAG 图中存在环, 无法拓扑排序
+var errGraphNotDAG = errors.New("图中存在环或无向边, 无法拓扑排序")
+
+// errPageRankOptions PageRank 参数不合法
+var errPageRankOptions = errors.New("damping 必须在 0 到 1 之间, tolerance 不能为负, 迭代次数必须为正")
+
+// PageRankOptions PageRank 参数
+type PageRankOptions struct {
+	// 阻尼系数, 0 表示使用默认值 0.85
+	Damping float64
+	// 两次迭代间各节点分值变化量之和小于该值时停止, 0 表示使用默认值 1e-6
+	Tolerance float64
+	// 最大迭代次数, 0 表示使用默认值 100
+	MaxIterations int
+}
+
+// withDefaults 填充默认值并校验
+// @return PageRankOptions
+// @return error
+func (opts PageRankOptions) withDefaults() (PageRankOptions, error) {
+	if opts.Damping == 0 {
+		opts.Damping = 0.85
+	}
+	if opts.Tolerance == 0 {
+		opts.Tolerance = 1e-6
+	}
+	if opts.MaxIterations == 0 {
+		opts.MaxIterations = 100
+	}
+	if !(opts.Damping > 0 && opts.Damping < 1) || !(opts.Tolerance >= 0) || opts.MaxIterations < 0 {
+		return opts, errPageRankOptions
+	}
+	return opts, nil
+}
+
+// GraphRank 节点及其分值
+type GraphRank struct {
+	Node  string
+	Score float64
+}
+
+// sortComponents 组件内节点按字典序排列, 组件按大小降序、首个节点字典序排列
+// @param comps [][]string
+// @return [][]string
+func sortComponents(comps [][]string) [][]string {
+	for _, c := range comps {
+		slices.Sort(c)
+	}
+	slices.SortFunc(comps, func(a, b []string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a[0], b[0])
+	})
+	return comps
+}
+
+// components 忽略边的方向求连通分量
+// @return [][]string
+func (g *graphObject) components() [][]string {
+	visited := make(map[string]bool, len(g.out))
+	comps := [][]string{}
+	for _, start := range g.nodeNames() {
+		if visited[start] {
+			continue
+		}
+		visited[start] = true
+		comp := []string{}
+		stack := []string{start}
+		for len(stack) > 0 {
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			comp = append(comp, node)
+			for _, adj := range []map[string]*graphArc{g.out[node], g.in[node]} {
+				for next := range adj {
+					if !visited[next] {
+						visited[next] = true
+						stack = append(stack, next)
+					}
+				}
+			}
+		}
+		comps = append(comps, comp)
+	}
+	return sortComponents(comps)
+}
+
+// sccFrame Tarjan 算法显式栈中的一帧
+type sccFrame struct {
+	node      string
+	neighbors []string
+	next      int
+}
+
+// stronglyConnected 使用 Tarjan 算法求强连通分量, 无向边视为两条方向相反的有向边
+// 使用显式栈代替递归, 链很长时也不会耗尽协程栈
+// @return [][]string
+func (g *graphObject) stronglyConnected() [][]string {
+	index := make(map[string]int, len(g.out))
+	low := make(map[string]int, len(g.out))
+	onStack := make(map[string]bool)
+	stack := []string{}
+	comps := [][]string{}
+	next := 0
+	enter := func(v string) sccFrame {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		return sccFrame{node: v, neighbors: slices.Collect(maps.Keys(g.out[v]))}
+	}
+	for _, root := range g.nodeNames() {
+		if _, seen := index[root]; seen {
+			continue
+		}
+		frames := []sccFrame{enter(root)}
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			v := f.node
+			if f.next < len(f.neighbors) {
+				w := f.neighbors[f.next]
+				f.next++
+				if _, seen := index[w]; !seen {
+					frames = append(frames, enter(w))
+				} else if onStack[w] {
+					low[v] = min(low[v], index[w])
+				}
+				continue
+			}
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].node
+				low[parent] = min(low[parent], low[v])
+			}
+			if low[v] != index[v] {
+				continue
+			}
+			comp := []string{}
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				comp = append(comp, w)
+				if w == v {
+					break
+				}
+			}
+			comps = append(comps, comp)
+		}
+	}
+	return sortComponents(comps)
+}
+
+// cycleFrame 环查找显式栈中的一帧
+type cycleFrame struct {
+	node, from    string
+	viaUndirected bool
+	neighbors     []string
+	next          int
+}
+
+// findCycle 查找一个环
+// 有向边按方向查找; 无向边不会沿进入时的同一条边立即返回, 因此单条无向边不构成环
+// 三色深度优先搜索使用显式栈代替递归
+// @return []string 环上的节点, 首尾为同一节点
+// @return bool 是否存在环
+func (g *graphObject) findCycle() ([]string, bool) {
+	const (
+		white = iota
+		gray
+		black
+	)
+	color := make(map[string]int, len(g.out))
+	parent := make(map[string]string)
+	enter := func(v, from string, viaUndirected bool) cycleFrame {
+		color[v] = gray
+		return cycleFrame{node: v, from: from, viaUndirected: viaUndirected, neighbors: slices.Sorted(maps.Keys(g.out[v]))}
+	}
+	for _, root := range g.nodeNames() {
+		if color[root] != white {
+			continue
+		}
+		frames := []cycleFrame{enter(root, "", false)}
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			v := f.node
+			if f.next == len(f.neighbors) {
+				color[v] = black
+				frames = frames[:len(frames)-1]
+				continue
+			}
+			w := f.neighbors[f.next]
+			f.next++
+			arc := g.out[v][w]
+			if f.viaUndirected && !arc.directed && w == f.from {
+				continue
+			}
+			switch color[w] {
+			case gray:
+				cycle := []string{w}
+				for node := v; node != w; node = parent[node] {
+					cycle = append(cycle, node)
+				}
+				cycle = append(cycle, w)
+				slices.Reverse(cycle)
+				return cycle, true
+			case white:
+				parent[w] = v
+				frames = append(frames, enter(w, v, !arc.directed))
+			}
+		}
+	}
+	return nil, false
+}
+
+// topologicalSort 使用 Kahn 算法求拓扑序, 同时入度为0的节点按字典序输出
+// @return []string
+// @return error 图中存在环或无向边时返回错误
+func (g *graphObject) topologicalSort() ([]string, error) {
+	indegree := make(map[string]int, len(g.in))
+	ready := []string{}
+	for node, arcs := range g.in {
+		indegree[node] = len(arcs)
+		if len(arcs) == 0 {
+			ready = append(ready, node)
+		}
+	}
+	// ready 按字典序降序排列, 从末尾取出最小的节点
+	desc := func(a, b string) int { return strings.Compare(b, a) }
+	slices.SortFunc(ready, desc)
+	order := make([]string, 0, len(g.out))
+	for len(ready) > 0 {
+		node := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+		order = append(order, node)
+		for next := range g.out[node] {
+			if indegree[next]--; indegree[next] == 0 {
+				i, _ := slices.BinarySearchFunc(ready, next, desc)
+				ready = slices.Insert(ready, i, next)
+			}
+		}
+	}
+	if len(order) != len(g.out) {
+		return nil, errGraphNotDAG
+	}
+	return order, nil
+}
+
+// minimumSpanningTree 使用 Kruskal 算法求最小生成森林, 有向边视为无向边
+// 同一对节点间有多条边时只会选用权重最小的一条
+// @return []GraphEdge 按权重升序
+// @return float64 总权重
+func (g *graphObject) minimumSpanningTree() ([]GraphEdge, float64) {
+	edges := g.edges()
+	slices.SortStableFunc(edges, func(a, b GraphEdge) int {
+		return cmp.Compare(a.Weight, b.Weight)
+	})
+	// 并查集按秩合并并压缩路径, 迭代实现
+	parent := make(map[string]string, len(g.out))
+	rank := make(map[string]int, len(g.out))
+	find := func(x string) string {
+		root := x
+		for {
+			p, ok := parent[root]
+			if !ok || p == root {
+				break
+			}
+			root = p
+		}
+		for x != root {
+			next := parent[x]
+			parent[x] = root
+			x = next
+		}
+		return root
+	}
+	tree := []GraphEdge{}
+	total := 0.0
+	for _, e := range edges {
+		ra, rb := find(e.From), find(e.To)
+		if ra == rb {
+			continue
+		}
+		if rank[ra] > rank[rb] {
+			ra, rb = rb, ra
+		}
+		parent[ra] = rb
+		if rank[ra] == rank[rb] {
+			rank[rb]++
+		}
+		tree = append(tree, e)
+		total += e.Weight
+	}
+	return tree, total
+}
+
+// pageRank 迭代计算 PageRank, 出边均分分值, 没有出边的节点将分值均分给所有节点
+// @param opts PageRankOptions 已填充默认值
+// @return []GraphRank 按分值降序
+// @return int 实际迭代次数
+func (g *graphObject) pageRank(opts PageRankOptions) ([]GraphRank, int) {
+	nodes := g.nodeNames()
+	n := float64(len(nodes))
+	rank := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		rank[node] = 1 / n
+	}
+	iterations := 0
+	for iterations < opts.MaxIterations {
+		iterations++
+		dangling := 0.0
+		for _, node := range nodes {
+			if len(g.out[node]) == 0 {
+				dangling += rank[node]
+			}
+		}
+		base := (1-opts.Damping)/n + opts.Damping*dangling/n
+		next := make(map[string]float64, len(nodes))
+		for _, node := range nodes {
+			sum := 0.0
+			for from := range g.in[node] {
+				sum += rank[from] / float64(len(g.out[from]))
+			}
+			next[node] = base + opts.Damping*sum
+		}
+		delta := 0.0
+		for _, node := range nodes {
+			delta += math.Abs(next[node] - rank[node])
+		}
+		rank = next
+		if delta < opts.Tolerance {
+			break
+		}
+	}
+	result := make([]GraphRank, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, GraphRank{Node: node, Score: rank[node]})
+	}
+	slices.SortStableFunc(result, func(a, b GraphRank) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return result, iterations
+}
+
+// ConnectedComponents 忽略边的方向求连通分量
+// @param key string 图名
+// @return [][]string 组件内节点按字典序, 组件按大小降序排列
+func (g *GkvGraph) ConnectedComponents(key string) [][]string {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return [][]string{}
+	}
+	return graph.components()
+}
+
+// StronglyConnectedComponents 使用 Tarjan 算法求强连通分量
+// @param key string 图名
+// @return [][]string 组件内节点按字典序, 组件按大小降序排列
+func (g *GkvGraph) StronglyConnectedComponents(key string) [][]string {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return [][]string{}
+	}
+	return graph.stronglyConnected()
+}
+
+// FindCycle 查找图中的一个环, 单条无向边不构成环
+// @param key string 图名
+// @return []string 环上的节点, 首尾为同一节点
+// @return bool 是否存在环
+func (g *GkvGraph) FindCycle(key string) ([]string, bool) {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return nil, false
+	}
+	return graph.findCycle()
+}
+
+// TopologicalSort 求有向无环图的拓扑序, 可选的节点按字典序优先
+// @param key string 图名
+// @return []string
+// @return error 图中存在环或无向边时返回错误
+func (g *GkvGraph) TopologicalSort(key string) ([]string, error) {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []string{}, nil
+	}
+	return graph.topologicalSort()
+}
+
+// MinimumSpanningTree 使用 Kruskal 算法求最小生成树, 图不连通时为最小生成森林, 有向边视为无向边
+// @param key string 图名
+// @return []GraphEdge 选中的边, 按权重升序
+// @return float64 总权重
+func (g *GkvGraph) MinimumSpanningTree(key string) ([]GraphEdge, float64) {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []GraphEdge{}, 0
+	}
+	return graph.minimumSpanningTree()
+}
+
+// PageRank 迭代计算各节点的 PageRank 分值, 无向边视为两条方向相反的有向边
+// @param key string 图名
+// @param opts PageRankOptions
+// @return []GraphRank 按分值降序排列
+// @return int 实际迭代次数
+// @return error 参数不合法时返回错误
+func (g *GkvGraph) PageRank(key string, opts PageRankOptions) ([]GraphRank, int, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, 0, err
+	}
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []GraphRank{}, 0, nil
+	}
+	ranks, iterations := graph.pageRank(opts)
+	return ranks, iterations, nil
+}
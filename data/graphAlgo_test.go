@@ -0,0 +1,194 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// buildGraph 由边的描述创建图, "a>b" 为有向边, "a-b" 为无向边, 单个名称为孤立节点
+// @param edges ...string
+// @return *graphObject
+func buildGraph(edges ...string) *graphObject {
+	g := newGraphObject()
+	for _, e := range edges {
+		switch {
+		case strings.Contains(e, ">"):
+			from, to, _ := strings.Cut(e, ">")
+			g.addEdge(GraphEdge{From: from, To: to, Directed: true, Weight: 1})
+		case strings.Contains(e, "-"):
+			from, to, _ := strings.Cut(e, "-")
+			g.addEdge(GraphEdge{From: from, To: to, Weight: 1})
+		default:
+			g.addNode(e, 0, 0)
+		}
+	}
+	return g
+}
+
+// joinComponents 将分量拼接为 "a,b|c" 的形式
+// @param comps [][]string
+// @return string
+func joinComponents(comps [][]string) string {
+	parts := make([]string, len(comps))
+	for i, c := range comps {
+		parts[i] = strings.Join(c, ",")
+	}
+	return strings.Join(parts, "|")
+}
+
+// TestStronglyConnected Tarjan 强连通分量, 分量按大小降序、分量内按字典序
+func TestStronglyConnected(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges []string
+		want  string
+	}{
+		{"空图", nil, ""},
+		{"单个节点", []string{"a"}, "a"},
+		{"自环", []string{"a>a", "b"}, "a|b"},
+		{"有向链各自成为分量", []string{"a>b", "b>c"}, "a|b|c"},
+		{"有向环", []string{"a>b", "b>c", "c>a"}, "a,b,c"},
+		{"无向边视为双向", []string{"a-b", "b>c"}, "a,b|c"},
+		{"两个环由单向边相连", []string{"a>b", "b>a", "b>c", "c>d", "d>e", "e>c"}, "c,d,e|a,b"},
+		{"环中带回到更早节点的边", []string{"a>b", "b>c", "c>d", "d>b", "d>a", "e>a"}, "a,b,c,d|e"},
+		{"多个平凡分量与一个环", []string{"x>a", "a>b", "b>a", "b>y"}, "a,b|x|y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinComponents(buildGraph(tt.edges...).stronglyConnected()); got != tt.want {
+				t.Fatalf("得到 %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestComponents 忽略方向的连通分量
+func TestComponents(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges []string
+		want  string
+	}{
+		{"有向边忽略方向", []string{"a>b", "c>b"}, "a,b,c"},
+		{"多个分量", []string{"a-b", "c>d", "d>e", "f"}, "c,d,e|a,b|f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinComponents(buildGraph(tt.edges...).components()); got != tt.want {
+				t.Fatalf("得到 %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFindCycle 有向环、无向环与单条无向边
+func TestFindCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges []string
+		found bool
+	}{
+		{"有向无环图", []string{"a>b", "a>c", "b>d", "c>d"}, false},
+		{"单条无向边不构成环", []string{"a-b"}, false},
+		{"无向树", []string{"a-b", "b-c", "b-d"}, false},
+		{"有向环", []string{"a>b", "b>c", "c>a"}, true},
+		{"自环", []string{"a>a"}, true},
+		{"无向三角形", []string{"a-b", "b-c", "c-a"}, true},
+		{"反向的两条有向边", []string{"a>b", "b>a"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := buildGraph(tt.edges...)
+			cycle, found := g.findCycle()
+			if found != tt.found {
+				t.Fatalf("得到 %v, 期望 %v", found, tt.found)
+			}
+			if !found {
+				return
+			}
+			if len(cycle) < 2 || cycle[0] != cycle[len(cycle)-1] {
+				t.Fatalf("环 %v 的首尾应为同一节点", cycle)
+			}
+			for i := 0; i+1 < len(cycle); i++ {
+				if g.out[cycle[i]][cycle[i+1]] == nil {
+					t.Fatalf("环 %v 中 %s 到 %s 没有边", cycle, cycle[i], cycle[i+1])
+				}
+			}
+		})
+	}
+}
+
+// TestTopologicalSort 可选的节点按字典序优先, 有环或无向边时返回错误
+func TestTopologicalSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges []string
+		want  string
+		err   error
+	}{
+		{"字典序优先", []string{"c>a", "b>a", "d"}, "b,c,a,d", nil},
+		{"菱形", []string{"a>c", "a>b", "b>d", "c>d"}, "a,b,c,d", nil},
+		{"有环", []string{"a>b", "b>a", "c"}, "", errGraphNotDAG},
+		{"无向边", []string{"a-b"}, "", errGraphNotDAG},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := buildGraph(tt.edges...).topologicalSort()
+			if !errors.Is(err, tt.err) || strings.Join(order, ",") != tt.want {
+				t.Fatalf("得到 %v %v, 期望 %s %v", order, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+// TestMinimumSpanningTree 最小生成森林的总权重与边数
+func TestMinimumSpanningTree(t *testing.T) {
+	g := newGraphObject()
+	for _, e := range []GraphEdge{
+		{From: "a", To: "b", Weight: 4}, {From: "a", To: "c", Weight: 1}, {From: "b", To: "c", Weight: 2},
+		{From: "c", To: "d", Weight: 5}, {From: "b", To: "d", Weight: 3, Directed: true},
+		{From: "x", To: "y", Weight: 7},
+	} {
+		g.addEdge(e)
+	}
+	tree, total := g.minimumSpanningTree()
+	if len(tree) != 4 || total != 13 {
+		t.Fatalf("得到 %d 条边总权重 %g, 期望 4 条边总权重 13", len(tree), total)
+	}
+	if !slices.IsSortedFunc(tree, func(a, b GraphEdge) int { return int(a.Weight - b.Weight) }) {
+		t.Fatal("边应按权重升序")
+	}
+}
+
+// TestGraphAlgoLongChain 很长的链上各算法不会耗尽协程栈
+func TestGraphAlgoLongChain(t *testing.T) {
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+	g := newGraphObject()
+	const n = 50000
+	for i := range n {
+		g.addEdge(GraphEdge{From: fmt.Sprint(i), To: fmt.Sprint(i + 1), Directed: true, Weight: 1})
+	}
+	if comps := g.stronglyConnected(); len(comps) != n+1 {
+		t.Fatalf("得到 %d 个强连通分量, 期望 %d", len(comps), n+1)
+	}
+	if _, found := g.findCycle(); found {
+		t.Fatal("链上不应有环")
+	}
+	g.addEdge(GraphEdge{From: fmt.Sprint(n), To: "0", Directed: true, Weight: 1})
+	if comps := g.stronglyConnected(); len(comps) != 1 || len(comps[0]) != n+1 {
+		t.Fatalf("首尾相连后应只有一个强连通分量, 得到 %d 个", len(comps))
+	}
+	if cycle, found := g.findCycle(); !found || len(cycle) != n+2 {
+		t.Fatalf("环长度 %d, 期望 %d", len(cycle), n+2)
+	}
+	if tree, total := g.minimumSpanningTree(); len(tree) != n || total != n {
+		t.Fatalf("生成树 %d 条边总权重 %g", len(tree), total)
+	}
+	if visits := g.traverse("0", TraverseDFS, TraverseOptions{}); len(visits) != n+1 || visits[n].Depth != n {
+		t.Fatalf("深度优先遍历访问了 %d 个节点", len(visits))
+	}
+}
@@ -0,0 +1,329 @@
+package data
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// GraphEdge 图中的一条边
+type GraphEdge struct {
+	From, To string
+	// 边的权重
+	Weight float64
+	// 是否为有向边, 无向边等价于两条方向相反、共享权重与属性的有向边
+	Directed bool
+	// 关系类型, 可为空
+	Type string
+	// 任意边属性
+	Props map[string]string
+}
+
+// GraphNode 图中的一个节点
+type GraphNode struct {
+	Name string
+	// 坐标, HasPos 为false时节点没有坐标
+	Pos    [2]float64
+	HasPos bool
+	// 标签, 按字典序排列
+	Labels []string
+	// 任意节点属性
+	Props map[string]string
+}
+
+// graphArc 邻接表中的一条有向弧, 无向边由两条弧组成
+type graphArc struct {
+	weight   float64
+	directed bool
+	typ      string
+	props    map[string]string
+}
+
+// graphObject 单个图的值
+type graphObject struct {
+	// 节点坐标信息：节点名 -> (x, y), 只由边引入的节点没有坐标
+	nodes map[string][2]float64
+	// 出边邻接表：节点名 -> 终点 -> 弧, 每个节点都有一项
+	out map[string]map[string]*graphArc
+	// 入边邻接表：节点名 -> 起点 -> 弧, 每个节点都有一项
+	in map[string]map[string]*graphArc
+	// 有坐标节点的空间索引
+	spatial *kdTree
+	// 节点标签：节点名 -> 标签集合
+	labels map[string]map[string]struct{}
+	// 标签索引：标签 -> 节点集合
+	labelIndex map[string]map[string]struct{}
+	// 节点属性：节点名 -> 属性名 -> 属性值
+	props map[string]map[string]string
+}
+
+// newGraphObject 创建空图
+// @return *graphObject
+func newGraphObject() *graphObject {
+	return &graphObject{
+		nodes:      make(map[string][2]float64),
+		out:        make(map[string]map[string]*graphArc),
+		in:         make(map[string]map[string]*graphArc),
+		spatial:    newKDTree(),
+		labels:     make(map[string]map[string]struct{}),
+		labelIndex: make(map[string]map[string]struct{}),
+		props:      make(map[string]map[string]string),
+	}
+}
+
+// hasNode 节点是否存在
+// @param name string
+// @return bool
+func (g *graphObject) hasNode(name string) bool {
+	_, exists := g.out[name]
+	return exists
+}
+
+// ensureNode 确保节点存在于邻接表中
+// @param name string
+func (g *graphObject) ensureNode(name string) {
+	if !g.hasNode(name) {
+		g.out[name] = make(map[string]*graphArc)
+		g.in[name] = make(map[string]*graphArc)
+	}
+}
+
+// addNode 添加节点及其坐标
+// @param name string 节点名
+// @param x, y float64 坐标
+func (g *graphObject) addNode(name string, x, y float64) {
+	g.nodes[name] = [2]float64{x, y}
+	g.spatial.insert(name, g.nodes[name])
+	g.ensureNode(name)
+}
+
+// addEdge 添加边, 已存在的边被替换
+// 无向边替换为有向边时反方向的弧同时被删除; 添加无向边时反方向已有的有向边被覆盖
+// @param e GraphEdge
+func (g *graphObject) addEdge(e GraphEdge) {
+	g.removeEdge(e.From, e.To)
+	g.ensureNode(e.From)
+	g.ensureNode(e.To)
+	props := maps.Clone(e.Props)
+	g.setArc(e.From, e.To, &graphArc{weight: e.Weight, directed: e.Directed, typ: e.Type, props: props})
+	if !e.Directed && e.From != e.To {
+		g.setArc(e.To, e.From, &graphArc{weight: e.Weight, typ: e.Type, props: props})
+	}
+}
+
+// setArc 写入一条弧
+// @param from, to string
+// @param arc *graphArc
+func (g *graphObject) setArc(from, to string, arc *graphArc) {
+	g.out[from][to] = arc
+	g.in[to][from] = arc
+}
+
+// removeEdge 删除边, 无向边的两个方向同时删除
+// @param from, to string
+// @return bool 边是否存在
+func (g *graphObject) removeEdge(from, to string) bool {
+	arc, exists := g.out[from][to]
+	if !exists {
+		return false
+	}
+	delete(g.out[from], to)
+	delete(g.in[to], from)
+	if !arc.directed {
+		if back := g.out[to][from]; back != nil && !back.directed {
+			delete(g.out[to], from)
+			delete(g.in[from], to)
+		}
+	}
+	return true
+}
+
+// removeNode 删除节点以及与其相连的全部边
+// @param name string
+// @return bool 节点是否存在
+func (g *graphObject) removeNode(name string) bool {
+	if !g.hasNode(name) {
+		return false
+	}
+	for to := range g.out[name] {
+		delete(g.in[to], name)
+	}
+	for from := range g.in[name] {
+		delete(g.out[from], name)
+	}
+	delete(g.out, name)
+	delete(g.in, name)
+	delete(g.nodes, name)
+	g.spatial.remove(name)
+	for label := range g.labels[name] {
+		g.removeLabel(name, label)
+	}
+	delete(g.labels, name)
+	delete(g.props, name)
+	return true
+}
+
+// addLabel 为节点添加标签, 节点不存在时创建
+// @param name string 节点名
+// @param label string 标签
+// @return bool 是否新增
+func (g *graphObject) addLabel(name, label string) bool {
+	g.ensureNode(name)
+	if _, exists := g.labels[name][label]; exists {
+		return false
+	}
+	if g.labels[name] == nil {
+		g.labels[name] = make(map[string]struct{})
+	}
+	g.labels[name][label] = struct{}{}
+	if g.labelIndex[label] == nil {
+		g.labelIndex[label] = make(map[string]struct{})
+	}
+	g.labelIndex[label][name] = struct{}{}
+	return true
+}
+
+// removeLabel 删除节点的标签
+// @param name string 节点名
+// @param label string 标签
+// @return bool 标签是否存在
+func (g *graphObject) removeLabel(name, label string) bool {
+	if _, exists := g.labels[name][label]; !exists {
+		return false
+	}
+	delete(g.labels[name], label)
+	if len(g.labels[name]) == 0 {
+		delete(g.labels, name)
+	}
+	delete(g.labelIndex[label], name)
+	if len(g.labelIndex[label]) == 0 {
+		delete(g.labelIndex, label)
+	}
+	return true
+}
+
+// hasLabel 节点是否带有标签
+// @param name string
+// @param label string
+// @return bool
+func (g *graphObject) hasLabel(name, label string) bool {
+	_, exists := g.labels[name][label]
+	return exists
+}
+
+// setProp 设置节点属性, 节点不存在时创建
+// @param name string 节点名
+// @param prop string 属性名
+// @param value string 属性值
+func (g *graphObject) setProp(name, prop, value string) {
+	g.ensureNode(name)
+	if g.props[name] == nil {
+		g.props[name] = make(map[string]string)
+	}
+	g.props[name][prop] = value
+}
+
+// removeProp 删除节点属性
+// @param name string 节点名
+// @param prop string 属性名
+// @return bool 属性是否存在
+func (g *graphObject) removeProp(name, prop string) bool {
+	if _, exists := g.props[name][prop]; !exists {
+		return false
+	}
+	delete(g.props[name], prop)
+	if len(g.props[name]) == 0 {
+		delete(g.props, name)
+	}
+	return true
+}
+
+// node 获取节点信息
+// @param name string
+// @return GraphNode
+// @return bool 节点是否存在
+func (g *graphObject) node(name string) (GraphNode, bool) {
+	if !g.hasNode(name) {
+		return GraphNode{}, false
+	}
+	n := GraphNode{
+		Name:   name,
+		Labels: slices.Sorted(maps.Keys(g.labels[name])),
+		Props:  maps.Clone(g.props[name]),
+	}
+	n.Pos, n.HasPos = g.nodes[name]
+	return n, true
+}
+
+// empty 图中是否没有任何节点
+// @return bool
+func (g *graphObject) empty() bool {
+	return len(g.out) == 0
+}
+
+// edge 获取一条边
+// @param from, to string
+// @return GraphEdge
+// @return bool 是否存在
+func (g *graphObject) edge(from, to string) (GraphEdge, bool) {
+	arc, exists := g.out[from][to]
+	if !exists {
+		return GraphEdge{}, false
+	}
+	return GraphEdge{From: from, To: to, Weight: arc.weight, Directed: arc.directed, Type: arc.typ, Props: maps.Clone(arc.props)}, true
+}
+
+// nodeNames 全部节点名(按字典序)
+// @return []string
+func (g *graphObject) nodeNames() []string {
+	return slices.Sorted(maps.Keys(g.out))
+}
+
+// edges 全部边(按起点、终点字典序), 无向边只列出一次, 起点不大于终点
+// @return []GraphEdge
+func (g *graphObject) edges() []GraphEdge {
+	result := []GraphEdge{}
+	for from, arcs := range g.out {
+		for to, arc := range arcs {
+			if !arc.directed && from > to {
+				continue
+			}
+			e, _ := g.edge(from, to)
+			result = append(result, e)
+		}
+	}
+	slices.SortFunc(result, func(a, b GraphEdge) int {
+		if c := strings.Compare(a.From, b.From); c != 0 {
+			return c
+		}
+		return strings.Compare(a.To, b.To)
+	})
+	return result
+}
+
+// degree 节点的入度与出度, 无向边同时计入两者
+// @param name string
+// @return int 入度
+// @return int 出度
+// @return bool 节点是否存在
+func (g *graphObject) degree(name string) (int, int, bool) {
+	if !g.hasNode(name) {
+		return 0, 0, false
+	}
+	return len(g.in[name]), len(g.out[name]), true
+}
+
+// distance 计算两节点的欧氏距离
+// @param a, b string 节点名
+// @return float64 距离, bool 是否存在
+func (g *graphObject) distance(a, b string) (float64, bool) {
+	na, oka := g.nodes[a]
+	nb, okb := g.nodes[b]
+	if !oka || !okb {
+		return 0, false
+	}
+	dx := na[0] - nb[0]
+	dy := na[1] - nb[1]
+	return math.Sqrt(dx*dx + dy*dy), true
+}
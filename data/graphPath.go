@@ -0,0 +1,294 @@
+package data
+
+import (
+	"container/heap"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// graphDefaultMaxExplored 单次最短路径查询默认最多展开的节点数
+const graphDefaultMaxExplored = 100000
+
+// errGraphExploreLimit 展开的节点数超过上限
+var errGraphExploreLimit = errors.New("最短路径搜索展开的节点数超过上限")
+
+// errGraphNegativeWeight 最短路径搜索遇到负权边
+var errGraphNegativeWeight = errors.New("最短路径搜索不支持负权边")
+
+// GraphPath 图中的一条路径
+type GraphPath struct {
+	// 依次经过的节点, 包含起点与终点
+	Nodes []string
+	// 路径上各边权重之和
+	Cost float64
+}
+
+// pathItem 优先队列中的一项
+type pathItem struct {
+	node string
+	// 起点到该节点的代价
+	cost float64
+	// cost 加上启发值, 决定出队顺序
+	priority float64
+}
+
+// pathQueue 按 priority 排列的最小堆
+type pathQueue []pathItem
+
+func (q pathQueue) Len() int           { return len(q) }
+func (q pathQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q pathQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x any)        { *q = append(*q, x.(pathItem)) }
+func (q *pathQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// pathSearch 一次最短路径查询的状态, 展开节点数在多次搜索间累计
+type pathSearch struct {
+	g *graphObject
+	// 最多展开的节点数
+	limit int
+	// 已展开的节点数
+	explored int
+	// 启发函数, 为nil时退化为 Dijkstra
+	heuristic func(node string) float64
+}
+
+// newPathSearch 创建最短路径查询
+// @param g *graphObject
+// @param limit int 最多展开的节点数
+// @param aStar bool 是否使用以终点欧氏距离为启发值的 A*
+// @param dst string 终点
+// @return *pathSearch
+func newPathSearch(g *graphObject, limit int, aStar bool, dst string) *pathSearch {
+	s := &pathSearch{g: g, limit: limit}
+	if _, ok := g.nodes[dst]; aStar && ok {
+		s.heuristic = func(node string) float64 {
+			// 没有坐标的节点距离为0
+			d, _ := g.distance(node, dst)
+			return d
+		}
+	}
+	return s
+}
+
+// run 搜索从 src 到 dst 的最短路径
+// 节点的代价变小时重新入队, 启发值可采纳但不满足一致性时仍能得到最短路径
+// @param src, dst string 起点与终点
+// @param bannedNodes map[string]bool 不允许经过的节点, 可为nil
+// @param bannedArcs map[[2]string]bool 不允许经过的弧, 可为nil
+// @return GraphPath
+// @return bool 是否可达
+// @return error 遇到负权边或超过展开上限时返回错误
+func (s *pathSearch) run(src, dst string, bannedNodes map[string]bool, bannedArcs map[[2]string]bool) (GraphPath, bool, error) {
+	if !s.g.hasNode(src) || !s.g.hasNode(dst) || bannedNodes[src] {
+		return GraphPath{}, false, nil
+	}
+	best := map[string]float64{src: 0}
+	prev := map[string]string{}
+	q := &pathQueue{{node: src, priority: s.h(src)}}
+	for q.Len() > 0 {
+		item := heap.Pop(q).(pathItem)
+		if item.cost > best[item.node] {
+			continue
+		}
+		if item.node == dst {
+			return GraphPath{Nodes: tracePath(prev, src, dst), Cost: item.cost}, true, nil
+		}
+		if s.explored++; s.explored > s.limit {
+			return GraphPath{}, false, errGraphExploreLimit
+		}
+		for next, arc := range s.g.out[item.node] {
+			if bannedNodes[next] || bannedArcs[[2]string{item.node, next}] {
+				continue
+			}
+			if arc.weight < 0 {
+				return GraphPath{}, false, errGraphNegativeWeight
+			}
+			cost := item.cost + arc.weight
+			if old, seen := best[next]; seen && old <= cost {
+				continue
+			}
+			best[next] = cost
+			prev[next] = item.node
+			heap.Push(q, pathItem{node: next, cost: cost, priority: cost + s.h(next)})
+		}
+	}
+	return GraphPath{}, false, nil
+}
+
+// h 节点的启发值
+// @param node string
+// @return float64
+func (s *pathSearch) h(node string) float64 {
+	if s.heuristic == nil {
+		return 0
+	}
+	return s.heuristic(node)
+}
+
+// tracePath 由前驱表还原路径
+// @param prev map[string]string
+// @param src, dst string
+// @return []string
+func tracePath(prev map[string]string, src, dst string) []string {
+	path := []string{dst}
+	for node := dst; node != src; {
+		node = prev[node]
+		path = append(path, node)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// pathCost 计算路径上各边的权重之和, 调用方需保证路径上的弧都存在
+// @param g *graphObject
+// @param nodes []string
+// @return float64
+func pathCost(g *graphObject, nodes []string) float64 {
+	cost := 0.0
+	for i := 0; i+1 < len(nodes); i++ {
+		cost += g.out[nodes[i]][nodes[i+1]].weight
+	}
+	return cost
+}
+
+// kShortestPaths 使用 Yen 算法求前k条无环最短路径
+// @param s *pathSearch
+// @param src, dst string
+// @param k int
+// @return []GraphPath 按代价升序, 不足k条时返回全部
+// @return error
+func kShortestPaths(s *pathSearch, src, dst string, k int) ([]GraphPath, error) {
+	first, found, err := s.run(src, dst, nil, nil)
+	if err != nil || !found {
+		return []GraphPath{}, err
+	}
+	result := []GraphPath{first}
+	candidates := []GraphPath{}
+	seen := map[string]bool{strings.Join(first.Nodes, "\x00"): true}
+	for len(result) < k {
+		last := result[len(result)-1].Nodes
+		for i := 0; i+1 < len(last); i++ {
+			root := last[:i+1]
+			bannedArcs := map[[2]string]bool{}
+			for _, p := range result {
+				if len(p.Nodes) > i+1 && slices.Equal(p.Nodes[:i+1], root) {
+					bannedArcs[[2]string{p.Nodes[i], p.Nodes[i+1]}] = true
+				}
+			}
+			bannedNodes := map[string]bool{}
+			for _, node := range root[:i] {
+				bannedNodes[node] = true
+			}
+			spur, found, err := s.run(root[i], dst, bannedNodes, bannedArcs)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+			nodes := append(slices.Clone(root), spur.Nodes[1:]...)
+			id := strings.Join(nodes, "\x00")
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			candidates = append(candidates, GraphPath{Nodes: nodes, Cost: pathCost(s.g, root) + spur.Cost})
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		i := 0
+		for j, c := range candidates {
+			if c.Cost < candidates[i].Cost {
+				i = j
+			}
+		}
+		result = append(result, candidates[i])
+		candidates = slices.Delete(candidates, i, i+1)
+	}
+	return result, nil
+}
+
+// SetMaxExplored 设置单次最短路径查询最多展开的节点数
+// @param n int 小于等于0时使用默认值
+func (g *GkvGraph) SetMaxExplored(n int) {
+	if n <= 0 {
+		n = graphDefaultMaxExplored
+	}
+	g.maxExplored = n
+}
+
+// exploreLimit 计算本次查询的展开上限, 不超过配置的上限
+// @param maxExplored int 调用方指定的上限, 小于等于0时使用配置的上限
+// @return int
+func (g *GkvGraph) exploreLimit(maxExplored int) int {
+	if maxExplored <= 0 || maxExplored > g.maxExplored {
+		return g.maxExplored
+	}
+	return maxExplored
+}
+
+// Dijkstra 求带权图中的最短路径, 边权不能为负
+// @param key string 图名
+// @param from, to string 起点与终点
+// @param maxExplored int 最多展开的节点数, 小于等于0或超过配置的上限时使用配置的上限
+// @return GraphPath
+// @return bool 是否可达
+// @return error 遇到负权边或超过展开上限时返回错误
+func (g *GkvGraph) Dijkstra(key, from, to string, maxExplored int) (GraphPath, bool, error) {
+	return g.shortestPath(key, from, to, maxExplored, false)
+}
+
+// AStar 以节点坐标到终点的欧氏距离为启发值求最短路径
+// 边权不小于两端节点的欧氏距离时启发值可采纳, 结果与 Dijkstra 相同且展开的节点更少;
+// 没有坐标的节点启发值为0
+// @param key string 图名
+// @param from, to string 起点与终点
+// @param maxExplored int 最多展开的节点数, 小于等于0或超过配置的上限时使用配置的上限
+// @return GraphPath
+// @return bool 是否可达
+// @return error 遇到负权边或超过展开上限时返回错误
+func (g *GkvGraph) AStar(key, from, to string, maxExplored int) (GraphPath, bool, error) {
+	return g.shortestPath(key, from, to, maxExplored, true)
+}
+
+// shortestPath Dijkstra 与 A* 的公共实现
+// @param key string
+// @param from, to string
+// @param maxExplored int
+// @param aStar bool
+// @return GraphPath
+// @return bool
+// @return error
+func (g *GkvGraph) shortestPath(key, from, to string, maxExplored int, aStar bool) (GraphPath, bool, error) {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return GraphPath{}, false, nil
+	}
+	return newPathSearch(graph, g.exploreLimit(maxExplored), aStar, to).run(from, to, nil, nil)
+}
+
+// KShortestPaths 使用 Yen 算法求前k条无环最短路径, 展开上限对全部子搜索累计计算
+// @param key string 图名
+// @param from, to string 起点与终点
+// @param k int 路径数量
+// @param maxExplored int 最多展开的节点数, 小于等于0或超过配置的上限时使用配置的上限
+// @return []GraphPath 按代价升序, 不足k条时返回全部
+// @return error 遇到负权边或超过展开上限时返回错误
+func (g *GkvGraph) KShortestPaths(key, from, to string, k, maxExplored int) ([]GraphPath, error) {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil || k <= 0 {
+		return []GraphPath{}, nil
+	}
+	return kShortestPaths(newPathSearch(graph, g.exploreLimit(maxExplored), false, to), from, to, k)
+}
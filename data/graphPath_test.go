@@ -0,0 +1,217 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestGraph 创建独立于全局实例的图存储
+// @return *GkvGraph
+func newTestGraph() *GkvGraph {
+	return &GkvGraph{
+		data:        make(map[string]*graphObject),
+		expireTimes: make(map[string]time.Time),
+		keyLock:     NewKeyLock(),
+		maxExplored: graphDefaultMaxExplored,
+	}
+}
+
+// addDirected 批量添加有向边, 每项为 起点 终点 权重
+// @param g *GkvGraph
+// @param key string
+// @param edges ...string 形如 "a b 1.5"
+func addDirected(g *GkvGraph, key string, edges ...string) {
+	for _, e := range edges {
+		var from, to string
+		var weight float64
+		fmt.Sscan(e, &from, &to, &weight)
+		g.AddEdge(key, GraphEdge{From: from, To: to, Weight: weight, Directed: true})
+	}
+}
+
+// TestDijkstra 可达、不可达、负权边与展开上限
+func TestDijkstra(t *testing.T) {
+	g := newTestGraph()
+	addDirected(g, "g", "a b 1", "b c 2", "a c 5", "c d 1", "b d 10", "e a 1")
+	g.AddNode("g", "lonely", 0, 0)
+	addDirected(g, "neg", "a b 1", "b c -1")
+	tests := []struct {
+		name     string
+		key      string
+		from, to string
+		limit    int
+		path     string
+		cost     float64
+		found    bool
+		err      error
+	}{
+		{"经过中间节点更短", "g", "a", "d", 0, "a,b,c,d", 4, true, nil},
+		{"起点即终点", "g", "a", "a", 0, "a", 0, true, nil},
+		{"有向边不能反向走", "g", "d", "a", 0, "", 0, false, nil},
+		{"孤立节点", "g", "a", "lonely", 0, "", 0, false, nil},
+		{"节点不存在", "g", "a", "missing", 0, "", 0, false, nil},
+		{"图不存在", "missing", "a", "b", 0, "", 0, false, nil},
+		{"负权边", "neg", "a", "c", 0, "", 0, false, errGraphNegativeWeight},
+		{"超过展开上限", "g", "a", "d", 2, "", 0, false, errGraphExploreLimit},
+		{"展开上限恰好足够", "g", "a", "d", 3, "a,b,c,d", 4, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, found, err := g.Dijkstra(tt.key, tt.from, tt.to, tt.limit)
+			if !errors.Is(err, tt.err) || found != tt.found {
+				t.Fatalf("得到 %v %v, 期望 %v %v", found, err, tt.found, tt.err)
+			}
+			if got := strings.Join(p.Nodes, ","); got != tt.path || p.Cost != tt.cost {
+				t.Fatalf("得到路径 %s 代价 %g, 期望 %s 代价 %g", got, p.Cost, tt.path, tt.cost)
+			}
+		})
+	}
+}
+
+// TestAStarMatchesDijkstra 边权不小于欧氏距离时 A* 与 Dijkstra 的代价相同且展开的节点不更多
+func TestAStarMatchesDijkstra(t *testing.T) {
+	g := newTestGraph()
+	r := rand.New(rand.NewPCG(42, 42))
+	const n = 20
+	name := func(i, j int) string { return fmt.Sprintf("%d,%d", i, j) }
+	for i := range n {
+		for j := range n {
+			g.AddNode("grid", name(i, j), float64(i), float64(j))
+		}
+	}
+	for i := range n {
+		for j := range n {
+			if i+1 < n {
+				g.AddEdge("grid", GraphEdge{From: name(i, j), To: name(i+1, j), Weight: 1 + r.Float64()*2})
+			}
+			if j+1 < n {
+				g.AddEdge("grid", GraphEdge{From: name(i, j), To: name(i, j+1), Weight: 1 + r.Float64()*2})
+			}
+		}
+	}
+	graph := g.data["grid"]
+	for _, dst := range []string{name(n-1, n-1), name(0, n-1), name(n/2, n/2), name(1, 0)} {
+		dijkstra := newPathSearch(graph, graphDefaultMaxExplored, false, dst)
+		d, found, err := dijkstra.run(name(0, 0), dst, nil, nil)
+		if !found || err != nil {
+			t.Fatalf("%s: Dijkstra 未找到路径: %v", dst, err)
+		}
+		aStar := newPathSearch(graph, graphDefaultMaxExplored, true, dst)
+		a, found, err := aStar.run(name(0, 0), dst, nil, nil)
+		if !found || err != nil {
+			t.Fatalf("%s: A* 未找到路径: %v", dst, err)
+		}
+		if math.Abs(a.Cost-d.Cost) > 1e-9 || math.Abs(pathCost(graph, a.Nodes)-a.Cost) > 1e-9 {
+			t.Fatalf("%s: A* 代价 %g, Dijkstra 代价 %g", dst, a.Cost, d.Cost)
+		}
+		if aStar.explored > dijkstra.explored {
+			t.Fatalf("%s: A* 展开了 %d 个节点, 多于 Dijkstra 的 %d 个", dst, aStar.explored, dijkstra.explored)
+		}
+	}
+}
+
+// allPathCosts 穷举两点间全部无环路径的代价, 升序
+// @param graph *graphObject
+// @param src, dst string
+// @return []float64
+func allPathCosts(graph *graphObject, src, dst string) []float64 {
+	costs := []float64{}
+	onPath := map[string]bool{}
+	var walk func(node string, cost float64)
+	walk = func(node string, cost float64) {
+		if node == dst {
+			costs = append(costs, cost)
+			return
+		}
+		onPath[node] = true
+		for next, arc := range graph.out[node] {
+			if !onPath[next] {
+				walk(next, cost+arc.weight)
+			}
+		}
+		onPath[node] = false
+	}
+	walk(src, 0)
+	slices.Sort(costs)
+	return costs
+}
+
+// TestKShortestPaths Yen 算法的路径顺序, 以及与穷举结果的比对
+func TestKShortestPaths(t *testing.T) {
+	g := newTestGraph()
+	// Yen 算法的经典示例
+	addDirected(g, "yen", "C D 3", "C E 2", "D F 4", "E D 1", "E F 2", "E G 3", "F G 2", "F H 1", "G H 2")
+	// 代价为 5、7 的路径唯一, 代价为 8 与 11 的路径各有多条, 同代价路径之间的顺序不做要求
+	all := []string{"C,E,F,H", "C,E,G,H", "C,D,F,H", "C,E,F,G,H", "C,E,D,F,H", "C,D,F,G,H", "C,E,D,F,G,H"}
+	tests := []struct {
+		name  string
+		k     int
+		costs []float64
+	}{
+		{"k为0", 0, []float64{}},
+		{"k为1即最短路径", 1, []float64{5}},
+		{"前三条", 3, []float64{5, 7, 8}},
+		{"不足k条时返回全部", 100, []float64{5, 7, 8, 8, 8, 11, 11}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := g.KShortestPaths("yen", "C", "H", tt.k, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seen := map[string]bool{}
+			for i, p := range paths {
+				id := strings.Join(p.Nodes, ",")
+				if i >= len(tt.costs) || p.Cost != tt.costs[i] || !slices.Contains(all, id) || seen[id] {
+					t.Fatalf("第 %d 条路径 %s 代价 %g, 期望代价序列 %v", i, id, p.Cost, tt.costs)
+				}
+				seen[id] = true
+			}
+			if len(paths) != len(tt.costs) {
+				t.Fatalf("得到 %d 条路径, 期望 %d 条", len(paths), len(tt.costs))
+			}
+			if tt.k >= 2 && (strings.Join(paths[0].Nodes, ",") != all[0] || strings.Join(paths[1].Nodes, ",") != all[1]) {
+				t.Fatalf("前两条路径为 %v %v", paths[0].Nodes, paths[1].Nodes)
+			}
+		})
+	}
+	// 第一次搜索最多展开 5 个节点即可找到最短路径, 之后的子搜索累计超过上限
+	if _, err := g.KShortestPaths("yen", "C", "H", 3, 5); !errors.Is(err, errGraphExploreLimit) {
+		t.Fatalf("展开上限对全部子搜索累计, 期望 errGraphExploreLimit, 得到 %v", err)
+	}
+	if _, found, err := g.Dijkstra("yen", "C", "H", 5); !found || err != nil {
+		t.Fatalf("展开 5 个节点应足以找到最短路径: %v", err)
+	}
+
+	r := rand.New(rand.NewPCG(7, 7))
+	for trial := range 30 {
+		key := fmt.Sprintf("random%d", trial)
+		g.AddNode(key, "0", 0, 0)
+		g.AddNode(key, "6", 0, 0)
+		for range 20 {
+			a, b := r.IntN(7), r.IntN(7)
+			if a != b {
+				g.AddEdge(key, GraphEdge{From: fmt.Sprint(a), To: fmt.Sprint(b), Weight: float64(1 + r.IntN(5)), Directed: r.IntN(2) == 0})
+			}
+		}
+		want := allPathCosts(g.data[key], "0", "6")
+		paths, err := g.KShortestPaths(key, "0", "6", 5, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(paths) != min(5, len(want)) {
+			t.Fatalf("第 %d 组: 得到 %d 条路径, 期望 %d 条", trial, len(paths), min(5, len(want)))
+		}
+		for i, p := range paths {
+			if p.Cost != want[i] {
+				t.Fatalf("第 %d 组第 %d 条路径代价 %g, 期望 %g", trial, i, p.Cost, want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,972 @@
+package data
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// errGraphQueryLimit 图查询展开的节点数超过上限
+var errGraphQueryLimit = errors.New("图查询展开的节点数超过上限")
+
+// errGraphQueryDone 已得到 LIMIT 指定数量的结果, 用于提前结束匹配
+var errGraphQueryDone = errors.New("graph query done")
+
+// GraphQueryResult 图查询结果
+type GraphQueryResult struct {
+	// 列名, 为 RETURN 中的别名或表达式原文
+	Columns []string
+	// 各行的值, 空值为 "null"
+	Rows [][]string
+}
+
+// gqKind 查询值的类型
+type gqKind int
+
+const (
+	gqKindNull gqKind = iota
+	gqKindNum
+	gqKindStr
+	gqKindBool
+)
+
+// gqValue 表达式的值, 零值为空值
+type gqValue struct {
+	kind gqKind
+	s    string
+	n    float64
+	b    bool
+}
+
+// gqStr 字符串值
+// @param s string
+// @return gqValue
+func gqStr(s string) gqValue {
+	return gqValue{kind: gqKindStr, s: s}
+}
+
+// gqNum 数值
+// @param n float64
+// @return gqValue
+func gqNum(n float64) gqValue {
+	return gqValue{kind: gqKindNum, n: n}
+}
+
+// gqBool 布尔值
+// @param b bool
+// @return gqValue
+func gqBool(b bool) gqValue {
+	return gqValue{kind: gqKindBool, b: b}
+}
+
+// String 值的文本形式
+// @return string
+func (v gqValue) String() string {
+	switch v.kind {
+	case gqKindNum:
+		return strconv.FormatFloat(v.n, 'f', -1, 64)
+	case gqKindStr:
+		return v.s
+	case gqKindBool:
+		return strconv.FormatBool(v.b)
+	}
+	return "null"
+}
+
+// number 将值转换为数值, 节点与边的属性都以字符串保存, 能解析为数字的字符串按数字比较
+// @return float64
+// @return bool 是否为数值
+func (v gqValue) number() (float64, bool) {
+	switch v.kind {
+	case gqKindNum:
+		return v.n, true
+	case gqKindStr:
+		n, err := strconv.ParseFloat(v.s, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return 0, false
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// gqCompare 比较两个值, 两边都是数值时按数值比较, 否则字符串按字典序、布尔值 false 小于 true
+// @param a, b gqValue
+// @return int
+// @return bool 是否可比较, 含空值或类型不同时不可比较
+func gqCompare(a, b gqValue) (int, bool) {
+	if a.kind == gqKindNull || b.kind == gqKindNull {
+		return 0, false
+	}
+	if a.kind == gqKindBool || b.kind == gqKindBool {
+		if a.kind != b.kind {
+			return 0, false
+		}
+		return cmp.Compare(boolToRank(a.b), boolToRank(b.b)), true
+	}
+	if an, ok := a.number(); ok {
+		if bn, ok := b.number(); ok {
+			return cmp.Compare(an, bn), true
+		}
+	}
+	if a.kind == gqKindStr && b.kind == gqKindStr {
+		return strings.Compare(a.s, b.s), true
+	}
+	return 0, false
+}
+
+// boolToRank false 为0, true 为1
+// @param b bool
+// @return int
+func boolToRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// gqOrder ORDER BY 使用的全序, 空值最大, 不可比较的值按类型排列
+// @param a, b gqValue
+// @return int
+func gqOrder(a, b gqValue) int {
+	if a.kind == gqKindNull || b.kind == gqKindNull {
+		return cmp.Compare(boolToRank(a.kind == gqKindNull), boolToRank(b.kind == gqKindNull))
+	}
+	if c, ok := gqCompare(a, b); ok {
+		return c
+	}
+	if c := cmp.Compare(a.kind, b.kind); c != 0 {
+		return c
+	}
+	return strings.Compare(a.String(), b.String())
+}
+
+// gqTruth 三值逻辑中的真值: 1 为真, 0 为假, -1 为空值或非布尔值
+// @param v gqValue
+// @return int
+func gqTruth(v gqValue) int {
+	if v.kind != gqKindBool {
+		return -1
+	}
+	return boolToRank(v.b)
+}
+
+// gqBound 变量绑定的节点或关系
+type gqBound struct {
+	// 节点变量绑定的节点名
+	node string
+	// 是否为关系变量
+	rel bool
+	// 关系的起点与终点, 按匹配时经过的方向
+	from, to string
+	arc      *graphArc
+}
+
+// String 关系的文本形式, 如 a-[:KNOWS]->b
+// @return string
+func (b gqBound) String() string {
+	if !b.rel {
+		return b.node
+	}
+	arrow := "-"
+	if b.arc.directed {
+		arrow = "->"
+	}
+	typ := ""
+	if b.arc.typ != "" {
+		typ = ":" + b.arc.typ
+	}
+	return b.from + "-[" + typ + "]" + arrow + b.to
+}
+
+// gqExpr 表达式
+type gqExpr interface {
+	// eval 在当前绑定下求值
+	eval(x *gqExec) gqValue
+	// String 表达式原文, 用作默认列名
+	String() string
+}
+
+// gqFunctions 支持的函数及其参数个数
+var gqFunctions = map[string]int{
+	"id":       1,
+	"type":     1,
+	"weight":   1,
+	"x":        1,
+	"y":        1,
+	"distance": 2,
+}
+
+// gqLiteral 字面量
+type gqLiteral struct {
+	v gqValue
+}
+
+func (e *gqLiteral) eval(*gqExec) gqValue { return e.v }
+
+func (e *gqLiteral) String() string {
+	if e.v.kind == gqKindStr {
+		return "'" + strings.ReplaceAll(e.v.s, "'", "\\'") + "'"
+	}
+	return e.v.String()
+}
+
+// gqVar 变量, 节点求值为节点名, 关系求值为关系的文本形式
+type gqVar struct {
+	v string
+}
+
+func (e *gqVar) eval(x *gqExec) gqValue {
+	b, ok := x.row[e.v]
+	if !ok {
+		return gqValue{}
+	}
+	return gqStr(b.String())
+}
+
+func (e *gqVar) String() string { return e.v }
+
+// gqProp 属性访问 v.name, 属性不存在时为空值
+type gqProp struct {
+	v, name string
+}
+
+func (e *gqProp) eval(x *gqExec) gqValue {
+	b, ok := x.row[e.v]
+	if !ok {
+		return gqValue{}
+	}
+	props := x.g.props[b.node]
+	if b.rel {
+		props = b.arc.props
+	}
+	if value, ok := props[e.name]; ok {
+		return gqStr(value)
+	}
+	return gqValue{}
+}
+
+func (e *gqProp) String() string { return e.v + "." + e.name }
+
+// gqCall 函数调用, 参数只能是变量
+type gqCall struct {
+	fn   string
+	args []string
+}
+
+func (e *gqCall) eval(x *gqExec) gqValue {
+	b, ok := x.row[e.args[0]]
+	if !ok {
+		return gqValue{}
+	}
+	switch e.fn {
+	case "id":
+		return gqStr(b.String())
+	case "type":
+		return gqStr(b.arc.typ)
+	case "weight":
+		return gqNum(b.arc.weight)
+	case "x", "y":
+		pos, ok := x.g.nodes[b.node]
+		if !ok {
+			return gqValue{}
+		}
+		if e.fn == "x" {
+			return gqNum(pos[0])
+		}
+		return gqNum(pos[1])
+	case "distance":
+		other, ok := x.row[e.args[1]]
+		if !ok {
+			return gqValue{}
+		}
+		if d, ok := x.g.distance(b.node, other.node); ok {
+			return gqNum(d)
+		}
+	}
+	return gqValue{}
+}
+
+func (e *gqCall) String() string { return e.fn + "(" + strings.Join(e.args, ", ") + ")" }
+
+// gqBinary 二元运算
+type gqBinary struct {
+	op   string
+	l, r gqExpr
+}
+
+func (e *gqBinary) eval(x *gqExec) gqValue {
+	l := e.l.eval(x)
+	switch e.op {
+	case "AND", "OR":
+		// 短路求值: AND 左侧为假或 OR 左侧为真时结果已确定
+		lt := gqTruth(l)
+		if e.op == "AND" && lt == 0 || e.op == "OR" && lt == 1 {
+			return gqBool(lt == 1)
+		}
+		rt := gqTruth(e.r.eval(x))
+		if e.op == "AND" && rt == 0 || e.op == "OR" && rt == 1 {
+			return gqBool(rt == 1)
+		}
+		if lt < 0 || rt < 0 {
+			return gqValue{}
+		}
+		return gqBool(rt == 1)
+	}
+	r := e.r.eval(x)
+	if l.kind == gqKindNull || r.kind == gqKindNull {
+		return gqValue{}
+	}
+	switch e.op {
+	case "CONTAINS":
+		return gqBool(strings.Contains(l.String(), r.String()))
+	case "STARTS WITH":
+		return gqBool(strings.HasPrefix(l.String(), r.String()))
+	case "ENDS WITH":
+		return gqBool(strings.HasSuffix(l.String(), r.String()))
+	}
+	c, ok := gqCompare(l, r)
+	if !ok {
+		// 类型不同的值不相等, 也没有大小关系
+		switch e.op {
+		case "=":
+			return gqBool(false)
+		case "<>":
+			return gqBool(true)
+		}
+		return gqValue{}
+	}
+	switch e.op {
+	case "=":
+		return gqBool(c == 0)
+	case "<>":
+		return gqBool(c != 0)
+	case "<":
+		return gqBool(c < 0)
+	case "<=":
+		return gqBool(c <= 0)
+	case ">":
+		return gqBool(c > 0)
+	}
+	return gqBool(c >= 0)
+}
+
+func (e *gqBinary) String() string { return e.l.String() + " " + e.op + " " + e.r.String() }
+
+// gqNot 逻辑非, 空值取反仍为空值
+type gqNot struct {
+	e gqExpr
+}
+
+func (e *gqNot) eval(x *gqExec) gqValue {
+	t := gqTruth(e.e.eval(x))
+	if t < 0 {
+		return gqValue{}
+	}
+	return gqBool(t == 0)
+}
+
+func (e *gqNot) String() string { return "NOT " + e.e.String() }
+
+// gqIsNull IS [NOT] NULL
+type gqIsNull struct {
+	e      gqExpr
+	negate bool
+}
+
+func (e *gqIsNull) eval(x *gqExec) gqValue {
+	return gqBool((e.e.eval(x).kind == gqKindNull) != e.negate)
+}
+
+func (e *gqIsNull) String() string {
+	if e.negate {
+		return e.e.String() + " IS NOT NULL"
+	}
+	return e.e.String() + " IS NULL"
+}
+
+// gqVarKind 变量类型
+type gqVarKind int
+
+const (
+	gqNodeVar gqVarKind = iota + 1
+	gqRelVar
+)
+
+// gqExprVars 收集表达式引用的变量并检查函数参数的类型
+// @param e gqExpr
+// @param kinds map[string]gqVarKind 模式中定义的变量
+// @param out map[string]bool 引用的变量
+// @return error 引用未定义的变量或参数类型不符时返回错误
+func gqExprVars(e gqExpr, kinds map[string]gqVarKind, out map[string]bool) error {
+	use := func(v string, want gqVarKind, fn string) error {
+		kind, ok := kinds[v]
+		if !ok {
+			return fmt.Errorf("未定义的变量: %s", v)
+		}
+		if want != 0 && kind != want {
+			what := "节点"
+			if want == gqRelVar {
+				what = "关系"
+			}
+			return fmt.Errorf("%s() 的参数必须为%s变量: %s", fn, what, v)
+		}
+		out[v] = true
+		return nil
+	}
+	switch e := e.(type) {
+	case *gqVar:
+		return use(e.v, 0, "")
+	case *gqProp:
+		return use(e.v, 0, "")
+	case *gqCall:
+		want := gqNodeVar
+		switch e.fn {
+		case "id":
+			want = 0
+		case "type", "weight":
+			want = gqRelVar
+		}
+		for _, arg := range e.args {
+			if err := use(arg, want, e.fn); err != nil {
+				return err
+			}
+		}
+	case *gqBinary:
+		if err := gqExprVars(e.l, kinds, out); err != nil {
+			return err
+		}
+		return gqExprVars(e.r, kinds, out)
+	case *gqNot:
+		return gqExprVars(e.e, kinds, out)
+	case *gqIsNull:
+		return gqExprVars(e.e, kinds, out)
+	}
+	return nil
+}
+
+// gqConjuncts 将 WHERE 条件按 AND 拆分
+// @param e gqExpr
+// @return []gqExpr
+func gqConjuncts(e gqExpr) []gqExpr {
+	if b, ok := e.(*gqBinary); ok && b.op == "AND" {
+		return append(gqConjuncts(b.l), gqConjuncts(b.r)...)
+	}
+	return []gqExpr{e}
+}
+
+// gqIDLookup 条件是否形如 id(v) = '节点名'
+// @param e gqExpr
+// @return string 变量名
+// @return string 节点名
+// @return bool
+func gqIDLookup(e gqExpr) (string, string, bool) {
+	b, ok := e.(*gqBinary)
+	if !ok || b.op != "=" {
+		return "", "", false
+	}
+	for _, pair := range [][2]gqExpr{{b.l, b.r}, {b.r, b.l}} {
+		call, isCall := pair[0].(*gqCall)
+		lit, isLit := pair[1].(*gqLiteral)
+		if isCall && isLit && call.fn == "id" && lit.v.kind == gqKindStr {
+			return call.args[0], lit.v.s, true
+		}
+	}
+	return "", "", false
+}
+
+// gqStep 执行计划中的一步: 绑定起点节点, 或从已绑定的节点沿关系扩展到下一个节点
+type gqStep struct {
+	// 本步绑定的节点
+	node *gqNodePattern
+	// 扩展经过的关系, 为nil时本步为起点
+	rel *gqRelPattern
+	// 扩展的出发节点变量
+	source string
+	// 相对出发节点的方向: 1 沿出边, -1 沿入边, 0 两者皆可
+	dir int
+	// 起点的候选节点, scan 为true时扫描全部节点
+	candidates []string
+	scan       bool
+	// 本步之后所需变量全部绑定的 WHERE 条件
+	filters []gqExpr
+}
+
+// planGraphQuery 生成执行计划
+// 每条模式优先从已绑定的变量开始, 其次是 WHERE 中 id(v) = '节点名' 指定的节点,
+// 再次是标签索引中节点最少的标签, 最后扫描全部节点; 从起点先向右再向左扩展,
+// WHERE 按 AND 拆分后下推到所需变量全部绑定的最早一步
+// @param g *graphObject
+// @param q *gqQuery
+// @return []gqStep
+// @return error 变量重复定义或引用未定义的变量时返回错误
+func planGraphQuery(g *graphObject, q *gqQuery) ([]gqStep, error) {
+	kinds := map[string]gqVarKind{}
+	define := func(v string, kind gqVarKind) error {
+		old, exists := kinds[v]
+		if exists && (old != kind || kind == gqRelVar) {
+			return fmt.Errorf("变量重复定义: %s", v)
+		}
+		kinds[v] = kind
+		return nil
+	}
+	for _, p := range q.patterns {
+		for i := range p.nodes {
+			if err := define(p.nodes[i].v, gqNodeVar); err != nil {
+				return nil, err
+			}
+		}
+		for i := range p.rels {
+			if err := define(p.rels[i].v, gqRelVar); err != nil {
+				return nil, err
+			}
+		}
+	}
+	conjuncts := []gqExpr{}
+	conjunctVars := []map[string]bool{}
+	if q.where != nil {
+		for _, c := range gqConjuncts(q.where) {
+			vars := map[string]bool{}
+			if err := gqExprVars(c, kinds, vars); err != nil {
+				return nil, err
+			}
+			conjuncts = append(conjuncts, c)
+			conjunctVars = append(conjunctVars, vars)
+		}
+	}
+	for _, item := range q.returns {
+		if err := gqExprVars(item.expr, kinds, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	aliases := map[string]bool{}
+	for _, item := range q.returns {
+		aliases[item.alias] = true
+	}
+	for _, item := range q.order {
+		if v, ok := item.expr.(*gqVar); ok && aliases[v.v] {
+			continue
+		}
+		if err := gqExprVars(item.expr, kinds, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	lookups := map[string]string{}
+	for _, c := range conjuncts {
+		if v, name, ok := gqIDLookup(c); ok && kinds[v] == gqNodeVar {
+			lookups[v] = name
+		}
+	}
+
+	bound := map[string]bool{}
+	steps := []gqStep{}
+	for pi := range q.patterns {
+		p := &q.patterns[pi]
+		start := gqChooseStart(g, p, bound, lookups)
+		first := gqStep{node: &p.nodes[start]}
+		if !bound[first.node.v] {
+			if name, ok := lookups[first.node.v]; ok {
+				first.candidates = []string{name}
+			} else if label, ok := gqSmallestLabel(g, first.node); ok {
+				first.candidates = slices.Sorted(maps.Keys(g.labelIndex[label]))
+			} else {
+				first.scan = true
+			}
+		}
+		steps = append(steps, first)
+		bound[first.node.v] = true
+		for i := start; i < len(p.rels); i++ {
+			steps = append(steps, gqStep{node: &p.nodes[i+1], rel: &p.rels[i], source: p.nodes[i].v, dir: p.rels[i].dir})
+		}
+		for i := start - 1; i >= 0; i-- {
+			steps = append(steps, gqStep{node: &p.nodes[i], rel: &p.rels[i], source: p.nodes[i+1].v, dir: -p.rels[i].dir})
+		}
+		for i := range p.nodes {
+			bound[p.nodes[i].v] = true
+		}
+	}
+
+	bound = map[string]bool{}
+	attached := make([]bool, len(conjuncts))
+	for si := range steps {
+		bound[steps[si].node.v] = true
+		if steps[si].rel != nil {
+			bound[steps[si].rel.v] = true
+		}
+		for ci, vars := range conjunctVars {
+			if attached[ci] {
+				continue
+			}
+			ready := true
+			for v := range vars {
+				ready = ready && bound[v]
+			}
+			if ready {
+				steps[si].filters = append(steps[si].filters, conjuncts[ci])
+				attached[ci] = true
+			}
+		}
+	}
+	return steps, nil
+}
+
+// gqChooseStart 选择模式的起点节点
+// @param g *graphObject
+// @param p *gqPattern
+// @param bound map[string]bool 之前的模式已绑定的变量
+// @param lookups map[string]string id(v) = '节点名' 指定的节点
+// @return int 起点在模式中的下标
+func gqChooseStart(g *graphObject, p *gqPattern, bound map[string]bool, lookups map[string]string) int {
+	for i := range p.nodes {
+		if bound[p.nodes[i].v] {
+			return i
+		}
+	}
+	for i := range p.nodes {
+		if _, ok := lookups[p.nodes[i].v]; ok {
+			return i
+		}
+	}
+	best, bestSize := 0, -1
+	for i := range p.nodes {
+		if label, ok := gqSmallestLabel(g, &p.nodes[i]); ok {
+			if size := len(g.labelIndex[label]); bestSize < 0 || size < bestSize {
+				best, bestSize = i, size
+			}
+		}
+	}
+	return best
+}
+
+// gqSmallestLabel 节点模式的标签中节点最少的一个
+// @param g *graphObject
+// @param np *gqNodePattern
+// @return string
+// @return bool 节点模式是否带有标签
+func gqSmallestLabel(g *graphObject, np *gqNodePattern) (string, bool) {
+	if len(np.labels) == 0 {
+		return "", false
+	}
+	best := np.labels[0]
+	for _, label := range np.labels[1:] {
+		if len(g.labelIndex[label]) < len(g.labelIndex[best]) {
+			best = label
+		}
+	}
+	return best, true
+}
+
+// gqMatchProps 属性是否全部等于模式中的字面量
+// @param props map[string]string
+// @param want map[string]gqValue
+// @return bool
+func gqMatchProps(props map[string]string, want map[string]gqValue) bool {
+	for name, v := range want {
+		value, ok := props[name]
+		if !ok {
+			return false
+		}
+		if c, ok := gqCompare(gqStr(value), v); !ok || c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// gqResultRow 一行结果及其排序键
+type gqResultRow struct {
+	values []gqValue
+	keys   []gqValue
+}
+
+// gqExec 查询的执行状态
+type gqExec struct {
+	g     *graphObject
+	q     *gqQuery
+	steps []gqStep
+	// 当前的变量绑定
+	row map[string]gqBound
+	// 当前匹配中已使用的关系, 同一条关系在一次匹配中只能出现一次
+	usedRels map[string]bool
+	// 最多展开的节点数与已展开的节点数
+	limit, explored int
+	rows            []gqResultRow
+	// DISTINCT 已输出的行
+	seen map[string]bool
+	// 不排序时已跳过的行数
+	skipped int
+}
+
+// matchNode 节点是否满足节点模式的标签与属性
+// @param np *gqNodePattern
+// @param name string
+// @return bool
+func (x *gqExec) matchNode(np *gqNodePattern, name string) bool {
+	for _, label := range np.labels {
+		if !x.g.hasLabel(name, label) {
+			return false
+		}
+	}
+	return gqMatchProps(x.g.props[name], np.props)
+}
+
+// matchRel 弧是否满足关系模式的类型与属性
+// @param rp *gqRelPattern
+// @param arc *graphArc
+// @return bool
+func (x *gqExec) matchRel(rp *gqRelPattern, arc *graphArc) bool {
+	if len(rp.types) > 0 && !slices.Contains(rp.types, arc.typ) {
+		return false
+	}
+	return gqMatchProps(arc.props, rp.props)
+}
+
+// visit 计数一次展开
+// @return error 超过上限时返回错误
+func (x *gqExec) visit() error {
+	if x.explored++; x.explored > x.limit {
+		return errGraphQueryLimit
+	}
+	return nil
+}
+
+// run 回溯执行第i步及之后的步骤
+// @param i int
+// @return error
+func (x *gqExec) run(i int) error {
+	if i == len(x.steps) {
+		return x.emit()
+	}
+	step := &x.steps[i]
+	if step.rel == nil {
+		if b, ok := x.row[step.node.v]; ok {
+			return x.bindNode(i, b.node)
+		}
+		candidates := step.candidates
+		if step.scan {
+			candidates = x.g.nodeNames()
+		}
+		for _, name := range candidates {
+			if !x.g.hasNode(name) {
+				continue
+			}
+			if err := x.visit(); err != nil {
+				return err
+			}
+			if err := x.bindNode(i, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	source := x.row[step.source].node
+	type candidate struct {
+		from, to string
+		arc      *graphArc
+	}
+	arcs := []candidate{}
+	if step.dir >= 0 {
+		for _, to := range slices.Sorted(maps.Keys(x.g.out[source])) {
+			arcs = append(arcs, candidate{source, to, x.g.out[source][to]})
+		}
+	}
+	if step.dir <= 0 {
+		for _, from := range slices.Sorted(maps.Keys(x.g.in[source])) {
+			arc := x.g.in[source][from]
+			// 无向边与自环在出边中已经出现过, 任意方向时不再重复匹配
+			if step.dir == 0 && (!arc.directed || from == source) {
+				continue
+			}
+			arcs = append(arcs, candidate{from, source, arc})
+		}
+	}
+	for _, c := range arcs {
+		if err := x.visit(); err != nil {
+			return err
+		}
+		if !x.matchRel(step.rel, c.arc) {
+			continue
+		}
+		relKey := c.from + "\x00" + c.to
+		if !c.arc.directed && c.from > c.to {
+			relKey = c.to + "\x00" + c.from
+		}
+		if x.usedRels[relKey] {
+			continue
+		}
+		target := c.to
+		if c.from != source {
+			target = c.from
+		}
+		x.usedRels[relKey] = true
+		x.row[step.rel.v] = gqBound{rel: true, from: c.from, to: c.to, arc: c.arc}
+		err := x.bindNode(i, target)
+		delete(x.row, step.rel.v)
+		delete(x.usedRels, relKey)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bindNode 将第i步的节点变量绑定到节点, 检查约束与条件后执行后续步骤
+// @param i int
+// @param name string
+// @return error
+func (x *gqExec) bindNode(i int, name string) error {
+	step := &x.steps[i]
+	if b, ok := x.row[step.node.v]; ok {
+		if b.node != name {
+			return nil
+		}
+	} else {
+		x.row[step.node.v] = gqBound{node: name}
+		defer delete(x.row, step.node.v)
+	}
+	if !x.matchNode(step.node, name) {
+		return nil
+	}
+	for _, f := range step.filters {
+		if gqTruth(f.eval(x)) != 1 {
+			return nil
+		}
+	}
+	return x.run(i + 1)
+}
+
+// emit 输出当前绑定对应的一行
+// @return error 不排序且已得到 LIMIT 行时返回 errGraphQueryDone
+func (x *gqExec) emit() error {
+	row := gqResultRow{values: make([]gqValue, len(x.q.returns))}
+	for i, item := range x.q.returns {
+		row.values[i] = item.expr.eval(x)
+	}
+	if x.q.distinct {
+		var key strings.Builder
+		for _, v := range row.values {
+			fmt.Fprintf(&key, "%d:%s\x00", v.kind, v.String())
+		}
+		if x.seen[key.String()] {
+			return nil
+		}
+		x.seen[key.String()] = true
+	}
+	if len(x.q.order) > 0 {
+		row.keys = make([]gqValue, len(x.q.order))
+		for i, item := range x.q.order {
+			row.keys[i] = x.orderKey(item.expr, row.values)
+		}
+		x.rows = append(x.rows, row)
+		return nil
+	}
+	if x.skipped < x.q.skip {
+		x.skipped++
+		return nil
+	}
+	x.rows = append(x.rows, row)
+	if x.q.limit >= 0 && len(x.rows) >= x.q.limit {
+		return errGraphQueryDone
+	}
+	return nil
+}
+
+// orderKey 计算排序键, ORDER BY 可以引用 RETURN 中的别名
+// @param e gqExpr
+// @param values []gqValue 当前行 RETURN 的值
+// @return gqValue
+func (x *gqExec) orderKey(e gqExpr, values []gqValue) gqValue {
+	if v, ok := e.(*gqVar); ok {
+		for i, item := range x.q.returns {
+			if item.alias == v.v {
+				return values[i]
+			}
+		}
+	}
+	return e.eval(x)
+}
+
+// runGraphQuery 执行查询
+// @param g *graphObject
+// @param q *gqQuery
+// @param limit int 最多展开的节点数
+// @return GraphQueryResult
+// @return error
+func runGraphQuery(g *graphObject, q *gqQuery, limit int) (GraphQueryResult, error) {
+	steps, err := planGraphQuery(g, q)
+	if err != nil {
+		return GraphQueryResult{}, err
+	}
+	result := GraphQueryResult{Columns: make([]string, len(q.returns)), Rows: [][]string{}}
+	for i, item := range q.returns {
+		result.Columns[i] = item.alias
+	}
+	if q.limit == 0 {
+		return result, nil
+	}
+	x := &gqExec{
+		g:        g,
+		q:        q,
+		steps:    steps,
+		row:      map[string]gqBound{},
+		usedRels: map[string]bool{},
+		limit:    limit,
+		seen:     map[string]bool{},
+	}
+	if err := x.run(0); err != nil && err != errGraphQueryDone {
+		return GraphQueryResult{}, err
+	}
+	rows := x.rows
+	if len(q.order) > 0 {
+		slices.SortStableFunc(rows, func(a, b gqResultRow) int {
+			for i, item := range q.order {
+				c := gqOrder(a.keys[i], b.keys[i])
+				if item.desc {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+			return 0
+		})
+		rows = rows[min(q.skip, len(rows)):]
+		if q.limit >= 0 && len(rows) > q.limit {
+			rows = rows[:q.limit]
+		}
+	}
+	for _, row := range rows {
+		values := make([]string, len(row.values))
+		for i, v := range row.values {
+			values[i] = v.String()
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, nil
+}
+
+// Query 执行 Cypher 风格的 MATCH 查询
+// 例如 MATCH (a:Person)-[:KNOWS]->(b) WHERE b.city = 'X' RETURN b ORDER BY b.age DESC LIMIT 10
+// 匹配中展开的节点与边数超过配置的上限时返回错误
+// @param key string 图名
+// @param query string 查询语句
+// @return GraphQueryResult
+// @return error 语法错误、变量错误或超过展开上限时返回错误
+func (g *GkvGraph) Query(key, query string) (GraphQueryResult, error) {
+	q, err := parseGraphQuery(query)
+	if err != nil {
+		return GraphQueryResult{}, err
+	}
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		graph = newGraphObject()
+	}
+	return runGraphQuery(graph, q, g.maxExplored)
+}
@@ -0,0 +1,714 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 图查询语言是 Cypher 的一个子集:
+//
+//	MATCH 模式 [, 模式 ...] [WHERE 条件] RETURN [DISTINCT] 表达式 [AS 别名], ...
+//	[ORDER BY 表达式 [ASC|DESC], ...] [SKIP n] [LIMIT n]
+//
+// 模式由节点与关系交替组成, 如 (a:Person {city: 'X'})-[r:KNOWS]->(b)<-[:LIKES]-(c)-[]-(d);
+// 条件支持 AND/OR/NOT、比较运算、IS [NOT] NULL、CONTAINS、STARTS WITH、ENDS WITH;
+// 表达式支持字面量、变量、属性访问 v.prop 以及函数 id、type、weight、x、y、distance
+
+// gqTokenKind 词法单元类型
+type gqTokenKind int
+
+const (
+	gqEOF gqTokenKind = iota
+	gqIdent
+	gqString
+	gqNumber
+	gqPunct
+)
+
+// gqToken 词法单元
+type gqToken struct {
+	kind gqTokenKind
+	text string
+	// 在查询语句中的字节位置, 用于报错
+	pos int
+}
+
+// gqTwoCharPuncts 由两个字符组成的符号
+var gqTwoCharPuncts = []string{"<=", ">=", "<>", "!="}
+
+// lexGraphQuery 将查询语句拆分为词法单元
+// @param query string
+// @return []gqToken 以 gqEOF 结尾
+// @return error
+func lexGraphQuery(query string) ([]gqToken, error) {
+	toks := []gqToken{}
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || isASCIILetter(c):
+			start := i
+			for i < len(query) && (query[i] == '_' || isASCIILetter(query[i]) || isASCIIDigit(query[i])) {
+				i++
+			}
+			toks = append(toks, gqToken{kind: gqIdent, text: query[start:i], pos: start})
+		case isASCIIDigit(c):
+			start := i
+			for i < len(query) && isASCIIDigit(query[i]) {
+				i++
+			}
+			if i+1 < len(query) && query[i] == '.' && isASCIIDigit(query[i+1]) {
+				i++
+				for i < len(query) && isASCIIDigit(query[i]) {
+					i++
+				}
+			}
+			toks = append(toks, gqToken{kind: gqNumber, text: query[start:i], pos: start})
+		case c == '\'' || c == '"':
+			start := i
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(query) {
+					return nil, fmt.Errorf("位置 %d: 字符串没有结束", start)
+				}
+				if query[i] == '\\' && i+1 < len(query) {
+					i++
+					b.WriteByte(query[i])
+					continue
+				}
+				if query[i] == c {
+					i++
+					break
+				}
+				b.WriteByte(query[i])
+			}
+			toks = append(toks, gqToken{kind: gqString, text: b.String(), pos: start})
+		default:
+			text := string(c)
+			for _, p := range gqTwoCharPuncts {
+				if strings.HasPrefix(query[i:], p) {
+					text = p
+				}
+			}
+			if !strings.Contains("()[]{}:,.-<>=|*", text) && len(text) == 1 {
+				return nil, fmt.Errorf("位置 %d: 无法识别的字符 %q", i, c)
+			}
+			toks = append(toks, gqToken{kind: gqPunct, text: text, pos: i})
+			i += len(text)
+		}
+	}
+	return append(toks, gqToken{kind: gqEOF, pos: len(query)}), nil
+}
+
+// isASCIILetter 是否为ASCII字母
+// @param c byte
+// @return bool
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isASCIIDigit 是否为ASCII数字
+// @param c byte
+// @return bool
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// gqNodePattern 模式中的节点
+type gqNodePattern struct {
+	// 变量名, 匿名节点为空
+	v      string
+	labels []string
+	props  map[string]gqValue
+}
+
+// gqRelPattern 模式中的关系
+type gqRelPattern struct {
+	// 变量名, 匿名关系为空
+	v string
+	// 允许的关系类型, 为空时不限制
+	types []string
+	props map[string]gqValue
+	// 方向: 1 为 -->, -1 为 <--, 0 为 -- (任意方向)
+	dir int
+}
+
+// gqPattern 一条路径模式, len(rels) == len(nodes)-1
+type gqPattern struct {
+	nodes []gqNodePattern
+	rels  []gqRelPattern
+}
+
+// gqReturnItem RETURN 中的一项
+type gqReturnItem struct {
+	expr  gqExpr
+	alias string
+}
+
+// gqOrderItem ORDER BY 中的一项
+type gqOrderItem struct {
+	expr gqExpr
+	desc bool
+}
+
+// gqQuery 解析后的查询
+type gqQuery struct {
+	patterns []gqPattern
+	// WHERE 条件, 没有时为nil
+	where    gqExpr
+	distinct bool
+	returns  []gqReturnItem
+	order    []gqOrderItem
+	skip     int
+	// 小于0表示不限制
+	limit int
+}
+
+// gqParser 递归下降语法分析器
+type gqParser struct {
+	toks []gqToken
+	i    int
+	// 匿名节点与关系的编号, 用于生成内部变量名
+	anon int
+}
+
+// parseGraphQuery 解析查询语句
+// @param query string
+// @return *gqQuery
+// @return error 语法错误时返回错误
+func parseGraphQuery(query string) (*gqQuery, error) {
+	toks, err := lexGraphQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &gqParser{toks: toks}
+	return p.parseQuery()
+}
+
+// peek 查看当前词法单元
+// @return gqToken
+func (p *gqParser) peek() gqToken {
+	return p.toks[p.i]
+}
+
+// next 取出当前词法单元
+// @return gqToken
+func (p *gqParser) next() gqToken {
+	t := p.toks[p.i]
+	if t.kind != gqEOF {
+		p.i++
+	}
+	return t
+}
+
+// isPunct 当前词法单元是否为指定符号
+// @param text string
+// @return bool
+func (p *gqParser) isPunct(text string) bool {
+	t := p.peek()
+	return t.kind == gqPunct && t.text == text
+}
+
+// acceptPunct 当前词法单元为指定符号时取出
+// @param text string
+// @return bool
+func (p *gqParser) acceptPunct(text string) bool {
+	if p.isPunct(text) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// isKeyword 当前词法单元是否为指定关键字(不区分大小写)
+// @param kw string
+// @return bool
+func (p *gqParser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.kind == gqIdent && strings.EqualFold(t.text, kw)
+}
+
+// acceptKeyword 当前词法单元为指定关键字时取出
+// @param kw string
+// @return bool
+func (p *gqParser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// errorf 生成带位置的语法错误
+// @param format string
+// @param args ...any
+// @return error
+func (p *gqParser) errorf(format string, args ...any) error {
+	t := p.peek()
+	near := t.text
+	if t.kind == gqEOF {
+		near = "语句结尾"
+	}
+	return fmt.Errorf("位置 %d (%s): %s", t.pos, near, fmt.Sprintf(format, args...))
+}
+
+// expectPunct 要求当前词法单元为指定符号
+// @param text string
+// @return error
+func (p *gqParser) expectPunct(text string) error {
+	if !p.acceptPunct(text) {
+		return p.errorf("需要 %q", text)
+	}
+	return nil
+}
+
+// expectKeyword 要求当前词法单元为指定关键字
+// @param kw string
+// @return error
+func (p *gqParser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return p.errorf("需要 %s", kw)
+	}
+	return nil
+}
+
+// expectIdent 要求当前词法单元为标识符
+// @return string
+// @return error
+func (p *gqParser) expectIdent() (string, error) {
+	t := p.peek()
+	if t.kind != gqIdent {
+		return "", p.errorf("需要标识符")
+	}
+	p.i++
+	return t.text, nil
+}
+
+// expectInt 要求当前词法单元为非负整数
+// @return int
+// @return error
+func (p *gqParser) expectInt() (int, error) {
+	t := p.peek()
+	n, err := strconv.Atoi(t.text)
+	if t.kind != gqNumber || err != nil {
+		return 0, p.errorf("需要非负整数")
+	}
+	p.i++
+	return n, nil
+}
+
+// anonVar 为匿名节点或关系生成不会与用户变量冲突的变量名
+// @return string
+func (p *gqParser) anonVar() string {
+	p.anon++
+	return fmt.Sprintf(" anon%d", p.anon)
+}
+
+// parseQuery 解析整条查询
+// @return *gqQuery
+// @return error
+func (p *gqParser) parseQuery() (*gqQuery, error) {
+	q := &gqQuery{limit: -1}
+	if err := p.expectKeyword("MATCH"); err != nil {
+		return nil, err
+	}
+	for {
+		pattern, err := p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+		q.patterns = append(q.patterns, pattern)
+		if !p.acceptPunct(",") {
+			break
+		}
+	}
+	if p.acceptKeyword("WHERE") {
+		where, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		q.where = where
+	}
+	if err := p.expectKeyword("RETURN"); err != nil {
+		return nil, err
+	}
+	q.distinct = p.acceptKeyword("DISTINCT")
+	for {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		item := gqReturnItem{expr: expr, alias: expr.String()}
+		if p.acceptKeyword("AS") {
+			if item.alias, err = p.expectIdent(); err != nil {
+				return nil, err
+			}
+		}
+		q.returns = append(q.returns, item)
+		if !p.acceptPunct(",") {
+			break
+		}
+	}
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			item := gqOrderItem{expr: expr}
+			if p.acceptKeyword("DESC") {
+				item.desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			q.order = append(q.order, item)
+			if !p.acceptPunct(",") {
+				break
+			}
+		}
+	}
+	var err error
+	if p.acceptKeyword("SKIP") {
+		if q.skip, err = p.expectInt(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		if q.limit, err = p.expectInt(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek().kind != gqEOF {
+		return nil, p.errorf("多余的内容")
+	}
+	return q, nil
+}
+
+// parsePattern 解析一条路径模式
+// @return gqPattern
+// @return error
+func (p *gqParser) parsePattern() (gqPattern, error) {
+	pattern := gqPattern{}
+	node, err := p.parseNodePattern()
+	if err != nil {
+		return pattern, err
+	}
+	pattern.nodes = append(pattern.nodes, node)
+	for p.isPunct("-") || p.isPunct("<") {
+		rel, err := p.parseRelPattern()
+		if err != nil {
+			return pattern, err
+		}
+		node, err := p.parseNodePattern()
+		if err != nil {
+			return pattern, err
+		}
+		pattern.rels = append(pattern.rels, rel)
+		pattern.nodes = append(pattern.nodes, node)
+	}
+	return pattern, nil
+}
+
+// parseNodePattern 解析 (v:Label {k: v})
+// @return gqNodePattern
+// @return error
+func (p *gqParser) parseNodePattern() (gqNodePattern, error) {
+	node := gqNodePattern{}
+	if err := p.expectPunct("("); err != nil {
+		return node, err
+	}
+	if p.peek().kind == gqIdent {
+		node.v = p.next().text
+	} else {
+		node.v = p.anonVar()
+	}
+	for p.acceptPunct(":") {
+		label, err := p.expectIdent()
+		if err != nil {
+			return node, err
+		}
+		node.labels = append(node.labels, label)
+	}
+	if p.isPunct("{") {
+		props, err := p.parsePropMap()
+		if err != nil {
+			return node, err
+		}
+		node.props = props
+	}
+	return node, p.expectPunct(")")
+}
+
+// parseRelPattern 解析 -[v:TYPE|TYPE {k: v}]->、<-[...]- 或 -[...]-, 方括号可省略
+// @return gqRelPattern
+// @return error
+func (p *gqParser) parseRelPattern() (gqRelPattern, error) {
+	rel := gqRelPattern{}
+	left := p.acceptPunct("<")
+	if err := p.expectPunct("-"); err != nil {
+		return rel, err
+	}
+	rel.v = p.anonVar()
+	if p.acceptPunct("[") {
+		if p.peek().kind == gqIdent {
+			rel.v = p.next().text
+		}
+		if p.acceptPunct(":") {
+			for {
+				typ, err := p.expectIdent()
+				if err != nil {
+					return rel, err
+				}
+				rel.types = append(rel.types, typ)
+				if !p.acceptPunct("|") {
+					break
+				}
+				p.acceptPunct(":")
+			}
+		}
+		if p.isPunct("{") {
+			props, err := p.parsePropMap()
+			if err != nil {
+				return rel, err
+			}
+			rel.props = props
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return rel, err
+		}
+	}
+	if err := p.expectPunct("-"); err != nil {
+		return rel, err
+	}
+	right := p.acceptPunct(">")
+	switch {
+	case left && right:
+		return rel, p.errorf("关系不能同时指向两端")
+	case left:
+		rel.dir = -1
+	case right:
+		rel.dir = 1
+	}
+	return rel, nil
+}
+
+// parsePropMap 解析 {k: 字面量, ...}
+// @return map[string]gqValue
+// @return error
+func (p *gqParser) parsePropMap() (map[string]gqValue, error) {
+	props := map[string]gqValue{}
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	if p.acceptPunct("}") {
+		return props, nil
+	}
+	for {
+		name, err := p.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		v, ok := p.parseLiteral()
+		if !ok {
+			return nil, p.errorf("属性值必须为字面量")
+		}
+		props[name] = v
+		if !p.acceptPunct(",") {
+			break
+		}
+	}
+	return props, p.expectPunct("}")
+}
+
+// parseLiteral 解析字符串、数字、TRUE/FALSE/NULL 字面量
+// @return gqValue
+// @return bool 当前位置是否为字面量
+func (p *gqParser) parseLiteral() (gqValue, bool) {
+	t := p.peek()
+	negative := false
+	if t.kind == gqPunct && t.text == "-" && p.toks[p.i+1].kind == gqNumber {
+		negative = true
+		t = p.toks[p.i+1]
+	}
+	switch {
+	case t.kind == gqString:
+		p.i++
+		return gqStr(t.text), true
+	case t.kind == gqNumber:
+		n, _ := strconv.ParseFloat(t.text, 64)
+		if negative {
+			n = -n
+			p.i++
+		}
+		p.i++
+		return gqNum(n), true
+	case t.kind == gqIdent && strings.EqualFold(t.text, "true"):
+		p.i++
+		return gqBool(true), true
+	case t.kind == gqIdent && strings.EqualFold(t.text, "false"):
+		p.i++
+		return gqBool(false), true
+	case t.kind == gqIdent && strings.EqualFold(t.text, "null"):
+		p.i++
+		return gqValue{}, true
+	}
+	return gqValue{}, false
+}
+
+// parseExpr 解析表达式, 优先级从低到高为 OR、AND、NOT、比较
+// @return gqExpr
+// @return error
+func (p *gqParser) parseExpr() (gqExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &gqBinary{op: "OR", l: left, r: right}
+	}
+	return left, nil
+}
+
+// parseAnd 解析 AND
+// @return gqExpr
+// @return error
+func (p *gqParser) parseAnd() (gqExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &gqBinary{op: "AND", l: left, r: right}
+	}
+	return left, nil
+}
+
+// parseNot 解析 NOT
+// @return gqExpr
+// @return error
+func (p *gqParser) parseNot() (gqExpr, error) {
+	if p.acceptKeyword("NOT") {
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &gqNot{e: e}, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison 解析比较运算、IS [NOT] NULL 与字符串匹配
+// @return gqExpr
+// @return error
+func (p *gqParser) parseComparison() (gqExpr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	switch {
+	case t.kind == gqPunct && strings.Contains(" = <> != < <= > >= ", " "+t.text+" "):
+		p.i++
+		op := t.text
+		if op == "!=" {
+			op = "<>"
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &gqBinary{op: op, l: left, r: right}, nil
+	case p.acceptKeyword("IS"):
+		negate := p.acceptKeyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &gqIsNull{e: left, negate: negate}, nil
+	case p.acceptKeyword("CONTAINS"):
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &gqBinary{op: "CONTAINS", l: left, r: right}, nil
+	case p.isKeyword("STARTS") || p.isKeyword("ENDS"):
+		op := strings.ToUpper(p.next().text) + " WITH"
+		if err := p.expectKeyword("WITH"); err != nil {
+			return nil, err
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &gqBinary{op: op, l: left, r: right}, nil
+	}
+	return left, nil
+}
+
+// parseOperand 解析字面量、括号表达式、函数调用、属性访问与变量
+// @return gqExpr
+// @return error
+func (p *gqParser) parseOperand() (gqExpr, error) {
+	if v, ok := p.parseLiteral(); ok {
+		return &gqLiteral{v: v}, nil
+	}
+	if p.acceptPunct("(") {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expectPunct(")")
+	}
+	name, err := p.expectIdent()
+	if err != nil {
+		return nil, p.errorf("需要表达式")
+	}
+	if p.acceptPunct("(") {
+		fn := strings.ToLower(name)
+		arity, known := gqFunctions[fn]
+		if !known {
+			return nil, fmt.Errorf("未知函数: %s", name)
+		}
+		call := &gqCall{fn: fn}
+		for !p.isPunct(")") {
+			arg, err := p.expectIdent()
+			if err != nil {
+				return nil, p.errorf("函数参数必须为变量")
+			}
+			call.args = append(call.args, arg)
+			if !p.acceptPunct(",") {
+				break
+			}
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		if len(call.args) != arity {
+			return nil, fmt.Errorf("函数 %s 需要 %d 个参数", fn, arity)
+		}
+		return call, nil
+	}
+	if p.acceptPunct(".") {
+		prop, err := p.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+		return &gqProp{v: name, name: prop}, nil
+	}
+	return &gqVar{v: name}, nil
+}
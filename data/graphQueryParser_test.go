@@ -0,0 +1,126 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestParseGraphQueryErrors 词法与语法错误的提示与位置
+func TestParseGraphQueryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"空语句", "", `位置 0 (语句结尾): 需要 MATCH`},
+		{"缺少MATCH", "RETURN a", `位置 0 (RETURN): 需要 MATCH`},
+		{"缺少RETURN", "MATCH (a)", `位置 9 (语句结尾): 需要 RETURN`},
+		{"RETURN后没有表达式", "MATCH (a) RETURN", `位置 16 (语句结尾): 需要表达式`},
+		{"节点缺少右括号", "MATCH (a RETURN a", `位置 9 (RETURN): 需要 ")"`},
+		{"标签为空", "MATCH (a:) RETURN a", `位置 9 ()): 需要标识符`},
+		{"关系缺少右方括号", "MATCH (a)-[r->(b) RETURN a", `位置 12 (-): 需要 "]"`},
+		{"关系类型为空", "MATCH (a)-[r:]->(b) RETURN a", `位置 13 (]): 需要标识符`},
+		{"关系同时指向两端", "MATCH (a)<-[]->(b) RETURN a", `位置 15 ((): 关系不能同时指向两端`},
+		{"关系指向两端且省略方括号", "MATCH (a)<-->(b) RETURN a", `位置 13 ((): 关系不能同时指向两端`},
+		{"属性值不是字面量", "MATCH (a {name: b}) RETURN a", `位置 16 (b): 属性值必须为字面量`},
+		{"属性表缺少右花括号", "MATCH (a {name: 'x') RETURN a", `位置 19 ()): 需要 "}"`},
+		{"字符串没有结束", "MATCH (a) WHERE a.x = 'oops RETURN a", `位置 22: 字符串没有结束`},
+		{"无法识别的字符", "MATCH (a) WHERE a.x = 1; RETURN a", `位置 23: 无法识别的字符 ';'`},
+		{"IS后缺少NULL", "MATCH (a) WHERE a.x IS 1 RETURN a", `位置 23 (1): 需要 NULL`},
+		{"STARTS后缺少WITH", "MATCH (a) WHERE a.x STARTS 'y' RETURN a", `位置 27 (y): 需要 WITH`},
+		{"属性名不是标识符", "MATCH (a) RETURN a.1", `位置 19 (1): 需要标识符`},
+		{"别名不是标识符", "MATCH (a) RETURN a AS 'x'", `位置 22 (x): 需要标识符`},
+		{"未知函数", "MATCH (a) RETURN foo(a)", `未知函数: foo`},
+		{"函数参数个数不符", "MATCH (a) RETURN distance(a)", `函数 distance 需要 2 个参数`},
+		{"函数参数不是变量", "MATCH (a) RETURN id('x')", `位置 20 (x): 函数参数必须为变量`},
+		{"ORDER后缺少BY", "MATCH (a) RETURN a ORDER a", `位置 25 (a): 需要 BY`},
+		{"LIMIT为负数", "MATCH (a) RETURN a LIMIT -1", `位置 25 (-): 需要非负整数`},
+		{"LIMIT为小数", "MATCH (a) RETURN a LIMIT 1.5", `位置 25 (1.5): 需要非负整数`},
+		{"LIMIT溢出", "MATCH (a) RETURN a LIMIT 99999999999999999999", `需要非负整数`},
+		{"SKIP不是数字", "MATCH (a) RETURN a SKIP x", `位置 24 (x): 需要非负整数`},
+		{"SKIP位于LIMIT之后", "MATCH (a) RETURN a LIMIT 1 SKIP 1", `位置 27 (SKIP): 多余的内容`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parseGraphQuery(tt.query)
+			if err == nil {
+				t.Fatalf("应返回错误, 得到 %+v", q)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("得到错误 %q, 期望包含 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestPlanGraphQueryErrors 变量未定义、重复定义与函数参数类型不符
+func TestPlanGraphQueryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"RETURN引用未定义的变量", "MATCH (a) RETURN b", "未定义的变量: b"},
+		{"WHERE引用未定义的变量", "MATCH (a) WHERE b.x = 1 RETURN a", "未定义的变量: b"},
+		{"关系变量重复定义", "MATCH (a)-[r]->(b), (c)-[r]->(d) RETURN a", "变量重复定义: r"},
+		{"节点与关系同名", "MATCH (a)-[a]->(b) RETURN a", "变量重复定义: a"},
+		{"type的参数为节点", "MATCH (a) RETURN type(a)", "type() 的参数必须为关系变量: a"},
+		{"x的参数为关系", "MATCH (a)-[r]->(b) RETURN x(r)", "x() 的参数必须为节点变量: r"},
+		{"节点变量可以重复出现", "MATCH (a)-->(b), (a)-->(c) RETURN a", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parseGraphQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = planGraphQuery(newGraphObject(), q)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("不应返回错误, 得到 %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("得到错误 %v, 期望 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseGraphQuery 解析结果中的模式、条件与各子句
+func TestParseGraphQuery(t *testing.T) {
+	q, err := parseGraphQuery("match (a:Person:Admin {age: -3, ok: TRUE})<-[:KNOWS|:LIKES]-(b)--(c) " +
+		"where not a.x is not null or b.y != 'it\\'s' return distinct a.x as x, b order by x desc, b skip 2 limit 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.patterns) != 1 || len(q.patterns[0].nodes) != 3 || len(q.patterns[0].rels) != 2 {
+		t.Fatalf("模式解析错误: %+v", q.patterns)
+	}
+	nodes, rels := q.patterns[0].nodes, q.patterns[0].rels
+	a := nodes[0]
+	if a.v != "a" || strings.Join(a.labels, ",") != "Person,Admin" || a.props["age"] != gqNum(-3) || a.props["ok"] != gqBool(true) {
+		t.Fatalf("节点解析错误: %+v", a)
+	}
+	if got := fmt.Sprint(rels[0].dir, rels[0].types, rels[1].dir, rels[1].types); got != "-1 [KNOWS LIKES] 0 []" {
+		t.Fatalf("关系解析错误: %s", got)
+	}
+	// 匿名关系使用带空格的内部变量名, 不会与用户变量冲突
+	if !strings.HasPrefix(rels[0].v, " ") || rels[0].v == rels[1].v {
+		t.Fatalf("匿名关系的变量名 %q %q", rels[0].v, rels[1].v)
+	}
+	if got := q.where.String(); got != `NOT a.x IS NOT NULL OR b.y <> 'it\'s'` {
+		t.Fatalf("条件解析为 %s", got)
+	}
+	if !q.distinct || len(q.returns) != 2 || q.returns[0].alias != "x" || q.returns[1].alias != "b" {
+		t.Fatalf("RETURN 解析错误: %+v", q.returns)
+	}
+	if len(q.order) != 2 || !q.order[0].desc || q.order[1].desc || q.skip != 2 || q.limit != 3 {
+		t.Fatalf("ORDER BY/SKIP/LIMIT 解析错误: %+v %d %d", q.order, q.skip, q.limit)
+	}
+	if q, err := parseGraphQuery("MATCH (n) RETURN n"); err != nil || q.limit != -1 || q.where != nil {
+		t.Fatalf("未指定 LIMIT 时应不限制: %+v %v", q, err)
+	}
+}
@@ -0,0 +1,166 @@
+package data
+
+import (
+	"maps"
+	"slices"
+)
+
+// TraverseOrder 遍历方式
+type TraverseOrder int
+
+const (
+	// TraverseDFS 深度优先
+	TraverseDFS TraverseOrder = iota
+	// TraverseBFS 广度优先
+	TraverseBFS
+)
+
+// TraverseOptions 遍历参数, 零值表示不限制
+type TraverseOptions struct {
+	// 最大深度, 深度达到该值的节点不再展开; 0 表示不限制
+	MaxDepth int
+	// 最多访问的节点数; 0 表示不限制
+	MaxNodes int
+	// 只沿属性全部匹配的边前进; 为空时不过滤
+	EdgeFilter map[string]string
+}
+
+// GraphVisit 遍历中访问的一个节点
+type GraphVisit struct {
+	Node string
+	// 到起点的深度(沿遍历树的边数), 起点为0
+	Depth int
+	// 遍历树中的父节点, 起点为空
+	Parent string
+}
+
+// traverseItem 待访问的节点
+type traverseItem struct {
+	node, parent string
+	depth        int
+}
+
+// matchEdge 边的属性是否满足过滤条件
+// @param arc *graphArc
+// @param filter map[string]string
+// @return bool
+func matchEdge(arc *graphArc, filter map[string]string) bool {
+	for name, value := range filter {
+		if v, ok := arc.props[name]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// traverse 从起点开始沿出边迭代遍历, 同一节点的邻居按字典序访问
+// 深度优先使用显式栈, 链很长时也不会耗尽协程栈
+// 深度优先且限制了 MaxDepth 时, 先经较深的路径访问到的节点可能因深度达到上限而未展开,
+// 之后经更浅的路径再次到达时会重新展开, 并把它的深度与父节点更新为更浅的路径,
+// 保证与起点相距不超过 MaxDepth 的节点都会被访问; 每个节点只在结果中出现一次
+// @param start string 起点
+// @param order TraverseOrder 遍历方式
+// @param opts TraverseOptions
+// @return []GraphVisit 按首次访问的顺序排列, 起点不存在时为空
+func (g *graphObject) traverse(start string, order TraverseOrder, opts TraverseOptions) []GraphVisit {
+	result := []GraphVisit{}
+	if !g.hasNode(start) {
+		return result
+	}
+	// 节点 -> 在结果中的下标
+	visited := map[string]int{}
+	pending := []traverseItem{{node: start}}
+	if order == TraverseBFS {
+		visited[start] = 0
+	}
+	// 深度优先时, 节点以比已访问时更浅的深度到达才需要重新展开
+	shallower := func(node string, depth int) bool {
+		i, seen := visited[node]
+		return !seen || (order == TraverseDFS && opts.MaxDepth > 0 && depth < result[i].Depth)
+	}
+	for len(pending) > 0 {
+		var item traverseItem
+		if order == TraverseBFS {
+			if opts.MaxNodes > 0 && len(result) >= opts.MaxNodes {
+				break
+			}
+			item, pending = pending[0], pending[1:]
+			result = append(result, GraphVisit{Node: item.node, Depth: item.depth, Parent: item.parent})
+		} else {
+			item, pending = pending[len(pending)-1], pending[:len(pending)-1]
+			// 同一节点可能被多次入栈, 只在第一次出栈或经更浅的路径出栈时展开
+			if !shallower(item.node, item.depth) {
+				continue
+			}
+			visit := GraphVisit{Node: item.node, Depth: item.depth, Parent: item.parent}
+			if i, seen := visited[item.node]; seen {
+				result[i] = visit
+			} else {
+				if opts.MaxNodes > 0 && len(result) >= opts.MaxNodes {
+					break
+				}
+				visited[item.node] = len(result)
+				result = append(result, visit)
+			}
+		}
+		if opts.MaxDepth > 0 && item.depth >= opts.MaxDepth {
+			continue
+		}
+		neighbors := slices.Sorted(maps.Keys(g.out[item.node]))
+		if order == TraverseDFS {
+			// 逆序入栈, 使字典序最小的邻居最先出栈
+			slices.Reverse(neighbors)
+		}
+		for _, next := range neighbors {
+			if !shallower(next, item.depth+1) || !matchEdge(g.out[item.node][next], opts.EdgeFilter) {
+				continue
+			}
+			if order == TraverseBFS {
+				visited[next] = len(result) + len(pending)
+			}
+			pending = append(pending, traverseItem{node: next, parent: item.node, depth: item.depth + 1})
+		}
+	}
+	return result
+}
+
+// visitOrder 提取遍历顺序
+// @param visits []GraphVisit
+// @return []string
+func visitOrder(visits []GraphVisit) []string {
+	order := make([]string, len(visits))
+	for i, v := range visits {
+		order[i] = v.Node
+	}
+	return order
+}
+
+// dfs 深度优先搜索，沿出边遍历, 返回遍历顺序
+// @param start string 起点
+// @return []string 遍历顺序, 起点不存在时为空
+func (g *graphObject) dfs(start string) []string {
+	return visitOrder(g.traverse(start, TraverseDFS, TraverseOptions{}))
+}
+
+// bfs 广度优先搜索，沿出边遍历, 返回遍历顺序
+// @param start string 起点
+// @return []string 遍历顺序, 起点不存在时为空
+func (g *graphObject) bfs(start string) []string {
+	return visitOrder(g.traverse(start, TraverseBFS, TraverseOptions{}))
+}
+
+// Traverse 从起点开始沿出边遍历, 返回每个节点的深度与遍历树中的父节点
+// @param key string 图名
+// @param start string 起点
+// @param order TraverseOrder 遍历方式
+// @param opts TraverseOptions 最大深度、最大节点数与边属性过滤
+// @return []GraphVisit 按访问顺序排列
+func (g *GkvGraph) Traverse(key, start string, order TraverseOrder, opts TraverseOptions) []GraphVisit {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []GraphVisit{}
+	}
+	return graph.traverse(start, order, opts)
+}
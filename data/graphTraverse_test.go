@@ -0,0 +1,83 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// formatVisits 将访问结果拼接为 "节点/深度/父节点" 的形式
+// @param visits []GraphVisit
+// @return string
+func formatVisits(visits []GraphVisit) string {
+	parts := make([]string, len(visits))
+	for i, v := range visits {
+		parts[i] = fmt.Sprintf("%s/%d/%s", v.Node, v.Depth, v.Parent)
+	}
+	return strings.Join(parts, " ")
+}
+
+// TestTraverse 深度优先与广度优先的访问顺序、深度限制、节点数限制与边过滤
+func TestTraverse(t *testing.T) {
+	g := buildGraph("a>b", "b>c", "a>c", "c>d", "d>e", "x>a")
+	g.addEdge(GraphEdge{From: "b", To: "f", Directed: true, Props: map[string]string{"type": "rail"}})
+	tests := []struct {
+		name  string
+		start string
+		order TraverseOrder
+		opts  TraverseOptions
+		want  string
+	}{
+		{"深度优先", "a", TraverseDFS, TraverseOptions{}, "a/0/ b/1/a c/2/b d/3/c e/4/d f/2/b"},
+		{"广度优先", "a", TraverseBFS, TraverseOptions{}, "a/0/ b/1/a c/1/a f/2/b d/2/c e/3/d"},
+		// 先经 a>b>c 以深度2到达 c, 之后经 a>c 以深度1到达时重新展开, d 才能在深度限制内被访问
+		{"深度优先经更浅的路径重新展开", "a", TraverseDFS, TraverseOptions{MaxDepth: 2}, "a/0/ b/1/a c/1/a f/2/b d/2/c"},
+		{"广度优先深度限制", "a", TraverseBFS, TraverseOptions{MaxDepth: 1}, "a/0/ b/1/a c/1/a"},
+		{"深度优先节点数限制", "a", TraverseDFS, TraverseOptions{MaxNodes: 3}, "a/0/ b/1/a c/2/b"},
+		{"广度优先节点数限制", "a", TraverseBFS, TraverseOptions{MaxNodes: 2}, "a/0/ b/1/a"},
+		{"边过滤", "a", TraverseBFS, TraverseOptions{EdgeFilter: map[string]string{"type": "rail"}}, "a/0/"},
+		{"只沿出边", "e", TraverseDFS, TraverseOptions{}, "e/0/"},
+		{"起点不存在", "missing", TraverseDFS, TraverseOptions{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatVisits(g.traverse(tt.start, tt.order, tt.opts)); got != tt.want {
+				t.Fatalf("得到 %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTraverseMaxDepthReachesAll 深度优先在深度限制内访问到的节点与广度优先相同, 深度为最短距离
+func TestTraverseMaxDepthReachesAll(t *testing.T) {
+	// 每个节点 i 指向 i+1 与 i+3, 深度优先先沿 i+1 走得很深
+	g := newGraphObject()
+	const n = 60
+	for i := range n {
+		for _, step := range []int{1, 3} {
+			g.addEdge(GraphEdge{From: fmt.Sprint(i), To: fmt.Sprint(i + step), Directed: true, Weight: 1})
+		}
+	}
+	for _, maxDepth := range []int{1, 2, 5, 10} {
+		depths := map[string]int{}
+		for _, v := range g.traverse("0", TraverseBFS, TraverseOptions{MaxDepth: maxDepth}) {
+			depths[v.Node] = v.Depth
+		}
+		visits := g.traverse("0", TraverseDFS, TraverseOptions{MaxDepth: maxDepth})
+		if len(visits) != len(depths) {
+			t.Fatalf("深度限制 %d: 深度优先访问了 %d 个节点, 广度优先访问了 %d 个", maxDepth, len(visits), len(depths))
+		}
+		parents := map[string]GraphVisit{}
+		for _, v := range visits {
+			parents[v.Node] = v
+		}
+		for _, v := range visits {
+			if want, ok := depths[v.Node]; !ok || v.Depth != want {
+				t.Fatalf("深度限制 %d: %s 的深度为 %d, 期望 %d", maxDepth, v.Node, v.Depth, want)
+			}
+			if v.Parent != "" && (parents[v.Parent].Depth != v.Depth-1 || g.out[v.Parent][v.Node] == nil) {
+				t.Fatalf("深度限制 %d: %s 的父节点 %s 不在更浅一层", maxDepth, v.Node, v.Parent)
+			}
+		}
+	}
+}
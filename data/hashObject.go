@@ -0,0 +1,153 @@
+package data
+
+// hashObject 单个映射的值
+// 字段数量与字段/值长度均不超过阈值时使用紧凑列表编码(字段与值交替存放), 否则使用哈希表编码
+type hashObject struct {
+	// 紧凑列表编码, 为nil表示使用哈希表编码
+	lp *listpack
+	// 哈希表编码
+	dict map[string]string
+}
+
+// newHashObject 创建空映射
+// @return *hashObject
+func newHashObject() *hashObject {
+	return &hashObject{lp: &listpack{}}
+}
+
+// encoding 当前编码名称
+// @return string
+func (h *hashObject) encoding() string {
+	if h.lp != nil {
+		return "listpack"
+	}
+	return "hashtable"
+}
+
+// len 字段数量
+// @return int
+func (h *hashObject) len() int {
+	if h == nil {
+		return 0
+	}
+	if h.lp != nil {
+		return h.lp.count / 2
+	}
+	return len(h.dict)
+}
+
+// find 在紧凑列表中查找字段
+// @param field string
+// @return int 字段条目的起始偏移, -1表示不存在
+// @return int 值条目的起始偏移
+func (h *hashObject) find(field string) (int, int) {
+	for off := 0; off < h.lp.size(); {
+		f, valOff := h.lp.entry(off)
+		_, next := h.lp.entry(valOff)
+		if string(f) == field {
+			return off, valOff
+		}
+		off = next
+	}
+	return -1, 0
+}
+
+// get 获取字段的值
+// @param field string
+// @return string 值
+// @return bool 字段是否存在
+func (h *hashObject) get(field string) (string, bool) {
+	if h == nil {
+		return "", false
+	}
+	if h.lp != nil {
+		off, valOff := h.find(field)
+		if off < 0 {
+			return "", false
+		}
+		v, _ := h.lp.entry(valOff)
+		return string(v), true
+	}
+	v, ok := h.dict[field]
+	return v, ok
+}
+
+// set 设置字段的值, 必要时转换为哈希表编码
+// @param field string
+// @param value string
+// @return bool 是否为新字段
+func (h *hashObject) set(field, value string) bool {
+	if h.lp != nil {
+		limit := encodingLimits.HashMaxListpackValue
+		if len(field) <= limit && len(value) <= limit {
+			off, valOff := h.find(field)
+			if off >= 0 {
+				h.lp.replace(valOff, []byte(value))
+				return false
+			}
+			if h.len() < encodingLimits.HashMaxListpackEntries {
+				h.lp.insert(h.lp.size(), []byte(field), []byte(value))
+				return true
+			}
+		}
+		h.convertToDict()
+	}
+	_, exists := h.dict[field]
+	h.dict[field] = value
+	return !exists
+}
+
+// del 删除字段
+// @param field string
+// @return bool 字段是否存在
+func (h *hashObject) del(field string) bool {
+	if h.lp != nil {
+		off, valOff := h.find(field)
+		if off < 0 {
+			return false
+		}
+		_, end := h.lp.entry(valOff)
+		h.lp.cut(off, end, 2)
+		return true
+	}
+	if _, ok := h.dict[field]; !ok {
+		return false
+	}
+	delete(h.dict, field)
+	return true
+}
+
+// each 遍历字段与值, fn返回false时停止
+// @param fn func(field, value string) bool
+func (h *hashObject) each(fn func(field, value string) bool) {
+	if h == nil {
+		return
+	}
+	if h.lp != nil {
+		for off := 0; off < h.lp.size(); {
+			f, valOff := h.lp.entry(off)
+			v, next := h.lp.entry(valOff)
+			if !fn(string(f), string(v)) {
+				return
+			}
+			off = next
+		}
+		return
+	}
+	for f, v := range h.dict {
+		if !fn(f, v) {
+			return
+		}
+	}
+}
+
+// convertToDict 转换为哈希表编码
+func (h *hashObject) convertToDict() {
+	dict := make(map[string]string, h.len()+1)
+	h.each(func(f, v string) bool {
+		dict[f] = v
+		return true
+	})
+	h.lp = nil
+	h.dict = dict
+}
@@ -0,0 +1,261 @@
+package data
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// Redis HyperLogLog 二进制格式(HYLL):
+// 16字节头部: "HYLL" + 编码(0稠密/1稀疏) + 3字节保留 + 8字节小端缓存基数(最高位为1表示缓存无效)
+// 稠密: 16384 个6位寄存器紧密排列, 低位在前
+// 稀疏: ZERO 00xxxxxx 表示 1-64 个0寄存器; XZERO 01xxxxxx yyyyyyyy 表示 1-16384 个0寄存器;
+// VAL 1vvvvvxx 表示 1-4 个值为 1-32 的寄存器
+
+const (
+	// redisHLLPrecision Redis 固定使用的精度
+	redisHLLPrecision = 14
+	// redisHLLRegisters Redis 的寄存器数量
+	redisHLLRegisters = 1 << redisHLLPrecision
+	// redisHLLHeaderSize 头部字节数
+	redisHLLHeaderSize = 16
+	// redisHLLDenseSize 稠密编码的总字节数
+	redisHLLDenseSize = redisHLLHeaderSize + (redisHLLRegisters*6+7)/8
+	// redisHLLEncDense 稠密编码标记
+	redisHLLEncDense = 0
+	// redisHLLEncSparse 稀疏编码标记
+	redisHLLEncSparse = 1
+	// redisHLLSparseValMax 稀疏编码能表示的最大寄存器值
+	redisHLLSparseValMax = 32
+)
+
+// redisHLLMagic Redis HyperLogLog 头部标识
+var redisHLLMagic = []byte("HYLL")
+
+// errRedisHLL Redis HyperLogLog 数据损坏
+var errRedisHLL = errors.New("不是合法的 Redis HyperLogLog 数据")
+
+// errRedisHLLPrecision 精度低于 Redis 固定精度, 无法导出
+var errRedisHLLPrecision = errors.New("精度低于14的 HyperLogLog 无法导出为 Redis 格式")
+
+// IsRedisHLL 判断数据是否以 Redis HyperLogLog 头部开始
+// @param b []byte
+// @return bool
+func IsRedisHLL(b []byte) bool {
+	return len(b) >= redisHLLHeaderSize && bytes.Equal(b[:4], redisHLLMagic) && b[4] <= redisHLLEncSparse
+}
+
+// encodeRedisHLL 将 HyperLogLog 编码为 Redis 格式
+// 稀疏编码且寄存器值不超过32时输出稀疏格式, 否则输出稠密格式
+// @param h *hllObject
+// @return []byte
+// @return error 精度低于14时返回错误
+func encodeRedisHLL(h *hllObject) ([]byte, error) {
+	if h.p < redisHLLPrecision {
+		return nil, errRedisHLLPrecision
+	}
+	regs := h.registers()
+	card := h.count()
+	if h.p > redisHLLPrecision {
+		regs = make([]uint8, redisHLLRegisters)
+		h.foldInto(regs, redisHLLPrecision)
+		card = hllEstimate(hllHistogram(regs, redisHLLPrecision), redisHLLPrecision)
+	}
+	header := make([]byte, redisHLLHeaderSize)
+	copy(header, redisHLLMagic)
+	binary.LittleEndian.PutUint64(header[8:], card)
+	if h.dense == nil && h.p == redisHLLPrecision && maxRegister(regs) <= redisHLLSparseValMax {
+		header[4] = redisHLLEncSparse
+		return append(header, encodeRedisSparse(regs)...), nil
+	}
+	header[4] = redisHLLEncDense
+	return append(header, encodeRedisDense(regs)...), nil
+}
+
+// maxRegister 寄存器中的最大值
+// @param regs []uint8
+// @return uint8
+func maxRegister(regs []uint8) uint8 {
+	var m uint8
+	for _, v := range regs {
+		m = max(m, v)
+	}
+	return m
+}
+
+// encodeRedisDense 将寄存器按6位紧密排列
+// @param regs []uint8 16384 个寄存器
+// @return []byte
+func encodeRedisDense(regs []uint8) []byte {
+	// 多分配一个字节, 最后一个寄存器写入高位字节时不越界
+	out := make([]byte, redisHLLDenseSize-redisHLLHeaderSize+1)
+	for i, v := range regs {
+		bit := i * 6
+		fb := uint(bit & 7)
+		out[bit/8] |= v << fb
+		out[bit/8+1] |= v >> (8 - fb)
+	}
+	return out[:len(out)-1]
+}
+
+// encodeRedisSparse 将寄存器编码为稀疏操作码序列
+// @param regs []uint8 16384 个寄存器, 值均不超过32
+// @return []byte
+func encodeRedisSparse(regs []uint8) []byte {
+	out := []byte{}
+	for i := 0; i < len(regs); {
+		run := 1
+		for i+run < len(regs) && regs[i+run] == regs[i] {
+			run++
+		}
+		if v := regs[i]; v == 0 {
+			for left := run; left > 0; {
+				n := min(left, 16384)
+				if n > 64 {
+					out = append(out, 0x40|byte((n-1)>>8), byte(n-1))
+				} else {
+					out = append(out, byte(n-1))
+				}
+				left -= n
+			}
+		} else {
+			for left := run; left > 0; {
+				n := min(left, 4)
+				out = append(out, 0x80|(v-1)<<2|byte(n-1))
+				left -= n
+			}
+		}
+		i += run
+	}
+	return out
+}
+
+// decodeRedisHLL 解析 Redis 格式的 HyperLogLog
+// @param b []byte
+// @return *hllObject 精度为14
+// @return error 数据损坏时返回错误
+func decodeRedisHLL(b []byte) (*hllObject, error) {
+	if !IsRedisHLL(b) {
+		return nil, errRedisHLL
+	}
+	regs := make([]uint8, redisHLLRegisters)
+	body := b[redisHLLHeaderSize:]
+	if b[4] == redisHLLEncDense {
+		if len(b) != redisHLLDenseSize {
+			return nil, errRedisHLL
+		}
+		for i := range regs {
+			bit := i * 6
+			fb := uint(bit & 7)
+			v := uint(body[bit/8]) >> fb
+			if bit/8+1 < len(body) {
+				v |= uint(body[bit/8+1]) << (8 - fb)
+			}
+			regs[i] = uint8(v & 63)
+			// 精度14时寄存器的值最大为 64-14+1
+			if regs[i] > 64-redisHLLPrecision+1 {
+				return nil, errRedisHLL
+			}
+		}
+	} else {
+		idx := 0
+		for i := 0; i < len(body); i++ {
+			op := body[i]
+			switch {
+			case op&0xC0 == 0x00:
+				idx += int(op&0x3F) + 1
+			case op&0xC0 == 0x40:
+				if i+1 >= len(body) {
+					return nil, errRedisHLL
+				}
+				idx += (int(op&0x3F)<<8 | int(body[i+1])) + 1
+				i++
+			default:
+				n := int(op&0x03) + 1
+				if idx+n > redisHLLRegisters {
+					return nil, errRedisHLL
+				}
+				for j := 0; j < n; j++ {
+					regs[idx+j] = (op>>2)&0x1F + 1
+				}
+				idx += n
+			}
+			if idx > redisHLLRegisters {
+				return nil, errRedisHLL
+			}
+		}
+		if idx != redisHLLRegisters {
+			return nil, errRedisHLL
+		}
+	}
+	h := newHLLObject(redisHLLPrecision)
+	for i, v := range regs {
+		if v != 0 {
+			h.set(i, v)
+		}
+	}
+	if card := binary.LittleEndian.Uint64(b[8:]); card&(1<<63) == 0 {
+		h.cacheCount(card)
+	}
+	return h, nil
+}
+
+// Dump 将 HyperLogLog 导出为 Redis 格式, 头部带有缓存的基数
+// @param key string
+// @return []byte
+// @return bool key是否存在
+// @return error 无法导出时返回错误
+func (hll *GkvHyperLoglog) Dump(key string) ([]byte, bool, error) {
+	hll.keyLock.RLockRow(key)
+	defer hll.keyLock.RUnLockRow(key)
+	h := hll.liveHLL(key)
+	if h == nil {
+		return nil, false, nil
+	}
+	b, err := encodeRedisHLL(h)
+	return b, true, err
+}
+
+// Restore 由 Redis 格式的数据创建 HyperLogLog, 覆盖已有的key
+// @param key string
+// @param b []byte Redis 格式数据
+// @return error 数据损坏时返回错误
+func (hll *GkvHyperLoglog) Restore(key string, b []byte) error {
+	h, err := decodeRedisHLL(b)
+	if err != nil {
+		return err
+	}
+	hll.keyLock.WLockRow(key)
+	defer hll.keyLock.WUnLockRow(key)
+	hll.data[key] = h
+	delete(hll.expireTimes, key)
+	notifyKeyspaceEvent(NotifyString, "set", key)
+	return nil
+}
+
+// GetString 按 Redis 语义读取字符串: 字符串不存在时, 同名 HyperLogLog 以 Redis 格式返回
+// @param key string
+// @return []byte
+// @return bool 是否存在
+func GetString(key string) ([]byte, bool) {
+	if v, ok := DataGkvString.Get(key); ok {
+		return v, true
+	}
+	if b, ok, err := DataGkvHyperLoglog.Dump(key); ok && err == nil {
+		return b, true
+	}
+	return nil, false
+}
+
+// SetString 按 Redis 语义写入字符串: 值为合法的 Redis HyperLogLog 数据时写入 HyperLogLog
+// 并删除同名字符串, 否则作为普通字符串写入并删除同名 HyperLogLog
+// @param key string
+// @param value []byte
+func SetString(key string, value []byte) {
+	if IsRedisHLL(value) && DataGkvHyperLoglog.Restore(key, value) == nil {
+		DataGkvString.deleteKey(key)
+		return
+	}
+	DataGkvHyperLoglog.deleteKey(key)
+	DataGkvString.Set(key, value)
+}
@@ -0,0 +1,306 @@
+package data
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+	"slices"
+	"sync/atomic"
+)
+
+const (
+	// hllMinPrecision 支持的最小精度
+	hllMinPrecision = 4
+	// hllMaxPrecision 支持的最大精度
+	hllMaxPrecision = 18
+	// hllDefaultPrecision 默认精度, 16384 个寄存器, 标准误差约 0.81%
+	hllDefaultPrecision = 14
+	// hllSeed 与 Redis 一致的哈希种子
+	hllSeed = 0xadc83b19
+)
+
+// errHLLPrecision 精度超出范围
+var errHLLPrecision = errors.New("HyperLogLog 精度必须在 4 到 18 之间")
+
+// murmurHash64A MurmurHash2 的 64 位版本, 与 Redis 计算 HyperLogLog 使用的哈希一致
+// @param data []byte
+// @param seed uint64
+// @return uint64
+func murmurHash64A(data []byte, seed uint64) uint64 {
+	const m = 0xc6a4a7935bd1e995
+	const r = 47
+	h := seed ^ (uint64(len(data)) * m)
+	for len(data) >= 8 {
+		k := binary.LittleEndian.Uint64(data)
+		k *= m
+		k ^= k >> r
+		k *= m
+		h ^= k
+		h *= m
+		data = data[8:]
+	}
+	if len(data) > 0 {
+		for i := len(data) - 1; i >= 0; i-- {
+			h ^= uint64(data[i]) << (8 * i)
+		}
+		h *= m
+	}
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+	return h
+}
+
+// hllPatLen 计算元素落入的寄存器与其值
+// 哈希低p位为寄存器下标, 其余位中末尾连续0的个数加1为寄存器的值
+// @param element string
+// @param p uint8 精度
+// @return int 寄存器下标
+// @return uint8 寄存器的值, 范围 1 到 64-p+1
+func hllPatLen(element string, p uint8) (int, uint8) {
+	hash := murmurHash64A([]byte(element), hllSeed)
+	index := int(hash & (1<<p - 1))
+	hash = hash>>p | 1<<(64-p)
+	return index, uint8(bits.TrailingZeros64(hash) + 1)
+}
+
+// hllObject 单个 HyperLogLog 的值
+// 基数较小时使用稀疏编码, 只记录非0寄存器; 超过阈值后转换为每个寄存器一个字节的稠密编码
+type hllObject struct {
+	// 精度, 寄存器数量为 2^p
+	p uint8
+	// 稀疏编码: 按下标升序排列的非0寄存器, 每项为 下标<<8 | 值; dense 非nil时不使用
+	sparse []uint32
+	// 稠密编码
+	dense []uint8
+	// 缓存的基数加1, 0表示缓存无效; 读锁下的并发计数会写入, 因此使用原子操作
+	cachedCard atomic.Uint64
+}
+
+// newHLLObject 创建空的 HyperLogLog
+// @param p uint8 精度
+// @return *hllObject
+func newHLLObject(p uint8) *hllObject {
+	return &hllObject{p: p}
+}
+
+// m 寄存器数量
+// @return int
+func (h *hllObject) m() int {
+	return 1 << h.p
+}
+
+// encoding 当前编码名称
+// @return string
+func (h *hllObject) encoding() string {
+	if h.dense != nil {
+		return "dense"
+	}
+	return "sparse"
+}
+
+// get 获取寄存器的值
+// @param index int
+// @return uint8
+func (h *hllObject) get(index int) uint8 {
+	if h.dense != nil {
+		return h.dense[index]
+	}
+	if i, found := h.searchSparse(index); found {
+		return uint8(h.sparse[i])
+	}
+	return 0
+}
+
+// searchSparse 在稀疏编码中查找寄存器
+// @param index int
+// @return int 位置
+// @return bool 是否存在
+func (h *hllObject) searchSparse(index int) (int, bool) {
+	return slices.BinarySearchFunc(h.sparse, uint32(index), func(e, target uint32) int {
+		return int(e>>8) - int(target)
+	})
+}
+
+// set 寄存器取 max(当前值, value), 稀疏编码超过阈值时转换为稠密编码
+// @param index int
+// @param value uint8
+// @return bool 寄存器是否改变
+func (h *hllObject) set(index int, value uint8) bool {
+	if h.dense != nil {
+		if h.dense[index] >= value {
+			return false
+		}
+		h.dense[index] = value
+		h.cachedCard.Store(0)
+		return true
+	}
+	i, found := h.searchSparse(index)
+	if found {
+		if uint8(h.sparse[i]) >= value {
+			return false
+		}
+		h.sparse[i] = uint32(index)<<8 | uint32(value)
+		h.cachedCard.Store(0)
+		return true
+	}
+	h.sparse = slices.Insert(h.sparse, i, uint32(index)<<8|uint32(value))
+	h.cachedCard.Store(0)
+	if 4*len(h.sparse) > encodingLimits.HLLSparseMaxBytes || 4*len(h.sparse) >= h.m() {
+		h.promote()
+	}
+	return true
+}
+
+// add 添加元素
+// @param element string
+// @return bool 是否有寄存器改变
+func (h *hllObject) add(element string) bool {
+	index, value := hllPatLen(element, h.p)
+	return h.set(index, value)
+}
+
+// promote 转换为稠密编码
+func (h *hllObject) promote() {
+	h.dense = h.registers()
+	h.sparse = nil
+}
+
+// registers 导出全部寄存器
+// @return []uint8 新分配的寄存器数组
+func (h *hllObject) registers() []uint8 {
+	regs := make([]uint8, h.m())
+	if h.dense != nil {
+		copy(regs, h.dense)
+		return regs
+	}
+	for _, e := range h.sparse {
+		regs[e>>8] = uint8(e)
+	}
+	return regs
+}
+
+// foldInto 将寄存器按最大值合并进精度不高于自身的寄存器数组
+// 精度从p降到q时, 下标的高 p-q 位成为剩余哈希的低位, 据此重新计算寄存器的值
+// @param regs []uint8 目标寄存器, 长度为 2^q
+// @param q uint8 目标精度
+func (h *hllObject) foldInto(regs []uint8, q uint8) {
+	shift := h.p - q
+	merge := func(index int, value uint8) {
+		if value == 0 {
+			return
+		}
+		if high := index >> q; high != 0 {
+			value = uint8(bits.TrailingZeros64(uint64(high)) + 1)
+		} else {
+			value += shift
+		}
+		if low := index & (1<<q - 1); regs[low] < value {
+			regs[low] = value
+		}
+	}
+	if h.dense != nil {
+		for i, v := range h.dense {
+			merge(i, v)
+		}
+		return
+	}
+	for _, e := range h.sparse {
+		merge(int(e>>8), uint8(e))
+	}
+}
+
+// hllSigma Ertl 改进估计中对值为0的寄存器的修正项
+// @param x float64
+// @return float64
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		zPrime := z
+		z += x * y
+		y += y
+		if z == zPrime {
+			return z
+		}
+	}
+}
+
+// hllTau Ertl 改进估计中对值达到上限的寄存器的修正项
+// @param x float64
+// @return float64
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		zPrime := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+		if z == zPrime {
+			return z / 3
+		}
+	}
+}
+
+// hllEstimate 使用 Ertl 的改进估计方法由寄存器直方图计算基数
+// 该方法在小基数与大基数下都无需额外的线性计数或偏差修正
+// @param hist []int 值为k的寄存器数量, 长度为 64-p+2
+// @param p uint8 精度
+// @return uint64
+func hllEstimate(hist []int, p uint8) uint64 {
+	q := 64 - int(p)
+	m := float64(int(1) << p)
+	z := m * hllTau((m-float64(hist[q+1]))/m)
+	for k := q; k >= 1; k-- {
+		z += float64(hist[k])
+		z *= 0.5
+	}
+	z += m * hllSigma(float64(hist[0])/m)
+	return uint64(math.Round(0.5 / math.Ln2 * m * m / z))
+}
+
+// hllHistogram 统计寄存器数组的直方图
+// @param regs []uint8
+// @param p uint8 精度
+// @return []int
+func hllHistogram(regs []uint8, p uint8) []int {
+	hist := make([]int, 64-int(p)+2)
+	for _, v := range regs {
+		hist[v]++
+	}
+	return hist
+}
+
+// cacheCount 缓存基数, 寄存器改变后失效
+// @param card uint64
+func (h *hllObject) cacheCount(card uint64) {
+	h.cachedCard.Store(card + 1)
+}
+
+// count 估算基数, 优先使用缓存
+// @return uint64
+func (h *hllObject) count() uint64 {
+	if c := h.cachedCard.Load(); c != 0 {
+		return c - 1
+	}
+	var card uint64
+	if h.dense != nil {
+		card = hllEstimate(hllHistogram(h.dense, h.p), h.p)
+	} else {
+		hist := make([]int, 64-int(h.p)+2)
+		hist[0] = h.m() - len(h.sparse)
+		for _, e := range h.sparse {
+			hist[uint8(e)]++
+		}
+		card = hllEstimate(hist, h.p)
+	}
+	h.cacheCount(card)
+	return card
+}
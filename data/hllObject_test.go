@@ -0,0 +1,48 @@
+package data
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// TestHLLDenseCountCache 稠密编码的寄存器改变后缓存的基数失效
+func TestHLLDenseCountCache(t *testing.T) {
+	h := newHLLObject(hllDefaultPrecision)
+	for i := 0; i < 2000; i++ {
+		h.add("a" + strconv.Itoa(i))
+	}
+	if h.encoding() != "dense" {
+		t.Fatalf("编码为 %s, 期望 dense", h.encoding())
+	}
+	before := h.count()
+	for i := 0; i < 18000; i++ {
+		h.add("b" + strconv.Itoa(i))
+	}
+	after := h.count()
+	if after < 19000 || after > 21000 {
+		t.Fatalf("添加前 %d, 添加后 %d, 期望约 20000", before, after)
+	}
+}
+
+// TestDecodeRedisHLLRegisterRange 稠密编码中超出范围的寄存器值被拒绝
+func TestDecodeRedisHLLRegisterRange(t *testing.T) {
+	h := newHLLObject(redisHLLPrecision)
+	h.promote()
+	h.dense[0] = 64 - redisHLLPrecision + 1
+	b, err := encodeRedisHLL(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodeRedisHLL(b); err != nil {
+		t.Fatalf("合法的寄存器值被拒绝: %v", err)
+	}
+	h.dense[0] = 63
+	b, err = encodeRedisHLL(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodeRedisHLL(b); !errors.Is(err, errRedisHLL) {
+		t.Fatalf("期望 errRedisHLL, 得到 %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package data
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+// intSet 紧凑整数集合
+// 所有元素按升序以相同宽度(2/4/8字节, 小端序)连续存放在一个字节数组中,
+// 插入超出当前宽度的元素时整体升级宽度
+type intSet struct {
+	// 每个元素占用的字节数
+	width int
+	// 元素数据
+	buf []byte
+}
+
+// newIntSet 创建空的整数集合
+// @return *intSet
+func newIntSet() *intSet {
+	return &intSet{width: 2}
+}
+
+// parseSetInt 判断成员是否可以用整数集合编码
+// 只接受与 strconv.FormatInt 输出完全一致的写法, 保证还原后的字符串不变
+// @param member string 成员
+// @return int64 整数值
+// @return bool 是否可编码
+func parseSetInt(member string) (int64, bool) {
+	v, err := strconv.ParseInt(member, 10, 64)
+	if err != nil || strconv.FormatInt(v, 10) != member {
+		return 0, false
+	}
+	return v, true
+}
+
+// intWidth 计算存放整数所需的最小宽度
+// @param v int64
+// @return int 字节数
+func intWidth(v int64) int {
+	switch {
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		return 2
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// len 元素数量
+// @return int
+func (s *intSet) len() int {
+	return len(s.buf) / s.width
+}
+
+// get 获取第i个元素
+// @param i int 下标
+// @return int64
+func (s *intSet) get(i int) int64 {
+	return readInt(s.buf[i*s.width:], s.width)
+}
+
+// search 二分查找元素
+// @param v int64
+// @return int 元素下标或应插入的位置
+// @return bool 是否存在
+func (s *intSet) search(v int64) (int, bool) {
+	lo, hi := 0, s.len()
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if s.get(mid) < v {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, lo < s.len() && s.get(lo) == v
+}
+
+// has 判断元素是否存在
+// @param v int64
+// @return bool
+func (s *intSet) has(v int64) bool {
+	_, ok := s.search(v)
+	return ok
+}
+
+// add 插入元素
+// @param v int64
+// @return bool 是否为新元素
+func (s *intSet) add(v int64) bool {
+	if w := intWidth(v); w > s.width {
+		s.upgrade(w)
+	}
+	pos, ok := s.search(v)
+	if ok {
+		return false
+	}
+	off := pos * s.width
+	s.buf = append(s.buf, make([]byte, s.width)...)
+	copy(s.buf[off+s.width:], s.buf[off:])
+	writeInt(s.buf[off:], s.width, v)
+	return true
+}
+
+// remove 删除元素
+// @param v int64
+// @return bool 元素是否存在
+func (s *intSet) remove(v int64) bool {
+	if intWidth(v) > s.width {
+		return false
+	}
+	pos, ok := s.search(v)
+	if !ok {
+		return false
+	}
+	off := pos * s.width
+	s.buf = append(s.buf[:off], s.buf[off+s.width:]...)
+	return true
+}
+
+// upgrade 将所有元素升级到更大的宽度
+// @param width int 新宽度
+func (s *intSet) upgrade(width int) {
+	n := s.len()
+	buf := make([]byte, n*width)
+	for i := 0; i < n; i++ {
+		writeInt(buf[i*width:], width, s.get(i))
+	}
+	s.width = width
+	s.buf = buf
+}
+
+// readInt 按宽度读取小端序整数
+func readInt(b []byte, width int) int64 {
+	switch width {
+	case 2:
+		return int64(int16(binary.LittleEndian.Uint16(b)))
+	case 4:
+		return int64(int32(binary.LittleEndian.Uint32(b)))
+	default:
+		return int64(binary.LittleEndian.Uint64(b))
+	}
+}
+
+// writeInt 按宽度写入小端序整数
+func writeInt(b []byte, width int, v int64) {
+	switch width {
+	case 2:
+		binary.LittleEndian.PutUint16(b, uint16(v))
+	case 4:
+		binary.LittleEndian.PutUint32(b, uint32(v))
+	default:
+		binary.LittleEndian.PutUint64(b, uint64(v))
+	}
+}
@@ -0,0 +1,358 @@
+package data
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+)
+
+// GraphNeighbor 空间查询结果中的一个节点
+type GraphNeighbor struct {
+	Node string
+	X, Y float64
+	// 到查询点的欧氏距离, 矩形查询时为到矩形中心的距离
+	Distance float64
+}
+
+// kdNode 二维KD树的节点, 偶数层按x划分, 奇数层按y划分
+type kdNode struct {
+	name        string
+	p           [2]float64
+	left, right *kdNode
+	// 已删除的节点保留在树中直到重建
+	deleted bool
+}
+
+// kdCompare 按划分轴比较两个节点, 该轴坐标相同时依次比较另一轴坐标与节点名,
+// 坐标重复的节点也能分到两侧, 因此查询时与划分线坐标相同的节点两侧都可能存在
+// @param a, b *kdNode
+// @param axis int
+// @return int
+func kdCompare(a, b *kdNode, axis int) int {
+	if c := cmp.Compare(a.p[axis], b.p[axis]); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.p[1-axis], b.p[1-axis]); c != 0 {
+		return c
+	}
+	return strings.Compare(a.name, b.name)
+}
+
+// kdAlpha 替罪羊式的平衡因子: 子树大小超过父节点子树大小的 kdAlpha 倍时视为失衡
+const kdAlpha = 0.75
+
+// kdTree 节点坐标的二维KD树
+// 插入直接下沉到叶子, 新节点的深度超过 log(1/kdAlpha) 为底的节点数对数时,
+// 沿插入路径找到最低的失衡祖先(替罪羊), 只按中位数重建它的子树;
+// 删除只做标记, 已删除的节点多于存活节点时重建整棵树
+type kdTree struct {
+	root *kdNode
+	// 节点名 -> 树中存活的节点
+	index map[string]*kdNode
+	// 已标记删除的节点数
+	dead int
+}
+
+// newKDTree 创建空树
+// @return *kdTree
+func newKDTree() *kdTree {
+	return &kdTree{index: make(map[string]*kdNode)}
+}
+
+// insert 插入或移动节点
+// @param name string
+// @param p [2]float64
+func (t *kdTree) insert(name string, p [2]float64) {
+	t.remove(name)
+	n := &kdNode{name: name, p: p}
+	t.index[name] = n
+	if t.root == nil {
+		t.root = n
+		return
+	}
+	// 新节点的全部祖先, path[i] 位于第i层
+	path := []*kdNode{}
+	cur := t.root
+	for {
+		path = append(path, cur)
+		axis := (len(path) - 1) % 2
+		next := &cur.right
+		if kdCompare(n, cur, axis) < 0 {
+			next = &cur.left
+		}
+		if *next == nil {
+			*next = n
+			break
+		}
+		cur = *next
+	}
+	size := len(t.index) + t.dead
+	if float64(len(path)) > math.Log(float64(size))/math.Log(1/kdAlpha) {
+		t.rebuildScapegoat(path, n)
+	}
+}
+
+// rebuildScapegoat 从新节点向上找到第一个失衡的祖先, 重建以它为根的子树
+// 新节点过深时这样的祖先一定存在
+// @param path []*kdNode 新节点的祖先, path[i] 位于第i层
+// @param n *kdNode 新节点
+func (t *kdTree) rebuildScapegoat(path []*kdNode, n *kdNode) {
+	child, childSize := n, 1
+	for depth := len(path) - 1; depth >= 0; depth-- {
+		parent := path[depth]
+		sibling := parent.left
+		if sibling == child {
+			sibling = parent.right
+		}
+		size := 1 + childSize + subtreeSize(sibling)
+		if float64(childSize) > kdAlpha*float64(size) {
+			nodes := t.collectLive(parent, nil)
+			root := buildKD(nodes, depth)
+			switch {
+			case depth == 0:
+				t.root = root
+			case path[depth-1].left == parent:
+				path[depth-1].left = root
+			default:
+				path[depth-1].right = root
+			}
+			return
+		}
+		child, childSize = parent, size
+	}
+}
+
+// subtreeSize 子树中的节点数, 包括已标记删除的节点
+// @param n *kdNode
+// @return int
+func subtreeSize(n *kdNode) int {
+	if n == nil {
+		return 0
+	}
+	return 1 + subtreeSize(n.left) + subtreeSize(n.right)
+}
+
+// collectLive 拆开子树, 收集其中存活的节点并丢弃已删除的节点
+// @param n *kdNode
+// @param nodes []*kdNode
+// @return []*kdNode
+func (t *kdTree) collectLive(n *kdNode, nodes []*kdNode) []*kdNode {
+	if n == nil {
+		return nodes
+	}
+	left, right := n.left, n.right
+	n.left, n.right = nil, nil
+	if n.deleted {
+		t.dead--
+	} else {
+		nodes = append(nodes, n)
+	}
+	nodes = t.collectLive(left, nodes)
+	return t.collectLive(right, nodes)
+}
+
+// remove 删除节点
+// @param name string
+// @return bool 节点是否存在
+func (t *kdTree) remove(name string) bool {
+	n, exists := t.index[name]
+	if !exists {
+		return false
+	}
+	n.deleted = true
+	delete(t.index, name)
+	t.dead++
+	if t.dead > len(t.index) {
+		t.rebuild()
+	}
+	return true
+}
+
+// rebuild 用存活节点按中位数重建整棵树
+func (t *kdTree) rebuild() {
+	t.root = buildKD(t.collectLive(t.root, nil), 0)
+}
+
+// buildKD 递归构建平衡的子树, 按 kdCompare 取中位数, 同一组节点总是得到同一棵树
+// @param nodes []*kdNode
+// @param depth int
+// @return *kdNode
+func buildKD(nodes []*kdNode, depth int) *kdNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	axis := depth % 2
+	slices.SortFunc(nodes, func(a, b *kdNode) int {
+		return kdCompare(a, b, axis)
+	})
+	mid := len(nodes) / 2
+	root := nodes[mid]
+	root.left = buildKD(nodes[:mid], depth+1)
+	root.right = buildKD(nodes[mid+1:], depth+1)
+	return root
+}
+
+// neighbor 生成查询结果
+// @param n *kdNode
+// @param q [2]float64 查询点
+// @return GraphNeighbor
+func (n *kdNode) neighbor(q [2]float64) GraphNeighbor {
+	return GraphNeighbor{Node: n.name, X: n.p[0], Y: n.p[1], Distance: math.Hypot(n.p[0]-q[0], n.p[1]-q[1])}
+}
+
+// sortNeighbors 按距离升序、名称字典序排列
+// @param result []GraphNeighbor
+func sortNeighbors(result []GraphNeighbor) {
+	slices.SortFunc(result, func(a, b GraphNeighbor) int {
+		if c := cmp.Compare(a.Distance, b.Distance); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Node, b.Node)
+	})
+}
+
+// nearest 查找距离查询点最近的k个节点
+// @param q [2]float64
+// @param k int
+// @return []GraphNeighbor 按距离升序
+func (t *kdTree) nearest(q [2]float64, k int) []GraphNeighbor {
+	best := []GraphNeighbor{}
+	if k <= 0 {
+		return best
+	}
+	var search func(n *kdNode, depth int)
+	search = func(n *kdNode, depth int) {
+		if n == nil {
+			return
+		}
+		axis := depth % 2
+		diff := q[axis] - n.p[axis]
+		near, far := n.right, n.left
+		if diff < 0 {
+			near, far = n.left, n.right
+		}
+		search(near, depth+1)
+		if !n.deleted {
+			nb := n.neighbor(q)
+			if len(best) < k || nb.Distance < best[len(best)-1].Distance {
+				i, _ := slices.BinarySearchFunc(best, nb, func(a, b GraphNeighbor) int {
+					return cmp.Compare(a.Distance, b.Distance)
+				})
+				best = slices.Insert(best, i, nb)
+				if len(best) > k {
+					best = best[:k]
+				}
+			}
+		}
+		// 划分线到查询点的距离不小于当前第k近的距离时, 另一侧不可能有更近的节点
+		if len(best) < k || math.Abs(diff) <= best[len(best)-1].Distance {
+			search(far, depth+1)
+		}
+	}
+	search(t.root, 0)
+	sortNeighbors(best)
+	return best
+}
+
+// withinRadius 查找与查询点距离不超过r的全部节点
+// @param q [2]float64
+// @param r float64
+// @return []GraphNeighbor 按距离升序
+func (t *kdTree) withinRadius(q [2]float64, r float64) []GraphNeighbor {
+	result := []GraphNeighbor{}
+	var search func(n *kdNode, depth int)
+	search = func(n *kdNode, depth int) {
+		if n == nil {
+			return
+		}
+		axis := depth % 2
+		if !n.deleted {
+			if nb := n.neighbor(q); nb.Distance <= r {
+				result = append(result, nb)
+			}
+		}
+		if q[axis]-r <= n.p[axis] {
+			search(n.left, depth+1)
+		}
+		if q[axis]+r >= n.p[axis] {
+			search(n.right, depth+1)
+		}
+	}
+	search(t.root, 0)
+	sortNeighbors(result)
+	return result
+}
+
+// withinRect 查找位于矩形(含边界)内的全部节点
+// @param lo [2]float64 左下角
+// @param hi [2]float64 右上角
+// @return []GraphNeighbor 按到矩形中心的距离升序
+func (t *kdTree) withinRect(lo, hi [2]float64) []GraphNeighbor {
+	center := [2]float64{(lo[0] + hi[0]) / 2, (lo[1] + hi[1]) / 2}
+	result := []GraphNeighbor{}
+	var search func(n *kdNode, depth int)
+	search = func(n *kdNode, depth int) {
+		if n == nil {
+			return
+		}
+		axis := depth % 2
+		if !n.deleted && n.p[0] >= lo[0] && n.p[0] <= hi[0] && n.p[1] >= lo[1] && n.p[1] <= hi[1] {
+			result = append(result, n.neighbor(center))
+		}
+		if lo[axis] <= n.p[axis] {
+			search(n.left, depth+1)
+		}
+		if hi[axis] >= n.p[axis] {
+			search(n.right, depth+1)
+		}
+	}
+	search(t.root, 0)
+	sortNeighbors(result)
+	return result
+}
+
+// Nearest 查找距离给定坐标最近的k个节点
+// @param key string 图名
+// @param x, y float64 查询点
+// @param k int 数量
+// @return []GraphNeighbor 按距离升序
+func (g *GkvGraph) Nearest(key string, x, y float64, k int) []GraphNeighbor {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []GraphNeighbor{}
+	}
+	return graph.spatial.nearest([2]float64{x, y}, k)
+}
+
+// WithinRadius 查找与给定坐标距离不超过 radius 的全部节点
+// @param key string 图名
+// @param x, y float64 查询点
+// @param radius float64 半径
+// @return []GraphNeighbor 按距离升序
+func (g *GkvGraph) WithinRadius(key string, x, y, radius float64) []GraphNeighbor {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []GraphNeighbor{}
+	}
+	return graph.spatial.withinRadius([2]float64{x, y}, radius)
+}
+
+// WithinRect 查找位于矩形(含边界)内的全部节点
+// @param key string 图名
+// @param minX, minY float64 左下角
+// @param maxX, maxY float64 右上角
+// @return []GraphNeighbor 按到矩形中心的距离升序
+func (g *GkvGraph) WithinRect(key string, minX, minY, maxX, maxY float64) []GraphNeighbor {
+	g.keyLock.RLockRow(key)
+	defer g.keyLock.RUnLockRow(key)
+	graph := g.liveGraph(key)
+	if graph == nil {
+		return []GraphNeighbor{}
+	}
+	return graph.spatial.withinRect([2]float64{minX, minY}, [2]float64{maxX, maxY})
+}
@@ -0,0 +1,107 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// kdDepth 子树的高度
+// @param n *kdNode
+// @return int
+func kdDepth(n *kdNode) int {
+	if n == nil {
+		return 0
+	}
+	return 1 + max(kdDepth(n.left), kdDepth(n.right))
+}
+
+// TestKDTreeDepth 各种插入顺序下树高都保持在替罪羊的深度上限附近
+func TestKDTreeDepth(t *testing.T) {
+	const n = 50000
+	tests := []struct {
+		name  string
+		point func(i int) [2]float64
+	}{
+		{"沿对角线递增", func(i int) [2]float64 { return [2]float64{float64(i), float64(i)} }},
+		{"沿x轴递减", func(i int) [2]float64 { return [2]float64{float64(-i), 0} }},
+		{"坐标全部相同", func(i int) [2]float64 { return [2]float64{1, 1} }},
+		{"螺旋", func(i int) [2]float64 {
+			return [2]float64{float64(i) * math.Cos(float64(i)), float64(i) * math.Sin(float64(i))}
+		}},
+	}
+	bound := int(math.Log(n)/math.Log(1/kdAlpha)) + 2
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newKDTree()
+			for i := range n {
+				tr.insert(fmt.Sprint(i), tt.point(i))
+			}
+			if d := kdDepth(tr.root); d > bound {
+				t.Fatalf("树高 %d, 超过上限 %d", d, bound)
+			}
+			if got := tr.withinRadius(tt.point(n-1), 0); len(got) == 0 {
+				t.Fatal("最后插入的节点应能被查询到")
+			}
+		})
+	}
+}
+
+// TestKDTreeQueries 随机插入、移动与删除后查询结果与穷举一致
+func TestKDTreeQueries(t *testing.T) {
+	r := rand.New(rand.NewPCG(44, 44))
+	tr := newKDTree()
+	points := map[string][2]float64{}
+	for i := range 20000 {
+		name := fmt.Sprint(r.IntN(2000))
+		if r.IntN(3) == 0 {
+			if _, exists := points[name]; tr.remove(name) != exists {
+				t.Fatalf("删除 %s 的返回值错误", name)
+			}
+			delete(points, name)
+			continue
+		}
+		p := [2]float64{float64(r.IntN(100)), float64(r.IntN(100))}
+		tr.insert(name, p)
+		points[name] = p
+		if i%500 != 0 {
+			continue
+		}
+		if len(tr.index) != len(points) || tr.dead > len(tr.index) {
+			t.Fatalf("存活 %d 个, 期望 %d 个, 已删除 %d 个", len(tr.index), len(points), tr.dead)
+		}
+		q := [2]float64{float64(r.IntN(100)), float64(r.IntN(100))}
+		within, inRect := 0, 0
+		dists := []float64{}
+		for _, p := range points {
+			d := math.Hypot(p[0]-q[0], p[1]-q[1])
+			dists = append(dists, d)
+			if d <= 10 {
+				within++
+			}
+			if p[0] >= q[0]-5 && p[0] <= q[0]+5 && p[1] >= q[1]-5 && p[1] <= q[1]+5 {
+				inRect++
+			}
+		}
+		if got := len(tr.withinRadius(q, 10)); got != within {
+			t.Fatalf("半径查询得到 %d 个, 期望 %d 个", got, within)
+		}
+		if got := len(tr.withinRect([2]float64{q[0] - 5, q[1] - 5}, [2]float64{q[0] + 5, q[1] + 5})); got != inRect {
+			t.Fatalf("矩形查询得到 %d 个, 期望 %d 个", got, inRect)
+		}
+		nearest := tr.nearest(q, 5)
+		if len(nearest) != min(5, len(points)) {
+			t.Fatalf("最近邻得到 %d 个", len(nearest))
+		}
+		closer := 0
+		for _, d := range dists {
+			if d < nearest[len(nearest)-1].Distance {
+				closer++
+			}
+		}
+		if closer >= len(nearest) {
+			t.Fatalf("有 %d 个节点比第 %d 近的结果更近", closer, len(nearest))
+		}
+	}
+}
@@ -2,6 +2,7 @@ package data
 
 import (
 	"hash/fnv"
+	"slices"
 	"sync"
 )
 
@@ -11,6 +12,9 @@ import (
 type KeyLock struct {
 	// 表锁: 维持映射关系
 	tableLock sync.Mutex
+	// 数据锁: 保护数据表本身. Go 的 map 不能并发写入, 写不同键的写者之间同样需要互斥,
+	// 因此行写锁独占持有、行读锁共享持有; 遍历数据表时由 RLockTable 共享持有, 替换或批量删除时由 LockTable 独占持有
+	dataLock sync.RWMutex
 	// 行锁: 特定键的锁
 	rowLocks map[uint32]*sync.RWMutex
 }
@@ -43,6 +47,7 @@ func NewKeyLock() *KeyLock {
 // @author xuyang
 // @datetime 2025-6-24 5:00
 func (keyLock *KeyLock) RLockRow(key string) {
+	keyLock.dataLock.RLock()
 	lockID := hashS(key)
 	keyLock.tableLock.Lock()
 	rowLock, ok := keyLock.rowLocks[lockID]
@@ -67,6 +72,7 @@ func (keyLock *KeyLock) RUnLockRow(key string) {
 		rowLock.RUnlock()
 	}
 	keyLock.tableLock.Unlock()
+	keyLock.dataLock.RUnlock()
 }
 
 // WLockRow(key string) 获取行写锁
@@ -75,6 +81,7 @@ func (keyLock *KeyLock) RUnLockRow(key string) {
 // @author xuyang
 // @datetime 2025-6-24 5:00
 func (keyLock *KeyLock) WLockRow(key string) {
+	keyLock.dataLock.Lock()
 	lockID := hashS(key)
 	keyLock.tableLock.Lock()
 	rowLock, ok := keyLock.rowLocks[lockID]
@@ -84,7 +91,7 @@ func (keyLock *KeyLock) WLockRow(key string) {
 		keyLock.rowLocks[lockID] = rowLock
 	}
 	keyLock.tableLock.Unlock()
-	rowLock.RLock()
+	rowLock.Lock()
 }
 
 // WUnLockRow(key string) 释放行写锁
@@ -96,7 +103,91 @@ func (keyLock *KeyLock) WUnLockRow(key string) {
 	lockID := hashS(key)
 	keyLock.tableLock.Lock()
 	if rowLock, ok := keyLock.rowLocks[lockID]; ok {
-		rowLock.RUnlock()
+		rowLock.Unlock()
 	}
 	keyLock.tableLock.Unlock()
+	keyLock.dataLock.Unlock()
+}
+
+// rowLocksOf 获取多个键对应的行锁, 按锁ID去重并排序
+// 多键操作统一按锁ID升序加锁, 避免相互等待造成死锁
+// @param keys []string 行键
+// @return []*sync.RWMutex 行锁
+func (keyLock *KeyLock) rowLocksOf(keys []string) []*sync.RWMutex {
+	ids := make([]uint32, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, hashS(key))
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	keyLock.tableLock.Lock()
+	defer keyLock.tableLock.Unlock()
+	locks := make([]*sync.RWMutex, len(ids))
+	for i, id := range ids {
+		rowLock, ok := keyLock.rowLocks[id]
+		if !ok {
+			rowLock = &sync.RWMutex{}
+			keyLock.rowLocks[id] = rowLock
+		}
+		locks[i] = rowLock
+	}
+	return locks
+}
+
+// RLockRows 获取多个行读锁
+// @param keys ...string 行键
+func (keyLock *KeyLock) RLockRows(keys ...string) {
+	keyLock.dataLock.RLock()
+	for _, rowLock := range keyLock.rowLocksOf(keys) {
+		rowLock.RLock()
+	}
+}
+
+// RUnLockRows 释放多个行读锁
+// @param keys ...string 行键
+func (keyLock *KeyLock) RUnLockRows(keys ...string) {
+	for _, rowLock := range keyLock.rowLocksOf(keys) {
+		rowLock.RUnlock()
+	}
+	keyLock.dataLock.RUnlock()
+}
+
+// WLockRows 获取多个行写锁
+// @param keys ...string 行键
+func (keyLock *KeyLock) WLockRows(keys ...string) {
+	keyLock.dataLock.Lock()
+	for _, rowLock := range keyLock.rowLocksOf(keys) {
+		rowLock.Lock()
+	}
+}
+
+// WUnLockRows 释放多个行写锁
+// @param keys ...string 行键
+func (keyLock *KeyLock) WUnLockRows(keys ...string) {
+	for _, rowLock := range keyLock.rowLocksOf(keys) {
+		rowLock.Unlock()
+	}
+	keyLock.dataLock.Unlock()
+}
+
+// RLockTable 获取表读锁, 用于只读地遍历整个数据表; 与行读锁共存, 与行写锁互斥
+// 持有行锁时不能调用, 否则可能死锁
+func (keyLock *KeyLock) RLockTable() {
+	keyLock.dataLock.RLock()
+}
+
+// RUnLockTable 释放表读锁
+func (keyLock *KeyLock) RUnLockTable() {
+	keyLock.dataLock.RUnlock()
+}
+
+// LockTable 获取表写锁, 用于替换整个数据表或批量删除键; 与所有行锁互斥
+// 持有行锁时不能调用, 否则会死锁
+func (keyLock *KeyLock) LockTable() {
+	keyLock.dataLock.Lock()
+}
+
+// UnLockTable 释放表写锁
+func (keyLock *KeyLock) UnLockTable() {
+	keyLock.dataLock.Unlock()
 }
@@ -0,0 +1,210 @@
+package data
+
+import (
+	"encoding/hex"
+	"errors"
+	"sort"
+	"time"
+)
+
+// errScanCursor SCAN 游标格式错误
+var errScanCursor = errors.New("游标无效")
+
+// keyedStore 按键存储的数据类型需实现的接口, 用于跨类型的键空间操作
+type keyedStore interface {
+	// liveKeys 返回所有未过期的键
+	liveKeys() []string
+	// deleteKey 删除整个键, 返回键是否存在
+	deleteKey(key string) bool
+	// removeExpired 删除所有已过期的键, 返回被删除的键
+	removeExpired() []string
+}
+
+// keyedStores 参与键空间操作的全部数据类型
+var keyedStores = []keyedStore{
+	DataGkvString,
+	DataGkvList,
+	DataGkvSet,
+	DataGkvMap,
+	DataGkvZSet,
+	DataGkvBitMap,
+	DataGkvHyperLoglog,
+	DataGkvGraph,
+	DataGkvStream,
+}
+
+// collectLiveKeys 收集某个数据表中所有未过期的键
+// 遍历期间持有表读锁, 与写入互斥
+// @param keyLock *KeyLock 锁实例
+// @param data map[string]V 数据表
+// @param expireTimes map[string]time.Time 过期时间表
+// @return []string 未过期的键
+func collectLiveKeys[V any](keyLock *KeyLock, data map[string]V, expireTimes map[string]time.Time) []string {
+	keyLock.RLockTable()
+	defer keyLock.RUnLockTable()
+	now := time.Now()
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if expireTime, exists := expireTimes[key]; exists && now.After(expireTime) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// removeKey 从某个数据表中删除整个键
+// @param keyLock *KeyLock 锁实例
+// @param data map[string]V 数据表
+// @param expireTimes map[string]time.Time 过期时间表
+// @param key string 键
+// @return bool 键是否存在
+func removeKey[V any](keyLock *KeyLock, data map[string]V, expireTimes map[string]time.Time, key string) bool {
+	keyLock.WLockRow(key)
+	defer keyLock.WUnLockRow(key)
+	_, exists := data[key]
+	delete(data, key)
+	delete(expireTimes, key)
+	if exists {
+		notifyKeyspaceEvent(NotifyGeneric, "del", key)
+	}
+	return exists
+}
+
+// removeExpiredKeys 删除某个数据表中所有已过期的键, 并发出 expired 通知
+// 持有表写锁, 期间没有其他读写
+// @param keyLock *KeyLock 锁实例
+// @param data map[string]V 数据表
+// @param expireTimes map[string]time.Time 过期时间表
+// @return []string 被删除的键
+func removeExpiredKeys[V any](keyLock *KeyLock, data map[string]V, expireTimes map[string]time.Time) []string {
+	keyLock.LockTable()
+	defer keyLock.UnLockTable()
+	now := time.Now()
+	removed := []string{}
+	for key, expireTime := range expireTimes {
+		if now.After(expireTime) {
+			delete(data, key)
+			delete(expireTimes, key)
+			removed = append(removed, key)
+			notifyKeyspaceEvent(NotifyExpired, "expired", key)
+		}
+	}
+	return removed
+}
+
+// ActiveExpireCycle 主动删除所有数据类型中已过期的键
+// 其余情况下过期的键只在被写入时才真正删除, 主动删除保证过期的键总会发出 expired 通知
+// 每个数据表在表锁下处理, 可与其他 goroutine 的读写并发调用(命令行在每次输入提示前调用)
+// @return int 被删除的键数量
+func ActiveExpireCycle() int {
+	removed := 0
+	for _, store := range keyedStores {
+		removed += len(store.removeExpired())
+	}
+	return removed
+}
+
+// allKeys 获取所有数据类型中未过期的键(去重并排序)
+// @return []string 所有键
+func allKeys() []string {
+	seen := make(map[string]struct{})
+	keys := []string{}
+	for _, store := range keyedStores {
+		for _, key := range store.liveKeys() {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Keys 获取所有数据类型中匹配模式的键
+// @param pattern string glob模式
+// @return []string 匹配的键(按字典序)
+func Keys(pattern string) []string {
+	result := []string{}
+	for _, key := range allKeys() {
+		if GlobMatch(pattern, key, false) {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// Scan 增量遍历键空间
+// 键按字典序遍历, 游标记录本次检查的最后一个键, 下一次从大于它的第一个键继续,
+// 因此遍历期间一直存在的键总会被返回, 不受其他键增删的影响
+// 每次最多检查count个键, 返回的键可能少于count
+// @param cursor string 游标, "0"表示开始新的遍历, 否则为上一次返回的游标
+// @param pattern string glob模式, 为空时匹配所有键
+// @param count int 本次检查的键数量
+// @return string 下一次调用的游标, "0"表示遍历结束
+// @return []string 本次匹配的键
+// @return error 游标无效时返回错误
+func Scan(cursor string, pattern string, count int) (string, []string, error) {
+	keys := allKeys()
+	if count <= 0 {
+		count = 10
+	}
+	start := 0
+	if cursor != "0" {
+		last, err := decodeScanCursor(cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		start = sort.Search(len(keys), func(i int) bool { return keys[i] > last })
+	}
+	end := min(start+count, len(keys))
+	result := []string{}
+	for _, key := range keys[start:end] {
+		if pattern == "" || GlobMatch(pattern, key, false) {
+			result = append(result, key)
+		}
+	}
+	if end == len(keys) {
+		return "0", result, nil
+	}
+	return encodeScanCursor(keys[end-1]), result, nil
+}
+
+// encodeScanCursor 将键编码为游标: "1" 加上键的十六进制形式, 长度为奇数, 不会与 "0" 混淆
+// @param key string
+// @return string
+func encodeScanCursor(key string) string {
+	return "1" + hex.EncodeToString([]byte(key))
+}
+
+// decodeScanCursor 由游标解析出上一次检查的最后一个键
+// @param cursor string
+// @return string
+// @return error
+func decodeScanCursor(cursor string) (string, error) {
+	if len(cursor) == 0 || cursor[0] != '1' {
+		return "", errScanCursor
+	}
+	key, err := hex.DecodeString(cursor[1:])
+	if err != nil {
+		return "", errScanCursor
+	}
+	return string(key), nil
+}
+
+// DeleteMatch 删除所有数据类型中匹配模式的键
+// @param pattern string glob模式
+// @return int 被删除的键数量
+func DeleteMatch(pattern string) int {
+	deleted := make(map[string]struct{})
+	for _, store := range keyedStores {
+		for _, key := range store.liveKeys() {
+			if GlobMatch(pattern, key, false) && store.deleteKey(key) {
+				deleted[key] = struct{}{}
+			}
+		}
+	}
+	return len(deleted)
+}
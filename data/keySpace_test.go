@@ -0,0 +1,154 @@
+package data
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestGlobMatch Redis 风格的 glob 模式
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, str string
+		nocase       bool
+		want         bool
+	}{
+		{"*", "", false, true},
+		{"*", "abc", false, true},
+		{"**", "x", false, true},
+		{"a*c", "abbbc", false, true},
+		{"a*c", "abbbd", false, false},
+		{"*a*b*c*", "xxaxxbxxcxx", false, true},
+		{"user:*:name", "user:42:name", false, true},
+		{"h?llo", "hello", false, true},
+		{"h?llo", "hllo", false, false},
+		{"?*", "", false, false},
+		{"h[ae]llo", "hallo", false, true},
+		{"h[ae]llo", "hillo", false, false},
+		{"h[^e]llo", "hallo", false, true},
+		{"h[^e]llo", "hello", false, false},
+		{"h[a-b]llo", "hbllo", false, true},
+		{"h[b-a]llo", "hbllo", false, true},
+		{`[\]]`, "]", false, true},
+		{`\*`, "*", false, true},
+		{`\*`, "a", false, false},
+		{`a\`, `a\`, false, true},
+		{"[abc", "b", false, true},
+		{"HELLO", "hello", true, true},
+		{"HELLO", "hello", false, false},
+		{"[A-Z]", "q", true, true},
+	}
+	for _, tt := range tests {
+		if got := GlobMatch(tt.pattern, tt.str, tt.nocase); got != tt.want {
+			t.Errorf("GlobMatch(%q, %q, %v) = %v, 期望 %v", tt.pattern, tt.str, tt.nocase, got, tt.want)
+		}
+	}
+}
+
+// TestScanCursor 游标为 "1" 加上键的十六进制形式, 其他形式的游标无效
+func TestScanCursor(t *testing.T) {
+	for _, key := range []string{"", "0", "a", "user:1", "中文", "\x00\xff"} {
+		cursor := encodeScanCursor(key)
+		if cursor == "0" || len(cursor)%2 != 1 {
+			t.Fatalf("键 %q 的游标 %q 可能与 \"0\" 混淆", key, cursor)
+		}
+		if got, err := decodeScanCursor(cursor); err != nil || got != key {
+			t.Fatalf("游标 %q 解析为 %q %v, 期望 %q", cursor, got, err, key)
+		}
+	}
+	for _, cursor := range []string{"", "5", "2ab", "10", "1zz", "161 "} {
+		if _, err := decodeScanCursor(cursor); !errors.Is(err, errScanCursor) {
+			t.Fatalf("游标 %q 应无效, 得到 %v", cursor, err)
+		}
+	}
+}
+
+// scanAll 用 Scan 完整遍历一次键空间
+// @param t *testing.T
+// @param pattern string
+// @param count int
+// @param between func() 每次调用 Scan 之间执行, 可为nil
+// @return []string 返回的全部键
+func scanAll(t *testing.T, pattern string, count int, between func()) []string {
+	t.Helper()
+	keys := []string{}
+	cursor := "0"
+	for range 1000 {
+		next, batch, err := Scan(cursor, pattern, count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, batch...)
+		if next == "0" {
+			return keys
+		}
+		cursor = next
+		if between != nil {
+			between()
+		}
+	}
+	t.Fatal("遍历没有结束")
+	return nil
+}
+
+// TestScan 跨类型遍历、模式过滤, 以及遍历期间增删其他键时一直存在的键不会被跳过
+func TestScan(t *testing.T) {
+	t.Cleanup(func() { DeleteMatch("*") })
+	DeleteMatch("*")
+	DataGkvString.Set("0", []byte("v"))
+	DataGkvString.Set("user:1", []byte("v"))
+	DataGkvSet.Add("user:2", "m")
+	DataGkvZSet.Add("other", "m", 1)
+	DataGkvMap.MSet("user:3", "f", "v")
+	// 同名的键出现在多个类型中时只返回一次
+	DataGkvSet.Add("other", "m")
+
+	all := "0,other,user:1,user:2,user:3"
+	tests := []struct {
+		name    string
+		pattern string
+		count   int
+		want    string
+	}{
+		{"逐个遍历", "", 1, all},
+		{"每次两个", "", 2, all},
+		{"count小于等于0时使用默认值", "", 0, all},
+		{"模式过滤", "user:*", 2, "user:1,user:2,user:3"},
+		{"没有匹配", "nope*", 2, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(scanAll(t, tt.pattern, tt.count, nil), ","); got != tt.want {
+				t.Fatalf("得到 %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+
+	if cursor, keys, err := Scan("0", "", 2); err != nil || cursor != encodeScanCursor("other") || strings.Join(keys, ",") != "0,other" {
+		t.Fatalf("第一次遍历得到 %q %v %v", cursor, keys, err)
+	}
+	if _, _, err := Scan("5", "", 2); !errors.Is(err, errScanCursor) {
+		t.Fatalf("无效的游标应返回 errScanCursor, 得到 %v", err)
+	}
+
+	// 前几次调用之间删除已返回的键, 并在游标前后添加新键, 原有的键仍全部返回且不重复
+	calls := 0
+	got := scanAll(t, "", 1, func() {
+		calls++
+		if calls > 3 {
+			return
+		}
+		DataGkvString.Delete("0")
+		DataGkvString.Set("user:1"+strings.Repeat("x", calls), []byte("v"))
+		DataGkvString.Set("a"+strings.Repeat("x", calls), []byte("v"))
+	})
+	for _, key := range []string{"other", "user:1", "user:2", "user:3"} {
+		if !slices.Contains(got, key) {
+			t.Fatalf("遍历期间一直存在的键 %s 被跳过: %v", key, got)
+		}
+	}
+	if len(slices.Compact(slices.Clone(got))) != len(got) {
+		t.Fatalf("遍历返回了重复的键: %v", got)
+	}
+}
@@ -0,0 +1,77 @@
+package data
+
+import (
+	"encoding/binary"
+)
+
+// listpack 紧凑列表
+// 所有条目连续存放在一个字节数组中, 每个条目为 uvarint长度 + 内容,
+// 适合元素很少时代替哈希表以节省内存, 查找为线性扫描
+type listpack struct {
+	// 条目数据
+	buf []byte
+	// 条目数量
+	count int
+}
+
+// entry 读取offset处的条目
+// @param off int 条目起始偏移
+// @return []byte 条目内容(引用内部数组, 修改前需复制)
+// @return int 下一条目的起始偏移
+func (lp *listpack) entry(off int) ([]byte, int) {
+	n, sz := binary.Uvarint(lp.buf[off:])
+	start := off + sz
+	end := start + int(n)
+	return lp.buf[start:end], end
+}
+
+// encodeEntries 编码若干条目
+// @param entries ...[]byte
+// @return []byte
+func encodeEntries(entries ...[]byte) []byte {
+	size := 0
+	for _, e := range entries {
+		size += binary.MaxVarintLen64 + len(e)
+	}
+	out := make([]byte, 0, size)
+	for _, e := range entries {
+		out = binary.AppendUvarint(out, uint64(len(e)))
+		out = append(out, e...)
+	}
+	return out
+}
+
+// insert 在offset处插入若干条目
+// @param off int 插入位置
+// @param entries ...[]byte 条目内容
+func (lp *listpack) insert(off int, entries ...[]byte) {
+	enc := encodeEntries(entries...)
+	lp.buf = append(lp.buf, enc...)
+	copy(lp.buf[off+len(enc):], lp.buf[off:])
+	copy(lp.buf[off:], enc)
+	lp.count += len(entries)
+}
+
+// cut 删除 [off, end) 区间内的n个条目
+// @param off int 起始偏移
+// @param end int 结束偏移
+// @param n int 区间内的条目数量
+func (lp *listpack) cut(off, end, n int) {
+	lp.buf = append(lp.buf[:off], lp.buf[end:]...)
+	lp.count -= n
+}
+
+// replace 用新内容替换offset处的单个条目
+// @param off int 条目起始偏移
+// @param value []byte 新内容
+func (lp *listpack) replace(off int, value []byte) {
+	_, end := lp.entry(off)
+	lp.cut(off, end, 1)
+	lp.insert(off, value)
+}
+
+// size 编码后占用的字节数
+// @return int
+func (lp *listpack) size() int {
+	return len(lp.buf)
+}
@@ -0,0 +1,136 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// NotifyClass 键空间通知的事件类别, 与 Redis 的 notify-keyspace-events 相同按位组合
+type NotifyClass uint32
+
+const (
+	// NotifyKeyspace K: 发布到 __keyspace@0__:<key>, 消息为事件名
+	NotifyKeyspace NotifyClass = 1 << iota
+	// NotifyKeyevent E: 发布到 __keyevent@0__:<event>, 消息为键名
+	NotifyKeyevent
+	// NotifyGeneric g: 与类型无关的命令, 如 del、expire
+	NotifyGeneric
+	// NotifyString $: 字符串命令, 位图与 HyperLogLog 同样属于此类
+	NotifyString
+	// NotifyList l: 链表命令
+	NotifyList
+	// NotifySet s: 集合命令
+	NotifySet
+	// NotifyHash h: 映射命令
+	NotifyHash
+	// NotifyZSet z: 有序集合命令, 包括地理位置命令
+	NotifyZSet
+	// NotifyExpired x: 键过期被删除
+	NotifyExpired
+	// NotifyEvicted e: 键因内存不足被淘汰, 目前没有淘汰机制, 不会产生此类事件
+	NotifyEvicted
+	// NotifyStream t: 流命令
+	NotifyStream
+	// NotifyGraph d: 图命令, 与 Redis 中模块类型的类别相同
+	NotifyGraph
+)
+
+// NotifyAll A: g$lshzxetd 的别名
+const NotifyAll = NotifyGeneric | NotifyString | NotifyList | NotifySet | NotifyHash | NotifyZSet |
+	NotifyExpired | NotifyEvicted | NotifyStream | NotifyGraph
+
+// notifyFlagChars 类别与配置字符的对应关系, 按 Redis 输出的顺序
+var notifyFlagChars = []struct {
+	class NotifyClass
+	char  byte
+}{
+	{NotifyGeneric, 'g'},
+	{NotifyString, '$'},
+	{NotifyList, 'l'},
+	{NotifySet, 's'},
+	{NotifyHash, 'h'},
+	{NotifyZSet, 'z'},
+	{NotifyExpired, 'x'},
+	{NotifyEvicted, 'e'},
+	{NotifyStream, 't'},
+	{NotifyGraph, 'd'},
+	{NotifyKeyspace, 'K'},
+	{NotifyKeyevent, 'E'},
+}
+
+// notifyFlags 当前开启的事件类别, 默认全部关闭
+var notifyFlags atomic.Uint32
+
+// ParseNotifyFlags 解析 g$lshzxetdKE 风格的配置, A 表示 g$lshzxetd
+// @param s string
+// @return NotifyClass
+// @return error 含有未知字符时返回错误
+func ParseNotifyFlags(s string) (NotifyClass, error) {
+	var flags NotifyClass
+	for i := 0; i < len(s); i++ {
+		if s[i] == 'A' {
+			flags |= NotifyAll
+			continue
+		}
+		found := false
+		for _, f := range notifyFlagChars {
+			if f.char == s[i] {
+				flags |= f.class
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("未知的键空间通知类别: %c", s[i])
+		}
+	}
+	return flags, nil
+}
+
+// String 配置字符形式, 包含全部类型类别时以 A 代替
+// @return string
+func (flags NotifyClass) String() string {
+	var sb strings.Builder
+	if flags&NotifyAll == NotifyAll {
+		sb.WriteByte('A')
+	}
+	for _, f := range notifyFlagChars {
+		if flags&f.class == 0 || (f.class&NotifyAll != 0 && flags&NotifyAll == NotifyAll) {
+			continue
+		}
+		sb.WriteByte(f.char)
+	}
+	return sb.String()
+}
+
+// SetNotifyFlags 设置开启的事件类别
+// 与 Redis 相同, 只有同时开启 K 或 E 之一以及至少一个类型类别时才会发出通知
+// @param flags NotifyClass
+func SetNotifyFlags(flags NotifyClass) {
+	notifyFlags.Store(uint32(flags))
+}
+
+// NotifyFlags 获取开启的事件类别
+// @return NotifyClass
+func NotifyFlags() NotifyClass {
+	return NotifyClass(notifyFlags.Load())
+}
+
+// notifyKeyspaceEvent 发出键空间通知, 通过 DataPubSub 发布
+// 可以在持有键锁时调用: 发布不会等待订阅者, 也不会获取任何键锁
+// @param class NotifyClass 事件所属的类型类别
+// @param event string 事件名, 如 set、del、expired
+// @param key string 键
+func notifyKeyspaceEvent(class NotifyClass, event, key string) {
+	flags := NotifyFlags()
+	if flags&class == 0 {
+		return
+	}
+	if flags&NotifyKeyspace != 0 {
+		DataPubSub.Publish("__keyspace@0__:"+key, event)
+	}
+	if flags&NotifyKeyevent != 0 {
+		DataPubSub.Publish("__keyevent@0__:"+event, key)
+	}
+}
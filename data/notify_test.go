@@ -0,0 +1,80 @@
+package data
+
+import (
+	"testing"
+)
+
+// TestParseNotifyFlags 配置字符的解析、A 的展开与输出形式
+func TestParseNotifyFlags(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  NotifyClass
+		str   string
+		isErr bool
+	}{
+		{"", 0, "", false},
+		{"A", NotifyAll, "A", false},
+		{"g$lshzxetd", NotifyAll, "A", false},
+		{"KEA", NotifyAll | NotifyKeyspace | NotifyKeyevent, "AKE", false},
+		{"Ae", NotifyAll, "A", false},
+		{"Kg", NotifyKeyspace | NotifyGeneric, "gK", false},
+		{"E$z", NotifyKeyevent | NotifyString | NotifyZSet, "$zE", false},
+		{"gg", NotifyGeneric, "g", false},
+		{"g$lshzxet", NotifyAll &^ NotifyGraph, "g$lshzxet", false},
+		{"KE", NotifyKeyspace | NotifyKeyevent, "KE", false},
+		{"a", 0, "", true},
+		{"KQ", 0, "", true},
+		{"K ", 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			flags, err := ParseNotifyFlags(tt.in)
+			if (err != nil) != tt.isErr || flags != tt.want {
+				t.Fatalf("得到 %b %v, 期望 %b 错误 %v", flags, err, tt.want, tt.isErr)
+			}
+			if got := flags.String(); got != tt.str {
+				t.Fatalf("输出 %q, 期望 %q", got, tt.str)
+			}
+			if again, err := ParseNotifyFlags(flags.String()); err != nil || again != flags {
+				t.Fatalf("输出 %q 无法解析回原值", flags.String())
+			}
+		})
+	}
+}
+
+// TestNotifyKeyspaceEvent 只有同时开启 K 或 E 以及事件的类型类别时才发布通知
+func TestNotifyKeyspaceEvent(t *testing.T) {
+	defer SetNotifyFlags(NotifyFlags())
+	s := DataPubSub.NewSubscriber()
+	defer s.Close()
+	s.PSubscribe("__key*")
+	tests := []struct {
+		name  string
+		flags string
+		class NotifyClass
+		event string
+		want  string
+	}{
+		{"全部关闭", "", NotifyZSet, "zadd", ""},
+		{"K与E", "KEz", NotifyZSet, "zadd", "__key*|__keyevent@0__:zadd|k __key*|__keyspace@0__:k|zadd"},
+		{"只有K", "Kz", NotifyZSet, "zadd", "__key*|__keyspace@0__:k|zadd"},
+		{"只有E", "Ez", NotifyZSet, "zadd", "__key*|__keyevent@0__:zadd|k"},
+		{"A包含图类别", "EA", NotifyGraph, "graph.addnode", "__key*|__keyevent@0__:graph.addnode|k"},
+		{"没有类型类别", "KE", NotifyZSet, "zadd", ""},
+		{"没有K与E", "z", NotifyZSet, "zadd", ""},
+		{"类别不符", "KEx", NotifyZSet, "zadd", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, err := ParseNotifyFlags(tt.flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			SetNotifyFlags(flags)
+			notifyKeyspaceEvent(tt.class, tt.event, "k")
+			if got := drainMessages(s); got != tt.want {
+				t.Fatalf("收到 %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package data
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrSlowSubscriber 订阅者的输出缓冲区已满, 连接被断开
+var ErrSlowSubscriber = errors.New("订阅者的输出缓冲区已满, 已断开")
+
+// ErrSubscriberClosed 订阅者已关闭
+var ErrSubscriberClosed = errors.New("订阅者已关闭")
+
+// pubSubDefaultBufferLimit 每个订阅者默认最多缓冲的消息数
+const pubSubDefaultBufferLimit = 1024
+
+// PubSubMessage 订阅者收到的消息
+type PubSubMessage struct {
+	// 匹配的模式, 通过频道订阅收到时为空
+	Pattern string
+	Channel string
+	Payload string
+}
+
+// PubSub 发布/订阅中心, 按频道名与 glob 模式将消息分发给订阅者
+type PubSub struct {
+	// mu 保护全部订阅关系与订阅者的关闭状态; 发布时持有读锁, 投递不会阻塞
+	mu       sync.RWMutex
+	channels map[string]map[*Subscriber]struct{}
+	patterns map[string]map[*Subscriber]struct{}
+	// 新订阅者的输出缓冲区大小(消息数)
+	bufferLimit int
+}
+
+// DataPubSub 全局发布/订阅实例
+var DataPubSub = NewPubSub(pubSubDefaultBufferLimit)
+
+// NewPubSub 创建发布/订阅中心
+// @param bufferLimit int 每个订阅者最多缓冲的消息数, 小于等于0时使用默认值1024
+// @return *PubSub
+func NewPubSub(bufferLimit int) *PubSub {
+	ps := &PubSub{
+		channels: make(map[string]map[*Subscriber]struct{}),
+		patterns: make(map[string]map[*Subscriber]struct{}),
+	}
+	ps.SetBufferLimit(bufferLimit)
+	return ps
+}
+
+// SetBufferLimit 设置之后创建的订阅者的输出缓冲区大小
+// @param limit int 最多缓冲的消息数, 小于等于0时使用默认值1024
+func (ps *PubSub) SetBufferLimit(limit int) {
+	if limit <= 0 {
+		limit = pubSubDefaultBufferLimit
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.bufferLimit = limit
+}
+
+// Subscriber 一个订阅者, 通过 Messages 接收消息
+// 输出缓冲区满时发布方不会等待, 而是断开该订阅者: 取消全部订阅并关闭消息通道, Err 返回 ErrSlowSubscriber
+type Subscriber struct {
+	ps       *PubSub
+	messages chan PubSubMessage
+	// 以下字段由 ps.mu 保护
+	channels map[string]struct{}
+	patterns map[string]struct{}
+	err      error
+}
+
+// NewSubscriber 创建订阅者
+// @return *Subscriber
+func (ps *PubSub) NewSubscriber() *Subscriber {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return &Subscriber{
+		ps:       ps,
+		messages: make(chan PubSubMessage, ps.bufferLimit),
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+	}
+}
+
+// Messages 消息通道, 订阅者被断开或关闭后通道关闭
+// @return <-chan PubSubMessage
+func (s *Subscriber) Messages() <-chan PubSubMessage {
+	return s.messages
+}
+
+// Err 订阅者被断开或关闭的原因
+// @return error 仍在订阅时为nil
+func (s *Subscriber) Err() error {
+	s.ps.mu.RLock()
+	defer s.ps.mu.RUnlock()
+	return s.err
+}
+
+// Subscribe 订阅频道
+// @param channels ...string
+// @return []int 每订阅一个频道后该订阅者的订阅总数(频道与模式之和)
+// @return error 订阅者已断开时返回原因
+func (s *Subscriber) Subscribe(channels ...string) ([]int, error) {
+	return s.ps.subscribe(s, s.channels, s.ps.channels, channels)
+}
+
+// PSubscribe 按 glob 模式订阅频道
+// @param patterns ...string
+// @return []int 每订阅一个模式后该订阅者的订阅总数
+// @return error 订阅者已断开时返回原因
+func (s *Subscriber) PSubscribe(patterns ...string) ([]int, error) {
+	return s.ps.subscribe(s, s.patterns, s.ps.patterns, patterns)
+}
+
+// Unsubscribe 取消订阅频道
+// @param channels ...string 为空时取消全部频道订阅
+// @return []string 被取消的频道
+// @return []int 每取消一个频道后该订阅者的订阅总数
+func (s *Subscriber) Unsubscribe(channels ...string) ([]string, []int) {
+	return s.ps.unsubscribe(s, s.channels, s.ps.channels, channels)
+}
+
+// PUnsubscribe 取消模式订阅
+// @param patterns ...string 为空时取消全部模式订阅
+// @return []string 被取消的模式
+// @return []int 每取消一个模式后该订阅者的订阅总数
+func (s *Subscriber) PUnsubscribe(patterns ...string) ([]string, []int) {
+	return s.ps.unsubscribe(s, s.patterns, s.ps.patterns, patterns)
+}
+
+// Channels 订阅者当前订阅的频道, 按名称排列
+// @return []string
+func (s *Subscriber) Channels() []string {
+	s.ps.mu.RLock()
+	defer s.ps.mu.RUnlock()
+	return sortedKeys(s.channels)
+}
+
+// Patterns 订阅者当前订阅的模式, 按名称排列
+// @return []string
+func (s *Subscriber) Patterns() []string {
+	s.ps.mu.RLock()
+	defer s.ps.mu.RUnlock()
+	return sortedKeys(s.patterns)
+}
+
+// Close 取消全部订阅并关闭消息通道, 重复调用无影响
+func (s *Subscriber) Close() {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	s.ps.disconnectLocked(s, ErrSubscriberClosed)
+}
+
+// subscribe 将订阅者加入频道或模式的订阅表
+// @param s *Subscriber
+// @param own map[string]struct{} 订阅者自己的频道或模式集合
+// @param table map[string]map[*Subscriber]struct{} 中心的频道或模式订阅表
+// @param names []string
+// @return []int
+// @return error
+func (ps *PubSub) subscribe(s *Subscriber, own map[string]struct{}, table map[string]map[*Subscriber]struct{}, names []string) ([]int, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	counts := make([]int, len(names))
+	for i, name := range names {
+		own[name] = struct{}{}
+		if table[name] == nil {
+			table[name] = make(map[*Subscriber]struct{})
+		}
+		table[name][s] = struct{}{}
+		counts[i] = len(s.channels) + len(s.patterns)
+	}
+	return counts, nil
+}
+
+// unsubscribe 将订阅者移出频道或模式的订阅表
+// @param s *Subscriber
+// @param own map[string]struct{} 订阅者自己的频道或模式集合
+// @param table map[string]map[*Subscriber]struct{} 中心的频道或模式订阅表
+// @param names []string 为空时取消 own 中的全部
+// @return []string
+// @return []int
+func (ps *PubSub) unsubscribe(s *Subscriber, own map[string]struct{}, table map[string]map[*Subscriber]struct{}, names []string) ([]string, []int) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if len(names) == 0 {
+		names = sortedKeys(own)
+	}
+	counts := make([]int, len(names))
+	for i, name := range names {
+		delete(own, name)
+		removeSubscriber(table, name, s)
+		counts[i] = len(s.channels) + len(s.patterns)
+	}
+	return names, counts
+}
+
+// removeSubscriber 从订阅表中移除订阅者, 没有订阅者的频道或模式一并删除
+// @param table map[string]map[*Subscriber]struct{}
+// @param name string
+// @param s *Subscriber
+func removeSubscriber(table map[string]map[*Subscriber]struct{}, name string, s *Subscriber) {
+	delete(table[name], s)
+	if len(table[name]) == 0 {
+		delete(table, name)
+	}
+}
+
+// disconnectLocked 取消订阅者的全部订阅并关闭消息通道, 调用方需持有 ps.mu 写锁
+// @param s *Subscriber
+// @param reason error
+func (ps *PubSub) disconnectLocked(s *Subscriber, reason error) {
+	if s.err != nil {
+		return
+	}
+	for name := range s.channels {
+		removeSubscriber(ps.channels, name, s)
+	}
+	for name := range s.patterns {
+		removeSubscriber(ps.patterns, name, s)
+	}
+	clear(s.channels)
+	clear(s.patterns)
+	s.err = reason
+	close(s.messages)
+}
+
+// Publish 向频道发布消息
+// 订阅了该频道的订阅者收到一次, 每个匹配的模式订阅再收到一次; 输出缓冲区已满的订阅者被断开
+// @param channel string
+// @param payload string
+// @return int 收到消息的次数
+func (ps *PubSub) Publish(channel, payload string) int {
+	received := 0
+	var slow []*Subscriber
+	ps.mu.RLock()
+	deliver := func(s *Subscriber, msg PubSubMessage) {
+		select {
+		case s.messages <- msg:
+			received++
+		default:
+			slow = append(slow, s)
+		}
+	}
+	for s := range ps.channels[channel] {
+		deliver(s, PubSubMessage{Channel: channel, Payload: payload})
+	}
+	for pattern, subscribers := range ps.patterns {
+		if !GlobMatch(pattern, channel, false) {
+			continue
+		}
+		for s := range subscribers {
+			deliver(s, PubSubMessage{Pattern: pattern, Channel: channel, Payload: payload})
+		}
+	}
+	ps.mu.RUnlock()
+	if len(slow) > 0 {
+		ps.mu.Lock()
+		for _, s := range slow {
+			ps.disconnectLocked(s, ErrSlowSubscriber)
+		}
+		ps.mu.Unlock()
+	}
+	return received
+}
+
+// ActiveChannels 获取至少有一个订阅者的频道(不含模式订阅)
+// @param pattern string glob 模式, 为空时返回全部
+// @return []string 按名称排列
+func (ps *PubSub) ActiveChannels(pattern string) []string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	result := []string{}
+	for channel := range ps.channels {
+		if pattern == "" || GlobMatch(pattern, channel, false) {
+			result = append(result, channel)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// NumSub 获取频道的订阅者数量(不含模式订阅)
+// @param channels ...string
+// @return []int
+func (ps *PubSub) NumSub(channels ...string) []int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	counts := make([]int, len(channels))
+	for i, channel := range channels {
+		counts[i] = len(ps.channels[channel])
+	}
+	return counts
+}
+
+// NumPat 获取被订阅的模式数量(同一模式被多个订阅者订阅时只计一次)
+// @return int
+func (ps *PubSub) NumPat() int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return len(ps.patterns)
+}
+
+// sortedKeys 集合中的全部元素, 按名称排列
+// @param set map[string]struct{}
+// @return []string
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
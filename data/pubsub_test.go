@@ -0,0 +1,183 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// drainMessages 取出订阅者已缓冲的全部消息, 拼接为 "模式|频道|内容" 的形式并排序
+// @param s *Subscriber
+// @return string
+func drainMessages(s *Subscriber) string {
+	got := []string{}
+	for {
+		select {
+		case m, ok := <-s.Messages():
+			if !ok {
+				slices.Sort(got)
+				return strings.Join(got, " ")
+			}
+			got = append(got, m.Pattern+"|"+m.Channel+"|"+m.Payload)
+		default:
+			slices.Sort(got)
+			return strings.Join(got, " ")
+		}
+	}
+}
+
+// TestPublishRouting 频道订阅收到一次, 每个匹配的模式订阅再收到一次
+func TestPublishRouting(t *testing.T) {
+	tests := []struct {
+		channel  string
+		received int
+		a, b, c  string
+	}{
+		{"news", 5, "|news|m", "*|news|m n*|news|m", "news|news|m |news|m"},
+		{"sport", 2, "|sport|m", "*|sport|m", ""},
+		{"nx", 2, "", "*|nx|m n*|nx|m", ""},
+		{"", 1, "", "*||m", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			ps := NewPubSub(16)
+			a, b, c := ps.NewSubscriber(), ps.NewSubscriber(), ps.NewSubscriber()
+			a.Subscribe("news", "sport")
+			b.PSubscribe("n*", "*")
+			c.Subscribe("news")
+			c.PSubscribe("news")
+			if n := ps.Publish(tt.channel, "m"); n != tt.received {
+				t.Fatalf("收到 %d 次, 期望 %d 次", n, tt.received)
+			}
+			for i, want := range []string{tt.a, tt.b, tt.c} {
+				if got := drainMessages([]*Subscriber{a, b, c}[i]); got != want {
+					t.Fatalf("第 %d 个订阅者收到 %q, 期望 %q", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestSubscribeCounts 订阅数、重复订阅、取消订阅与统计
+func TestSubscribeCounts(t *testing.T) {
+	ps := NewPubSub(0)
+	s := ps.NewSubscriber()
+	if cap(s.messages) != pubSubDefaultBufferLimit {
+		t.Fatalf("缓冲区大小为 %d, 期望默认值 %d", cap(s.messages), pubSubDefaultBufferLimit)
+	}
+	if counts, err := s.Subscribe("b", "a", "b"); err != nil || fmt.Sprint(counts) != "[1 2 2]" {
+		t.Fatalf("订阅数 %v %v", counts, err)
+	}
+	if counts, _ := s.PSubscribe("a*"); fmt.Sprint(counts) != "[3]" {
+		t.Fatalf("模式订阅后订阅数 %v", counts)
+	}
+	if fmt.Sprint(s.Channels(), s.Patterns()) != "[a b] [a*]" {
+		t.Fatalf("订阅了 %v %v", s.Channels(), s.Patterns())
+	}
+	other := ps.NewSubscriber()
+	other.Subscribe("a")
+	other.PSubscribe("a*", "b*")
+	if fmt.Sprint(ps.NumSub("a", "b", "c"), ps.NumPat(), ps.ActiveChannels(""), ps.ActiveChannels("b*")) != "[2 1 0] 2 [a b] [b]" {
+		t.Fatalf("统计为 %v %d %v", ps.NumSub("a", "b", "c"), ps.NumPat(), ps.ActiveChannels(""))
+	}
+	if names, counts := s.Unsubscribe("c", "a"); fmt.Sprint(names, counts) != "[c a] [3 2]" {
+		t.Fatalf("取消订阅 %v %v", names, counts)
+	}
+	if names, counts := s.Unsubscribe(); fmt.Sprint(names, counts) != "[b] [1]" {
+		t.Fatalf("取消全部频道订阅 %v %v", names, counts)
+	}
+	if names, counts := s.PUnsubscribe(); fmt.Sprint(names, counts) != "[a*] [0]" {
+		t.Fatalf("取消全部模式订阅 %v %v", names, counts)
+	}
+	if fmt.Sprint(ps.NumSub("a", "b"), ps.NumPat(), ps.ActiveChannels("")) != "[1 0] 2 [a]" {
+		t.Fatal("取消订阅后其他订阅者的订阅应保留")
+	}
+
+	ps.SetBufferLimit(3)
+	if cap(ps.NewSubscriber().messages) != 3 || cap(other.messages) != pubSubDefaultBufferLimit {
+		t.Fatal("缓冲区大小只影响之后创建的订阅者")
+	}
+	other.Close()
+	other.Close()
+	if !errors.Is(other.Err(), ErrSubscriberClosed) || ps.NumPat() != 0 || len(ps.ActiveChannels("")) != 0 {
+		t.Fatalf("关闭后 %v, 仍有 %d 个模式", other.Err(), ps.NumPat())
+	}
+	if _, err := other.PSubscribe("x"); !errors.Is(err, ErrSubscriberClosed) {
+		t.Fatalf("关闭后订阅应返回 ErrSubscriberClosed, 得到 %v", err)
+	}
+}
+
+// TestSlowSubscriberDisconnect 缓冲区已满的订阅者被断开, 已缓冲的消息仍可读出, 其他订阅者不受影响
+func TestSlowSubscriberDisconnect(t *testing.T) {
+	ps := NewPubSub(2)
+	slow, fast := ps.NewSubscriber(), ps.NewSubscriber()
+	slow.Subscribe("ch")
+	slow.PSubscribe("c*")
+	fast.Subscribe("ch")
+	if n := ps.Publish("ch", "1"); n != 3 || slow.Err() != nil {
+		t.Fatalf("收到 %d 次, 错误 %v", n, slow.Err())
+	}
+	drainMessages(fast)
+	// slow 的缓冲区已有 2 条消息, 频道订阅的投递失败即被断开
+	if n := ps.Publish("ch", "2"); n != 1 {
+		t.Fatalf("收到 %d 次, 期望只有 fast 收到", n)
+	}
+	if !errors.Is(slow.Err(), ErrSlowSubscriber) {
+		t.Fatalf("期望 ErrSlowSubscriber, 得到 %v", slow.Err())
+	}
+	if got := drainMessages(slow); got != "c*|ch|1 |ch|1" {
+		t.Fatalf("断开前已缓冲的消息为 %q", got)
+	}
+	if _, ok := <-slow.Messages(); ok {
+		t.Fatal("断开后消息通道应关闭")
+	}
+	if fmt.Sprint(ps.NumSub("ch"), ps.NumPat(), slow.Channels(), slow.Patterns()) != "[1] 0 [] []" {
+		t.Fatal("断开后应取消全部订阅")
+	}
+	if _, err := slow.Subscribe("ch"); !errors.Is(err, ErrSlowSubscriber) {
+		t.Fatalf("断开后订阅应返回 ErrSlowSubscriber, 得到 %v", err)
+	}
+	slow.Close()
+	if !errors.Is(slow.Err(), ErrSlowSubscriber) {
+		t.Fatal("断开后关闭不应改变原因")
+	}
+	if n := ps.Publish("ch", "3"); n != 1 || drainMessages(fast) != "|ch|2 |ch|3" {
+		t.Fatal("fast 应继续收到消息")
+	}
+}
+
+// TestPublishConcurrent 并发发布、订阅、取消订阅与断开时不会向已关闭的通道发送消息
+func TestPublishConcurrent(t *testing.T) {
+	ps := NewPubSub(4)
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 200 {
+				ps.Publish(fmt.Sprint("ch", j%4), fmt.Sprint(i))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				s := ps.NewSubscriber()
+				s.Subscribe("ch0", "ch1")
+				s.PSubscribe("ch*")
+				// 只有一半的订阅者读取消息, 其余的会因缓冲区满被断开
+				if i%2 == 0 {
+					drainMessages(s)
+				}
+				s.Unsubscribe("ch0")
+				s.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	if ps.NumPat() != 0 || len(ps.ActiveChannels("")) != 0 {
+		t.Fatalf("全部订阅者关闭后仍有 %d 个模式与频道 %v", ps.NumPat(), ps.ActiveChannels(""))
+	}
+}
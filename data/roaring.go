@@ -0,0 +1,655 @@
+package data
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"slices"
+	"sort"
+)
+
+const (
+	// roaringChunkBits 每个容器覆盖的位数为 2^16
+	roaringChunkBits = 16
+	// roaringChunkSize 每个容器覆盖的位数
+	roaringChunkSize = 1 << roaringChunkBits
+	// roaringArrayMax 数组容器的最大元素数, 超过后位图容器更省空间
+	roaringArrayMax = 4096
+	// roaringWords 位图容器中 uint64 的数量
+	roaringWords = roaringChunkSize / 64
+	// roaringBitmapBytes 位图容器占用的字节数
+	roaringBitmapBytes = roaringChunkSize / 8
+)
+
+// roaringContainer 覆盖一个 2^16 位区块的容器, 位置为区块内的偏移 0-65535
+type roaringContainer interface {
+	// card 为1的位数
+	card() int
+	// has 某一位是否为1
+	has(x uint16) bool
+	// add 将某一位置1, 返回修改后的容器(可能转换了类型)
+	add(x uint16) roaringContainer
+	// remove 将某一位置0, 返回修改后的容器(可能转换了类型)
+	remove(x uint16) roaringContainer
+	// countRange 统计 [lo, hi] 内为1的位数
+	countRange(lo, hi int) int
+	// next 查找不小于from且值为bit的第一个位置, 区块内不存在时为-1
+	next(from int, bit bool) int
+	// words 以位图形式导出全部位
+	words() *[roaringWords]uint64
+}
+
+// arrayContainer 数组容器: 有序存放为1的位置, 适合稀疏区块
+type arrayContainer struct {
+	values []uint16
+}
+
+// card 为1的位数
+func (c *arrayContainer) card() int {
+	return len(c.values)
+}
+
+// has 某一位是否为1
+func (c *arrayContainer) has(x uint16) bool {
+	_, found := slices.BinarySearch(c.values, x)
+	return found
+}
+
+// add 将某一位置1, 超过数组容器上限时转换为位图容器
+func (c *arrayContainer) add(x uint16) roaringContainer {
+	i, found := slices.BinarySearch(c.values, x)
+	if found {
+		return c
+	}
+	if len(c.values) >= roaringArrayMax {
+		return newBitmapContainer(c.words()).add(x)
+	}
+	c.values = slices.Insert(c.values, i, x)
+	return c
+}
+
+// remove 将某一位置0
+func (c *arrayContainer) remove(x uint16) roaringContainer {
+	if i, found := slices.BinarySearch(c.values, x); found {
+		c.values = slices.Delete(c.values, i, i+1)
+	}
+	return c
+}
+
+// countRange 统计 [lo, hi] 内为1的位数
+func (c *arrayContainer) countRange(lo, hi int) int {
+	from := sort.Search(len(c.values), func(i int) bool { return int(c.values[i]) >= lo })
+	to := sort.Search(len(c.values), func(i int) bool { return int(c.values[i]) > hi })
+	return to - from
+}
+
+// next 查找不小于from且值为bit的第一个位置
+func (c *arrayContainer) next(from int, bit bool) int {
+	i := sort.Search(len(c.values), func(i int) bool { return int(c.values[i]) >= from })
+	if bit {
+		if i < len(c.values) {
+			return int(c.values[i])
+		}
+		return -1
+	}
+	for ; i < len(c.values) && int(c.values[i]) == from; i++ {
+		from++
+	}
+	if from >= roaringChunkSize {
+		return -1
+	}
+	return from
+}
+
+// words 以位图形式导出全部位
+func (c *arrayContainer) words() *[roaringWords]uint64 {
+	w := &[roaringWords]uint64{}
+	for _, v := range c.values {
+		w[v>>6] |= 1 << (v & 63)
+	}
+	return w
+}
+
+// bitmapContainer 位图容器: 固定 8KB, 适合稠密区块
+type bitmapContainer struct {
+	w *[roaringWords]uint64
+	n int
+}
+
+// newBitmapContainer 由位图创建容器
+// @param w *[roaringWords]uint64
+// @return *bitmapContainer
+func newBitmapContainer(w *[roaringWords]uint64) *bitmapContainer {
+	n := 0
+	for _, v := range w {
+		n += bits.OnesCount64(v)
+	}
+	return &bitmapContainer{w: w, n: n}
+}
+
+// card 为1的位数
+func (c *bitmapContainer) card() int {
+	return c.n
+}
+
+// has 某一位是否为1
+func (c *bitmapContainer) has(x uint16) bool {
+	return c.w[x>>6]&(1<<(x&63)) != 0
+}
+
+// add 将某一位置1
+func (c *bitmapContainer) add(x uint16) roaringContainer {
+	if !c.has(x) {
+		c.w[x>>6] |= 1 << (x & 63)
+		c.n++
+	}
+	return c
+}
+
+// remove 将某一位置0, 元素足够少时转换为数组容器
+func (c *bitmapContainer) remove(x uint16) roaringContainer {
+	if !c.has(x) {
+		return c
+	}
+	c.w[x>>6] &^= 1 << (x & 63)
+	c.n--
+	if c.n <= roaringArrayMax {
+		return wordsToArray(c.w, c.n)
+	}
+	return c
+}
+
+// countRange 统计 [lo, hi] 内为1的位数
+func (c *bitmapContainer) countRange(lo, hi int) int {
+	return wordsCountRange(c.w, lo, hi)
+}
+
+// next 查找不小于from且值为bit的第一个位置
+func (c *bitmapContainer) next(from int, bit bool) int {
+	return wordsNext(c.w, from, bit)
+}
+
+// words 以位图形式导出全部位
+func (c *bitmapContainer) words() *[roaringWords]uint64 {
+	w := *c.w
+	return &w
+}
+
+// roaringRun 游程容器中连续为1的一段 [start, last]
+type roaringRun struct {
+	start, last uint16
+}
+
+// runContainer 游程容器: 存放连续为1的区间, 适合大段连续的区块
+// 区间按起点有序, 互不相交且互不相邻
+type runContainer struct {
+	runs []roaringRun
+}
+
+// card 为1的位数
+func (c *runContainer) card() int {
+	n := 0
+	for _, r := range c.runs {
+		n += int(r.last) - int(r.start) + 1
+	}
+	return n
+}
+
+// search 第一个终点不小于x的区间下标
+// @param x int
+// @return int
+func (c *runContainer) search(x int) int {
+	return sort.Search(len(c.runs), func(i int) bool { return int(c.runs[i].last) >= x })
+}
+
+// has 某一位是否为1
+func (c *runContainer) has(x uint16) bool {
+	i := c.search(int(x))
+	return i < len(c.runs) && c.runs[i].start <= x
+}
+
+// add 将某一位置1, 必要时与相邻区间合并; 区间过多时转换为更省空间的容器
+func (c *runContainer) add(x uint16) roaringContainer {
+	i := c.search(int(x))
+	if i < len(c.runs) && c.runs[i].start <= x {
+		return c
+	}
+	joinPrev := i > 0 && int(c.runs[i-1].last)+1 == int(x)
+	joinNext := i < len(c.runs) && int(c.runs[i].start) == int(x)+1
+	switch {
+	case joinPrev && joinNext:
+		c.runs[i-1].last = c.runs[i].last
+		c.runs = slices.Delete(c.runs, i, i+1)
+	case joinPrev:
+		c.runs[i-1].last = x
+	case joinNext:
+		c.runs[i].start = x
+	default:
+		c.runs = slices.Insert(c.runs, i, roaringRun{x, x})
+	}
+	if len(c.runs) > roaringArrayMax/2 {
+		return optimizeContainer(c.words())
+	}
+	return c
+}
+
+// remove 将某一位置0, 必要时拆分区间
+func (c *runContainer) remove(x uint16) roaringContainer {
+	i := c.search(int(x))
+	if i >= len(c.runs) || c.runs[i].start > x {
+		return c
+	}
+	r := c.runs[i]
+	switch {
+	case r.start == r.last:
+		c.runs = slices.Delete(c.runs, i, i+1)
+	case x == r.start:
+		c.runs[i].start++
+	case x == r.last:
+		c.runs[i].last--
+	default:
+		c.runs[i].last = x - 1
+		c.runs = slices.Insert(c.runs, i+1, roaringRun{x + 1, r.last})
+	}
+	if len(c.runs) > roaringArrayMax/2 {
+		return optimizeContainer(c.words())
+	}
+	return c
+}
+
+// countRange 统计 [lo, hi] 内为1的位数
+func (c *runContainer) countRange(lo, hi int) int {
+	n := 0
+	for i := c.search(lo); i < len(c.runs) && int(c.runs[i].start) <= hi; i++ {
+		n += min(int(c.runs[i].last), hi) - max(int(c.runs[i].start), lo) + 1
+	}
+	return n
+}
+
+// next 查找不小于from且值为bit的第一个位置
+func (c *runContainer) next(from int, bit bool) int {
+	i := c.search(from)
+	inRun := i < len(c.runs) && int(c.runs[i].start) <= from
+	if bit {
+		if i >= len(c.runs) {
+			return -1
+		}
+		return max(int(c.runs[i].start), from)
+	}
+	if !inRun {
+		return from
+	}
+	if p := int(c.runs[i].last) + 1; p < roaringChunkSize {
+		return p
+	}
+	return -1
+}
+
+// words 以位图形式导出全部位
+func (c *runContainer) words() *[roaringWords]uint64 {
+	w := &[roaringWords]uint64{}
+	for _, r := range c.runs {
+		wordsSetRange(w, int(r.start), int(r.last))
+	}
+	return w
+}
+
+// wordsSetRange 将位图中 [lo, hi] 全部置1
+// @param w *[roaringWords]uint64
+// @param lo int
+// @param hi int
+func wordsSetRange(w *[roaringWords]uint64, lo, hi int) {
+	for i := lo >> 6; i <= hi>>6; i++ {
+		mask := ^uint64(0)
+		if i == lo>>6 {
+			mask &= ^uint64(0) << (lo & 63)
+		}
+		if i == hi>>6 {
+			mask &= ^uint64(0) >> (63 - hi&63)
+		}
+		w[i] |= mask
+	}
+}
+
+// wordsCountRange 统计位图中 [lo, hi] 内为1的位数
+// @param w *[roaringWords]uint64
+// @param lo int
+// @param hi int
+// @return int
+func wordsCountRange(w *[roaringWords]uint64, lo, hi int) int {
+	n := 0
+	for i := lo >> 6; i <= hi>>6; i++ {
+		v := w[i]
+		if i == lo>>6 {
+			v &= ^uint64(0) << (lo & 63)
+		}
+		if i == hi>>6 {
+			v &= ^uint64(0) >> (63 - hi&63)
+		}
+		n += bits.OnesCount64(v)
+	}
+	return n
+}
+
+// wordsNext 查找位图中不小于from且值为bit的第一个位置
+// @param w *[roaringWords]uint64
+// @param from int
+// @param bit bool
+// @return int 不存在时为-1
+func wordsNext(w *[roaringWords]uint64, from int, bit bool) int {
+	for i := from >> 6; i < roaringWords; i++ {
+		v := w[i]
+		if !bit {
+			v = ^v
+		}
+		if i == from>>6 {
+			v &= ^uint64(0) << (from & 63)
+		}
+		if v != 0 {
+			return i<<6 + bits.TrailingZeros64(v)
+		}
+	}
+	return -1
+}
+
+// wordsToArray 将位图转换为数组容器
+// @param w *[roaringWords]uint64
+// @param n int 为1的位数
+// @return *arrayContainer
+func wordsToArray(w *[roaringWords]uint64, n int) *arrayContainer {
+	values := make([]uint16, 0, n)
+	for i, v := range w {
+		for v != 0 {
+			values = append(values, uint16(i<<6+bits.TrailingZeros64(v)))
+			v &= v - 1
+		}
+	}
+	return &arrayContainer{values: values}
+}
+
+// wordsToRuns 将位图转换为游程容器
+// @param w *[roaringWords]uint64
+// @return *runContainer
+func wordsToRuns(w *[roaringWords]uint64) *runContainer {
+	runs := []roaringRun{}
+	for p := wordsNext(w, 0, true); p >= 0; {
+		end := wordsNext(w, p, false)
+		if end < 0 {
+			end = roaringChunkSize
+		}
+		runs = append(runs, roaringRun{uint16(p), uint16(end - 1)})
+		if end >= roaringChunkSize {
+			break
+		}
+		p = wordsNext(w, end, true)
+	}
+	return &runContainer{runs: runs}
+}
+
+// optimizeContainer 根据位图内容选择占用空间最小的容器
+// 数组容器每个元素2字节, 位图容器固定8KB, 游程容器每段4字节
+// @param w *[roaringWords]uint64
+// @return roaringContainer 全为0时为nil
+func optimizeContainer(w *[roaringWords]uint64) roaringContainer {
+	n, runs := 0, 0
+	var carry uint64
+	for _, v := range w {
+		n += bits.OnesCount64(v)
+		runs += bits.OnesCount64(v &^ (v<<1 | carry))
+		carry = v >> 63
+	}
+	if n == 0 {
+		return nil
+	}
+	size := roaringBitmapBytes
+	if n <= roaringArrayMax {
+		size = 2 * n
+	}
+	if 4*runs < size {
+		return wordsToRuns(w)
+	}
+	if n <= roaringArrayMax {
+		return wordsToArray(w, n)
+	}
+	return &bitmapContainer{w: w, n: n}
+}
+
+// roaring 压缩位图: 将位按 2^16 分块, 每块按稀疏程度选择数组、位图或游程容器,
+// 不存在的块全为0, 设置极大偏移的单个位只需要一个很小的数组容器
+type roaring struct {
+	// 块号(偏移 >> 16), 升序
+	keys []int
+	// 与块号一一对应的容器
+	containers []roaringContainer
+	// 逻辑字节长度, 与连续字节编码一致, 只增不减
+	length int
+}
+
+// newRoaringFromBytes 由连续字节编码创建压缩位图
+// @param b []byte
+// @return *roaring
+func newRoaringFromBytes(b []byte) *roaring {
+	r := &roaring{length: len(b)}
+	for chunk := 0; chunk*roaringBitmapBytes < len(b); chunk++ {
+		part := b[chunk*roaringBitmapBytes : min(len(b), (chunk+1)*roaringBitmapBytes)]
+		w := &[roaringWords]uint64{}
+		for i := 0; i < len(part); i += 8 {
+			var word [8]byte
+			copy(word[:], part[i:])
+			w[i/8] = binary.LittleEndian.Uint64(word[:])
+		}
+		if c := optimizeContainer(w); c != nil {
+			r.keys = append(r.keys, chunk)
+			r.containers = append(r.containers, c)
+		}
+	}
+	return r
+}
+
+// container 获取块对应的容器
+// @param chunk int 块号
+// @return roaringContainer 不存在时为nil
+func (r *roaring) container(chunk int) roaringContainer {
+	if i, found := slices.BinarySearch(r.keys, chunk); found {
+		return r.containers[i]
+	}
+	return nil
+}
+
+// getBit 获取某一位
+// @param offset int
+// @return bool
+func (r *roaring) getBit(offset int) bool {
+	c := r.container(offset >> roaringChunkBits)
+	return c != nil && c.has(uint16(offset))
+}
+
+// setBit 设置某一位
+// @param offset int
+// @param value bool
+func (r *roaring) setBit(offset int, value bool) {
+	r.length = max(r.length, offset/8+1)
+	chunk := offset >> roaringChunkBits
+	i, found := slices.BinarySearch(r.keys, chunk)
+	switch {
+	case found && value:
+		r.containers[i] = r.containers[i].add(uint16(offset))
+	case found:
+		r.containers[i] = r.containers[i].remove(uint16(offset))
+		if r.containers[i].card() == 0 {
+			r.keys = slices.Delete(r.keys, i, i+1)
+			r.containers = slices.Delete(r.containers, i, i+1)
+		}
+	case value:
+		r.keys = slices.Insert(r.keys, i, chunk)
+		r.containers = slices.Insert(r.containers, i, roaringContainer(&arrayContainer{values: []uint16{uint16(offset)}}))
+	}
+}
+
+// count 统计位区间 [startBit, endBit] 内为1的位数
+// @param startBit int
+// @param endBit int
+// @return int
+func (r *roaring) count(startBit, endBit int) int {
+	n := 0
+	first := sort.SearchInts(r.keys, startBit>>roaringChunkBits)
+	for i := first; i < len(r.keys) && r.keys[i] <= endBit>>roaringChunkBits; i++ {
+		base := r.keys[i] << roaringChunkBits
+		lo := max(startBit-base, 0)
+		hi := min(endBit-base, roaringChunkSize-1)
+		if lo == 0 && hi == roaringChunkSize-1 {
+			n += r.containers[i].card()
+		} else {
+			n += r.containers[i].countRange(lo, hi)
+		}
+	}
+	return n
+}
+
+// pos 在位区间 [startBit, endBit] 内查找第一个值为bit的位
+// @param bit bool
+// @param startBit int
+// @param endBit int
+// @return int 未找到为-1
+func (r *roaring) pos(bit bool, startBit, endBit int) int {
+	if bit {
+		first := sort.SearchInts(r.keys, startBit>>roaringChunkBits)
+		for i := first; i < len(r.keys) && r.keys[i] <= endBit>>roaringChunkBits; i++ {
+			base := r.keys[i] << roaringChunkBits
+			if p := r.containers[i].next(max(startBit-base, 0), true); p >= 0 {
+				if base+p <= endBit {
+					return base + p
+				}
+				return -1
+			}
+		}
+		return -1
+	}
+	for p := startBit; p <= endBit; {
+		chunk := p >> roaringChunkBits
+		base := chunk << roaringChunkBits
+		c := r.container(chunk)
+		if c == nil {
+			return p
+		}
+		if q := c.next(p-base, false); q >= 0 {
+			if base+q <= endBit {
+				return base + q
+			}
+			return -1
+		}
+		p = base + roaringChunkSize
+	}
+	return -1
+}
+
+// roaringBitOp 对若干压缩位图按块执行按位运算, 结果长度为最长来源的长度
+// @param op BitOperation
+// @param srcs []*roaring
+// @return *roaring
+func roaringBitOp(op BitOperation, srcs []*roaring) *roaring {
+	result := &roaring{}
+	for _, src := range srcs {
+		result.length = max(result.length, src.length)
+	}
+	appendChunk := func(chunk int, c roaringContainer) {
+		if c != nil && c.card() > 0 {
+			result.keys = append(result.keys, chunk)
+			result.containers = append(result.containers, c)
+		}
+	}
+	switch op {
+	case BitNot:
+		// 不存在的块取反后为全1, 用单个区间的行程容器表示;
+		// 位偏移不超过 MaxBitOffset, 因此最多处理 65536 个块
+		totalBits := result.length * 8
+		for chunk := 0; chunk<<roaringChunkBits < totalBits; chunk++ {
+			last := min(totalBits-chunk<<roaringChunkBits, roaringChunkSize) - 1
+			c := srcs[0].container(chunk)
+			if c == nil {
+				appendChunk(chunk, &runContainer{runs: []roaringRun{{0, uint16(last)}}})
+				continue
+			}
+			w := c.words()
+			for i := range w {
+				w[i] = ^w[i]
+			}
+			if last < roaringChunkSize-1 {
+				for p := last + 1; p < roaringChunkSize; p++ {
+					w[p>>6] &^= 1 << (p & 63)
+				}
+			}
+			appendChunk(chunk, optimizeContainer(w))
+		}
+	case BitAnd:
+		for i, chunk := range srcs[0].keys {
+			containers := []roaringContainer{srcs[0].containers[i]}
+			for _, src := range srcs[1:] {
+				if c := src.container(chunk); c != nil {
+					containers = append(containers, c)
+				} else {
+					containers = nil
+					break
+				}
+			}
+			if containers != nil {
+				appendChunk(chunk, andContainers(containers))
+			}
+		}
+	default:
+		chunks := []int{}
+		for _, src := range srcs {
+			chunks = append(chunks, src.keys...)
+		}
+		slices.Sort(chunks)
+		for _, chunk := range slices.Compact(chunks) {
+			acc := &[roaringWords]uint64{}
+			for _, src := range srcs {
+				c := src.container(chunk)
+				if c == nil {
+					continue
+				}
+				w := c.words()
+				for j := range acc {
+					if op == BitOr {
+						acc[j] |= w[j]
+					} else {
+						acc[j] ^= w[j]
+					}
+				}
+			}
+			appendChunk(chunk, optimizeContainer(acc))
+		}
+	}
+	return result
+}
+
+// andContainers 计算多个容器的交集, 有数组容器时只需逐个检查其中的元素
+// @param containers []roaringContainer
+// @return roaringContainer 全为0时为nil
+func andContainers(containers []roaringContainer) roaringContainer {
+	for i, c := range containers {
+		arr, ok := c.(*arrayContainer)
+		if !ok {
+			continue
+		}
+		values := []uint16{}
+	next:
+		for _, v := range arr.values {
+			for j, other := range containers {
+				if j != i && !other.has(v) {
+					continue next
+				}
+			}
+			values = append(values, v)
+		}
+		return &arrayContainer{values: values}
+	}
+	acc := containers[0].words()
+	for _, c := range containers[1:] {
+		w := c.words()
+		for j := range acc {
+			acc[j] &= w[j]
+		}
+	}
+	return optimizeContainer(acc)
+}
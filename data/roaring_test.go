@@ -0,0 +1,203 @@
+package data
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// containerKind 容器类型名
+// @param c roaringContainer
+// @return string
+func containerKind(c roaringContainer) string {
+	switch c.(type) {
+	case nil:
+		return "nil"
+	case *arrayContainer:
+		return "array"
+	case *bitmapContainer:
+		return "bitmap"
+	case *runContainer:
+		return "run"
+	}
+	return fmt.Sprintf("%T", c)
+}
+
+// checkContainer 将容器与逐位的期望值比对
+// @param t *testing.T
+// @param c roaringContainer
+// @param want *[roaringChunkSize]bool
+func checkContainer(t *testing.T, c roaringContainer, want *[roaringChunkSize]bool) {
+	t.Helper()
+	n := 0
+	for x, bit := range want {
+		if c.has(uint16(x)) != bit {
+			t.Fatalf("%s 容器第 %d 位为 %v, 期望 %v", containerKind(c), x, !bit, bit)
+		}
+		if bit {
+			n++
+		}
+	}
+	if c.card() != n {
+		t.Fatalf("%s 容器的基数为 %d, 期望 %d", containerKind(c), c.card(), n)
+	}
+	w := c.words()
+	for _, probe := range []int{0, 1, 63, 64, 4095, 30000, roaringChunkSize - 1} {
+		for _, bit := range []bool{true, false} {
+			if got, expect := c.next(probe, bit), wordsNext(w, probe, bit); got != expect {
+				t.Fatalf("%s 容器 next(%d, %v) = %d, 期望 %d", containerKind(c), probe, bit, got, expect)
+			}
+		}
+		if got, expect := c.countRange(probe, roaringChunkSize-1), wordsCountRange(w, probe, roaringChunkSize-1); got != expect {
+			t.Fatalf("%s 容器 countRange(%d) = %d, 期望 %d", containerKind(c), probe, got, expect)
+		}
+	}
+}
+
+// TestOptimizeContainer 按位的分布选择占用空间最小的容器
+func TestOptimizeContainer(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(w *[roaringWords]uint64)
+		want string
+	}{
+		{"全为0", func(w *[roaringWords]uint64) {}, "nil"},
+		{"少量分散的位", func(w *[roaringWords]uint64) {
+			for x := 0; x < roaringChunkSize; x += 1000 {
+				w[x>>6] |= 1 << (x & 63)
+			}
+		}, "array"},
+		{"恰好为数组上限的分散位", func(w *[roaringWords]uint64) {
+			for x := 0; x < roaringArrayMax*16; x += 16 {
+				w[x>>6] |= 1 << (x & 63)
+			}
+		}, "array"},
+		{"超过数组上限的分散位", func(w *[roaringWords]uint64) {
+			for x := 0; x < roaringChunkSize; x += 2 {
+				w[x>>6] |= 1 << (x & 63)
+			}
+		}, "bitmap"},
+		{"一段连续的位", func(w *[roaringWords]uint64) { wordsSetRange(w, 100, 20000) }, "run"},
+		{"全为1", func(w *[roaringWords]uint64) { wordsSetRange(w, 0, roaringChunkSize-1) }, "run"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &[roaringWords]uint64{}
+			tt.set(w)
+			c := optimizeContainer(w)
+			if got := containerKind(c); got != tt.want {
+				t.Fatalf("得到 %s 容器, 期望 %s", got, tt.want)
+			}
+			if c != nil {
+				want := &[roaringChunkSize]bool{}
+				for x := range want {
+					want[x] = w[x>>6]&(1<<(x&63)) != 0
+				}
+				checkContainer(t, c, want)
+			}
+		})
+	}
+}
+
+// TestRoaringContainerConversion 增删位时容器在数组、位图与游程之间转换且内容不变
+func TestRoaringContainerConversion(t *testing.T) {
+	want := &[roaringChunkSize]bool{}
+	var c roaringContainer = &arrayContainer{}
+	set := func(x int, bit bool) {
+		want[x] = bit
+		if bit {
+			c = c.add(uint16(x))
+		} else {
+			c = c.remove(uint16(x))
+		}
+	}
+
+	for x := 0; x < roaringArrayMax*2; x += 2 {
+		set(x, true)
+	}
+	if containerKind(c) != "array" {
+		t.Fatalf("%d 个元素应为数组容器, 得到 %s", roaringArrayMax, containerKind(c))
+	}
+	set(roaringArrayMax*2, true)
+	if containerKind(c) != "bitmap" {
+		t.Fatalf("超过 %d 个元素应转换为位图容器, 得到 %s", roaringArrayMax, containerKind(c))
+	}
+	checkContainer(t, c, want)
+	set(0, false)
+	if containerKind(c) != "array" {
+		t.Fatalf("删除到 %d 个元素应转换回数组容器, 得到 %s", roaringArrayMax, containerKind(c))
+	}
+	checkContainer(t, c, want)
+
+	// 游程容器: 在连续区间中挖洞会拆分区间, 区间过多时转换为其他容器
+	want = &[roaringChunkSize]bool{}
+	for x := range want {
+		want[x] = true
+	}
+	full := &[roaringWords]uint64{}
+	wordsSetRange(full, 0, roaringChunkSize-1)
+	c = optimizeContainer(full)
+	for x := 1; x < roaringChunkSize && containerKind(c) == "run"; x += 4 {
+		set(x, false)
+	}
+	if kind := containerKind(c); kind != "bitmap" {
+		t.Fatalf("区间过多时应转换为位图容器, 得到 %s", kind)
+	}
+	checkContainer(t, c, want)
+
+	// 随机增删后与逐位的期望值一致
+	r := rand.New(rand.NewPCG(36, 36))
+	for range 20000 {
+		x := r.IntN(roaringChunkSize)
+		if r.IntN(3) == 0 {
+			x = r.IntN(256)
+		}
+		set(x, r.IntN(2) == 0)
+	}
+	checkContainer(t, c, want)
+
+	// 相邻的位合并为同一个区间
+	want = &[roaringChunkSize]bool{}
+	run := &runContainer{}
+	c = run
+	for _, x := range []int{5, 7, 6, 4, 8, 100, 99, 101} {
+		set(x, true)
+	}
+	if containerKind(c) != "run" || len(run.runs) != 2 || run.runs[0] != (roaringRun{4, 8}) || run.runs[1] != (roaringRun{99, 101}) {
+		t.Fatalf("相邻的位应合并为区间, 得到 %+v", run.runs)
+	}
+	checkContainer(t, c, want)
+}
+
+// TestRoaringFromBytes 由连续字节编码转换后每一位与长度不变
+func TestRoaringFromBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"空", []byte{}},
+		{"全为0", make([]byte, roaringBitmapBytes*2+3)},
+		{"跨块的末尾字节", append(make([]byte, roaringBitmapBytes), 0x81)},
+		{"稀疏", func() []byte {
+			b := make([]byte, roaringBitmapBytes*3)
+			for i := 0; i < len(b); i += 997 {
+				b[i] = 0x10
+			}
+			return b
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRoaringFromBytes(tt.b)
+			if r.length != len(tt.b) {
+				t.Fatalf("长度 %d, 期望 %d", r.length, len(tt.b))
+			}
+			for offset := 0; offset < len(tt.b)*8; offset++ {
+				want := tt.b[offset/8]&(1<<(offset%8)) != 0
+				if r.getBit(offset) != want {
+					t.Fatalf("第 %d 位为 %v, 期望 %v", offset, !want, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package data
+
+import (
+	"strconv"
+)
+
+// setObject 单个集合的值
+// 成员全部为整数且数量不超过阈值时使用整数集合编码, 否则使用哈希表编码
+type setObject struct {
+	// 整数集合编码, 为nil表示使用哈希表编码
+	ints *intSet
+	// 哈希表编码
+	dict map[string]struct{}
+}
+
+// newSetObject 根据成员创建集合, 自动选择编码
+// @param members []string 成员
+// @return *setObject
+func newSetObject(members []string) *setObject {
+	set := &setObject{ints: newIntSet()}
+	for _, m := range members {
+		set.add(m)
+	}
+	return set
+}
+
+// encoding 当前编码名称
+// @return string
+func (set *setObject) encoding() string {
+	if set.ints != nil {
+		return "intset"
+	}
+	return "hashtable"
+}
+
+// len 成员数量
+// @return int
+func (set *setObject) len() int {
+	if set == nil {
+		return 0
+	}
+	if set.ints != nil {
+		return set.ints.len()
+	}
+	return len(set.dict)
+}
+
+// has 判断成员是否存在
+// @param member string
+// @return bool
+func (set *setObject) has(member string) bool {
+	if set == nil {
+		return false
+	}
+	if set.ints != nil {
+		v, ok := parseSetInt(member)
+		return ok && set.ints.has(v)
+	}
+	_, ok := set.dict[member]
+	return ok
+}
+
+// add 添加成员, 必要时转换为哈希表编码
+// @param member string
+// @return bool 是否为新成员
+func (set *setObject) add(member string) bool {
+	if set.ints != nil {
+		if v, ok := parseSetInt(member); ok {
+			if set.ints.has(v) {
+				return false
+			}
+			if set.ints.len() < encodingLimits.SetMaxIntsetEntries {
+				return set.ints.add(v)
+			}
+		}
+		set.convertToDict()
+	}
+	if _, ok := set.dict[member]; ok {
+		return false
+	}
+	set.dict[member] = struct{}{}
+	return true
+}
+
+// remove 移除成员
+// @param member string
+// @return bool 成员是否存在
+func (set *setObject) remove(member string) bool {
+	if set.ints != nil {
+		v, ok := parseSetInt(member)
+		return ok && set.ints.remove(v)
+	}
+	if _, ok := set.dict[member]; !ok {
+		return false
+	}
+	delete(set.dict, member)
+	return true
+}
+
+// each 遍历成员, fn返回false时停止
+// @param fn func(member string) bool
+func (set *setObject) each(fn func(member string) bool) {
+	if set == nil {
+		return
+	}
+	if set.ints != nil {
+		for i := 0; i < set.ints.len(); i++ {
+			if !fn(strconv.FormatInt(set.ints.get(i), 10)) {
+				return
+			}
+		}
+		return
+	}
+	for m := range set.dict {
+		if !fn(m) {
+			return
+		}
+	}
+}
+
+// members 获取所有成员
+// @return []string
+func (set *setObject) members() []string {
+	result := make([]string, 0, set.len())
+	set.each(func(m string) bool {
+		result = append(result, m)
+		return true
+	})
+	return result
+}
+
+// convertToDict 转换为哈希表编码
+func (set *setObject) convertToDict() {
+	dict := make(map[string]struct{}, set.ints.len()+1)
+	for i := 0; i < set.ints.len(); i++ {
+		dict[strconv.FormatInt(set.ints.get(i), 10)] = struct{}{}
+	}
+	set.ints = nil
+	set.dict = dict
+}
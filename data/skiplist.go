@@ -0,0 +1,328 @@
+package data
+
+import (
+	"math/rand/v2"
+)
+
+const (
+	// skiplistMaxLevel 跳表最大层数, 足以容纳 2^64 个元素
+	skiplistMaxLevel = 32
+	// skiplistP 节点晋升到上一层的概率
+	skiplistP = 0.25
+)
+
+// skiplistLevel 跳表节点的某一层
+type skiplistLevel struct {
+	// 本层的下一个节点
+	forward *skiplistNode
+	// 到下一个节点跨越的元素个数, 用于计算排名
+	span int
+}
+
+// skiplistNode 跳表节点
+type skiplistNode struct {
+	member   string
+	score    float64
+	backward *skiplistNode
+	level    []skiplistLevel
+}
+
+// skiplist 带跨度的跳表, 按 (分数, 成员) 升序排列
+// 插入、删除、按排名定位、按分数定位均为 O(log n)
+type skiplist struct {
+	header *skiplistNode
+	tail   *skiplistNode
+	length int
+	level  int
+}
+
+// newSkiplist 创建空跳表
+// @return *skiplist
+func newSkiplist() *skiplist {
+	return &skiplist{
+		header: &skiplistNode{level: make([]skiplistLevel, skiplistMaxLevel)},
+		level:  1,
+	}
+}
+
+// randomLevel 随机生成新节点的层数
+// @return int
+func randomLevel() int {
+	level := 1
+	for level < skiplistMaxLevel && rand.Float64() < skiplistP {
+		level++
+	}
+	return level
+}
+
+// first 第一个节点
+// @return *skiplistNode
+func (sl *skiplist) first() *skiplistNode {
+	return sl.header.level[0].forward
+}
+
+// insert 插入节点, 调用方需保证成员不存在
+// @param score float64
+// @param member string
+// @return *skiplistNode 新节点
+func (sl *skiplist) insert(score float64, member string) *skiplistNode {
+	var update [skiplistMaxLevel]*skiplistNode
+	var rank [skiplistMaxLevel]int
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		if i < sl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.level[i].forward != nil && zsetLess(x.level[i].forward.score, x.level[i].forward.member, score, member) {
+			rank[i] += x.level[i].span
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+	level := randomLevel()
+	if level > sl.level {
+		for i := sl.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = sl.header
+			update[i].level[i].span = sl.length
+		}
+		sl.level = level
+	}
+	x = &skiplistNode{member: member, score: score, level: make([]skiplistLevel, level)}
+	for i := 0; i < level; i++ {
+		x.level[i].forward = update[i].level[i].forward
+		update[i].level[i].forward = x
+		x.level[i].span = update[i].level[i].span - (rank[0] - rank[i])
+		update[i].level[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < sl.level; i++ {
+		update[i].level[i].span++
+	}
+	if update[0] != sl.header {
+		x.backward = update[0]
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x
+	} else {
+		sl.tail = x
+	}
+	sl.length++
+	return x
+}
+
+// deleteNode 摘除节点
+// @param x *skiplistNode 待删除节点
+// @param update []*skiplistNode 每一层中位于x之前的节点
+func (sl *skiplist) deleteNode(x *skiplistNode, update []*skiplistNode) {
+	for i := 0; i < sl.level; i++ {
+		if update[i].level[i].forward == x {
+			update[i].level[i].span += x.level[i].span - 1
+			update[i].level[i].forward = x.level[i].forward
+		} else {
+			update[i].level[i].span--
+		}
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x.backward
+	} else {
+		sl.tail = x.backward
+	}
+	for sl.level > 1 && sl.header.level[sl.level-1].forward == nil {
+		sl.level--
+	}
+	sl.length--
+}
+
+// findUpdate 查找每一层中位于 (score, member) 之前的最后一个节点
+// @param score float64
+// @param member string
+// @param update []*skiplistNode 输出
+// @return *skiplistNode 第0层中的下一个节点
+func (sl *skiplist) findUpdate(score float64, member string, update []*skiplistNode) *skiplistNode {
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && zsetLess(x.level[i].forward.score, x.level[i].forward.member, score, member) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+	return x.level[0].forward
+}
+
+// delete 删除节点
+// @param score float64
+// @param member string
+// @return bool 节点是否存在
+func (sl *skiplist) delete(score float64, member string) bool {
+	var update [skiplistMaxLevel]*skiplistNode
+	x := sl.findUpdate(score, member, update[:])
+	if x != nil && x.score == score && x.member == member {
+		sl.deleteNode(x, update[:])
+		return true
+	}
+	return false
+}
+
+// updateScore 修改成员分数, 位置不变时原地修改
+// @param curScore float64 当前分数
+// @param member string
+// @param newScore float64 新分数
+func (sl *skiplist) updateScore(curScore float64, member string, newScore float64) {
+	var update [skiplistMaxLevel]*skiplistNode
+	x := sl.findUpdate(curScore, member, update[:])
+	if x == nil || x.score != curScore || x.member != member {
+		return
+	}
+	if (x.backward == nil || zsetLess(x.backward.score, x.backward.member, newScore, member)) &&
+		(x.level[0].forward == nil || zsetLess(newScore, member, x.level[0].forward.score, x.level[0].forward.member)) {
+		x.score = newScore
+		return
+	}
+	sl.deleteNode(x, update[:])
+	sl.insert(newScore, member)
+}
+
+// rank 获取节点排名
+// @param score float64
+// @param member string
+// @return int 从1开始的排名, 0表示不存在
+func (sl *skiplist) rank(score float64, member string) int {
+	rank := 0
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && !zsetLess(score, member, x.level[i].forward.score, x.level[i].forward.member) {
+			rank += x.level[i].span
+			x = x.level[i].forward
+		}
+		if x != sl.header && x.member == member {
+			return rank
+		}
+	}
+	return 0
+}
+
+// byRank 按排名获取节点
+// @param rank int 从1开始的排名
+// @return *skiplistNode 不存在时为nil
+func (sl *skiplist) byRank(rank int) *skiplistNode {
+	traversed := 0
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && traversed+x.level[i].span <= rank {
+			traversed += x.level[i].span
+			x = x.level[i].forward
+		}
+		if traversed == rank {
+			return x
+		}
+	}
+	return nil
+}
+
+// isInRange 跳表中是否可能存在区间内的节点
+// @param r zrangeBound
+// @return bool
+func (sl *skiplist) isInRange(r zrangeBound) bool {
+	if r.empty() {
+		return false
+	}
+	if sl.tail == nil || !r.gteMin(sl.tail.score, sl.tail.member) {
+		return false
+	}
+	first := sl.first()
+	return first != nil && r.lteMax(first.score, first.member)
+}
+
+// firstInRange 区间内的第一个节点
+// @param r zrangeBound
+// @return *skiplistNode 不存在时为nil
+func (sl *skiplist) firstInRange(r zrangeBound) *skiplistNode {
+	if !sl.isInRange(r) {
+		return nil
+	}
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && !r.gteMin(x.level[i].forward.score, x.level[i].forward.member) {
+			x = x.level[i].forward
+		}
+	}
+	x = x.level[0].forward
+	if x == nil || !r.lteMax(x.score, x.member) {
+		return nil
+	}
+	return x
+}
+
+// lastInRange 区间内的最后一个节点
+// @param r zrangeBound
+// @return *skiplistNode 不存在时为nil
+func (sl *skiplist) lastInRange(r zrangeBound) *skiplistNode {
+	if !sl.isInRange(r) {
+		return nil
+	}
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && r.lteMax(x.level[i].forward.score, x.level[i].forward.member) {
+			x = x.level[i].forward
+		}
+	}
+	if x == sl.header || !r.gteMin(x.score, x.member) {
+		return nil
+	}
+	return x
+}
+
+// deleteRange 删除区间内的节点, 并同步删除字典中的成员
+// @param r zrangeBound
+// @param dict map[string]float64 成员字典
+// @return int 删除的数量
+func (sl *skiplist) deleteRange(r zrangeBound, dict map[string]float64) int {
+	var update [skiplistMaxLevel]*skiplistNode
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && !r.gteMin(x.level[i].forward.score, x.level[i].forward.member) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+	x = x.level[0].forward
+	removed := 0
+	for x != nil && r.lteMax(x.score, x.member) {
+		next := x.level[0].forward
+		sl.deleteNode(x, update[:])
+		delete(dict, x.member)
+		removed++
+		x = next
+	}
+	return removed
+}
+
+// deleteRangeByRank 删除排名区间内的节点, 并同步删除字典中的成员
+// @param start int 起始排名(从1开始, 含)
+// @param end int 结束排名(含)
+// @param dict map[string]float64 成员字典
+// @return int 删除的数量
+func (sl *skiplist) deleteRangeByRank(start, end int, dict map[string]float64) int {
+	var update [skiplistMaxLevel]*skiplistNode
+	traversed := 0
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && traversed+x.level[i].span < start {
+			traversed += x.level[i].span
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+	traversed++
+	x = x.level[0].forward
+	removed := 0
+	for x != nil && traversed <= end {
+		next := x.level[0].forward
+		sl.deleteNode(x, update[:])
+		delete(dict, x.member)
+		removed++
+		traversed++
+		x = next
+	}
+	return removed
+}
@@ -0,0 +1,168 @@
+package data
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// checkSkiplist 校验跳表的排序、跨度、后退指针与长度, 并与期望的有序成员逐一比对
+// @param t *testing.T
+// @param sl *skiplist
+// @param want []ZSetEntry 期望的升序成员
+func checkSkiplist(t *testing.T, sl *skiplist, want []ZSetEntry) {
+	t.Helper()
+	if sl.length != len(want) {
+		t.Fatalf("长度 %d, 期望 %d", sl.length, len(want))
+	}
+	// 每个节点的排名, 用于校验各层跨度
+	ranks := map[*skiplistNode]int{sl.header: 0}
+	var prev *skiplistNode
+	x := sl.header.level[0].forward
+	for i := 0; x != nil; i++ {
+		if x.member != want[i].Member || x.score != want[i].Score {
+			t.Fatalf("第 %d 个节点为 %s/%g, 期望 %s/%g", i+1, x.member, x.score, want[i].Member, want[i].Score)
+		}
+		if x.backward != prev {
+			t.Fatalf("节点 %s 的后退指针错误", x.member)
+		}
+		ranks[x] = i + 1
+		prev, x = x, x.level[0].forward
+	}
+	if sl.tail != prev {
+		t.Fatal("尾节点错误")
+	}
+	for node, rank := range ranks {
+		for i := 0; i < len(node.level) && (node != sl.header || i < sl.level); i++ {
+			next := node.level[i].forward
+			if next != nil && node.level[i].span != ranks[next]-rank {
+				t.Fatalf("排名 %d 的节点第 %d 层跨度为 %d, 期望 %d", rank, i, node.level[i].span, ranks[next]-rank)
+			}
+		}
+	}
+	for i, e := range want {
+		if r := sl.rank(e.Score, e.Member); r != i+1 {
+			t.Fatalf("%s 的排名为 %d, 期望 %d", e.Member, r, i+1)
+		}
+		if n := sl.byRank(i + 1); n == nil || n.member != e.Member {
+			t.Fatalf("排名 %d 的节点错误", i+1)
+		}
+	}
+	if sl.byRank(len(want)+1) != nil {
+		t.Fatal("超出长度的排名应返回nil")
+	}
+}
+
+// sortEntries 按 (分数, 成员) 升序排列
+// @param entries []ZSetEntry
+func sortEntries(entries []ZSetEntry) {
+	slices.SortFunc(entries, func(a, b ZSetEntry) int {
+		switch {
+		case zsetLess(a.Score, a.Member, b.Score, b.Member):
+			return -1
+		case zsetLess(b.Score, b.Member, a.Score, a.Member):
+			return 1
+		}
+		return 0
+	})
+}
+
+// TestSkiplistSpan 随机插入、删除、改分与区间删除后跨度与排名保持正确
+func TestSkiplistSpan(t *testing.T) {
+	tests := []struct {
+		name string
+		// 成员数与分数取值范围, 取值范围小时大量成员分数相同
+		members, scores int
+	}{
+		{"少量成员", 8, 100},
+		{"分数全部相同", 200, 1},
+		{"分数大量重复", 500, 10},
+		{"分数分散", 2000, 1 << 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rand.New(rand.NewPCG(1, uint64(tt.members)))
+			sl := newSkiplist()
+			dict := map[string]float64{}
+			entries := func() []ZSetEntry {
+				result := make([]ZSetEntry, 0, len(dict))
+				for m, s := range dict {
+					result = append(result, ZSetEntry{Member: m, Score: s})
+				}
+				sortEntries(result)
+				return result
+			}
+			for i := 0; i < tt.members*4; i++ {
+				member := fmt.Sprintf("m%d", r.IntN(tt.members))
+				score := float64(r.IntN(tt.scores))
+				old, exists := dict[member]
+				switch {
+				case !exists:
+					sl.insert(score, member)
+					dict[member] = score
+				case r.IntN(2) == 0:
+					sl.updateScore(old, member, score)
+					dict[member] = score
+				default:
+					if !sl.delete(old, member) {
+						t.Fatalf("删除 %s 失败", member)
+					}
+					delete(dict, member)
+				}
+			}
+			checkSkiplist(t, sl, entries())
+
+			half := float64(tt.scores / 2)
+			removed := sl.deleteRange(newScoreRange(ScoreBound{Value: half}, ScoreBound{Value: half + float64(tt.scores)/4}), dict)
+			want := entries()
+			checkSkiplist(t, sl, want)
+			if len(dict) != sl.length {
+				t.Fatalf("区间删除了 %d 个, 字典剩余 %d, 跳表剩余 %d", removed, len(dict), sl.length)
+			}
+
+			if len(want) >= 3 {
+				removed = sl.deleteRangeByRank(2, len(want)-1, dict)
+				if removed != len(want)-2 {
+					t.Fatalf("按排名删除了 %d 个, 期望 %d", removed, len(want)-2)
+				}
+				checkSkiplist(t, sl, []ZSetEntry{want[0], want[len(want)-1]})
+			}
+		})
+	}
+}
+
+// TestSkiplistRangeEnds 区间的首尾节点
+func TestSkiplistRangeEnds(t *testing.T) {
+	sl := newSkiplist()
+	for i := 1; i <= 10; i++ {
+		sl.insert(float64(i), fmt.Sprintf("m%02d", i))
+	}
+	tests := []struct {
+		name        string
+		min, max    ScoreBound
+		first, last string
+	}{
+		{"闭区间", ScoreBound{Value: 3}, ScoreBound{Value: 5}, "m03", "m05"},
+		{"开区间", ScoreBound{Value: 3, Exclusive: true}, ScoreBound{Value: 5, Exclusive: true}, "m04", "m04"},
+		{"覆盖全部", ScoreBound{Value: -100}, ScoreBound{Value: 100}, "m01", "m10"},
+		{"落在两个分数之间", ScoreBound{Value: 3.2}, ScoreBound{Value: 3.8}, "", ""},
+		{"高于最大值", ScoreBound{Value: 11}, ScoreBound{Value: 20}, "", ""},
+		{"端点相同的开区间", ScoreBound{Value: 4, Exclusive: true}, ScoreBound{Value: 4}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newScoreRange(tt.min, tt.max)
+			first, last := sl.firstInRange(r), sl.lastInRange(r)
+			if tt.first == "" {
+				if first != nil || last != nil {
+					t.Fatal("区间应为空")
+				}
+				return
+			}
+			if first == nil || last == nil || first.member != tt.first || last.member != tt.last {
+				t.Fatalf("区间首尾为 %v %v, 期望 %s %s", first, last, tt.first, tt.last)
+			}
+		})
+	}
+}
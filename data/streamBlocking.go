@@ -0,0 +1,103 @@
+package data
+
+import (
+	"sync"
+	"time"
+)
+
+// streamWaiter 一个阻塞等待中的读取请求
+type streamWaiter struct {
+	// 等待的全部流
+	keys []string
+	// 有新条目时写入, 缓冲为1, 写入方不会阻塞
+	wake chan struct{}
+}
+
+// streamBlocking 流上阻塞等待的读取请求表
+// 与有序集合的阻塞弹出不同, 读取不会消耗条目, 因此新条目写入时唤醒该key上的全部请求, 由请求自行重新读取
+type streamBlocking struct {
+	mu      sync.Mutex
+	waiters map[string]map[*streamWaiter]struct{}
+}
+
+// newStreamBlocking 创建空的等待表
+// @return *streamBlocking
+func newStreamBlocking() *streamBlocking {
+	return &streamBlocking{waiters: make(map[string]map[*streamWaiter]struct{})}
+}
+
+// register 登记等待请求, 调用方需持有全部key的锁, 以免错过登记前的写入
+// @param keys []string
+// @return *streamWaiter
+func (b *streamBlocking) register(keys []string) *streamWaiter {
+	w := &streamWaiter{keys: keys, wake: make(chan struct{}, 1)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range keys {
+		if b.waiters[key] == nil {
+			b.waiters[key] = make(map[*streamWaiter]struct{})
+		}
+		b.waiters[key][w] = struct{}{}
+	}
+	return w
+}
+
+// unregister 从全部key上移除等待请求
+// @param w *streamWaiter
+func (b *streamBlocking) unregister(w *streamWaiter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range w.keys {
+		delete(b.waiters[key], w)
+		if len(b.waiters[key]) == 0 {
+			delete(b.waiters, key)
+		}
+	}
+}
+
+// signal 唤醒在key上等待的全部请求
+// @param key string
+func (b *streamBlocking) signal(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for w := range b.waiters[key] {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// blockUntil 反复尝试读取, 没有结果时阻塞等待新条目, 直到读到结果或超时
+// try 在持有全部key的锁时调用, 返回结果是否非空; 返回空结果时在释放锁之前登记等待, 保证不会错过之后的写入
+// @param keys []string
+// @param timeout time.Duration 最长等待时间, 0表示一直等待
+// @param lock, unlock func() 对全部key加锁与解锁
+// @param try func() (bool, error) 尝试读取, 返回 (是否读到结果, 错误)
+// @return bool 是否读到结果
+// @return error
+func (b *streamBlocking) blockUntil(keys []string, timeout time.Duration, lock, unlock func(), try func() (bool, error)) (bool, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		lock()
+		found, err := try()
+		if found || err != nil {
+			unlock()
+			return found, err
+		}
+		w := b.register(keys)
+		unlock()
+		select {
+		case <-w.wake:
+			b.unregister(w)
+		case <-expired:
+			b.unregister(w)
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package data
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// StreamPendingEntry 消费者组待确认列表(PEL)中的一项: 已投递给消费者但尚未确认的条目
+type StreamPendingEntry struct {
+	ID StreamID
+	// 当前持有该条目的消费者
+	Consumer string
+	// 最近一次投递的时间
+	DeliveredAt time.Time
+	// 投递次数
+	DeliveryCount int
+}
+
+// StreamConsumerPending 消费者及其待确认的条目数
+type StreamConsumerPending struct {
+	Name    string
+	Pending int
+}
+
+// StreamPendingSummary 待确认列表的概要
+type StreamPendingSummary struct {
+	Count    int
+	Min, Max StreamID
+	// 有待确认条目的消费者, 按名称排列
+	Consumers []StreamConsumerPending
+}
+
+// streamConsumer 消费者组中的一个消费者
+type streamConsumer struct {
+	// 最近一次读取或认领的时间
+	seen time.Time
+	// 待确认的条目数
+	pending int
+}
+
+// streamGroup 消费者组
+type streamGroup struct {
+	// 已投递给组内消费者的最大ID, ">" 读取其后的条目
+	lastDelivered StreamID
+	// 待确认列表, 按ID升序排列
+	pel []*StreamPendingEntry
+	// 消费者
+	consumers map[string]*streamConsumer
+}
+
+// newStreamGroup 创建消费者组
+// @param lastDelivered StreamID 从该ID之后开始投递
+// @return *streamGroup
+func newStreamGroup(lastDelivered StreamID) *streamGroup {
+	return &streamGroup{lastDelivered: lastDelivered, consumers: make(map[string]*streamConsumer)}
+}
+
+// consumer 获取消费者, 不存在时创建, 并刷新其活跃时间
+// @param name string
+// @param now time.Time
+// @return *streamConsumer
+func (g *streamGroup) consumer(name string, now time.Time) *streamConsumer {
+	c, exists := g.consumers[name]
+	if !exists {
+		c = &streamConsumer{}
+		g.consumers[name] = c
+	}
+	c.seen = now
+	return c
+}
+
+// pendingIndex 查找待确认的条目
+// @param id StreamID
+// @return int 不存在时为应插入的位置
+// @return bool 是否存在
+func (g *streamGroup) pendingIndex(id StreamID) (int, bool) {
+	return slices.BinarySearchFunc(g.pel, id, func(p *StreamPendingEntry, id StreamID) int {
+		return p.ID.Compare(id)
+	})
+}
+
+// deliver 将条目投递给消费者: 新条目加入待确认列表, 已在列表中的条目转移给该消费者并增加投递次数
+// @param id StreamID
+// @param consumer string
+// @param now time.Time
+func (g *streamGroup) deliver(id StreamID, consumer string, now time.Time) {
+	i, exists := g.pendingIndex(id)
+	if !exists {
+		g.pel = slices.Insert(g.pel, i, &StreamPendingEntry{ID: id, Consumer: consumer, DeliveredAt: now, DeliveryCount: 1})
+		g.consumer(consumer, now).pending++
+		return
+	}
+	p := g.pel[i]
+	g.assign(p, consumer, now)
+	p.DeliveryCount++
+}
+
+// assign 将待确认的条目转移给消费者
+// @param p *StreamPendingEntry
+// @param consumer string
+// @param now time.Time
+func (g *streamGroup) assign(p *StreamPendingEntry, consumer string, now time.Time) {
+	if p.Consumer != consumer {
+		if old, exists := g.consumers[p.Consumer]; exists {
+			old.pending--
+		}
+		g.consumer(consumer, now).pending++
+		p.Consumer = consumer
+	}
+	p.DeliveredAt = now
+}
+
+// ack 确认条目, 将其移出待确认列表
+// @param id StreamID
+// @return bool 条目是否在待确认列表中
+func (g *streamGroup) ack(id StreamID) bool {
+	i, exists := g.pendingIndex(id)
+	if !exists {
+		return false
+	}
+	if c, ok := g.consumers[g.pel[i].Consumer]; ok {
+		c.pending--
+	}
+	g.pel = slices.Delete(g.pel, i, i+1)
+	return true
+}
+
+// deleteConsumer 删除消费者及其全部待确认条目
+// @param name string
+// @return int 被删除的待确认条目数
+func (g *streamGroup) deleteConsumer(name string) int {
+	c, exists := g.consumers[name]
+	if !exists {
+		return 0
+	}
+	g.pel = slices.DeleteFunc(g.pel, func(p *StreamPendingEntry) bool {
+		return p.Consumer == name
+	})
+	delete(g.consumers, name)
+	return c.pending
+}
+
+// readNew 读取 lastDelivered 之后的新条目并投递给消费者
+// @param s *streamObject
+// @param consumer string
+// @param count int 小于等于0表示不限制
+// @param noAck bool 为true时不加入待确认列表
+// @param now time.Time
+// @return []StreamEntry
+func (g *streamGroup) readNew(s *streamObject, consumer string, count int, noAck bool, now time.Time) []StreamEntry {
+	g.consumer(consumer, now)
+	entries := s.after(g.lastDelivered, count)
+	for _, e := range entries {
+		g.lastDelivered = e.ID
+		if !noAck {
+			g.deliver(e.ID, consumer, now)
+		}
+	}
+	return entries
+}
+
+// readHistory 读取消费者自己待确认的条目, 不改变投递次数; 已从流中删除的条目字段为nil
+// @param s *streamObject
+// @param consumer string
+// @param after StreamID 只返回ID大于该值的条目
+// @param count int 小于等于0表示不限制
+// @param now time.Time
+// @return []StreamEntry
+func (g *streamGroup) readHistory(s *streamObject, consumer string, after StreamID, count int, now time.Time) []StreamEntry {
+	g.consumer(consumer, now)
+	entries := []StreamEntry{}
+	for _, p := range g.pel {
+		if count > 0 && len(entries) >= count {
+			break
+		}
+		if p.Consumer != consumer || p.ID.Compare(after) <= 0 {
+			continue
+		}
+		e, exists := s.get(p.ID)
+		if !exists {
+			e = StreamEntry{ID: p.ID}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// summary 待确认列表的概要
+// @return StreamPendingSummary
+func (g *streamGroup) summary() StreamPendingSummary {
+	result := StreamPendingSummary{Count: len(g.pel), Consumers: []StreamConsumerPending{}}
+	if len(g.pel) == 0 {
+		return result
+	}
+	result.Min, result.Max = g.pel[0].ID, g.pel[len(g.pel)-1].ID
+	for name, c := range g.consumers {
+		if c.pending > 0 {
+			result.Consumers = append(result.Consumers, StreamConsumerPending{Name: name, Pending: c.pending})
+		}
+	}
+	slices.SortFunc(result.Consumers, func(a, b StreamConsumerPending) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+// StreamPendingOptions XPENDING 的区间查询参数
+type StreamPendingOptions struct {
+	// 闭区间
+	Start, End StreamID
+	// 最多返回的数量
+	Count int
+	// 只返回该消费者的条目, 为空时不限制
+	Consumer string
+	// 只返回空闲时间不小于该值的条目
+	MinIdle time.Duration
+}
+
+// pendingRange 按区间查询待确认的条目
+// @param opts StreamPendingOptions
+// @param now time.Time
+// @return []StreamPendingEntry
+func (g *streamGroup) pendingRange(opts StreamPendingOptions, now time.Time) []StreamPendingEntry {
+	result := []StreamPendingEntry{}
+	i, _ := g.pendingIndex(opts.Start)
+	for ; i < len(g.pel) && len(result) < opts.Count; i++ {
+		p := g.pel[i]
+		if p.ID.Compare(opts.End) > 0 {
+			break
+		}
+		if (opts.Consumer != "" && p.Consumer != opts.Consumer) || now.Sub(p.DeliveredAt) < opts.MinIdle {
+			continue
+		}
+		result = append(result, *p)
+	}
+	return result
+}
+
+// StreamClaimOptions XCLAIM 的可选参数
+type StreamClaimOptions struct {
+	// 认领后的投递时间, 零值表示当前时间; 对应 IDLE 与 TIME 参数
+	DeliveredAt time.Time
+	// SetRetryCount 为true时将投递次数设为 RetryCount, 否则投递次数加1
+	SetRetryCount bool
+	RetryCount    int
+	// 条目不在待确认列表中但仍在流中时, 也加入待确认列表
+	Force bool
+	// 只返回ID, 且不增加投递次数
+	JustID bool
+}
+
+// claim 将空闲时间足够长的待确认条目转移给消费者, 已从流中删除的条目从待确认列表中移除
+// @param s *streamObject
+// @param consumer string
+// @param minIdle time.Duration
+// @param ids []StreamID
+// @param opts StreamClaimOptions
+// @param now time.Time
+// @return []StreamEntry 被认领的条目
+func (g *streamGroup) claim(s *streamObject, consumer string, minIdle time.Duration, ids []StreamID, opts StreamClaimOptions, now time.Time) []StreamEntry {
+	deliveredAt := opts.DeliveredAt
+	if deliveredAt.IsZero() {
+		deliveredAt = now
+	}
+	g.consumer(consumer, now)
+	result := []StreamEntry{}
+	for _, id := range ids {
+		e, inStream := s.get(id)
+		i, pending := g.pendingIndex(id)
+		if !pending {
+			if !opts.Force || !inStream {
+				continue
+			}
+			g.pel = slices.Insert(g.pel, i, &StreamPendingEntry{ID: id, Consumer: consumer, DeliveredAt: deliveredAt})
+			g.consumers[consumer].pending++
+		}
+		p := g.pel[i]
+		if pending && now.Sub(p.DeliveredAt) < minIdle {
+			continue
+		}
+		if !inStream {
+			g.ack(id)
+			continue
+		}
+		g.assign(p, consumer, now)
+		p.DeliveredAt = deliveredAt
+		switch {
+		case opts.SetRetryCount:
+			p.DeliveryCount = opts.RetryCount
+		case !opts.JustID:
+			p.DeliveryCount++
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// autoClaimAttemptsFactor autoClaim 每认领一个条目最多检查的待确认条目数
+const autoClaimAttemptsFactor = 10
+
+// MaxAutoClaimCount autoClaim 一次最多认领的数量, 保证检查次数不溢出
+const MaxAutoClaimCount = math.MaxInt / autoClaimAttemptsFactor
+
+// autoClaim 从 start 开始扫描待确认列表, 认领空闲时间足够长的条目
+// @param s *streamObject
+// @param consumer string
+// @param minIdle time.Duration
+// @param start StreamID
+// @param count int 最多认领的数量
+// @param justID bool 为true时不增加投递次数
+// @param now time.Time
+// @return StreamID 下次扫描的起点, 扫描完整个列表时为 0-0
+// @return []StreamEntry 被认领的条目
+// @return []StreamID 已从流中删除而被移出待确认列表的ID
+func (g *streamGroup) autoClaim(s *streamObject, consumer string, minIdle time.Duration, start StreamID, count int, justID bool, now time.Time) (StreamID, []StreamEntry, []StreamID) {
+	g.consumer(consumer, now)
+	claimed := []StreamEntry{}
+	deleted := []StreamID{}
+	// 与 Redis 相同, 每次最多检查 count 的若干倍个条目, 避免长时间占用锁
+	attempts := count * autoClaimAttemptsFactor
+	i, _ := g.pendingIndex(start)
+	for i < len(g.pel) && len(claimed) < count && attempts > 0 {
+		attempts--
+		p := g.pel[i]
+		if now.Sub(p.DeliveredAt) < minIdle {
+			i++
+			continue
+		}
+		e, inStream := s.get(p.ID)
+		if !inStream {
+			deleted = append(deleted, p.ID)
+			g.ack(p.ID)
+			continue
+		}
+		g.assign(p, consumer, now)
+		if !justID {
+			p.DeliveryCount++
+		}
+		claimed = append(claimed, e)
+		i++
+	}
+	next := StreamID{}
+	if i < len(g.pel) {
+		next = g.pel[i].ID
+	}
+	return next, claimed, deleted
+}
+
+// streamGroupSnapshot 消费者组的快照形式
+type streamGroupSnapshot struct {
+	LastDelivered StreamID
+	Pending       []StreamPendingEntry
+	// 消费者 -> 最近活跃时间
+	Consumers map[string]time.Time
+}
+
+// snapshot 生成快照
+// @return streamGroupSnapshot
+func (g *streamGroup) snapshot() streamGroupSnapshot {
+	s := streamGroupSnapshot{
+		LastDelivered: g.lastDelivered,
+		Pending:       make([]StreamPendingEntry, len(g.pel)),
+		Consumers:     make(map[string]time.Time, len(g.consumers)),
+	}
+	for i, p := range g.pel {
+		s.Pending[i] = *p
+	}
+	for name, c := range g.consumers {
+		s.Consumers[name] = c.seen
+	}
+	return s
+}
+
+// streamGroupFromSnapshot 由快照还原消费者组
+// @param s streamGroupSnapshot
+// @return *streamGroup
+func streamGroupFromSnapshot(s streamGroupSnapshot) *streamGroup {
+	g := newStreamGroup(s.LastDelivered)
+	for name, seen := range s.Consumers {
+		g.consumers[name] = &streamConsumer{seen: seen}
+	}
+	for _, p := range s.Pending {
+		c, exists := g.consumers[p.Consumer]
+		if !exists {
+			c = &streamConsumer{seen: p.DeliveredAt}
+			g.consumers[p.Consumer] = c
+		}
+		c.pending++
+		g.pel = append(g.pel, &p)
+	}
+	slices.SortFunc(g.pel, func(a, b *StreamPendingEntry) int {
+		return a.ID.Compare(b.ID)
+	})
+	return g
+}
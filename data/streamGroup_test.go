@@ -0,0 +1,241 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newGroupFixture 创建包含 1-0 到 n-0 的流, 以及已将全部条目投递给 alice 的消费者组
+// @param n int
+// @param at time.Time 投递时间
+// @return *streamObject
+// @return *streamGroup
+func newGroupFixture(n int, at time.Time) (*streamObject, *streamGroup) {
+	s := newStreamObject()
+	for i := 1; i <= n; i++ {
+		s.append(StreamID{uint64(i), 0}, []string{"f", fmt.Sprint(i)})
+	}
+	g := newStreamGroup(StreamID{})
+	g.readNew(s, "alice", 0, false, at)
+	return s, g
+}
+
+// formatPEL 将待确认列表拼接为 "ID/消费者/投递次数" 的形式
+// @param g *streamGroup
+// @return string
+func formatPEL(g *streamGroup) string {
+	parts := make([]string, len(g.pel))
+	for i, p := range g.pel {
+		parts[i] = fmt.Sprintf("%s/%s/%d", p.ID, p.Consumer, p.DeliveryCount)
+	}
+	return strings.Join(parts, " ")
+}
+
+// TestStreamGroupPEL 读取新条目、重新投递、确认与删除消费者时待确认列表与消费者计数的变化
+func TestStreamGroupPEL(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	s := newStreamObject()
+	for i := 1; i <= 5; i++ {
+		s.append(StreamID{uint64(i), 0}, []string{"f", fmt.Sprint(i)})
+	}
+	g := newStreamGroup(StreamID{})
+	if got := joinIDs(g.readNew(s, "alice", 2, false, t0)); got != "1-0,2-0" {
+		t.Fatalf("alice 读到 %s", got)
+	}
+	// NOACK 读取的条目推进投递位置但不进入待确认列表
+	if got := joinIDs(g.readNew(s, "bob", 0, true, t0)); got != "3-0,4-0,5-0" || g.lastDelivered != (StreamID{5, 0}) {
+		t.Fatalf("bob 读到 %s, 投递位置 %v", got, g.lastDelivered)
+	}
+	if got := g.readNew(s, "bob", 0, false, t0); len(got) != 0 {
+		t.Fatalf("没有新条目时读到 %s", joinIDs(got))
+	}
+	sum := g.summary()
+	if sum.Count != 2 || sum.Min != (StreamID{1, 0}) || sum.Max != (StreamID{2, 0}) || fmt.Sprint(sum.Consumers) != "[{alice 2}]" {
+		t.Fatalf("概要 %+v", sum)
+	}
+
+	tests := []struct {
+		name     string
+		consumer string
+		after    StreamID
+		count    int
+		want     string
+	}{
+		{"全部历史", "alice", StreamID{}, 0, "1-0,2-0"},
+		{"指定起点之后", "alice", StreamID{1, 0}, 0, "2-0"},
+		{"数量限制", "alice", StreamID{}, 1, "1-0"},
+		{"只返回自己的条目", "bob", StreamID{}, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinIDs(g.readHistory(s, tt.consumer, tt.after, tt.count, t0)); got != tt.want {
+				t.Fatalf("得到 %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+
+	g.deliver(StreamID{1, 0}, "bob", t0.Add(time.Second))
+	if got := formatPEL(g); got != "1-0/bob/2 2-0/alice/1" || g.consumers["alice"].pending != 1 || g.consumers["bob"].pending != 1 {
+		t.Fatalf("重新投递后 %s", got)
+	}
+	if !g.ack(StreamID{1, 0}) || g.ack(StreamID{1, 0}) || g.consumers["bob"].pending != 0 {
+		t.Fatal("重复确认应返回false, 且消费者的计数只减少一次")
+	}
+	s.remove([]StreamID{{2, 0}})
+	if got := g.readHistory(s, "alice", StreamID{}, 0, t0); len(got) != 1 || got[0].ID != (StreamID{2, 0}) || got[0].Fields != nil {
+		t.Fatalf("已删除的条目应以空字段返回, 得到 %+v", got)
+	}
+	if n := g.deleteConsumer("alice"); n != 1 || len(g.pel) != 0 || g.consumers["alice"] != nil {
+		t.Fatalf("删除消费者移除了 %d 个条目, 剩余 %s", n, formatPEL(g))
+	}
+	if n := g.deleteConsumer("alice"); n != 0 {
+		t.Fatalf("删除不存在的消费者得到 %d", n)
+	}
+}
+
+// TestStreamPendingRange 按区间、数量、消费者与空闲时间查询待确认的条目
+func TestStreamPendingRange(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	_, g := newGroupFixture(3, t0)
+	g.deliver(StreamID{2, 0}, "bob", t0.Add(time.Minute))
+	g.deliver(StreamID{3, 0}, "alice", t0.Add(2*time.Minute))
+	now := t0.Add(3 * time.Minute)
+	tests := []struct {
+		name string
+		opts StreamPendingOptions
+		want string
+	}{
+		{"全部", StreamPendingOptions{End: streamMaxID, Count: 10}, "1-0,2-0,3-0"},
+		{"数量限制", StreamPendingOptions{End: streamMaxID, Count: 2}, "1-0,2-0"},
+		{"闭区间", StreamPendingOptions{Start: StreamID{2, 0}, End: StreamID{2, 0}, Count: 10}, "2-0"},
+		{"指定消费者", StreamPendingOptions{End: streamMaxID, Count: 10, Consumer: "alice"}, "1-0,3-0"},
+		{"最小空闲时间", StreamPendingOptions{End: streamMaxID, Count: 10, MinIdle: 2 * time.Minute}, "1-0,2-0"},
+		{"数量为0", StreamPendingOptions{End: streamMaxID}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := g.pendingRange(tt.opts, now)
+			ids := make([]string, len(entries))
+			for i, p := range entries {
+				ids[i] = p.ID.String()
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Fatalf("得到 %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestStreamClaim 空闲时间、FORCE、JUSTID、RETRYCOUNT 与已删除条目的处理
+func TestStreamClaim(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	now := t0.Add(10 * time.Second)
+	ids := []StreamID{{1, 0}, {2, 0}, {3, 0}, {4, 0}}
+	tests := []struct {
+		name    string
+		minIdle time.Duration
+		opts    StreamClaimOptions
+		claimed string
+		pel     string
+	}{
+		{"空闲时间为0", 0, StreamClaimOptions{}, "1-0,2-0", "1-0/bob/2 2-0/bob/3"},
+		{"空闲时间不足的条目不认领", 5 * time.Second, StreamClaimOptions{}, "1-0", "1-0/bob/2 2-0/alice/2"},
+		{"已删除但空闲时间不足的条目保留", 20 * time.Second, StreamClaimOptions{}, "", "1-0/alice/1 2-0/alice/2 4-0/alice/1"},
+		{"FORCE认领不在列表中的条目", 0, StreamClaimOptions{Force: true}, "1-0,2-0,3-0", "1-0/bob/2 2-0/bob/3 3-0/bob/1"},
+		{"JUSTID不增加投递次数", 0, StreamClaimOptions{JustID: true}, "1-0,2-0", "1-0/bob/1 2-0/bob/2"},
+		{"RETRYCOUNT", 0, StreamClaimOptions{SetRetryCount: true, RetryCount: 7}, "1-0,2-0", "1-0/bob/7 2-0/bob/7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1-0 于 t0 投递, 2-0 于 t0+8s 重新投递, 3-0 已确认, 4-0 已从流中删除
+			s, g := newGroupFixture(4, t0)
+			g.deliver(StreamID{2, 0}, "alice", t0.Add(8*time.Second))
+			g.ack(StreamID{3, 0})
+			s.remove([]StreamID{{4, 0}})
+			if got := joinIDs(g.claim(s, "bob", tt.minIdle, ids, tt.opts, now)); got != tt.claimed {
+				t.Fatalf("认领了 %s, 期望 %s", got, tt.claimed)
+			}
+			if got := formatPEL(g); got != tt.pel {
+				t.Fatalf("待确认列表为 %s, 期望 %s", got, tt.pel)
+			}
+			pending := 0
+			for _, c := range g.consumers {
+				pending += c.pending
+			}
+			if pending != len(g.pel) {
+				t.Fatalf("消费者的计数之和 %d 与待确认列表的长度 %d 不一致", pending, len(g.pel))
+			}
+		})
+	}
+
+	s, g := newGroupFixture(1, t0)
+	g.claim(s, "bob", 0, ids[:1], StreamClaimOptions{DeliveredAt: t0.Add(-time.Hour)}, now)
+	if p := g.pel[0]; !p.DeliveredAt.Equal(t0.Add(-time.Hour)) {
+		t.Fatalf("指定 IDLE/TIME 时投递时间为 %v", p.DeliveredAt)
+	}
+}
+
+// TestStreamAutoClaim 扫描游标、已删除条目的清理、检查次数上限与 count 的范围
+func TestStreamAutoClaim(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	now := t0.Add(10 * time.Second)
+	tests := []struct {
+		name    string
+		start   StreamID
+		count   int
+		claimed string
+		deleted string
+		next    StreamID
+	}{
+		{"从头开始", StreamID{}, 2, "1-0,3-0", "2-0", StreamID{4, 0}},
+		{"扫描到末尾", StreamID{4, 0}, 10, "6-0", "4-0", StreamID{}},
+		{"起点不在列表中", StreamID{2, 5}, 1, "3-0", "", StreamID{4, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 2-0 与 4-0 已从流中删除, 5-0 的空闲时间不足
+			s, g := newGroupFixture(6, t0)
+			s.remove([]StreamID{{2, 0}, {4, 0}})
+			g.deliver(StreamID{5, 0}, "alice", t0.Add(9*time.Second))
+			next, claimed, deleted := g.autoClaim(s, "bob", 5*time.Second, tt.start, tt.count, false, now)
+			deletedIDs := make([]string, len(deleted))
+			for i, id := range deleted {
+				deletedIDs[i] = id.String()
+			}
+			if joinIDs(claimed) != tt.claimed || strings.Join(deletedIDs, ",") != tt.deleted || next != tt.next {
+				t.Fatalf("得到 %s %v %v, 期望 %s %s %v", joinIDs(claimed), deletedIDs, next, tt.claimed, tt.deleted, tt.next)
+			}
+			for _, id := range deleted {
+				if _, pending := g.pendingIndex(id); pending {
+					t.Fatalf("%v 已从流中删除, 应移出待确认列表", id)
+				}
+			}
+		})
+	}
+
+	// 每认领一个条目最多检查 autoClaimAttemptsFactor 个待确认条目
+	s, g := newGroupFixture(30, t0)
+	next, claimed, _ := g.autoClaim(s, "bob", time.Hour, StreamID{}, 1, false, now)
+	if len(claimed) != 0 || next != (StreamID{autoClaimAttemptsFactor + 1, 0}) {
+		t.Fatalf("认领了 %d 个, 下次起点 %v", len(claimed), next)
+	}
+
+	st := newTestStream()
+	if err := st.CreateGroup("s", "g", "0", true); err != nil {
+		t.Fatal(err)
+	}
+	for _, count := range []int{0, -1, MaxAutoClaimCount + 1} {
+		if _, _, _, err := st.AutoClaim("s", "g", "bob", 0, StreamID{}, count, false); !errors.Is(err, errAutoClaimCount) {
+			t.Fatalf("count 为 %d 时应返回 errAutoClaimCount, 得到 %v", count, err)
+		}
+	}
+	if _, _, _, err := st.AutoClaim("s", "g", "bob", 0, StreamID{}, MaxAutoClaimCount, false); err != nil {
+		t.Fatalf("count 为上限时不应返回错误: %v", err)
+	}
+	if _, _, _, err := st.AutoClaim("s", "missing", "bob", 0, StreamID{}, 1, false); err == nil {
+		t.Fatal("组不存在时应返回错误")
+	}
+}
@@ -0,0 +1,347 @@
+package data
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errStreamID ID 格式错误
+var errStreamID = errors.New("流ID格式错误, 应为 毫秒时间戳-序号")
+
+// errStreamIDTooSmall 新条目的ID不大于流中最大的ID
+var errStreamIDTooSmall = errors.New("指定的ID必须大于流中最大的ID")
+
+// errStreamIDZero 新条目的ID为 0-0
+var errStreamIDZero = errors.New("流ID必须大于 0-0")
+
+// errStreamIDExhausted 已经无法生成更大的ID
+var errStreamIDExhausted = errors.New("流中最大的ID已达到上限, 无法生成新的ID")
+
+// StreamID 流中条目的ID, 由毫秒时间戳与同一毫秒内的序号组成, 在流中严格递增
+type StreamID struct {
+	Ms, Seq uint64
+}
+
+// streamMaxID 最大的ID, 即区间端点 "+"
+var streamMaxID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+
+// String ID 的文本形式 ms-seq
+// @return string
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// Compare 比较两个ID
+// @param other StreamID
+// @return int
+func (id StreamID) Compare(other StreamID) int {
+	if c := cmp.Compare(id.Ms, other.Ms); c != 0 {
+		return c
+	}
+	return cmp.Compare(id.Seq, other.Seq)
+}
+
+// next 紧随其后的ID
+// @return StreamID
+// @return bool 是否存在, 已是最大ID时为false
+func (id StreamID) next() (StreamID, bool) {
+	switch {
+	case id.Seq < math.MaxUint64:
+		return StreamID{id.Ms, id.Seq + 1}, true
+	case id.Ms < math.MaxUint64:
+		return StreamID{id.Ms + 1, 0}, true
+	}
+	return id, false
+}
+
+// prev 紧邻其前的ID
+// @return StreamID
+// @return bool 是否存在, 已是 0-0 时为false
+func (id StreamID) prev() (StreamID, bool) {
+	switch {
+	case id.Seq > 0:
+		return StreamID{id.Ms, id.Seq - 1}, true
+	case id.Ms > 0:
+		return StreamID{id.Ms - 1, math.MaxUint64}, true
+	}
+	return id, false
+}
+
+// parseStreamID 解析 ms-seq 或只有毫秒的ID
+// @param s string
+// @param defaultSeq uint64 省略序号时使用的序号
+// @return StreamID
+// @return error
+func parseStreamID(s string, defaultSeq uint64) (StreamID, error) {
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return StreamID{}, errStreamID
+	}
+	if !hasSeq {
+		return StreamID{ms, defaultSeq}, nil
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return StreamID{}, errStreamID
+	}
+	return StreamID{ms, seq}, nil
+}
+
+// ParseStreamID 解析ID, 省略序号时序号为0
+// @param s string
+// @return StreamID
+// @return error
+func ParseStreamID(s string) (StreamID, error) {
+	return parseStreamID(s, 0)
+}
+
+// ParseStreamRangeID 解析区间端点
+// 支持 "-"(最小ID)、"+"(最大ID)、省略序号的ID(起点序号为0, 终点序号为最大值)以及以 "(" 开头的开区间端点
+// @param s string
+// @param end bool 是否为区间终点
+// @return StreamID 闭区间端点
+// @return bool 区间是否可能非空, 开区间端点越界时为false
+// @return error
+func ParseStreamRangeID(s string, end bool) (StreamID, bool, error) {
+	switch s {
+	case "-":
+		return StreamID{}, true, nil
+	case "+":
+		return streamMaxID, true, nil
+	}
+	exclusive := strings.HasPrefix(s, "(")
+	s = strings.TrimPrefix(s, "(")
+	var defaultSeq uint64
+	if end {
+		defaultSeq = math.MaxUint64
+	}
+	id, err := parseStreamID(s, defaultSeq)
+	if err != nil || !exclusive {
+		return id, err == nil, err
+	}
+	if end {
+		id, ok := id.prev()
+		return id, ok, nil
+	}
+	id, ok := id.next()
+	return id, ok, nil
+}
+
+// StreamEntry 流中的一个条目
+type StreamEntry struct {
+	ID StreamID
+	// 字段名与字段值交替排列; 读取消费者组待确认列表中已被删除的条目时为nil
+	Fields []string
+}
+
+// StreamTrimStrategy 裁剪策略
+type StreamTrimStrategy int
+
+const (
+	// StreamTrimNone 不裁剪
+	StreamTrimNone StreamTrimStrategy = iota
+	// StreamTrimMaxLen 只保留最新的 MaxLen 个条目
+	StreamTrimMaxLen
+	// StreamTrimMinID 删除ID小于 MinID 的条目
+	StreamTrimMinID
+)
+
+// StreamTrim 裁剪参数
+type StreamTrim struct {
+	Strategy StreamTrimStrategy
+	MaxLen   int
+	MinID    StreamID
+}
+
+// streamObject 单个流的值
+type streamObject struct {
+	// 按ID升序排列的条目
+	entries []StreamEntry
+	// 流中出现过的最大ID, 条目被删除或裁剪后仍然保留, 保证ID不会重复
+	lastID StreamID
+	// 曾经添加过的条目总数
+	added uint64
+	// 消费者组
+	groups map[string]*streamGroup
+}
+
+// newStreamObject 创建空流
+// @return *streamObject
+func newStreamObject() *streamObject {
+	return &streamObject{groups: make(map[string]*streamGroup)}
+}
+
+// nextID 根据 XADD 的ID参数生成新条目的ID
+// "*" 使用当前毫秒时间, 时间回退时沿用最大ID的毫秒数并递增序号; "ms-*" 自动生成序号; 其余为完整指定的ID
+// @param spec string
+// @param now time.Time
+// @return StreamID
+// @return error
+func (s *streamObject) nextID(spec string, now time.Time) (StreamID, error) {
+	if spec == "*" {
+		ms := uint64(max(now.UnixMilli(), 0))
+		if ms > s.lastID.Ms {
+			return StreamID{ms, 0}, nil
+		}
+		id, ok := s.lastID.next()
+		if !ok {
+			return id, errStreamIDExhausted
+		}
+		return id, nil
+	}
+	var id StreamID
+	if msPart, ok := strings.CutSuffix(spec, "-*"); ok {
+		ms, err := strconv.ParseUint(msPart, 10, 64)
+		if err != nil {
+			return id, errStreamID
+		}
+		switch {
+		case ms < s.lastID.Ms:
+			return id, errStreamIDTooSmall
+		case ms > s.lastID.Ms:
+			id = StreamID{ms, 0}
+		case s.lastID.Seq == math.MaxUint64:
+			return id, errStreamIDTooSmall
+		default:
+			id = StreamID{ms, s.lastID.Seq + 1}
+		}
+		return id, nil
+	}
+	id, err := parseStreamID(spec, 0)
+	if err != nil {
+		return id, err
+	}
+	if id == (StreamID{}) {
+		return id, errStreamIDZero
+	}
+	if id.Compare(s.lastID) <= 0 {
+		return id, errStreamIDTooSmall
+	}
+	return id, nil
+}
+
+// append 追加条目, 调用方需保证ID大于 lastID
+// @param id StreamID
+// @param fields []string
+func (s *streamObject) append(id StreamID, fields []string) {
+	s.entries = append(s.entries, StreamEntry{ID: id, Fields: slices.Clone(fields)})
+	s.lastID = id
+	s.added++
+}
+
+// search 第一个ID不小于给定ID的条目下标
+// @param id StreamID
+// @return int
+func (s *streamObject) search(id StreamID) int {
+	i, _ := slices.BinarySearchFunc(s.entries, id, func(e StreamEntry, id StreamID) int {
+		return e.ID.Compare(id)
+	})
+	return i
+}
+
+// get 按ID获取条目
+// @param id StreamID
+// @return StreamEntry
+// @return bool 是否存在
+func (s *streamObject) get(id StreamID) (StreamEntry, bool) {
+	i := s.search(id)
+	if i < len(s.entries) && s.entries[i].ID == id {
+		return s.entries[i], true
+	}
+	return StreamEntry{}, false
+}
+
+// rangeEntries 获取闭区间内的条目
+// @param start, end StreamID
+// @param count int 最多返回的数量, 小于等于0表示不限制
+// @param rev bool 是否从 end 向 start 倒序返回
+// @return []StreamEntry
+func (s *streamObject) rangeEntries(start, end StreamID, count int, rev bool) []StreamEntry {
+	result := []StreamEntry{}
+	if start.Compare(end) > 0 {
+		return result
+	}
+	lo, hi := s.search(start), len(s.entries)
+	if next, ok := end.next(); ok {
+		hi = s.search(next)
+	}
+	selected := s.entries[lo:hi]
+	if count > 0 && len(selected) > count {
+		if rev {
+			selected = selected[len(selected)-count:]
+		} else {
+			selected = selected[:count]
+		}
+	}
+	result = append(result, selected...)
+	if rev {
+		slices.Reverse(result)
+	}
+	return result
+}
+
+// after 获取ID大于给定ID的条目
+// @param id StreamID
+// @param count int 最多返回的数量, 小于等于0表示不限制
+// @return []StreamEntry
+func (s *streamObject) after(id StreamID, count int) []StreamEntry {
+	start, ok := id.next()
+	if !ok {
+		return []StreamEntry{}
+	}
+	return s.rangeEntries(start, streamMaxID, count, false)
+}
+
+// trim 裁剪流的头部
+// @param t StreamTrim
+// @return int 删除的条目数
+func (s *streamObject) trim(t StreamTrim) int {
+	n := 0
+	switch t.Strategy {
+	case StreamTrimMaxLen:
+		n = max(len(s.entries)-t.MaxLen, 0)
+	case StreamTrimMinID:
+		n = s.search(t.MinID)
+	}
+	if n == 0 {
+		return 0
+	}
+	// 清空被裁剪的条目以释放字段, 之后追加时底层数组会按存活的条目重新分配
+	clear(s.entries[:n])
+	s.entries = s.entries[n:]
+	return n
+}
+
+// remove 删除指定ID的条目
+// @param ids []StreamID
+// @return int 删除的条目数
+func (s *streamObject) remove(ids []StreamID) int {
+	removed := 0
+	for _, id := range ids {
+		i := s.search(id)
+		if i < len(s.entries) && s.entries[i].ID == id {
+			s.entries = slices.Delete(s.entries, i, i+1)
+			removed++
+		}
+	}
+	return removed
+}
+
+// group 获取消费者组
+// @param name string
+// @return *streamGroup
+// @return error 组不存在时返回错误
+func (s *streamObject) group(name string) (*streamGroup, error) {
+	g, exists := s.groups[name]
+	if !exists {
+		return nil, fmt.Errorf("消费者组 %s 不存在", name)
+	}
+	return g, nil
+}
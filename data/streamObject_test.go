@@ -0,0 +1,186 @@
+package data
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestStream 创建独立于全局实例的流存储
+// @return *GkvStream
+func newTestStream() *GkvStream {
+	return &GkvStream{
+		data:        make(map[string]*streamObject),
+		expireTimes: make(map[string]time.Time),
+		keyLock:     NewKeyLock(),
+		blocked:     newStreamBlocking(),
+	}
+}
+
+// joinIDs 将条目的ID拼接为 "1-0,2-0" 的形式
+// @param entries []StreamEntry
+// @return string
+func joinIDs(entries []StreamEntry) string {
+	parts := make([]string, len(entries))
+	for i, e := range entries {
+		parts[i] = e.ID.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// TestParseStreamID 完整ID、省略序号的ID与格式错误
+func TestParseStreamID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want StreamID
+		err  error
+	}{
+		{"5-3", StreamID{5, 3}, nil},
+		{"5", StreamID{5, 0}, nil},
+		{"0-0", StreamID{}, nil},
+		{"18446744073709551615-18446744073709551615", streamMaxID, nil},
+		{"", StreamID{}, errStreamID},
+		{"-1", StreamID{}, errStreamID},
+		{"5-", StreamID{}, errStreamID},
+		{"a-1", StreamID{}, errStreamID},
+		{"5-1-2", StreamID{}, errStreamID},
+		{"5-*", StreamID{}, errStreamID},
+		{"18446744073709551616", StreamID{}, errStreamID},
+		{"5-18446744073709551616", StreamID{}, errStreamID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			id, err := ParseStreamID(tt.in)
+			if !errors.Is(err, tt.err) || id != tt.want {
+				t.Fatalf("得到 %v %v, 期望 %v %v", id, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+// TestParseStreamRangeID 区间端点的特殊值、省略的序号与开区间
+func TestParseStreamRangeID(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		end  bool
+		want StreamID
+		ok   bool
+		err  error
+	}{
+		{"最小ID", "-", false, StreamID{}, true, nil},
+		{"最大ID", "+", true, streamMaxID, true, nil},
+		{"起点省略序号", "5", false, StreamID{5, 0}, true, nil},
+		{"终点省略序号", "5", true, StreamID{5, math.MaxUint64}, true, nil},
+		{"开区间起点", "(5-1", false, StreamID{5, 2}, true, nil},
+		{"开区间终点", "(5-1", true, StreamID{5, 0}, true, nil},
+		{"开区间起点省略序号", "(5", false, StreamID{5, 1}, true, nil},
+		{"开区间终点借位", "(5-0", true, StreamID{4, math.MaxUint64}, true, nil},
+		{"开区间起点进位", "(5-18446744073709551615", false, StreamID{6, 0}, true, nil},
+		{"开区间终点低于最小ID", "(0-0", true, StreamID{}, false, nil},
+		{"开区间起点超过最大ID", "(18446744073709551615-18446744073709551615", false, streamMaxID, false, nil},
+		{"格式错误", "(x", false, StreamID{}, false, errStreamID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok, err := ParseStreamRangeID(tt.in, tt.end)
+			if !errors.Is(err, tt.err) || id != tt.want || ok != tt.ok {
+				t.Fatalf("得到 %v %v %v, 期望 %v %v %v", id, ok, err, tt.want, tt.ok, tt.err)
+			}
+		})
+	}
+}
+
+// TestStreamNextID XADD 的ID参数: 自动生成、时钟回退、指定毫秒与完整指定
+func TestStreamNextID(t *testing.T) {
+	tests := []struct {
+		name   string
+		lastID StreamID
+		spec   string
+		nowMs  int64
+		want   StreamID
+		err    error
+	}{
+		{"使用当前时间", StreamID{5, 3}, "*", 10, StreamID{10, 0}, nil},
+		{"时钟回退时递增序号", StreamID{5, 3}, "*", 1, StreamID{5, 4}, nil},
+		{"时钟回退且序号用尽时进位", StreamID{5, math.MaxUint64}, "*", 1, StreamID{6, 0}, nil},
+		{"无法生成更大的ID", streamMaxID, "*", 1, StreamID{}, errStreamIDExhausted},
+		{"相同毫秒递增序号", StreamID{5, 3}, "5-*", 0, StreamID{5, 4}, nil},
+		{"更大的毫秒序号从0开始", StreamID{5, 3}, "6-*", 0, StreamID{6, 0}, nil},
+		{"更小的毫秒", StreamID{5, 3}, "4-*", 0, StreamID{}, errStreamIDTooSmall},
+		{"相同毫秒序号用尽", StreamID{5, math.MaxUint64}, "5-*", 0, StreamID{}, errStreamIDTooSmall},
+		{"毫秒格式错误", StreamID{}, "x-*", 0, StreamID{}, errStreamID},
+		{"完整指定", StreamID{5, 3}, "5-4", 0, StreamID{5, 4}, nil},
+		{"省略序号", StreamID{5, 3}, "6", 0, StreamID{6, 0}, nil},
+		{"等于最大ID", StreamID{5, 3}, "5-3", 0, StreamID{}, errStreamIDTooSmall},
+		{"小于最大ID", StreamID{5, 3}, "5", 0, StreamID{}, errStreamIDTooSmall},
+		{"0-0", StreamID{}, "0-0", 0, StreamID{}, errStreamIDZero},
+		{"格式错误", StreamID{}, "abc", 0, StreamID{}, errStreamID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStreamObject()
+			s.lastID = tt.lastID
+			id, err := s.nextID(tt.spec, time.UnixMilli(tt.nowMs))
+			if !errors.Is(err, tt.err) || (err == nil && id != tt.want) {
+				t.Fatalf("得到 %v %v, 期望 %v %v", id, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+// TestStreamRangeAndTrim 区间读取、裁剪与删除, 裁剪后最大ID仍然保留
+func TestStreamRangeAndTrim(t *testing.T) {
+	st := newTestStream()
+	for _, id := range []string{"1-0", "1-1", "2-0", "3-0", "5-0"} {
+		if _, _, err := st.Add("s", id, []string{"f", id}, StreamAddOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name       string
+		start, end StreamID
+		count      int
+		rev        bool
+		want       string
+	}{
+		{"全部", StreamID{}, streamMaxID, 0, false, "1-0,1-1,2-0,3-0,5-0"},
+		{"闭区间", StreamID{1, 1}, StreamID{3, 0}, 0, false, "1-1,2-0,3-0"},
+		{"数量限制", StreamID{}, streamMaxID, 2, false, "1-0,1-1"},
+		{"倒序的数量限制取末尾", StreamID{}, streamMaxID, 2, true, "5-0,3-0"},
+		{"起点大于终点", StreamID{3, 0}, StreamID{2, 0}, 0, false, ""},
+		{"区间内没有条目", StreamID{4, 0}, StreamID{4, math.MaxUint64}, 0, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinIDs(st.Range("s", tt.start, tt.end, tt.count, tt.rev)); got != tt.want {
+				t.Fatalf("得到 %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+
+	if n := st.Remove("s", StreamID{2, 0}, StreamID{2, 0}, StreamID{4, 0}); n != 1 {
+		t.Fatalf("删除了 %d 个条目, 期望 1 个", n)
+	}
+	if n := st.Trim("s", StreamTrim{Strategy: StreamTrimMaxLen, MaxLen: 3}); n != 1 || st.Len("s") != 3 {
+		t.Fatalf("按长度裁剪删除了 %d 个条目, 剩余 %d 个", n, st.Len("s"))
+	}
+	if n := st.Trim("s", StreamTrim{Strategy: StreamTrimMinID, MinID: StreamID{3, 0}}); n != 1 {
+		t.Fatalf("按最小ID裁剪删除了 %d 个条目, 期望 1 个", n)
+	}
+	if got := joinIDs(st.Range("s", StreamID{}, streamMaxID, 0, false)); got != "3-0,5-0" {
+		t.Fatalf("裁剪后剩余 %s", got)
+	}
+	st.Trim("s", StreamTrim{Strategy: StreamTrimMinID, MinID: streamMaxID})
+	if _, _, err := st.Add("s", "4-0", []string{"f", "v"}, StreamAddOptions{}); !errors.Is(err, errStreamIDTooSmall) {
+		t.Fatalf("清空后仍不能使用更小的ID, 得到 %v", err)
+	}
+	if _, _, err := st.Add("s", "1-0", []string{"f"}, StreamAddOptions{}); !errors.Is(err, errStreamFields) {
+		t.Fatalf("字段不成对应返回 errStreamFields, 得到 %v", err)
+	}
+	if _, ok, err := st.Add("missing", "*", []string{"f", "v"}, StreamAddOptions{NoMkStream: true}); ok || err != nil || st.Len("missing") != 0 {
+		t.Fatal("指定 NoMkStream 时不应创建流")
+	}
+}
@@ -0,0 +1,319 @@
+package data
+
+import (
+	"math"
+	"slices"
+)
+
+// ZAggregate 并集与交集中同一成员多个分数的合并方式
+type ZAggregate int
+
+const (
+	// ZAggregateSum 分数求和
+	ZAggregateSum ZAggregate = iota
+	// ZAggregateMin 取最小分数
+	ZAggregateMin
+	// ZAggregateMax 取最大分数
+	ZAggregateMax
+)
+
+// ZCombineOptions 有序集合并集与交集的参数
+type ZCombineOptions struct {
+	// 每个输入集合的权重, 分数乘以权重后参与合并, 未指定的集合权重为1
+	Weights []float64
+	// 分数合并方式
+	Aggregate ZAggregate
+}
+
+// weight 第i个输入集合的权重
+// @param i int
+// @return float64
+func (opts *ZCombineOptions) weight(i int) float64 {
+	if i < len(opts.Weights) {
+		return opts.Weights[i]
+	}
+	return 1
+}
+
+// aggregate 合并两个分数
+// @param acc float64 已合并的分数
+// @param score float64 新的分数
+// @return float64
+func (opts *ZCombineOptions) aggregate(acc, score float64) float64 {
+	switch opts.Aggregate {
+	case ZAggregateMin:
+		return min(acc, score)
+	case ZAggregateMax:
+		return max(acc, score)
+	}
+	return zsetSafeScore(acc + score)
+}
+
+// zsetSafeScore 将运算产生的 NaN (如 inf + -inf, 0 * inf) 视为0, 与 Redis 一致
+// @param score float64
+// @return float64
+func zsetSafeScore(score float64) float64 {
+	if math.IsNaN(score) {
+		return 0
+	}
+	return score
+}
+
+// zsetSource 参与集合运算的输入, 有序集合或普通集合(成员分数视为1)
+type zsetSource struct {
+	zset *zsetObject
+	set  *setObject
+}
+
+// len 成员数量
+// @return int
+func (src zsetSource) len() int {
+	if src.zset != nil {
+		return src.zset.len()
+	}
+	return src.set.len()
+}
+
+// score 获取成员分数
+// @param member string
+// @return float64
+// @return bool 成员是否存在
+func (src zsetSource) score(member string) (float64, bool) {
+	if src.zset != nil {
+		return src.zset.score(member)
+	}
+	return 1, src.set.has(member)
+}
+
+// each 遍历成员与分数, fn返回false时停止
+// @param fn func(member string, score float64) bool
+func (src zsetSource) each(fn func(member string, score float64) bool) {
+	if src.zset != nil {
+		src.zset.each(fn)
+		return
+	}
+	src.set.each(func(m string) bool {
+		return fn(m, 1)
+	})
+}
+
+// sourcesLocked 获取参与运算的输入, 优先使用有序集合, 不存在时使用同名普通集合
+// 调用方需持有全部key在有序集合与集合中的锁
+// @param keys []string
+// @return []zsetSource
+func (gkvZSet *GkvZSet) sourcesLocked(keys []string) []zsetSource {
+	sources := make([]zsetSource, len(keys))
+	for i, key := range keys {
+		if z := gkvZSet.liveZSet(key); z != nil {
+			sources[i] = zsetSource{zset: z}
+		} else {
+			sources[i] = zsetSource{set: DataGkvSet.liveMembers(key)}
+		}
+	}
+	return sources
+}
+
+// zunionScores 计算带权重的并集
+// @param sources []zsetSource
+// @param opts *ZCombineOptions
+// @return map[string]float64 成员 -> 合并后的分数
+func zunionScores(sources []zsetSource, opts *ZCombineOptions) map[string]float64 {
+	result := make(map[string]float64)
+	for i, src := range sources {
+		weight := opts.weight(i)
+		src.each(func(m string, s float64) bool {
+			s = zsetSafeScore(s * weight)
+			if acc, ok := result[m]; ok {
+				result[m] = opts.aggregate(acc, s)
+			} else {
+				result[m] = s
+			}
+			return true
+		})
+	}
+	return result
+}
+
+// zinterScores 计算带权重的交集, 从最小的输入开始遍历
+// @param sources []zsetSource
+// @param opts *ZCombineOptions
+// @return map[string]float64 成员 -> 合并后的分数
+func zinterScores(sources []zsetSource, opts *ZCombineOptions) map[string]float64 {
+	result := make(map[string]float64)
+	if len(sources) == 0 {
+		return result
+	}
+	order := make([]int, len(sources))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return sources[a].len() - sources[b].len()
+	})
+	smallest := order[0]
+	sources[smallest].each(func(m string, s float64) bool {
+		acc := zsetSafeScore(s * opts.weight(smallest))
+		for _, i := range order[1:] {
+			other, ok := sources[i].score(m)
+			if !ok {
+				return true
+			}
+			acc = opts.aggregate(acc, zsetSafeScore(other*opts.weight(i)))
+		}
+		result[m] = acc
+		return true
+	})
+	return result
+}
+
+// zdiffScores 计算第一个输入与后续输入的差集, 保留第一个输入中的分数
+// @param sources []zsetSource
+// @return map[string]float64 成员 -> 分数
+func zdiffScores(sources []zsetSource) map[string]float64 {
+	result := make(map[string]float64)
+	if len(sources) == 0 {
+		return result
+	}
+	sources[0].each(func(m string, s float64) bool {
+		for _, other := range sources[1:] {
+			if _, ok := other.score(m); ok {
+				return true
+			}
+		}
+		result[m] = s
+		return true
+	})
+	return result
+}
+
+// sortedEntries 将运算结果按 (分数, 成员) 升序排列
+// @param scores map[string]float64
+// @return []ZSetEntry
+func sortedEntries(scores map[string]float64) []ZSetEntry {
+	entries := make([]ZSetEntry, 0, len(scores))
+	for m, s := range scores {
+		entries = append(entries, ZSetEntry{m, s})
+	}
+	slices.SortFunc(entries, func(a, b ZSetEntry) int {
+		if zsetLess(a.Score, a.Member, b.Score, b.Member) {
+			return -1
+		}
+		return 1
+	})
+	return entries
+}
+
+// combine 在持有全部输入读锁的情况下执行集合运算
+// @param keys []string 输入集合
+// @param op func([]zsetSource) map[string]float64 集合运算
+// @return []ZSetEntry 按分数升序排列的结果
+func (gkvZSet *GkvZSet) combine(keys []string, op func([]zsetSource) map[string]float64) []ZSetEntry {
+	gkvZSet.keyLock.RLockRows(keys...)
+	defer gkvZSet.keyLock.RUnLockRows(keys...)
+	DataGkvSet.keyLock.RLockRows(keys...)
+	defer DataGkvSet.keyLock.RUnLockRows(keys...)
+	return sortedEntries(op(gkvZSet.sourcesLocked(keys)))
+}
+
+// combineStore 在持有目标写锁与输入锁的情况下执行集合运算并覆盖目标集合, 结果为空时删除目标集合
+// @param dst string 目标集合
+// @param event string 写入目标集合时发出的通知事件名
+// @param keys []string 输入集合
+// @param op func([]zsetSource) map[string]float64 集合运算
+// @return int 结果集合的成员数量
+func (gkvZSet *GkvZSet) combineStore(dst, event string, keys []string, op func([]zsetSource) map[string]float64) int {
+	locked := append([]string{dst}, keys...)
+	gkvZSet.keyLock.WLockRows(locked...)
+	defer gkvZSet.keyLock.WUnLockRows(locked...)
+	DataGkvSet.keyLock.RLockRows(keys...)
+	defer DataGkvSet.keyLock.RUnLockRows(keys...)
+	entries := sortedEntries(op(gkvZSet.sourcesLocked(keys)))
+	gkvZSet.storeLocked(dst, event, entries)
+	return len(entries)
+}
+
+// Union 计算多个有序集合(或普通集合)的带权重并集
+// @param opts ZCombineOptions 权重与合并方式
+// @param keys ...string 输入集合
+// @return []ZSetEntry 按分数升序排列的结果
+func (gkvZSet *GkvZSet) Union(opts ZCombineOptions, keys ...string) []ZSetEntry {
+	return gkvZSet.combine(keys, func(sources []zsetSource) map[string]float64 {
+		return zunionScores(sources, &opts)
+	})
+}
+
+// Inter 计算多个有序集合(或普通集合)的带权重交集
+// @param opts ZCombineOptions 权重与合并方式
+// @param keys ...string 输入集合
+// @return []ZSetEntry 按分数升序排列的结果
+func (gkvZSet *GkvZSet) Inter(opts ZCombineOptions, keys ...string) []ZSetEntry {
+	return gkvZSet.combine(keys, func(sources []zsetSource) map[string]float64 {
+		return zinterScores(sources, &opts)
+	})
+}
+
+// Diff 计算第一个集合与后续集合的差集
+// @param keys ...string 输入集合
+// @return []ZSetEntry 按分数升序排列的结果
+func (gkvZSet *GkvZSet) Diff(keys ...string) []ZSetEntry {
+	return gkvZSet.combine(keys, zdiffScores)
+}
+
+// UnionStore 计算带权重并集并写入目标集合
+// @param dst string 目标集合
+// @param opts ZCombineOptions 权重与合并方式
+// @param keys ...string 输入集合
+// @return int 结果集合的成员数量
+func (gkvZSet *GkvZSet) UnionStore(dst string, opts ZCombineOptions, keys ...string) int {
+	return gkvZSet.combineStore(dst, "zunionstore", keys, func(sources []zsetSource) map[string]float64 {
+		return zunionScores(sources, &opts)
+	})
+}
+
+// InterStore 计算带权重交集并写入目标集合
+// @param dst string 目标集合
+// @param opts ZCombineOptions 权重与合并方式
+// @param keys ...string 输入集合
+// @return int 结果集合的成员数量
+func (gkvZSet *GkvZSet) InterStore(dst string, opts ZCombineOptions, keys ...string) int {
+	return gkvZSet.combineStore(dst, "zinterstore", keys, func(sources []zsetSource) map[string]float64 {
+		return zinterScores(sources, &opts)
+	})
+}
+
+// DiffStore 计算差集并写入目标集合
+// @param dst string 目标集合
+// @param keys ...string 输入集合
+// @return int 结果集合的成员数量
+func (gkvZSet *GkvZSet) DiffStore(dst string, keys ...string) int {
+	return gkvZSet.combineStore(dst, "zdiffstore", keys, zdiffScores)
+}
+
+// InterCard 计算交集的成员数量
+// @param limit int 计数上限, 达到后提前返回, 0表示不限制
+// @param keys ...string 输入集合
+// @return int 交集成员数量
+func (gkvZSet *GkvZSet) InterCard(limit int, keys ...string) int {
+	gkvZSet.keyLock.RLockRows(keys...)
+	defer gkvZSet.keyLock.RUnLockRows(keys...)
+	DataGkvSet.keyLock.RLockRows(keys...)
+	defer DataGkvSet.keyLock.RUnLockRows(keys...)
+	sources := gkvZSet.sourcesLocked(keys)
+	if len(sources) == 0 {
+		return 0
+	}
+	slices.SortFunc(sources, func(a, b zsetSource) int {
+		return a.len() - b.len()
+	})
+	count := 0
+	sources[0].each(func(m string, _ float64) bool {
+		for _, other := range sources[1:] {
+			if _, ok := other.score(m); !ok {
+				return true
+			}
+		}
+		count++
+		return limit <= 0 || count < limit
+	})
+	return count
+}
@@ -0,0 +1,138 @@
+package data
+
+import (
+	"sync"
+	"time"
+)
+
+// ZPopResult 阻塞弹出的结果
+type ZPopResult struct {
+	// 弹出成员所在的集合
+	Key string
+	// 被弹出的成员, 弹出最高分时按分数从高到低排列
+	Entries []ZSetEntry
+}
+
+// zsetWaiter 一个阻塞等待中的弹出请求
+type zsetWaiter struct {
+	// 等待的全部集合, 按请求中的顺序
+	keys []string
+	// 每次弹出的数量
+	count int
+	// 是否弹出分数最高的成员
+	max bool
+	// 是否已被服务或已超时, 由 zsetBlocking.mu 保护
+	done bool
+	// 被服务时写入结果, 缓冲为1, 写入方不会阻塞
+	result chan ZPopResult
+}
+
+// zsetBlocking 有序集合上阻塞等待的请求表
+// 每个key维护一个先进先出的等待队列, 成员写入时按阻塞的先后顺序依次服务,
+// 同时在多个key上等待的请求只会被服务一次
+type zsetBlocking struct {
+	mu      sync.Mutex
+	waiters map[string][]*zsetWaiter
+}
+
+// newZSetBlocking 创建空的等待表
+// @return *zsetBlocking
+func newZSetBlocking() *zsetBlocking {
+	return &zsetBlocking{waiters: make(map[string][]*zsetWaiter)}
+}
+
+// register 登记等待请求, 调用方需持有全部key的写锁, 以免错过登记前的写入
+// @param w *zsetWaiter
+func (b *zsetBlocking) register(w *zsetWaiter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range w.keys {
+		b.waiters[key] = append(b.waiters[key], w)
+	}
+}
+
+// unregisterLocked 从全部key的等待队列中移除请求, 调用方需持有 b.mu
+// @param w *zsetWaiter
+func (b *zsetBlocking) unregisterLocked(w *zsetWaiter) {
+	w.done = true
+	for _, key := range w.keys {
+		queue := b.waiters[key]
+		for i, other := range queue {
+			if other == w {
+				queue = append(queue[:i], queue[i+1:]...)
+				break
+			}
+		}
+		if len(queue) == 0 {
+			delete(b.waiters, key)
+		} else {
+			b.waiters[key] = queue
+		}
+	}
+}
+
+// cancel 超时后撤销等待请求
+// @param w *zsetWaiter
+// @return bool 请求是否仍在等待(false表示已被服务, 结果在 w.result 中)
+func (b *zsetBlocking) cancel(w *zsetWaiter) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if w.done {
+		return false
+	}
+	b.unregisterLocked(w)
+	return true
+}
+
+// serveBlockedLocked key写入成员后按先进先出顺序服务在该key上等待的请求, 调用方需持有key的写锁
+// @param key string 集合名
+func (gkvZSet *GkvZSet) serveBlockedLocked(key string) {
+	b := gkvZSet.blocked
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.waiters[key]) > 0 && gkvZSet.liveZSet(key).len() > 0 {
+		w := b.waiters[key][0]
+		b.unregisterLocked(w)
+		w.result <- ZPopResult{Key: key, Entries: gkvZSet.popLocked(key, w.count, w.max)}
+	}
+}
+
+// BlockingPop 从多个集合中按顺序找到第一个非空集合并弹出成员, 全部为空时阻塞等待
+// 多个请求阻塞在同一个key上时, 按阻塞的先后顺序被服务
+// @param keys []string 集合名
+// @param count int 弹出数量
+// @param max bool 为true时弹出分数最高的成员
+// @param timeout time.Duration 最长等待时间, 0表示一直等待
+// @return ZPopResult 弹出结果
+// @return bool 是否弹出了成员, 超时为false
+func (gkvZSet *GkvZSet) BlockingPop(keys []string, count int, max bool, timeout time.Duration) (ZPopResult, bool) {
+	if len(keys) == 0 || count <= 0 {
+		return ZPopResult{}, false
+	}
+	gkvZSet.keyLock.WLockRows(keys...)
+	for _, key := range keys {
+		if entries := gkvZSet.popLocked(key, count, max); len(entries) > 0 {
+			gkvZSet.keyLock.WUnLockRows(keys...)
+			return ZPopResult{Key: key, Entries: entries}, true
+		}
+	}
+	w := &zsetWaiter{keys: keys, count: count, max: max, result: make(chan ZPopResult, 1)}
+	gkvZSet.blocked.register(w)
+	gkvZSet.keyLock.WUnLockRows(keys...)
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case res := <-w.result:
+		return res, true
+	case <-expired:
+		if gkvZSet.blocked.cancel(w) {
+			return ZPopResult{}, false
+		}
+		return <-w.result, true
+	}
+}
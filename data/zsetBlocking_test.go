@@ -0,0 +1,147 @@
+package data
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitBlocked 等待直到key上有n个阻塞中的请求
+// @param t *testing.T
+// @param z *GkvZSet
+// @param key string
+// @param n int
+func waitBlocked(t *testing.T, z *GkvZSet, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		z.blocked.mu.Lock()
+		got := len(z.blocked.waiters[key])
+		z.blocked.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s 上有 %d 个阻塞请求, 期望 %d", key, got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestBlockingPopImmediate 有非空集合时不阻塞, 按key的顺序选择第一个非空集合
+func TestBlockingPopImmediate(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  []string
+		count int
+		max   bool
+		key   string
+		want  string
+	}{
+		{"跳过空集合", []string{"empty", "a", "b"}, 1, false, "a", "a1"},
+		{"弹出最高分", []string{"b", "a"}, 1, true, "b", "b3"},
+		{"数量超过成员数", []string{"a"}, 10, false, "a", "a1,a2"},
+		{"最高分按分数降序", []string{"b"}, 2, true, "b", "b3,b2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			z := newTestZSet()
+			z.Add("a", "a1", 1)
+			z.Add("a", "a2", 2)
+			z.Add("b", "b1", 1)
+			z.Add("b", "b2", 2)
+			z.Add("b", "b3", 3)
+			res, ok := z.BlockingPop(tt.keys, tt.count, tt.max, time.Millisecond)
+			if !ok || res.Key != tt.key || joinMembers(res.Entries) != tt.want {
+				t.Fatalf("得到 %v %+v, 期望 %s %s", ok, res, tt.key, tt.want)
+			}
+		})
+	}
+	z := newTestZSet()
+	for _, count := range []int{0, -1} {
+		if _, ok := z.BlockingPop([]string{"a"}, count, false, 0); ok {
+			t.Fatalf("count=%d 应立即返回", count)
+		}
+	}
+	if _, ok := z.BlockingPop(nil, 1, false, 0); ok {
+		t.Fatal("没有key时应立即返回")
+	}
+}
+
+// TestBlockingPopTimeout 超时后返回false, 且不留下等待请求
+func TestBlockingPopTimeout(t *testing.T) {
+	z := newTestZSet()
+	start := time.Now()
+	if _, ok := z.BlockingPop([]string{"a", "b"}, 1, false, 20*time.Millisecond); ok {
+		t.Fatal("空集合应超时")
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("未等待到超时")
+	}
+	if len(z.blocked.waiters) != 0 {
+		t.Fatalf("超时后仍有等待请求: %v", z.blocked.waiters)
+	}
+	z.Add("a", "m", 1)
+	if z.Cardinality("a") != 1 {
+		t.Fatal("超时的请求不应再弹出成员")
+	}
+}
+
+// TestBlockingPopFIFO 同一key上的请求按阻塞顺序被服务, 在多个key上等待的请求只服务一次
+func TestBlockingPopFIFO(t *testing.T) {
+	z := newTestZSet()
+	const n = 3
+	results := make([]chan ZPopResult, n)
+	for i := range results {
+		results[i] = make(chan ZPopResult, 1)
+		go func() {
+			res, _ := z.BlockingPop([]string{"x", "y"}, 1, false, 0)
+			results[i] <- res
+		}()
+		waitBlocked(t, z, "y", i+1)
+	}
+	for i := range n {
+		key := []string{"x", "y"}[i%2]
+		z.Add(key, fmt.Sprintf("m%d", i), float64(i))
+		res := <-results[i]
+		if res.Key != key || len(res.Entries) != 1 || res.Entries[0].Member != fmt.Sprintf("m%d", i) {
+			t.Fatalf("第 %d 个请求得到 %+v", i, res)
+		}
+	}
+	if len(z.blocked.waiters) != 0 || z.Cardinality("x")+z.Cardinality("y") != 0 {
+		t.Fatal("全部请求被服务后不应留下等待请求或成员")
+	}
+}
+
+// TestBlockingPopConcurrent 并发写入时每个成员恰好被一个请求弹出
+func TestBlockingPopConcurrent(t *testing.T) {
+	z := newTestZSet()
+	const n = 50
+	var wg sync.WaitGroup
+	popped := make(chan string, n)
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, ok := z.BlockingPop([]string{"q"}, 1, false, 5*time.Second); ok {
+				popped <- res.Entries[0].Member
+			}
+		}()
+	}
+	for i := range n {
+		z.Add("q", fmt.Sprintf("m%d", i), float64(i))
+	}
+	wg.Wait()
+	close(popped)
+	seen := map[string]bool{}
+	for m := range popped {
+		if seen[m] {
+			t.Fatalf("成员 %s 被弹出了两次", m)
+		}
+		seen[m] = true
+	}
+	if len(seen)+z.Cardinality("q") != n {
+		t.Fatalf("弹出 %d 个, 剩余 %d 个", len(seen), z.Cardinality("q"))
+	}
+}
@@ -0,0 +1,449 @@
+package data
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+)
+
+// 地理位置以 52 位 geohash 作为分数保存在有序集合中, 编码方式与 Redis 相同:
+// 经纬度各量化为 26 位, 纬度位于偶数位、经度位于奇数位交错组合
+
+const (
+	// geoStep 经纬度各自的量化位数
+	geoStep = 26
+	// geoLatMin, geoLatMax 可编码的纬度范围(Web 墨卡托投影的范围)
+	geoLatMin = -85.05112878
+	geoLatMax = 85.05112878
+	// geoLonMin, geoLonMax 可编码的经度范围
+	geoLonMin = -180.0
+	geoLonMax = 180.0
+	// geoEarthRadius 计算距离使用的地球半径(米)
+	geoEarthRadius = 6372797.560856
+)
+
+// geoAlphabet geohash 字符串使用的 base32 字符
+const geoAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// errGeoCoords 经纬度超出范围
+var errGeoCoords = errors.New("经纬度超出范围, 经度必须在 -180 到 180 之间, 纬度必须在 -85.05112878 到 85.05112878 之间")
+
+// errGeoMember 中心成员不存在
+var errGeoMember = errors.New("中心成员不存在")
+
+// errGeoShape 查询范围不合法
+var errGeoShape = errors.New("必须且只能指定半径或矩形之一, 且半径与宽高不能为负")
+
+// geoUnits 距离单位 -> 每单位的米数
+var geoUnits = map[string]float64{
+	"m":  1,
+	"km": 1000,
+	"mi": 1609.34,
+	"ft": 0.3048,
+}
+
+// ParseGeoUnit 解析距离单位 m、km、mi、ft
+// @param s string
+// @return float64 每单位的米数
+// @return bool 单位是否合法
+func ParseGeoUnit(s string) (float64, bool) {
+	unit, ok := geoUnits[strings.ToLower(s)]
+	return unit, ok
+}
+
+// GeoMember 带经纬度的成员
+type GeoMember struct {
+	Member   string
+	Lon, Lat float64
+}
+
+// GeoSort 查询结果的排序方式
+type GeoSort int
+
+const (
+	// GeoSortNone 不排序
+	GeoSortNone GeoSort = iota
+	// GeoSortAsc 按距离升序
+	GeoSortAsc
+	// GeoSortDesc 按距离降序
+	GeoSortDesc
+)
+
+// GeoQuery 地理位置查询参数
+type GeoQuery struct {
+	// 中心成员, 为空时使用 Lon, Lat 作为中心
+	FromMember string
+	Lon, Lat   float64
+	// 圆形范围的半径, 与矩形范围二选一
+	Radius float64
+	// 矩形范围的宽(东西方向)与高(南北方向), 中心位于矩形中心
+	Width, Height float64
+	ByBox         bool
+	// 距离单位的米数, 0 表示米; 半径、宽高与结果中的距离均使用该单位
+	Unit float64
+	Sort GeoSort
+	// 最多返回的数量, 0 表示不限制; 未指定 Any 且未指定排序时按距离升序取前 Count 个
+	Count int
+	// 找到 Count 个成员后立即停止, 不保证是最近的
+	Any bool
+}
+
+// GeoResult 查询结果中的一个成员
+type GeoResult struct {
+	Member string
+	// 到中心的距离, 单位与查询相同
+	Dist float64
+	// 成员的 52 位 geohash 分数
+	Hash     uint64
+	Lon, Lat float64
+}
+
+// geoInterleave 交错组合两个 26 位整数, x 位于偶数位, y 位于奇数位
+// @param x, y uint32
+// @return uint64
+func geoInterleave(x, y uint32) uint64 {
+	var bits uint64
+	for i := 0; i < geoStep; i++ {
+		bits |= uint64(x>>i&1) << (2 * i)
+		bits |= uint64(y>>i&1) << (2*i + 1)
+	}
+	return bits
+}
+
+// geoDeinterleave 拆分交错组合的整数
+// @param bits uint64
+// @return uint32 偶数位组成的 x
+// @return uint32 奇数位组成的 y
+func geoDeinterleave(bits uint64) (uint32, uint32) {
+	var x, y uint32
+	for i := 0; i < geoStep; i++ {
+		x |= uint32(bits>>(2*i)&1) << i
+		y |= uint32(bits>>(2*i+1)&1) << i
+	}
+	return x, y
+}
+
+// geoEncodeRange 在给定经纬度范围内按 step 位精度编码
+// @param lon, lat float64
+// @param latMin, latMax float64 纬度范围
+// @param step int 每个坐标的位数
+// @return uint64 2*step 位的 geohash
+func geoEncodeRange(lon, lat, latMin, latMax float64, step int) uint64 {
+	cells := float64(uint64(1) << step)
+	latOffset := min(uint64((lat-latMin)/(latMax-latMin)*cells), uint64(cells)-1)
+	lonOffset := min(uint64((lon-geoLonMin)/(geoLonMax-geoLonMin)*cells), uint64(cells)-1)
+	return geoInterleave(uint32(latOffset), uint32(lonOffset))
+}
+
+// geoEncode 将经纬度编码为 52 位 geohash
+// @param lon, lat float64
+// @return uint64
+func geoEncode(lon, lat float64) uint64 {
+	return geoEncodeRange(lon, lat, geoLatMin, geoLatMax, geoStep)
+}
+
+// geoDecode 将 52 位 geohash 解码为所在格子的中心
+// @param hash uint64
+// @return float64 经度
+// @return float64 纬度
+func geoDecode(hash uint64) (float64, float64) {
+	latOffset, lonOffset := geoDeinterleave(hash)
+	cells := float64(uint64(1) << geoStep)
+	latCell := (geoLatMax - geoLatMin) / cells
+	lonCell := (geoLonMax - geoLonMin) / cells
+	lat := geoLatMin + (float64(latOffset)+0.5)*latCell
+	lon := geoLonMin + (float64(lonOffset)+0.5)*lonCell
+	return max(geoLonMin, min(geoLonMax, lon)), max(geoLatMin, min(geoLatMax, lat))
+}
+
+// geoHashString 生成 11 个字符的标准 geohash 字符串
+// 标准 geohash 的纬度范围为 -90 到 90, 因此先解码再按标准范围重新编码; 52 位只够 10 个字符, 与 Redis 相同第 11 个字符固定为 0
+// @param hash uint64 有序集合中的分数
+// @return string
+func geoHashString(hash uint64) string {
+	lon, lat := geoDecode(hash)
+	bits := geoEncodeRange(lon, lat, -90, 90, geoStep)
+	var b strings.Builder
+	for i := 0; i < 11; i++ {
+		idx := 0
+		if i < 10 {
+			idx = int(bits >> (52 - (i+1)*5) & 0x1f)
+		}
+		b.WriteByte(geoAlphabet[idx])
+	}
+	return b.String()
+}
+
+// geoDistance 用半正矢公式计算两点间的球面距离
+// @param lon1, lat1 float64
+// @param lon2, lat2 float64
+// @return float64 米
+func geoDistance(lon1, lat1, lon2, lat2 float64) float64 {
+	rad := math.Pi / 180
+	u := math.Sin((lat2 - lat1) * rad / 2)
+	v := math.Sin((lon2 - lon1) * rad / 2)
+	a := u*u + math.Cos(lat1*rad)*math.Cos(lat2*rad)*v*v
+	return 2 * geoEarthRadius * math.Asin(math.Sqrt(a))
+}
+
+// validGeoCoords 经纬度是否可以编码
+// @param lon, lat float64
+// @return bool
+func validGeoCoords(lon, lat float64) bool {
+	return lon >= geoLonMin && lon <= geoLonMax && lat >= geoLatMin && lat <= geoLatMax
+}
+
+// geoSearchArea 一次查询的中心、范围(米)与候选分数区间
+type geoSearchArea struct {
+	lon, lat float64
+	// 圆形范围的半径, 矩形范围的宽与高
+	radius, width, height float64
+	byBox                 bool
+}
+
+// contains 成员是否在范围内
+// 矩形范围分别检查南北与东西方向上到中心的距离, 与 Redis 相同
+// @param lon, lat float64 成员坐标
+// @return float64 到中心的距离(米)
+// @return bool
+func (a *geoSearchArea) contains(lon, lat float64) (float64, bool) {
+	if !a.byBox {
+		d := geoDistance(a.lon, a.lat, lon, lat)
+		return d, d <= a.radius
+	}
+	if geoDistance(lon, lat, lon, a.lat) > a.height/2 || geoDistance(lon, lat, a.lon, lat) > a.width/2 {
+		return 0, false
+	}
+	return geoDistance(a.lon, a.lat, lon, lat), true
+}
+
+// ranges 计算覆盖查询范围的分数区间
+// 选择格子不小于查询范围外接经纬度矩形的最大精度, 中心所在格子与周围8个格子一定能覆盖整个范围
+// @return [][2]uint64 左闭右开的分数区间
+func (a *geoSearchArea) ranges() [][2]uint64 {
+	halfHeight, halfWidth := a.radius, a.radius
+	if a.byBox {
+		halfHeight, halfWidth = a.height/2, a.width/2
+	}
+	latDelta := halfHeight / geoEarthRadius * 180 / math.Pi
+	// 经度方向使用范围内最靠近极点的纬度, 此处一度经线最短
+	edgeLat := min(math.Abs(a.lat)+latDelta, 90)
+	lonDelta := 360.0
+	if cos := math.Cos(edgeLat * math.Pi / 180); cos > 1e-12 {
+		lonDelta = min(halfWidth/(geoEarthRadius*cos)*180/math.Pi, 360)
+	}
+	step := geoStep
+	for step > 0 {
+		cells := float64(uint64(1) << step)
+		if (geoLatMax-geoLatMin)/cells >= latDelta && (geoLonMax-geoLonMin)/cells >= lonDelta {
+			break
+		}
+		step--
+	}
+	if step == 0 {
+		return [][2]uint64{{0, 1 << (2 * geoStep)}}
+	}
+	cells := float64(uint64(1) << step)
+	latCell := (geoLatMax - geoLatMin) / cells
+	lonCell := (geoLonMax - geoLonMin) / cells
+	shift := 2 * (geoStep - step)
+	seen := map[uint64]bool{}
+	result := [][2]uint64{}
+	for dLat := -1; dLat <= 1; dLat++ {
+		// 超出纬度范围时取边缘的格子, 由去重合并
+		lat := max(geoLatMin, min(geoLatMax, a.lat+float64(dLat)*latCell))
+		for dLon := -1; dLon <= 1; dLon++ {
+			lon := a.lon + float64(dLon)*lonCell
+			// 跨越 180 度经线时绕回另一侧
+			if lon < geoLonMin {
+				lon += 360
+			} else if lon > geoLonMax {
+				lon -= 360
+			}
+			hash := geoEncodeRange(lon, lat, geoLatMin, geoLatMax, step)
+			if seen[hash] {
+				continue
+			}
+			seen[hash] = true
+			result = append(result, [2]uint64{hash << shift, (hash + 1) << shift})
+		}
+	}
+	return result
+}
+
+// geoSearchLocked 执行地理位置查询, 调用方需持有key的锁
+// @param z *zsetObject 可为nil
+// @param q GeoQuery
+// @return []GeoResult 距离使用查询的单位
+// @return error
+func geoSearchLocked(z *zsetObject, q GeoQuery) ([]GeoResult, error) {
+	unit := q.Unit
+	if unit == 0 {
+		unit = 1
+	}
+	if q.Radius < 0 || q.Width < 0 || q.Height < 0 || (q.ByBox && q.Radius != 0) {
+		return nil, errGeoShape
+	}
+	area := &geoSearchArea{lon: q.Lon, lat: q.Lat, radius: q.Radius * unit, width: q.Width * unit, height: q.Height * unit, byBox: q.ByBox}
+	if q.FromMember != "" {
+		if z == nil {
+			return nil, errGeoMember
+		}
+		score, ok := z.score(q.FromMember)
+		if !ok {
+			return nil, errGeoMember
+		}
+		area.lon, area.lat = geoDecode(uint64(score))
+	} else if !validGeoCoords(area.lon, area.lat) {
+		return nil, errGeoCoords
+	}
+	result := []GeoResult{}
+	if z == nil {
+		return result, nil
+	}
+	sortBy := q.Sort
+	if sortBy == GeoSortNone && q.Count > 0 && !q.Any {
+		sortBy = GeoSortAsc
+	}
+	done := false
+	for _, r := range area.ranges() {
+		bound := newScoreRange(ScoreBound{Value: float64(r[0])}, ScoreBound{Value: float64(r[1]), Exclusive: true})
+		z.scanRange(bound, false, func(e ZSetEntry) bool {
+			lon, lat := geoDecode(uint64(e.Score))
+			d, ok := area.contains(lon, lat)
+			if !ok {
+				return true
+			}
+			result = append(result, GeoResult{Member: e.Member, Dist: d / unit, Hash: uint64(e.Score), Lon: lon, Lat: lat})
+			done = q.Any && q.Count > 0 && len(result) >= q.Count
+			return !done
+		})
+		if done {
+			break
+		}
+	}
+	if sortBy != GeoSortNone {
+		slices.SortFunc(result, func(a, b GeoResult) int {
+			c := cmp.Compare(a.Dist, b.Dist)
+			if c == 0 {
+				c = strings.Compare(a.Member, b.Member)
+			}
+			if sortBy == GeoSortDesc {
+				return -c
+			}
+			return c
+		})
+	}
+	if q.Count > 0 && len(result) > q.Count {
+		result = result[:q.Count]
+	}
+	return result, nil
+}
+
+// GeoAdd 按条件添加或更新成员的经纬度, 整个操作在key的写锁内原子完成
+// @param key string 集合名
+// @param flags ZAddFlags 条件选项, 不支持 GT 与 LT
+// @param members ...GeoMember 成员及经纬度
+// @return int 新增的成员数量, 指定CH时为新增与位置变化的成员数量
+// @return error 选项冲突或经纬度超出范围时返回错误, 此时不做任何修改
+func (gkvZSet *GkvZSet) GeoAdd(key string, flags ZAddFlags, members ...GeoMember) (int, error) {
+	if err := flags.validate(); err != nil {
+		return 0, err
+	}
+	entries := make([]ZSetEntry, 0, len(members))
+	for _, m := range members {
+		if !validGeoCoords(m.Lon, m.Lat) {
+			return 0, errGeoCoords
+		}
+		entries = append(entries, ZSetEntry{Member: m.Member, Score: float64(geoEncode(m.Lon, m.Lat))})
+	}
+	return gkvZSet.AddWithFlags(key, flags, entries...)
+}
+
+// GeoPos 获取成员的经纬度, 由 geohash 解码得到, 与写入时的值有微小误差
+// @param key string 集合名
+// @param members ...string 成员
+// @return []GeoMember 与 members 一一对应
+// @return []bool 成员是否存在
+func (gkvZSet *GkvZSet) GeoPos(key string, members ...string) ([]GeoMember, []bool) {
+	scores, found := gkvZSet.MScore(key, members...)
+	result := make([]GeoMember, len(members))
+	for i, member := range members {
+		result[i].Member = member
+		if found[i] {
+			result[i].Lon, result[i].Lat = geoDecode(uint64(scores[i]))
+		}
+	}
+	return result, found
+}
+
+// GeoDist 计算两个成员间的球面距离
+// @param key string 集合名
+// @param a, b string 成员
+// @param unit float64 距离单位的米数, 0 表示米
+// @return float64
+// @return bool 两个成员是否都存在
+func (gkvZSet *GkvZSet) GeoDist(key, a, b string, unit float64) (float64, bool) {
+	if unit == 0 {
+		unit = 1
+	}
+	pos, found := gkvZSet.GeoPos(key, a, b)
+	if !found[0] || !found[1] {
+		return 0, false
+	}
+	return geoDistance(pos[0].Lon, pos[0].Lat, pos[1].Lon, pos[1].Lat) / unit, true
+}
+
+// GeoHash 获取成员的 11 位标准 geohash 字符串
+// @param key string 集合名
+// @param members ...string 成员
+// @return []string 与 members 一一对应
+// @return []bool 成员是否存在
+func (gkvZSet *GkvZSet) GeoHash(key string, members ...string) ([]string, []bool) {
+	scores, found := gkvZSet.MScore(key, members...)
+	result := make([]string, len(members))
+	for i := range members {
+		if found[i] {
+			result[i] = geoHashString(uint64(scores[i]))
+		}
+	}
+	return result, found
+}
+
+// GeoSearch 查询圆形或矩形范围内的成员
+// @param key string 集合名
+// @param q GeoQuery 查询参数
+// @return []GeoResult
+// @return error 参数不合法或中心成员不存在时返回错误
+func (gkvZSet *GkvZSet) GeoSearch(key string, q GeoQuery) ([]GeoResult, error) {
+	gkvZSet.keyLock.RLockRow(key)
+	defer gkvZSet.keyLock.RUnLockRow(key)
+	return geoSearchLocked(gkvZSet.liveZSet(key), q)
+}
+
+// GeoSearchStore 将查询结果原子地写入目标集合, 结果为空时删除目标集合
+// @param dst string 目标集合
+// @param src string 源集合
+// @param q GeoQuery 查询参数
+// @param storeDist bool 为true时以距离作为分数, 否则保留 geohash 分数
+// @return int 结果集合的成员数量
+// @return error 参数不合法或中心成员不存在时返回错误, 此时目标集合不变
+func (gkvZSet *GkvZSet) GeoSearchStore(dst, src string, q GeoQuery, storeDist bool) (int, error) {
+	gkvZSet.keyLock.WLockRows(dst, src)
+	defer gkvZSet.keyLock.WUnLockRows(dst, src)
+	results, err := geoSearchLocked(gkvZSet.liveZSet(src), q)
+	if err != nil {
+		return 0, err
+	}
+	entries := make([]ZSetEntry, len(results))
+	for i, r := range results {
+		entries[i] = ZSetEntry{Member: r.Member, Score: float64(r.Hash)}
+		if storeDist {
+			entries[i].Score = r.Dist
+		}
+	}
+	gkvZSet.storeLocked(dst, "geosearchstore", entries)
+	return len(entries), nil
+}
@@ -0,0 +1,195 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// newSicily 创建包含 Redis 文档示例中四个地点的集合
+// @return *GkvZSet
+func newSicily() *GkvZSet {
+	z := newTestZSet()
+	z.GeoAdd("Sicily", ZAddFlags{},
+		GeoMember{"Palermo", 13.361389, 38.115556},
+		GeoMember{"Catania", 15.087269, 37.502669},
+		GeoMember{"edge1", 12.758489, 38.788135},
+		GeoMember{"edge2", 17.241510, 38.788135})
+	return z
+}
+
+// TestGeoEncode 分数、geohash 字符串与解码后的坐标与 Redis 一致
+func TestGeoEncode(t *testing.T) {
+	z := newSicily()
+	tests := []struct {
+		member string
+		score  float64
+		hash   string
+		pos    string
+	}{
+		{"Palermo", 3479099956230698, "sqc8b49rny0", "13.361389338970 38.115556395496"},
+		{"Catania", 3479447370796909, "sqdtr74hyu0", "15.087267458439 37.502668423332"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.member, func(t *testing.T) {
+			if score, _ := z.Score("Sicily", tt.member); score != tt.score {
+				t.Fatalf("分数 %d, 期望 %d", int64(score), int64(tt.score))
+			}
+			if hash, _ := z.GeoHash("Sicily", tt.member); hash[0] != tt.hash {
+				t.Fatalf("geohash %s, 期望 %s", hash[0], tt.hash)
+			}
+			pos, _ := z.GeoPos("Sicily", tt.member)
+			// 解码得到格子的中心, 与 Redis 的输出只有浮点舍入的差异
+			if got := fmt.Sprintf("%.12f %.12f", pos[0].Lon, pos[0].Lat); got != tt.pos {
+				t.Fatalf("坐标 %s, 期望 %s", got, tt.pos)
+			}
+		})
+	}
+	if hash, found := z.GeoHash("Sicily", "missing"); found[0] || hash[0] != "" {
+		t.Fatalf("不存在的成员得到 %q", hash[0])
+	}
+	if geoEncode(geoLonMin, geoLatMin) != 0 || geoEncode(geoLonMax, geoLatMax) != 1<<(2*geoStep)-1 {
+		t.Fatal("范围的两端应编码为最小与最大的分数")
+	}
+	r := rand.New(rand.NewPCG(47, 47))
+	for range 1000 {
+		x, y := r.Uint32()>>(32-geoStep), r.Uint32()>>(32-geoStep)
+		if gx, gy := geoDeinterleave(geoInterleave(x, y)); gx != x || gy != y {
+			t.Fatalf("交错组合 %d %d 后拆分得到 %d %d", x, y, gx, gy)
+		}
+	}
+}
+
+// TestGeoDist 两个成员间的距离与单位换算
+func TestGeoDist(t *testing.T) {
+	z := newSicily()
+	tests := []struct {
+		unit string
+		want string
+	}{
+		{"m", "166274.1516"},
+		{"km", "166.2742"},
+		{"mi", "103.3182"},
+		{"FT", "545518.8700"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			unit, ok := ParseGeoUnit(tt.unit)
+			if !ok {
+				t.Fatalf("无法解析单位 %s", tt.unit)
+			}
+			if d, _ := z.GeoDist("Sicily", "Palermo", "Catania", unit); fmt.Sprintf("%.4f", d) != tt.want {
+				t.Fatalf("距离 %.4f, 期望 %s", d, tt.want)
+			}
+		})
+	}
+	if _, ok := ParseGeoUnit("yd"); ok {
+		t.Fatal("不支持的单位应解析失败")
+	}
+	if _, ok := z.GeoDist("Sicily", "Palermo", "missing", 0); ok {
+		t.Fatal("成员不存在时应返回false")
+	}
+}
+
+// TestGeoSearch 圆形与矩形范围、排序、数量限制与参数错误
+func TestGeoSearch(t *testing.T) {
+	z := newSicily()
+	tests := []struct {
+		name string
+		key  string
+		q    GeoQuery
+		want string
+		err  error
+	}{
+		{"半径", "Sicily", GeoQuery{Lon: 15, Lat: 37, Radius: 200, Unit: 1000, Sort: GeoSortAsc}, "Catania:56.4413 Palermo:190.4424", nil},
+		{"较小的半径", "Sicily", GeoQuery{Lon: 15, Lat: 37, Radius: 100, Unit: 1000}, "Catania:56.4413", nil},
+		{"矩形", "Sicily", GeoQuery{Lon: 15, Lat: 37, Width: 400, Height: 400, ByBox: true, Unit: 1000, Sort: GeoSortAsc},
+			"Catania:56.4413 Palermo:190.4424 edge2:279.7403 edge1:279.7405", nil},
+		{"降序", "Sicily", GeoQuery{Lon: 15, Lat: 37, Radius: 200, Unit: 1000, Sort: GeoSortDesc}, "Palermo:190.4424 Catania:56.4413", nil},
+		{"指定数量时按距离取最近的", "Sicily", GeoQuery{Lon: 15, Lat: 37, Width: 400, Height: 400, ByBox: true, Unit: 1000, Count: 2},
+			"Catania:56.4413 Palermo:190.4424", nil},
+		{"以成员为中心", "Sicily", GeoQuery{FromMember: "Palermo", Radius: 170, Unit: 1000, Sort: GeoSortAsc}, "Palermo:0.0000 edge1:91.4007 Catania:166.2742", nil},
+		{"集合不存在", "missing", GeoQuery{Lon: 15, Lat: 37, Radius: 200}, "", nil},
+		{"中心成员不存在", "Sicily", GeoQuery{FromMember: "missing", Radius: 200}, "", errGeoMember},
+		{"集合不存在时中心成员也不存在", "missing", GeoQuery{FromMember: "Palermo", Radius: 200}, "", errGeoMember},
+		{"同时指定半径与矩形", "Sicily", GeoQuery{Lon: 15, Lat: 37, Radius: 200, Width: 1, Height: 1, ByBox: true}, "", errGeoShape},
+		{"半径为负", "Sicily", GeoQuery{Lon: 15, Lat: 37, Radius: -1}, "", errGeoShape},
+		{"中心纬度超出范围", "Sicily", GeoQuery{Lon: 15, Lat: 86, Radius: 200}, "", errGeoCoords},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := z.GeoSearch(tt.key, tt.q)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("得到错误 %v, 期望 %v", err, tt.err)
+			}
+			parts := make([]string, len(results))
+			for i, r := range results {
+				parts[i] = fmt.Sprintf("%s:%.4f", r.Member, r.Dist)
+			}
+			if got := strings.Join(parts, " "); got != tt.want {
+				t.Fatalf("得到 %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+	if results, _ := z.GeoSearch("Sicily", GeoQuery{Lon: 15, Lat: 37, Radius: 20000, Unit: 1000, Count: 3, Any: true}); len(results) != 3 {
+		t.Fatalf("指定 ANY 时得到 %d 个成员, 期望 3 个", len(results))
+	}
+	if n, err := z.GeoSearchStore("dst", "Sicily", GeoQuery{Lon: 15, Lat: 37, Radius: 200, Unit: 1000}, true); n != 2 || err != nil {
+		t.Fatalf("写入 %d 个成员: %v", n, err)
+	}
+	if score, _ := z.Score("dst", "Palermo"); fmt.Sprintf("%.4f", score) != "190.4424" {
+		t.Fatalf("以距离作为分数, 得到 %g", score)
+	}
+	if _, err := z.GeoAdd("Sicily", ZAddFlags{}, GeoMember{"ok", 0, 0}, GeoMember{"pole", 0, 86}); !errors.Is(err, errGeoCoords) {
+		t.Fatalf("纬度超出范围应返回 errGeoCoords, 得到 %v", err)
+	}
+	if _, found := z.Score("Sicily", "ok"); found {
+		t.Fatal("经纬度超出范围时不应添加任何成员")
+	}
+}
+
+// TestGeoSearchMatchesBruteForce 查询结果与逐个检查全部成员的结果一致, 包括 180 度经线与高纬度附近
+func TestGeoSearchMatchesBruteForce(t *testing.T) {
+	z := newTestZSet()
+	r := rand.New(rand.NewPCG(1, 2))
+	points := map[string][2]float64{}
+	for i := range 3000 {
+		lon, lat := r.Float64()*360-180, r.Float64()*170-85
+		if i%3 == 0 {
+			lon = 179.5 + r.Float64()*0.5
+			if i%2 == 0 {
+				lon = -lon
+			}
+		}
+		member := fmt.Sprint("m", i)
+		z.GeoAdd("world", ZAddFlags{}, GeoMember{member, lon, lat})
+		pos, _ := z.GeoPos("world", member)
+		points[member] = [2]float64{pos[0].Lon, pos[0].Lat}
+	}
+	for k := range 200 {
+		lon, lat := r.Float64()*360-180, r.Float64()*170-85
+		if k%4 == 0 {
+			lon = 179.9
+		}
+		if k%7 == 0 {
+			lat = 84.9
+		}
+		radius := []float64{1000, 50000, 300000, 3000000}[k%4]
+		q := GeoQuery{Lon: lon, Lat: lat, Radius: radius}
+		if k%2 == 1 {
+			q = GeoQuery{Lon: lon, Lat: lat, Width: radius, Height: radius * 1.5, ByBox: true}
+		}
+		area := &geoSearchArea{lon: q.Lon, lat: q.Lat, radius: q.Radius, width: q.Width, height: q.Height, byBox: q.ByBox}
+		want := 0
+		for _, p := range points {
+			if _, ok := area.contains(p[0], p[1]); ok {
+				want++
+			}
+		}
+		if got, _ := z.GeoSearch("world", q); len(got) != want {
+			t.Fatalf("第 %d 次查询 %+v 得到 %d 个成员, 期望 %d 个", k, q, len(got), want)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package data
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+)
+
+// ZSetEntry 有序集合成员及分数
+type ZSetEntry struct {
+	Member string
+	Score  float64
+}
+
+// zsetLess 有序集合的排序规则: 先按分数升序, 分数相同时按成员字典序
+// @param aScore float64
+// @param aMember string
+// @param bScore float64
+// @param bMember string
+// @return bool a是否排在b之前
+func zsetLess(aScore float64, aMember string, bScore float64, bMember string) bool {
+	if aScore != bScore {
+		return aScore < bScore
+	}
+	return aMember < bMember
+}
+
+// zsetObject 单个有序集合的值
+// 成员数量与成员长度不超过阈值时使用按序排列的紧凑列表编码(成员与分数交替存放),
+// 否则使用跳表 + 成员字典编码, 排名、区间查询与删除均为 O(log n)
+type zsetObject struct {
+	// 紧凑列表编码, 为nil表示使用跳表编码
+	lp *listpack
+	// 跳表编码: 成员 -> 分数
+	dict map[string]float64
+	// 跳表编码: 按 (分数, 成员) 排序
+	zsl *skiplist
+}
+
+// newZSetObject 创建空有序集合
+// @return *zsetObject
+func newZSetObject() *zsetObject {
+	return &zsetObject{lp: &listpack{}}
+}
+
+// encodeScore 将分数编码为紧凑列表条目
+func encodeScore(score float64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, math.Float64bits(score))
+}
+
+// decodeScore 解码紧凑列表中的分数条目
+func decodeScore(b []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+// encoding 当前编码名称
+// @return string
+func (z *zsetObject) encoding() string {
+	if z.lp != nil {
+		return "listpack"
+	}
+	return "skiplist"
+}
+
+// len 成员数量
+// @return int
+func (z *zsetObject) len() int {
+	if z == nil {
+		return 0
+	}
+	if z.lp != nil {
+		return z.lp.count / 2
+	}
+	return z.zsl.length
+}
+
+// find 在紧凑列表中查找成员
+// @param member string
+// @return int 成员条目的起始偏移, -1表示不存在
+// @return int 分数条目之后的偏移
+// @return float64 分数
+func (z *zsetObject) find(member string) (int, int, float64) {
+	for off := 0; off < z.lp.size(); {
+		m, scoreOff := z.lp.entry(off)
+		s, next := z.lp.entry(scoreOff)
+		if string(m) == member {
+			return off, next, decodeScore(s)
+		}
+		off = next
+	}
+	return -1, 0, 0
+}
+
+// score 获取成员分数
+// @param member string
+// @return float64 分数
+// @return bool 成员是否存在
+func (z *zsetObject) score(member string) (float64, bool) {
+	if z == nil {
+		return 0, false
+	}
+	if z.lp != nil {
+		off, _, score := z.find(member)
+		return score, off >= 0
+	}
+	score, ok := z.dict[member]
+	return score, ok
+}
+
+// add 设置成员分数, 必要时转换为跳表编码
+// @param member string
+// @param score float64
+// @return bool 是否为新成员
+func (z *zsetObject) add(member string, score float64) bool {
+	if z.lp != nil {
+		existed := z.remove(member)
+		if len(member) <= encodingLimits.ZSetMaxListpackValue && z.len() < encodingLimits.ZSetMaxListpackEntries {
+			z.insertSorted(member, score)
+			return !existed
+		}
+		z.convertToSkiplist()
+		z.dict[member] = score
+		z.zsl.insert(score, member)
+		return !existed
+	}
+	if cur, exists := z.dict[member]; exists {
+		if cur != score {
+			z.zsl.updateScore(cur, member, score)
+			z.dict[member] = score
+		}
+		return false
+	}
+	z.dict[member] = score
+	z.zsl.insert(score, member)
+	return true
+}
+
+// insertSorted 按排序规则将成员插入紧凑列表
+// @param member string
+// @param score float64
+func (z *zsetObject) insertSorted(member string, score float64) {
+	off := 0
+	for off < z.lp.size() {
+		m, scoreOff := z.lp.entry(off)
+		s, next := z.lp.entry(scoreOff)
+		if zsetLess(score, member, decodeScore(s), string(m)) {
+			break
+		}
+		off = next
+	}
+	z.lp.insert(off, []byte(member), encodeScore(score))
+}
+
+// remove 移除成员
+// @param member string
+// @return bool 成员是否存在
+func (z *zsetObject) remove(member string) bool {
+	if z.lp != nil {
+		off, end, _ := z.find(member)
+		if off < 0 {
+			return false
+		}
+		z.lp.cut(off, end, 2)
+		return true
+	}
+	score, ok := z.dict[member]
+	if !ok {
+		return false
+	}
+	delete(z.dict, member)
+	z.zsl.delete(score, member)
+	return true
+}
+
+// each 按排序规则遍历成员与分数, fn返回false时停止
+// @param fn func(member string, score float64) bool
+func (z *zsetObject) each(fn func(member string, score float64) bool) {
+	if z == nil {
+		return
+	}
+	if z.lp != nil {
+		for off := 0; off < z.lp.size(); {
+			m, scoreOff := z.lp.entry(off)
+			s, next := z.lp.entry(scoreOff)
+			if !fn(string(m), decodeScore(s)) {
+				return
+			}
+			off = next
+		}
+		return
+	}
+	for x := z.zsl.first(); x != nil; x = x.level[0].forward {
+		if !fn(x.member, x.score) {
+			return
+		}
+	}
+}
+
+// sorted 按排序规则获取全部成员
+// @return []ZSetEntry
+func (z *zsetObject) sorted() []ZSetEntry {
+	arr := make([]ZSetEntry, 0, z.len())
+	z.each(func(m string, s float64) bool {
+		arr = append(arr, ZSetEntry{m, s})
+		return true
+	})
+	return arr
+}
+
+// rank 获取成员的升序排名
+// @param member string
+// @return int 从0开始的排名
+// @return bool 成员是否存在
+func (z *zsetObject) rank(member string) (int, bool) {
+	if z == nil {
+		return 0, false
+	}
+	if z.lp != nil {
+		rank, i := -1, 0
+		z.each(func(m string, _ float64) bool {
+			if m == member {
+				rank = i
+				return false
+			}
+			i++
+			return true
+		})
+		return rank, rank >= 0
+	}
+	score, ok := z.dict[member]
+	if !ok {
+		return 0, false
+	}
+	return z.zsl.rank(score, member) - 1, true
+}
+
+// rangeByRank 获取排名区间内的成员(升序)
+// @param start int 起始排名(从0开始, 含), 调用方需保证 0 <= start <= end < len
+// @param end int 结束排名(含)
+// @return []ZSetEntry
+func (z *zsetObject) rangeByRank(start, end int) []ZSetEntry {
+	result := make([]ZSetEntry, 0, end-start+1)
+	if z.lp != nil {
+		i := 0
+		z.each(func(m string, s float64) bool {
+			if i >= start {
+				result = append(result, ZSetEntry{m, s})
+			}
+			i++
+			return i <= end
+		})
+		return result
+	}
+	for x := z.zsl.byRank(start + 1); x != nil && len(result) < end-start+1; x = x.level[0].forward {
+		result = append(result, ZSetEntry{x.member, x.score})
+	}
+	return result
+}
+
+// scanRange 按顺序遍历区间内的成员, fn返回false时停止
+// @param r zrangeBound 分数或字典序区间
+// @param rev bool 是否从大到小遍历
+// @param fn func(e ZSetEntry) bool
+func (z *zsetObject) scanRange(r zrangeBound, rev bool, fn func(e ZSetEntry) bool) {
+	if z == nil || r.empty() {
+		return
+	}
+	if z.lp != nil {
+		entries := z.sorted()
+		for i := range entries {
+			e := entries[i]
+			if rev {
+				e = entries[len(entries)-1-i]
+			}
+			if r.gteMin(e.Score, e.Member) && r.lteMax(e.Score, e.Member) && !fn(e) {
+				return
+			}
+		}
+		return
+	}
+	if rev {
+		for x := z.zsl.lastInRange(r); x != nil && r.gteMin(x.score, x.member); x = x.backward {
+			if !fn(ZSetEntry{x.member, x.score}) {
+				return
+			}
+		}
+		return
+	}
+	for x := z.zsl.firstInRange(r); x != nil && r.lteMax(x.score, x.member); x = x.level[0].forward {
+		if !fn(ZSetEntry{x.member, x.score}) {
+			return
+		}
+	}
+}
+
+// countRange 统计区间内的成员数量
+// @param r zrangeBound 分数或字典序区间
+// @return int
+func (z *zsetObject) countRange(r zrangeBound) int {
+	if z == nil {
+		return 0
+	}
+	if z.lp != nil {
+		count := 0
+		z.scanRange(r, false, func(ZSetEntry) bool {
+			count++
+			return true
+		})
+		return count
+	}
+	first := z.zsl.firstInRange(r)
+	if first == nil {
+		return 0
+	}
+	last := z.zsl.lastInRange(r)
+	return z.zsl.rank(last.score, last.member) - z.zsl.rank(first.score, first.member) + 1
+}
+
+// deleteRange 删除区间内的成员
+// @param r zrangeBound 分数或字典序区间
+// @return int 删除的数量
+func (z *zsetObject) deleteRange(r zrangeBound) int {
+	if z.lp != nil {
+		members := []string{}
+		z.scanRange(r, false, func(e ZSetEntry) bool {
+			members = append(members, e.Member)
+			return true
+		})
+		for _, m := range members {
+			z.remove(m)
+		}
+		return len(members)
+	}
+	if r.empty() {
+		return 0
+	}
+	return z.zsl.deleteRange(r, z.dict)
+}
+
+// query 按查询参数获取区间内的成员
+// @param opts *ZRangeOptions 查询参数
+// @return []ZSetEntry
+func (z *zsetObject) query(opts *ZRangeOptions) []ZSetEntry {
+	if opts.By == ZRangeByRank {
+		n := z.len()
+		start, stop, ok := normalizeRankRange(opts.Start, opts.Stop, n)
+		if !ok {
+			return []ZSetEntry{}
+		}
+		if !opts.Rev {
+			return z.rangeByRank(start, stop)
+		}
+		entries := z.rangeByRank(n-1-stop, n-1-start)
+		slices.Reverse(entries)
+		return entries
+	}
+	result := []ZSetEntry{}
+	if opts.Limit && (opts.Offset < 0 || opts.Count == 0) {
+		return result
+	}
+	skip := 0
+	if opts.Limit {
+		skip = opts.Offset
+	}
+	z.scanRange(opts.bound(), opts.Rev, func(e ZSetEntry) bool {
+		if skip > 0 {
+			skip--
+			return true
+		}
+		result = append(result, e)
+		return !opts.Limit || opts.Count < 0 || len(result) < opts.Count
+	})
+	return result
+}
+
+// deleteByOptions 按查询参数删除区间内的成员, 忽略 Rev 与 Limit
+// @param opts *ZRangeOptions 查询参数
+// @return int 删除的数量
+func (z *zsetObject) deleteByOptions(opts *ZRangeOptions) int {
+	if opts.By != ZRangeByRank {
+		return z.deleteRange(opts.bound())
+	}
+	start, stop, ok := normalizeRankRange(opts.Start, opts.Stop, z.len())
+	if !ok {
+		return 0
+	}
+	return z.deleteRangeByRank(start, stop)
+}
+
+// deleteRangeByRank 删除排名区间内的成员
+// @param start int 起始排名(从0开始, 含), 调用方需保证 0 <= start <= end < len
+// @param end int 结束排名(含)
+// @return int 删除的数量
+func (z *zsetObject) deleteRangeByRank(start, end int) int {
+	if z.lp != nil {
+		entries := z.rangeByRank(start, end)
+		for _, e := range entries {
+			z.remove(e.Member)
+		}
+		return len(entries)
+	}
+	return z.zsl.deleteRangeByRank(start+1, end+1, z.dict)
+}
+
+// convertToSkiplist 转换为跳表编码
+func (z *zsetObject) convertToSkiplist() {
+	dict := make(map[string]float64, z.len()+1)
+	zsl := newSkiplist()
+	z.each(func(m string, s float64) bool {
+		dict[m] = s
+		zsl.insert(s, m)
+		return true
+	})
+	z.lp = nil
+	z.dict = dict
+	z.zsl = zsl
+}
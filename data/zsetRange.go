@@ -0,0 +1,195 @@
+package data
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ZRangeBy 有序集合区间查询的类型
+type ZRangeBy int
+
+const (
+	// ZRangeByRank 按排名区间
+	ZRangeByRank ZRangeBy = iota
+	// ZRangeByScore 按分数区间
+	ZRangeByScore
+	// ZRangeByLex 按成员字典序区间(要求所有成员分数相同)
+	ZRangeByLex
+)
+
+// ScoreBound 分数区间的一端
+type ScoreBound struct {
+	Value     float64
+	Exclusive bool
+}
+
+// LexBound 字典序区间的一端
+type LexBound struct {
+	Value     string
+	Exclusive bool
+	// Inf 为 -1 表示 "-" (负无穷), 为 1 表示 "+" (正无穷), 为 0 表示普通值
+	Inf int
+}
+
+// ZRangeOptions 有序集合区间查询参数
+type ZRangeOptions struct {
+	By ZRangeBy
+	// 排名区间, By 为 ZRangeByRank 时使用, 负数表示倒数
+	Start, Stop int
+	// 分数区间, By 为 ZRangeByScore 时使用
+	Min, Max ScoreBound
+	// 字典序区间, By 为 ZRangeByLex 时使用
+	LexMin, LexMax LexBound
+	// 是否按降序返回
+	Rev bool
+	// 是否指定了 LIMIT, 仅对分数与字典序区间有效
+	Limit bool
+	// LIMIT offset count, Count 为负数表示不限制数量
+	Offset, Count int
+}
+
+// errScoreBound 分数区间格式错误
+var errScoreBound = errors.New("分数区间端点必须为数字")
+
+// errLexBound 字典序区间格式错误
+var errLexBound = errors.New("字典序区间端点必须以 [ 或 ( 开头, 或为 - / +")
+
+// ParseScoreBound 解析分数区间端点
+// 支持 "1.5"、"(1.5"(开区间)、"-inf"、"+inf"
+// @param s string
+// @return ScoreBound
+// @return error
+func ParseScoreBound(s string) (ScoreBound, error) {
+	bound := ScoreBound{}
+	if strings.HasPrefix(s, "(") {
+		bound.Exclusive = true
+		s = s[1:]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) {
+		return bound, errScoreBound
+	}
+	bound.Value = v
+	return bound, nil
+}
+
+// ParseLexBound 解析字典序区间端点
+// 支持 "[a"(闭区间)、"(a"(开区间)、"-"、"+"
+// @param s string
+// @return LexBound
+// @return error
+func ParseLexBound(s string) (LexBound, error) {
+	switch {
+	case s == "-":
+		return LexBound{Inf: -1}, nil
+	case s == "+":
+		return LexBound{Inf: 1}, nil
+	case strings.HasPrefix(s, "["):
+		return LexBound{Value: s[1:]}, nil
+	case strings.HasPrefix(s, "("):
+		return LexBound{Value: s[1:], Exclusive: true}, nil
+	}
+	return LexBound{}, errLexBound
+}
+
+// zrangeBound 分数区间或字典序区间, 供跳表与紧凑列表统一处理
+type zrangeBound interface {
+	// gteMin 元素是否不低于区间下界
+	gteMin(score float64, member string) bool
+	// lteMax 元素是否不高于区间上界
+	lteMax(score float64, member string) bool
+	// empty 区间是否必然为空
+	empty() bool
+}
+
+// zrangeSpec 分数区间
+type zrangeSpec struct {
+	min, max     float64
+	minEx, maxEx bool
+}
+
+// newScoreRange 根据区间端点创建分数区间
+// @param min, max ScoreBound
+// @return *zrangeSpec
+func newScoreRange(min, max ScoreBound) *zrangeSpec {
+	return &zrangeSpec{min: min.Value, max: max.Value, minEx: min.Exclusive, maxEx: max.Exclusive}
+}
+
+// gteMin 分数是否不低于区间下界
+func (r *zrangeSpec) gteMin(score float64, _ string) bool {
+	if r.minEx {
+		return score > r.min
+	}
+	return score >= r.min
+}
+
+// lteMax 分数是否不高于区间上界
+func (r *zrangeSpec) lteMax(score float64, _ string) bool {
+	if r.maxEx {
+		return score < r.max
+	}
+	return score <= r.max
+}
+
+// empty 分数区间是否必然为空
+func (r *zrangeSpec) empty() bool {
+	return r.min > r.max || (r.min == r.max && (r.minEx || r.maxEx))
+}
+
+// zlexRangeSpec 字典序区间
+type zlexRangeSpec struct {
+	min, max LexBound
+}
+
+// gteMin 成员是否不低于区间下界
+func (r *zlexRangeSpec) gteMin(_ float64, member string) bool {
+	switch r.min.Inf {
+	case -1:
+		return true
+	case 1:
+		return false
+	}
+	if r.min.Exclusive {
+		return member > r.min.Value
+	}
+	return member >= r.min.Value
+}
+
+// lteMax 成员是否不高于区间上界
+func (r *zlexRangeSpec) lteMax(_ float64, member string) bool {
+	switch r.max.Inf {
+	case 1:
+		return true
+	case -1:
+		return false
+	}
+	if r.max.Exclusive {
+		return member < r.max.Value
+	}
+	return member <= r.max.Value
+}
+
+// empty 字典序区间是否必然为空
+func (r *zlexRangeSpec) empty() bool {
+	if r.min.Inf == 1 || r.max.Inf == -1 {
+		return true
+	}
+	if r.min.Inf == -1 || r.max.Inf == 1 {
+		return false
+	}
+	return r.min.Value > r.max.Value || (r.min.Value == r.max.Value && (r.min.Exclusive || r.max.Exclusive))
+}
+
+// bound 根据查询参数构造分数或字典序区间
+// @return zrangeBound 按排名查询时为nil
+func (opts *ZRangeOptions) bound() zrangeBound {
+	switch opts.By {
+	case ZRangeByScore:
+		return newScoreRange(opts.Min, opts.Max)
+	case ZRangeByLex:
+		return &zlexRangeSpec{min: opts.LexMin, max: opts.LexMax}
+	}
+	return nil
+}
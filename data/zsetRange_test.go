@@ -0,0 +1,187 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestZSet 创建独立于全局实例的有序集合存储
+// @return *GkvZSet
+func newTestZSet() *GkvZSet {
+	return &GkvZSet{
+		data:        make(map[string]*zsetObject),
+		expireTimes: make(map[string]time.Time),
+		keyLock:     NewKeyLock(),
+		blocked:     newZSetBlocking(),
+	}
+}
+
+// joinMembers 按顺序拼接成员名
+// @param entries []ZSetEntry
+// @return string
+func joinMembers(entries []ZSetEntry) string {
+	return strings.Join(entryMembers(entries), ",")
+}
+
+// fillZSet 写入 a..e 分数 1..5, big 为true时再写入足够多的高分成员使编码转为跳表
+// @param z *GkvZSet
+// @param key string
+// @param big bool
+func fillZSet(z *GkvZSet, key string, big bool) {
+	for i, m := range []string{"a", "b", "c", "d", "e"} {
+		z.Add(key, m, float64(i+1))
+	}
+	if big {
+		for i := 0; i < encodingLimits.ZSetMaxListpackEntries; i++ {
+			z.Add(key, fmt.Sprintf("z%03d", i), 100)
+		}
+	}
+}
+
+// TestZRangeByScoreBounds 分数区间的开闭端点、无穷端点、REV 与 LIMIT, 两种编码结果一致
+func TestZRangeByScoreBounds(t *testing.T) {
+	score := func(s string) ScoreBound {
+		b, err := ParseScoreBound(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	tests := []struct {
+		name     string
+		min, max string
+		rev      bool
+		limit    []int
+		want     string
+	}{
+		{"闭区间", "2", "4", false, nil, "b,c,d"},
+		{"左开", "(2", "4", false, nil, "c,d"},
+		{"右开", "2", "(4", false, nil, "b,c"},
+		{"两端开", "(2", "(3", false, nil, ""},
+		{"下界大于上界", "4", "2", false, nil, ""},
+		{"端点相同", "3", "3", false, nil, "c"},
+		{"端点相同且开", "(3", "3", false, nil, ""},
+		{"负无穷", "-inf", "(2", false, nil, "a"},
+		{"降序", "2", "4", true, nil, "d,c,b"},
+		{"LIMIT", "-inf", "5", false, []int{1, 2}, "b,c"},
+		{"降序 LIMIT", "-inf", "5", true, []int{1, 2}, "d,c"},
+		{"LIMIT 负数数量不限制", "1", "5", false, []int{3, -1}, "d,e"},
+		{"LIMIT 偏移超出", "1", "5", false, []int{10, 1}, ""},
+		{"LIMIT 数量为0", "1", "5", false, []int{0, 0}, ""},
+	}
+	for _, big := range []bool{false, true} {
+		z := newTestZSet()
+		fillZSet(z, "k", big)
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/跳表=%v", tt.name, big), func(t *testing.T) {
+				opts := ZRangeOptions{By: ZRangeByScore, Min: score(tt.min), Max: score(tt.max), Rev: tt.rev}
+				if tt.limit != nil {
+					opts.Limit, opts.Offset, opts.Count = true, tt.limit[0], tt.limit[1]
+				}
+				if got := joinMembers(z.Range("k", opts)); got != tt.want {
+					t.Fatalf("得到 %q, 期望 %q", got, tt.want)
+				}
+				if tt.limit == nil && !tt.rev {
+					if n := z.Count("k", opts); n != strings.Count(tt.want, ",")+min(len(tt.want), 1) {
+						t.Fatalf("计数为 %d, 期望与查询结果 %q 一致", n, tt.want)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestZRangeByLexBounds 字典序区间的开闭端点与 -/+
+func TestZRangeByLexBounds(t *testing.T) {
+	lex := func(s string) LexBound {
+		b, err := ParseLexBound(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	tests := []struct {
+		name     string
+		min, max string
+		rev      bool
+		want     string
+	}{
+		{"全部", "-", "+", false, "a,aa,b,c,d"},
+		{"全部降序", "-", "+", true, "d,c,b,aa,a"},
+		{"闭区间", "[aa", "[c", false, "aa,b,c"},
+		{"开区间", "(a", "(c", false, "aa,b"},
+		{"前缀", "[a", "(b", false, "a,aa"},
+		{"上界为负无穷", "-", "-", false, ""},
+		{"下界为正无穷", "+", "+", false, ""},
+		{"下界大于上界", "[c", "[a", false, ""},
+		{"端点相同且开", "(b", "[b", false, ""},
+	}
+	for _, big := range []bool{false, true} {
+		z := newTestZSet()
+		for _, m := range []string{"a", "aa", "b", "c", "d"} {
+			z.Add("k", m, 0)
+		}
+		// 跳表编码时追加的成员都排在 d 之后, 只出现在上界为 + 的结果中
+		filler := []ZSetEntry{}
+		if big {
+			for i := 0; i < encodingLimits.ZSetMaxListpackEntries; i++ {
+				filler = append(filler, ZSetEntry{Member: fmt.Sprintf("e%03d", i)})
+				z.Add("k", filler[i].Member, 0)
+			}
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/跳表=%v", tt.name, big), func(t *testing.T) {
+				opts := ZRangeOptions{By: ZRangeByLex, LexMin: lex(tt.min), LexMax: lex(tt.max), Rev: tt.rev}
+				want := tt.want
+				if big && tt.max == "+" && tt.min != "+" {
+					if tt.rev {
+						reversed := slices.Clone(filler)
+						slices.Reverse(reversed)
+						want = joinMembers(reversed) + "," + want
+					} else {
+						want += "," + joinMembers(filler)
+					}
+				}
+				if got := joinMembers(z.Range("k", opts)); got != want {
+					t.Fatalf("得到 %q, 期望 %q", got, want)
+				}
+			})
+		}
+	}
+	for _, s := range []string{"a", "", "{a"} {
+		if _, err := ParseLexBound(s); err == nil {
+			t.Fatalf("%q 应为非法端点", s)
+		}
+	}
+	for _, s := range []string{"nan", "(nan", "x", "("} {
+		if _, err := ParseScoreBound(s); err == nil {
+			t.Fatalf("%q 应为非法端点", s)
+		}
+	}
+}
+
+// TestZRemoveRangeAndMembers 区间删除与批量删除返回实际删除的数量, 删空后集合被删除
+func TestZRemoveRangeAndMembers(t *testing.T) {
+	for _, big := range []bool{false, true} {
+		z := newTestZSet()
+		fillZSet(z, "k", big)
+		total := z.Cardinality("k")
+		removed := z.RemoveRange("k", ZRangeOptions{By: ZRangeByScore, Min: ScoreBound{Value: 2}, Max: ScoreBound{Value: 3}})
+		if removed != 2 || z.Cardinality("k") != total-2 {
+			t.Fatalf("跳表=%v: 区间删除了 %d 个", big, removed)
+		}
+		if n := z.Remove("k", "a", "a", "b", "missing", "d"); n != 2 {
+			t.Fatalf("跳表=%v: 删除了 %d 个, 期望 2", big, n)
+		}
+		members := entryMembers(z.Range("k", ZRangeOptions{By: ZRangeByRank, Start: 0, Stop: -1}))
+		if n := z.Remove("k", members...); n != len(members) {
+			t.Fatalf("跳表=%v: 删除了 %d 个, 期望 %d", big, n, len(members))
+		}
+		if _, exists := z.data["k"]; exists || slices.Contains(z.liveKeys(), "k") {
+			t.Fatalf("跳表=%v: 删空的集合应被删除", big)
+		}
+	}
+}
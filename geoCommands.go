@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"gopherkv/data"
+	"strconv"
+	"strings"
+)
+
+const geoAddUsage = "geoadd \"key\" [nx|xx] [ch] longitude latitude \"member\" [longitude latitude \"member\" ...]"
+
+const geoSearchOptions = "(frommember \"member\" | fromlonlat longitude latitude) (byradius radius m|km|ft|mi | bybox width height m|km|ft|mi) [asc|desc] [count n [any]]"
+
+// execGeoCommand 执行地理位置相关命令, 成员保存在有序集合中
+// @param fields []string 拆分后的命令
+// @return bool 是否为地理位置命令
+func execGeoCommand(fields []string) bool {
+	switch strings.ToLower(fields[0]) {
+	case "geoadd":
+		if len(fields) < 5 {
+			usageError(geoAddUsage)
+			return true
+		}
+		execGeoAdd(fields[1], fields[2:])
+	case "geopos":
+		if len(fields) < 3 {
+			usageError("geopos \"key\" \"member\" [\"member\" ...]")
+			return true
+		}
+		positions, found := data.DataGkvZSet.GeoPos(fields[1], fields[2:]...)
+		for i, p := range positions {
+			if found[i] {
+				fmt.Printf("%d) %s %s\n", i+1, formatScore(p.Lon), formatScore(p.Lat))
+			} else {
+				fmt.Printf("%d) (nil)\n", i+1)
+			}
+		}
+	case "geodist":
+		const usage = "geodist \"key\" \"member\" \"member\" [m|km|ft|mi]"
+		if len(fields) != 4 && len(fields) != 5 {
+			usageError(usage)
+			return true
+		}
+		unit := 1.0
+		if len(fields) == 5 {
+			var ok bool
+			if unit, ok = data.ParseGeoUnit(fields[4]); !ok {
+				usageError(usage)
+				return true
+			}
+		}
+		if d, ok := data.DataGkvZSet.GeoDist(fields[1], fields[2], fields[3], unit); ok {
+			fmt.Println(formatGeoDist(d))
+		} else {
+			fmt.Println("(nil)")
+		}
+	case "geohash":
+		if len(fields) < 3 {
+			usageError("geohash \"key\" \"member\" [\"member\" ...]")
+			return true
+		}
+		hashes, found := data.DataGkvZSet.GeoHash(fields[1], fields[2:]...)
+		for i, h := range hashes {
+			if found[i] {
+				fmt.Printf("%d) %s\n", i+1, h)
+			} else {
+				fmt.Printf("%d) (nil)\n", i+1)
+			}
+		}
+	case "geosearch":
+		const usage = "geosearch \"key\" " + geoSearchOptions + " [withcoord] [withdist] [withhash]"
+		if len(fields) < 2 {
+			usageError(usage)
+			return true
+		}
+		q, flags, err := parseGeoSearchArgs(fields[2:], false)
+		if err != nil {
+			fmt.Println(err)
+			usageError(usage)
+			return true
+		}
+		results, err := data.DataGkvZSet.GeoSearch(fields[1], q)
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		printGeoResults(results, flags)
+	case "geosearchstore":
+		const usage = "geosearchstore \"destination\" \"source\" " + geoSearchOptions + " [storedist]"
+		if len(fields) < 3 {
+			usageError(usage)
+			return true
+		}
+		q, flags, err := parseGeoSearchArgs(fields[3:], true)
+		if err != nil {
+			fmt.Println(err)
+			usageError(usage)
+			return true
+		}
+		n, err := data.DataGkvZSet.GeoSearchStore(fields[1], fields[2], q, flags.storeDist)
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		fmt.Println(n)
+	default:
+		return false
+	}
+	return true
+}
+
+// execGeoAdd 解析并执行 geoadd 的选项与经纬度成员
+// @param key string 集合名
+// @param args []string key 之后的参数
+func execGeoAdd(key string, args []string) {
+	flags := data.ZAddFlags{}
+	i := 0
+loop:
+	for ; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "nx":
+			flags.NX = true
+		case "xx":
+			flags.XX = true
+		case "ch":
+			flags.CH = true
+		default:
+			break loop
+		}
+	}
+	triples := args[i:]
+	if len(triples) == 0 || len(triples)%3 != 0 {
+		usageError(geoAddUsage)
+		return
+	}
+	members := make([]data.GeoMember, 0, len(triples)/3)
+	for j := 0; j < len(triples); j += 3 {
+		coords, ok := parseFloats(triples[j : j+2])
+		if !ok {
+			fmt.Println("经纬度必须为数字")
+			return
+		}
+		members = append(members, data.GeoMember{Member: triples[j+2], Lon: coords[0], Lat: coords[1]})
+	}
+	count, err := data.DataGkvZSet.GeoAdd(key, flags, members...)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(count)
+}
+
+// geoOutputFlags 查询结果的输出选项
+type geoOutputFlags struct {
+	withCoord, withDist, withHash bool
+	storeDist                     bool
+}
+
+// parseGeoSearchArgs 解析 geosearch 与 geosearchstore 的参数
+// @param args []string key 之后的参数
+// @param store bool 是否为 geosearchstore, 只有它接受 storedist, 不接受 with* 选项
+// @return data.GeoQuery
+// @return geoOutputFlags
+// @return error
+func parseGeoSearchArgs(args []string, store bool) (data.GeoQuery, geoOutputFlags, error) {
+	q := data.GeoQuery{}
+	flags := geoOutputFlags{}
+	hasCenter, hasShape := false, false
+	// need 检查选项之后是否还有n个参数
+	need := func(i, n int) error {
+		if i+n >= len(args) {
+			return fmt.Errorf("%s 缺少参数", args[i])
+		}
+		return nil
+	}
+	for i := 0; i < len(args); i++ {
+		opt := strings.ToLower(args[i])
+		switch opt {
+		case "frommember":
+			if err := need(i, 1); err != nil {
+				return q, flags, err
+			}
+			if hasCenter {
+				return q, flags, fmt.Errorf("frommember 与 fromlonlat 只能指定一个")
+			}
+			q.FromMember, hasCenter = args[i+1], true
+			i++
+		case "fromlonlat":
+			if err := need(i, 2); err != nil {
+				return q, flags, err
+			}
+			if hasCenter {
+				return q, flags, fmt.Errorf("frommember 与 fromlonlat 只能指定一个")
+			}
+			coords, ok := parseFloats(args[i+1 : i+3])
+			if !ok {
+				return q, flags, fmt.Errorf("经纬度必须为数字")
+			}
+			q.Lon, q.Lat, hasCenter = coords[0], coords[1], true
+			i += 2
+		case "byradius", "bybox":
+			n := 2
+			if opt == "bybox" {
+				n = 3
+			}
+			if err := need(i, n); err != nil {
+				return q, flags, err
+			}
+			if hasShape {
+				return q, flags, fmt.Errorf("byradius 与 bybox 只能指定一个")
+			}
+			size, ok := parseFloats(args[i+1 : i+n])
+			if !ok {
+				return q, flags, fmt.Errorf("范围必须为数字")
+			}
+			unit, ok := data.ParseGeoUnit(args[i+n])
+			if !ok {
+				return q, flags, fmt.Errorf("距离单位必须为 m、km、ft 或 mi")
+			}
+			if opt == "bybox" {
+				q.Width, q.Height, q.ByBox = size[0], size[1], true
+			} else {
+				q.Radius = size[0]
+			}
+			q.Unit, hasShape = unit, true
+			i += n
+		case "asc":
+			q.Sort = data.GeoSortAsc
+		case "desc":
+			q.Sort = data.GeoSortDesc
+		case "count":
+			if err := need(i, 1); err != nil {
+				return q, flags, err
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				return q, flags, fmt.Errorf("count 必须为正整数")
+			}
+			q.Count = n
+			i++
+			if i+1 < len(args) && strings.ToLower(args[i+1]) == "any" {
+				q.Any = true
+				i++
+			}
+		case "withcoord", "withdist", "withhash":
+			if store {
+				return q, flags, fmt.Errorf("geosearchstore 不支持 %s", args[i])
+			}
+			flags.withCoord = flags.withCoord || opt == "withcoord"
+			flags.withDist = flags.withDist || opt == "withdist"
+			flags.withHash = flags.withHash || opt == "withhash"
+		case "storedist":
+			if !store {
+				return q, flags, fmt.Errorf("geosearch 不支持 storedist")
+			}
+			flags.storeDist = true
+		default:
+			return q, flags, fmt.Errorf("未知参数: %s", args[i])
+		}
+	}
+	if !hasCenter || !hasShape {
+		return q, flags, fmt.Errorf("必须指定中心(frommember 或 fromlonlat)与范围(byradius 或 bybox)")
+	}
+	return q, flags, nil
+}
+
+// printGeoResults 打印查询结果, 每行一个成员, 按选项依次附加距离、geohash 分数与经纬度
+// @param results []data.GeoResult
+// @param flags geoOutputFlags
+func printGeoResults(results []data.GeoResult, flags geoOutputFlags) {
+	if len(results) == 0 {
+		fmt.Println("(empty list or set)")
+		return
+	}
+	for i, r := range results {
+		line := []string{r.Member}
+		if flags.withDist {
+			line = append(line, formatGeoDist(r.Dist))
+		}
+		if flags.withHash {
+			line = append(line, strconv.FormatUint(r.Hash, 10))
+		}
+		if flags.withCoord {
+			line = append(line, formatScore(r.Lon), formatScore(r.Lat))
+		}
+		fmt.Printf("%d) %s\n", i+1, strings.Join(line, " "))
+	}
+}
+
+// formatGeoDist 距离保留4位小数, 与 Redis 相同
+// @param d float64
+// @return string
+func formatGeoDist(d float64) string {
+	return strconv.FormatFloat(d, 'f', 4, 64)
+}
@@ -0,0 +1,625 @@
+package main
+
+import (
+	"fmt"
+	"gopherkv/data"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const graphAddEdgeUsage = "graph.addedge \"key\" \"from\" \"to\" [weight w] [directed] [type T] [prop \"name\" \"value\" ...]"
+
+// execGraphCommand 执行图相关命令
+// @param fields []string 拆分后的命令
+// @return bool 是否为图命令
+func execGraphCommand(fields []string) bool {
+	switch strings.ToLower(fields[0]) {
+	case "graph.addnode":
+		if len(fields) != 5 {
+			usageError("graph.addnode \"key\" \"node\" x y")
+			return true
+		}
+		x, errX := strconv.ParseFloat(fields[3], 64)
+		y, errY := strconv.ParseFloat(fields[4], 64)
+		if errX != nil || errY != nil {
+			usageError("graph.addnode \"key\" \"node\" x y")
+			return true
+		}
+		data.DataGkvGraph.AddNode(fields[1], fields[2], x, y)
+		fmt.Println("OK")
+	case "graph.addedge":
+		if len(fields) < 4 {
+			usageError(graphAddEdgeUsage)
+			return true
+		}
+		edge, err := parseGraphEdge(fields[2], fields[3], fields[4:])
+		if err != nil {
+			fmt.Println(err)
+			usageError(graphAddEdgeUsage)
+			return true
+		}
+		data.DataGkvGraph.AddEdge(fields[1], edge)
+		fmt.Println("OK")
+	case "graph.remedge":
+		if len(fields) != 4 {
+			usageError("graph.remedge \"key\" \"from\" \"to\"")
+			return true
+		}
+		fmt.Println(boolToInt(data.DataGkvGraph.RemoveEdge(fields[1], fields[2], fields[3])))
+	case "graph.remnode":
+		if len(fields) != 3 {
+			usageError("graph.remnode \"key\" \"node\"")
+			return true
+		}
+		fmt.Println(boolToInt(data.DataGkvGraph.RemoveNode(fields[1], fields[2])))
+	case "graph.addlabel", "graph.remlabel":
+		name := strings.ToLower(fields[0])
+		if len(fields) < 4 {
+			usageError(name + " \"key\" \"node\" \"label\" [\"label\" ...]")
+			return true
+		}
+		if name == "graph.addlabel" {
+			fmt.Println(data.DataGkvGraph.AddLabels(fields[1], fields[2], fields[3:]...))
+		} else {
+			fmt.Println(data.DataGkvGraph.RemoveLabels(fields[1], fields[2], fields[3:]...))
+		}
+	case "graph.setprop":
+		if len(fields) < 5 || len(fields)%2 != 1 {
+			usageError("graph.setprop \"key\" \"node\" \"name\" \"value\" [\"name\" \"value\" ...]")
+			return true
+		}
+		props := make(map[string]string)
+		for i := 3; i < len(fields); i += 2 {
+			props[fields[i]] = fields[i+1]
+		}
+		data.DataGkvGraph.SetNodeProps(fields[1], fields[2], props)
+		fmt.Println("OK")
+	case "graph.remprop":
+		if len(fields) < 4 {
+			usageError("graph.remprop \"key\" \"node\" \"name\" [\"name\" ...]")
+			return true
+		}
+		fmt.Println(data.DataGkvGraph.RemoveNodeProps(fields[1], fields[2], fields[3:]...))
+	case "graph.node":
+		if len(fields) != 3 {
+			usageError("graph.node \"key\" \"node\"")
+			return true
+		}
+		node, ok := data.DataGkvGraph.Node(fields[1], fields[2])
+		if !ok {
+			fmt.Println("(nil)")
+			return true
+		}
+		printGraphNode(node)
+	case "graph.query":
+		if len(fields) < 3 {
+			usageError("graph.query \"key\" \"MATCH ... RETURN ...\"")
+			return true
+		}
+		// 查询语句中的空格可以不加引号, 剩余的参数拼接为完整的语句
+		result, err := data.DataGkvGraph.Query(fields[1], strings.Join(fields[2:], " "))
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		printGraphQueryResult(result)
+	case "graph.nodes":
+		if len(fields) != 2 {
+			usageError("graph.nodes \"key\"")
+			return true
+		}
+		printList(data.DataGkvGraph.Nodes(fields[1]))
+	case "graph.edges":
+		if len(fields) != 2 {
+			usageError("graph.edges \"key\"")
+			return true
+		}
+		edges := data.DataGkvGraph.Edges(fields[1])
+		if len(edges) == 0 {
+			fmt.Println("(empty list or set)")
+			return true
+		}
+		for _, e := range edges {
+			fmt.Println(formatGraphEdge(e))
+		}
+	case "graph.degree":
+		if len(fields) != 3 {
+			usageError("graph.degree \"key\" \"node\"")
+			return true
+		}
+		in, out, ok := data.DataGkvGraph.Degree(fields[1], fields[2])
+		if !ok {
+			fmt.Println("(nil)")
+			return true
+		}
+		fmt.Println("in:", in)
+		fmt.Println("out:", out)
+	case "graph.dist":
+		if len(fields) != 4 {
+			usageError("graph.dist \"key\" \"node\" \"node\"")
+			return true
+		}
+		if d, ok := data.DataGkvGraph.EuclideanDistance(fields[1], fields[2], fields[3]); ok {
+			fmt.Println(formatScore(d))
+		} else {
+			fmt.Println("(nil)")
+		}
+	case "graph.dijkstra", "graph.astar":
+		name := strings.ToLower(fields[0])
+		usage := name + " \"key\" \"from\" \"to\" [maxexplored n]"
+		if len(fields) != 4 && len(fields) != 6 {
+			usageError(usage)
+			return true
+		}
+		limit, ok := parseMaxExplored(fields[4:])
+		if !ok {
+			usageError(usage)
+			return true
+		}
+		var path data.GraphPath
+		var found bool
+		var err error
+		if name == "graph.dijkstra" {
+			path, found, err = data.DataGkvGraph.Dijkstra(fields[1], fields[2], fields[3], limit)
+		} else {
+			path, found, err = data.DataGkvGraph.AStar(fields[1], fields[2], fields[3], limit)
+		}
+		switch {
+		case err != nil:
+			fmt.Println(err)
+		case !found:
+			fmt.Println("(nil)")
+		default:
+			fmt.Println(formatGraphPath(path))
+		}
+	case "graph.kshortest":
+		const usage = "graph.kshortest \"key\" \"from\" \"to\" k [maxexplored n]"
+		if len(fields) != 5 && len(fields) != 7 {
+			usageError(usage)
+			return true
+		}
+		k, err := strconv.Atoi(fields[4])
+		limit, ok := parseMaxExplored(fields[5:])
+		if err != nil || k <= 0 || !ok {
+			usageError(usage)
+			return true
+		}
+		paths, err := data.DataGkvGraph.KShortestPaths(fields[1], fields[2], fields[3], k, limit)
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		if len(paths) == 0 {
+			fmt.Println("(empty list or set)")
+			return true
+		}
+		for i, p := range paths {
+			fmt.Printf("%d) %s\n", i+1, formatGraphPath(p))
+		}
+	case "graph.components", "graph.scc":
+		name := strings.ToLower(fields[0])
+		if len(fields) != 2 {
+			usageError(name + " \"key\"")
+			return true
+		}
+		var comps [][]string
+		if name == "graph.components" {
+			comps = data.DataGkvGraph.ConnectedComponents(fields[1])
+		} else {
+			comps = data.DataGkvGraph.StronglyConnectedComponents(fields[1])
+		}
+		if len(comps) == 0 {
+			fmt.Println("(empty list or set)")
+			return true
+		}
+		for i, comp := range comps {
+			fmt.Printf("%d) ", i+1)
+			printList(comp)
+		}
+	case "graph.cycle":
+		if len(fields) != 2 {
+			usageError("graph.cycle \"key\"")
+			return true
+		}
+		if cycle, ok := data.DataGkvGraph.FindCycle(fields[1]); ok {
+			printList(cycle)
+		} else {
+			fmt.Println("(nil)")
+		}
+	case "graph.toposort":
+		if len(fields) != 2 {
+			usageError("graph.toposort \"key\"")
+			return true
+		}
+		order, err := data.DataGkvGraph.TopologicalSort(fields[1])
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		printList(order)
+	case "graph.mst":
+		if len(fields) != 2 {
+			usageError("graph.mst \"key\"")
+			return true
+		}
+		edges, total := data.DataGkvGraph.MinimumSpanningTree(fields[1])
+		for _, e := range edges {
+			fmt.Println(formatGraphEdge(e))
+		}
+		fmt.Println("total:", formatScore(total))
+	case "graph.pagerank":
+		const usage = "graph.pagerank \"key\" [damping d] [tolerance t] [maxiter n] [limit n]"
+		if len(fields) < 2 {
+			usageError(usage)
+			return true
+		}
+		opts, limit, ok := parsePageRankArgs(fields[2:])
+		if !ok {
+			usageError(usage)
+			return true
+		}
+		ranks, iterations, err := data.DataGkvGraph.PageRank(fields[1], opts)
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		if len(ranks) == 0 {
+			fmt.Println("(empty list or set)")
+			return true
+		}
+		if limit > 0 && limit < len(ranks) {
+			ranks = ranks[:limit]
+		}
+		for i, r := range ranks {
+			fmt.Printf("%d) \"%s\" %s\n", i+1, r.Node, formatScore(r.Score))
+		}
+		fmt.Println("iterations:", iterations)
+	case "graph.nearest":
+		const usage = "graph.nearest \"key\" x y k"
+		if len(fields) != 5 {
+			usageError(usage)
+			return true
+		}
+		pt, ok := parseFloats(fields[2:4])
+		k, err := strconv.Atoi(fields[4])
+		if !ok || err != nil || k <= 0 {
+			usageError(usage)
+			return true
+		}
+		printGraphNeighbors(data.DataGkvGraph.Nearest(fields[1], pt[0], pt[1], k))
+	case "graph.radius":
+		const usage = "graph.radius \"key\" x y radius"
+		if len(fields) != 5 {
+			usageError(usage)
+			return true
+		}
+		args, ok := parseFloats(fields[2:5])
+		if !ok || args[2] < 0 {
+			usageError(usage)
+			return true
+		}
+		printGraphNeighbors(data.DataGkvGraph.WithinRadius(fields[1], args[0], args[1], args[2]))
+	case "graph.rect":
+		const usage = "graph.rect \"key\" minx miny maxx maxy"
+		if len(fields) != 6 {
+			usageError(usage)
+			return true
+		}
+		args, ok := parseFloats(fields[2:6])
+		if !ok || args[0] > args[2] || args[1] > args[3] {
+			usageError(usage)
+			return true
+		}
+		printGraphNeighbors(data.DataGkvGraph.WithinRect(fields[1], args[0], args[1], args[2], args[3]))
+	case "graph.dfs", "graph.bfs":
+		name := strings.ToLower(fields[0])
+		usage := name + " \"key\" \"start\" [maxdepth n] [maxnodes n] [where \"prop\" \"value\" ...] [withdepth] [withparent]"
+		if len(fields) < 3 {
+			usageError(usage)
+			return true
+		}
+		opts, withDepth, withParent, err := parseTraverseArgs(fields[3:])
+		if err != nil {
+			fmt.Println(err)
+			usageError(usage)
+			return true
+		}
+		order := data.TraverseDFS
+		if name == "graph.bfs" {
+			order = data.TraverseBFS
+		}
+		printGraphVisits(data.DataGkvGraph.Traverse(fields[1], fields[2], order, opts), withDepth, withParent)
+	case "graph.del":
+		if len(fields) != 2 {
+			usageError("graph.del \"key\"")
+			return true
+		}
+		fmt.Println(boolToInt(data.DataGkvGraph.Delete(fields[1])))
+	case "graph.settime":
+		if len(fields) != 3 {
+			usageError("graph.settime \"key\" (milliseconds)")
+			return true
+		}
+		ms, err := strconv.Atoi(fields[2])
+		if err != nil {
+			usageError("graph.settime \"key\" (milliseconds)")
+			return true
+		}
+		fmt.Println(boolToInt(data.DataGkvGraph.SetTime(fields[1], ms)))
+	case "graph.ttl":
+		if len(fields) != 2 {
+			usageError("graph.ttl \"key\"")
+			return true
+		}
+		switch ttl := data.DataGkvGraph.GetTTL(fields[1]); ttl {
+		case -1:
+			fmt.Println("(nil)")
+		case -2:
+			fmt.Println("未设置过期时间")
+		default:
+			fmt.Println(ttl)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// parseGraphEdge 解析 graph.addedge 的可选参数, 默认为权重1的无向边
+// @param from, to string 节点名
+// @param args []string 节点名之后的参数
+// @return data.GraphEdge
+// @return error
+func parseGraphEdge(from, to string, args []string) (data.GraphEdge, error) {
+	edge := data.GraphEdge{From: from, To: to, Weight: 1}
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "weight":
+			if i+1 >= len(args) {
+				return edge, fmt.Errorf("weight 需要指定权重")
+			}
+			w, err := strconv.ParseFloat(args[i+1], 64)
+			if err != nil || math.IsNaN(w) {
+				return edge, fmt.Errorf("权重必须为数字")
+			}
+			edge.Weight = w
+			i++
+		case "directed":
+			edge.Directed = true
+		case "type":
+			if i+1 >= len(args) {
+				return edge, fmt.Errorf("type 需要指定关系类型")
+			}
+			edge.Type = args[i+1]
+			i++
+		case "prop":
+			if i+2 >= len(args) {
+				return edge, fmt.Errorf("prop 需要指定属性名与属性值")
+			}
+			if edge.Props == nil {
+				edge.Props = make(map[string]string)
+			}
+			edge.Props[args[i+1]] = args[i+2]
+			i += 2
+		default:
+			return edge, fmt.Errorf("未知参数: %s", args[i])
+		}
+	}
+	return edge, nil
+}
+
+// formatGraphEdge 格式化一条边, 有向边用 -> 连接, 无向边用 -- 连接, 属性按名称排序
+// @param e data.GraphEdge
+// @return string
+func formatGraphEdge(e data.GraphEdge) string {
+	arrow := "--"
+	if e.Directed {
+		arrow = "->"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\"%s\" %s \"%s\" weight %s", e.From, arrow, e.To, formatScore(e.Weight))
+	if e.Type != "" {
+		fmt.Fprintf(&b, " type %s", e.Type)
+	}
+	for _, name := range slices.Sorted(maps.Keys(e.Props)) {
+		fmt.Fprintf(&b, " %s=%s", name, e.Props[name])
+	}
+	return b.String()
+}
+
+// parseMaxExplored 解析可选的 maxexplored n 参数
+// @param args []string
+// @return int 未指定时为0
+// @return bool 参数是否合法
+func parseMaxExplored(args []string) (int, bool) {
+	if len(args) == 0 {
+		return 0, true
+	}
+	if len(args) != 2 || strings.ToLower(args[0]) != "maxexplored" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(args[1])
+	return n, err == nil && n > 0
+}
+
+// formatGraphPath 格式化一条路径及其代价
+// @param p data.GraphPath
+// @return string
+func formatGraphPath(p data.GraphPath) string {
+	nodes := make([]string, len(p.Nodes))
+	for i, node := range p.Nodes {
+		nodes[i] = "\"" + node + "\""
+	}
+	return strings.Join(nodes, " -> ") + " (cost " + formatScore(p.Cost) + ")"
+}
+
+// parsePageRankArgs 解析 graph.pagerank 的可选参数
+// @param args []string key 之后的参数
+// @return data.PageRankOptions
+// @return int 最多输出的节点数, 0 表示全部
+// @return bool 参数是否合法
+func parsePageRankArgs(args []string) (data.PageRankOptions, int, bool) {
+	opts := data.PageRankOptions{}
+	limit := 0
+	if len(args)%2 != 0 {
+		return opts, 0, false
+	}
+	for i := 0; i < len(args); i += 2 {
+		var err error
+		switch strings.ToLower(args[i]) {
+		case "damping":
+			opts.Damping, err = strconv.ParseFloat(args[i+1], 64)
+		case "tolerance":
+			opts.Tolerance, err = strconv.ParseFloat(args[i+1], 64)
+		case "maxiter":
+			opts.MaxIterations, err = strconv.Atoi(args[i+1])
+		case "limit":
+			limit, err = strconv.Atoi(args[i+1])
+		default:
+			return opts, 0, false
+		}
+		if err != nil {
+			return opts, 0, false
+		}
+	}
+	return opts, limit, true
+}
+
+// parseFloats 解析若干浮点数, 不接受 NaN
+// @param args []string
+// @return []float64
+// @return bool 是否全部合法
+func parseFloats(args []string) ([]float64, bool) {
+	result := make([]float64, len(args))
+	for i, arg := range args {
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil || math.IsNaN(v) {
+			return nil, false
+		}
+		result[i] = v
+	}
+	return result, true
+}
+
+// printGraphNeighbors 打印空间查询结果
+// @param result []data.GraphNeighbor
+func printGraphNeighbors(result []data.GraphNeighbor) {
+	if len(result) == 0 {
+		fmt.Println("(empty list or set)")
+		return
+	}
+	for i, nb := range result {
+		fmt.Printf("%d) \"%s\" (%s, %s) distance %s\n", i+1, nb.Node, formatScore(nb.X), formatScore(nb.Y), formatScore(nb.Distance))
+	}
+}
+
+// parseTraverseArgs 解析 graph.dfs / graph.bfs 的可选参数
+// @param args []string 起点之后的参数
+// @return data.TraverseOptions
+// @return bool 是否输出深度
+// @return bool 是否输出父节点
+// @return error
+func parseTraverseArgs(args []string) (data.TraverseOptions, bool, bool, error) {
+	opts := data.TraverseOptions{}
+	withDepth, withParent := false, false
+	for i := 0; i < len(args); i++ {
+		switch sub := strings.ToLower(args[i]); sub {
+		case "maxdepth", "maxnodes":
+			if i+1 >= len(args) {
+				return opts, false, false, fmt.Errorf("%s 需要指定数量", sub)
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				return opts, false, false, fmt.Errorf("%s 必须为正整数", sub)
+			}
+			if sub == "maxdepth" {
+				opts.MaxDepth = n
+			} else {
+				opts.MaxNodes = n
+			}
+			i++
+		case "where":
+			if i+2 >= len(args) {
+				return opts, false, false, fmt.Errorf("where 需要指定属性名与属性值")
+			}
+			if opts.EdgeFilter == nil {
+				opts.EdgeFilter = make(map[string]string)
+			}
+			opts.EdgeFilter[args[i+1]] = args[i+2]
+			i += 2
+		case "withdepth":
+			withDepth = true
+		case "withparent":
+			withParent = true
+		default:
+			return opts, false, false, fmt.Errorf("未知参数: %s", args[i])
+		}
+	}
+	return opts, withDepth, withParent, nil
+}
+
+// printGraphVisits 打印遍历结果, 不要求深度与父节点时只输出遍历顺序
+// @param visits []data.GraphVisit
+// @param withDepth bool
+// @param withParent bool
+func printGraphVisits(visits []data.GraphVisit, withDepth, withParent bool) {
+	if !withDepth && !withParent {
+		order := make([]string, len(visits))
+		for i, v := range visits {
+			order[i] = v.Node
+		}
+		printList(order)
+		return
+	}
+	if len(visits) == 0 {
+		fmt.Println("(empty list or set)")
+		return
+	}
+	for i, v := range visits {
+		line := fmt.Sprintf("%d) \"%s\"", i+1, v.Node)
+		if withDepth {
+			line += fmt.Sprintf(" depth %d", v.Depth)
+		}
+		if withParent {
+			if i == 0 {
+				line += " parent (nil)"
+			} else {
+				line += fmt.Sprintf(" parent \"%s\"", v.Parent)
+			}
+		}
+		fmt.Println(line)
+	}
+}
+
+// printGraphNode 打印节点的坐标、标签与属性, 属性按名称排序
+// @param node data.GraphNode
+func printGraphNode(node data.GraphNode) {
+	if node.HasPos {
+		fmt.Printf("pos: %s %s\n", formatScore(node.Pos[0]), formatScore(node.Pos[1]))
+	}
+	if len(node.Labels) > 0 {
+		fmt.Println("labels:", strings.Join(node.Labels, " "))
+	}
+	for _, name := range slices.Sorted(maps.Keys(node.Props)) {
+		fmt.Printf("%s=%s\n", name, node.Props[name])
+	}
+	if !node.HasPos && len(node.Labels) == 0 && len(node.Props) == 0 {
+		fmt.Println("(empty list or set)")
+	}
+}
+
+// printGraphQueryResult 打印查询结果, 第一行为列名, 之后每行一条结果, 各列以 | 分隔
+// @param result data.GraphQueryResult
+func printGraphQueryResult(result data.GraphQueryResult) {
+	if len(result.Rows) == 0 {
+		fmt.Println("(empty list or set)")
+		return
+	}
+	fmt.Println(strings.Join(result.Columns, " | "))
+	for i, row := range result.Rows {
+		fmt.Printf("%d) %s\n", i+1, strings.Join(row, " | "))
+	}
+}
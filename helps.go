@@ -15,12 +15,12 @@ type Command struct {
 var Commands = []Command{
 	{
 		Name:        "set",
-		Description: "设置键值对",
+		Description: "设置键值对, 值为 Redis HyperLogLog 格式(HYLL)时导入为 HyperLogLog",
 		Usage:       "set \"key\" \"value\"",
 	},
 	{
 		Name:        "get",
-		Description: "获取键对应的值",
+		Description: "获取键对应的值, HyperLogLog 以 Redis 格式(HYLL)导出",
 		Usage:       "get \"key\"",
 	},
 	{
@@ -40,8 +40,18 @@ var Commands = []Command{
 	},
 	{
 		Name:        "keys",
-		Description: "获取所有键",
-		Usage:       "keys",
+		Description: "获取所有数据类型中匹配模式的键(默认匹配全部)",
+		Usage:       "keys [\"pattern\"]",
+	},
+	{
+		Name:        "scan",
+		Description: "增量遍历所有数据类型的键",
+		Usage:       "scan cursor [match \"pattern\"] [count n]",
+	},
+	{
+		Name:        "delmatch",
+		Description: "删除所有数据类型中匹配模式的键, 返回删除数量",
+		Usage:       "delmatch \"pattern\"",
 	},
 	{
 		Name:        "kvs",
@@ -58,6 +68,581 @@ var Commands = []Command{
 		Description: "获取键的剩余生存时间（毫秒）",
 		Usage:       "getlasttime \"key\"",
 	},
+	{
+		Name:        "sadd",
+		Description: "向集合添加成员, 返回新增数量",
+		Usage:       "sadd \"key\" \"member\" [\"member\" ...]",
+	},
+	{
+		Name:        "srem",
+		Description: "从集合移除成员, 返回移除数量",
+		Usage:       "srem \"key\" \"member\" [\"member\" ...]",
+	},
+	{
+		Name:        "sismember",
+		Description: "判断成员是否在集合中",
+		Usage:       "sismember \"key\" \"member\"",
+	},
+	{
+		Name:        "smismember",
+		Description: "批量判断成员是否在集合中",
+		Usage:       "smismember \"key\" \"member\" [\"member\" ...]",
+	},
+	{
+		Name:        "smembers",
+		Description: "获取集合所有成员",
+		Usage:       "smembers \"key\"",
+	},
+	{
+		Name:        "scard",
+		Description: "获取集合成员数量",
+		Usage:       "scard \"key\"",
+	},
+	{
+		Name:        "spop",
+		Description: "随机弹出集合成员",
+		Usage:       "spop \"key\" [count]",
+	},
+	{
+		Name:        "srandmember",
+		Description: "随机获取集合成员, count为负数时允许重复",
+		Usage:       "srandmember \"key\" [count]",
+	},
+	{
+		Name:        "smove",
+		Description: "将成员从源集合原子地移动到目标集合",
+		Usage:       "smove \"source\" \"destination\" \"member\"",
+	},
+	{
+		Name:        "sinter",
+		Description: "计算多个集合的交集",
+		Usage:       "sinter \"key\" [\"key\" ...]",
+	},
+	{
+		Name:        "sunion",
+		Description: "计算多个集合的并集",
+		Usage:       "sunion \"key\" [\"key\" ...]",
+	},
+	{
+		Name:        "sdiff",
+		Description: "计算第一个集合与其余集合的差集",
+		Usage:       "sdiff \"key\" [\"key\" ...]",
+	},
+	{
+		Name:        "sintercard",
+		Description: "计算交集的成员数量, 可用limit提前结束",
+		Usage:       "sintercard numkeys \"key\" [\"key\" ...] [limit n]",
+	},
+	{
+		Name:        "sinterstore",
+		Description: "计算交集并写入目标集合",
+		Usage:       "sinterstore \"destination\" \"key\" [\"key\" ...]",
+	},
+	{
+		Name:        "sunionstore",
+		Description: "计算并集并写入目标集合",
+		Usage:       "sunionstore \"destination\" \"key\" [\"key\" ...]",
+	},
+	{
+		Name:        "sdiffstore",
+		Description: "计算差集并写入目标集合",
+		Usage:       "sdiffstore \"destination\" \"key\" [\"key\" ...]",
+	},
+	{
+		Name:        "object",
+		Description: "查询集合/映射/有序集合/位图/HyperLogLog当前使用的内部编码",
+		Usage:       "object encoding \"key\"",
+	},
+	{
+		Name:        "zadd",
+		Description: "向有序集合添加成员或更新分数, 支持 nx/xx/gt/lt 条件、ch 统计修改数量与 incr 增量模式",
+		Usage:       "zadd \"key\" [nx|xx] [gt|lt] [ch] [incr] score \"member\" [score \"member\" ...]",
+	},
+	{
+		Name:        "zrem",
+		Description: "从有序集合移除成员, 返回移除数量",
+		Usage:       "zrem \"key\" \"member\" [\"member\" ...]",
+	},
+	{
+		Name:        "zscore",
+		Description: "获取成员分数",
+		Usage:       "zscore \"key\" \"member\"",
+	},
+	{
+		Name:        "zrank",
+		Description: "获取成员升序排名(从0开始)",
+		Usage:       "zrank \"key\" \"member\"",
+	},
+	{
+		Name:        "zrevrank",
+		Description: "获取成员降序排名(从0开始)",
+		Usage:       "zrevrank \"key\" \"member\"",
+	},
+	{
+		Name:        "zcard",
+		Description: "获取有序集合成员数量",
+		Usage:       "zcard \"key\"",
+	},
+	{
+		Name:        "zrange",
+		Description: "按排名、分数或字典序区间获取成员, 支持倒序、分页与返回分数",
+		Usage:       "zrange \"key\" start stop [byscore|bylex] [rev] [limit offset count] [withscores]",
+	},
+	{
+		Name:        "zrangestore",
+		Description: "将区间查询结果写入目标有序集合",
+		Usage:       "zrangestore \"dst\" \"src\" start stop [byscore|bylex] [rev] [limit offset count]",
+	},
+	{
+		Name:        "zrangebyscore",
+		Description: "按分数区间获取成员(升序), (1.5 表示开区间, 支持 -inf/+inf",
+		Usage:       "zrangebyscore \"key\" min max [withscores] [limit offset count]",
+	},
+	{
+		Name:        "zcount",
+		Description: "统计分数区间内的成员数量",
+		Usage:       "zcount \"key\" min max",
+	},
+	{
+		Name:        "zlexcount",
+		Description: "统计字典序区间内的成员数量, 区间形如 [a (a - +",
+		Usage:       "zlexcount \"key\" min max",
+	},
+	{
+		Name:        "zremrangebyscore",
+		Description: "删除分数区间内的成员, 返回删除数量",
+		Usage:       "zremrangebyscore \"key\" min max",
+	},
+	{
+		Name:        "zremrangebyrank",
+		Description: "删除排名区间内的成员, 返回删除数量",
+		Usage:       "zremrangebyrank \"key\" start stop",
+	},
+	{
+		Name:        "zremrangebylex",
+		Description: "删除字典序区间内的成员, 返回删除数量",
+		Usage:       "zremrangebylex \"key\" min max",
+	},
+	{
+		Name:        "zincrby",
+		Description: "为成员分数加上增量, 成员不存在时以增量作为分数添加",
+		Usage:       "zincrby \"key\" increment \"member\"",
+	},
+	{
+		Name:        "zmscore",
+		Description: "批量获取成员分数",
+		Usage:       "zmscore \"key\" \"member\" [\"member\" ...]",
+	},
+	{
+		Name:        "zrandmember",
+		Description: "随机获取成员但不移除, count为负数时允许重复",
+		Usage:       "zrandmember \"key\" [count [withscores]]",
+	},
+	{
+		Name:        "zpopmin",
+		Description: "弹出分数最低的若干成员",
+		Usage:       "zpopmin \"key\" [count]",
+	},
+	{
+		Name:        "zpopmax",
+		Description: "弹出分数最高的若干成员",
+		Usage:       "zpopmax \"key\" [count]",
+	},
+	{
+		Name:        "zunion",
+		Description: "计算多个有序集合的带权重并集, 普通集合成员分数视为1",
+		Usage:       "zunion numkeys \"key\" [\"key\" ...] [weights weight [weight ...]] [aggregate sum|min|max] [withscores]",
+	},
+	{
+		Name:        "zinter",
+		Description: "计算多个有序集合的带权重交集, 普通集合成员分数视为1",
+		Usage:       "zinter numkeys \"key\" [\"key\" ...] [weights weight [weight ...]] [aggregate sum|min|max] [withscores]",
+	},
+	{
+		Name:        "zdiff",
+		Description: "计算第一个有序集合与后续集合的差集",
+		Usage:       "zdiff numkeys \"key\" [\"key\" ...] [withscores]",
+	},
+	{
+		Name:        "zunionstore",
+		Description: "计算带权重并集并写入目标有序集合",
+		Usage:       "zunionstore \"destination\" numkeys \"key\" [\"key\" ...] [weights weight [weight ...]] [aggregate sum|min|max]",
+	},
+	{
+		Name:        "zinterstore",
+		Description: "计算带权重交集并写入目标有序集合",
+		Usage:       "zinterstore \"destination\" numkeys \"key\" [\"key\" ...] [weights weight [weight ...]] [aggregate sum|min|max]",
+	},
+	{
+		Name:        "zdiffstore",
+		Description: "计算差集并写入目标有序集合",
+		Usage:       "zdiffstore \"destination\" numkeys \"key\" [\"key\" ...]",
+	},
+	{
+		Name:        "zintercard",
+		Description: "计算多个有序集合交集的成员数量",
+		Usage:       "zintercard numkeys \"key\" [\"key\" ...] [limit n]",
+	},
+	{
+		Name:        "bzpopmin",
+		Description: "阻塞弹出第一个非空有序集合中分数最低的成员, timeout为大于0的秒数",
+		Usage:       "bzpopmin \"key\" [\"key\" ...] timeout",
+	},
+	{
+		Name:        "bzpopmax",
+		Description: "阻塞弹出第一个非空有序集合中分数最高的成员, timeout为大于0的秒数",
+		Usage:       "bzpopmax \"key\" [\"key\" ...] timeout",
+	},
+	{
+		Name:        "bzmpop",
+		Description: "阻塞从第一个非空有序集合中弹出若干个分数最低或最高的成员, timeout为大于0的秒数",
+		Usage:       "bzmpop timeout numkeys \"key\" [\"key\" ...] min|max [count n]",
+	},
+	{
+		Name:        "setbit",
+		Description: "设置位图中某一位的值",
+		Usage:       "setbit \"key\" offset 0|1",
+	},
+	{
+		Name:        "getbit",
+		Description: "获取位图中某一位的值",
+		Usage:       "getbit \"key\" offset",
+	},
+	{
+		Name:        "bitcount",
+		Description: "统计位图区间内为1的位数, 区间默认以字节为单位",
+		Usage:       "bitcount \"key\" [start end [byte|bit]]",
+	},
+	{
+		Name:        "bitpos",
+		Description: "查找位图区间内第一个0或1的位置",
+		Usage:       "bitpos \"key\" 0|1 [start [end [byte|bit]]]",
+	},
+	{
+		Name:        "bitop",
+		Description: "对若干位图执行按位与/或/异或/取反并写入目标key",
+		Usage:       "bitop and|or|xor|not \"destkey\" \"key\" [\"key\" ...]",
+	},
+	{
+		Name:        "bitfield",
+		Description: "在位图中原子地读写任意位宽(u1-u63/i1-i64)的整数, offset 以 # 开头表示按类型宽度计算",
+		Usage:       "bitfield \"key\" [get type offset] [set type offset value] [incrby type offset increment] [overflow wrap|sat|fail] ...",
+	},
+	{
+		Name:        "bitfield_ro",
+		Description: "只读地读取位图中的整数",
+		Usage:       "bitfield_ro \"key\" [get type offset ...]",
+	},
+	{
+		Name:        "pfadd",
+		Description: "向 HyperLogLog 添加若干元素, 有寄存器改变时返回1",
+		Usage:       "pfadd \"key\" [\"element\" ...]",
+	},
+	{
+		Name:        "pfcount",
+		Description: "估算基数, 指定多个key时估算并集的基数且不修改任何key",
+		Usage:       "pfcount \"key\" [\"key\" ...]",
+	},
+	{
+		Name:        "pfmerge",
+		Description: "将若干 HyperLogLog 合并到目标key",
+		Usage:       "pfmerge \"destkey\" [\"sourcekey\" ...]",
+	},
+	{
+		Name:        "graph.addnode",
+		Description: "向图添加带坐标的节点, 图不存在时创建",
+		Usage:       "graph.addnode \"key\" \"node\" x y",
+	},
+	{
+		Name:        "graph.addedge",
+		Description: "向图添加边, 默认为权重1的无向边, 可指定权重、方向、关系类型与属性, 已存在的边被替换",
+		Usage:       "graph.addedge \"key\" \"from\" \"to\" [weight w] [directed] [type T] [prop \"name\" \"value\" ...]",
+	},
+	{
+		Name:        "graph.dist",
+		Description: "计算图中两节点的欧氏距离",
+		Usage:       "graph.dist \"key\" \"node\" \"node\"",
+	},
+	{
+		Name:        "graph.dfs",
+		Description: "从起点开始沿出边深度优先遍历图, 可限制深度与节点数、按边属性过滤, 并输出深度或父节点",
+		Usage:       "graph.dfs \"key\" \"start\" [maxdepth n] [maxnodes n] [where \"prop\" \"value\" ...] [withdepth] [withparent]",
+	},
+	{
+		Name:        "graph.bfs",
+		Description: "从起点开始沿出边广度优先遍历图, 可限制深度与节点数、按边属性过滤, 并输出深度或父节点",
+		Usage:       "graph.bfs \"key\" \"start\" [maxdepth n] [maxnodes n] [where \"prop\" \"value\" ...] [withdepth] [withparent]",
+	},
+	{
+		Name:        "graph.del",
+		Description: "删除整个图",
+		Usage:       "graph.del \"key\"",
+	},
+	{
+		Name:        "graph.settime",
+		Description: "设置图的过期时间(毫秒)",
+		Usage:       "graph.settime \"key\" (milliseconds)",
+	},
+	{
+		Name:        "graph.ttl",
+		Description: "获取图的剩余生存时间(毫秒)",
+		Usage:       "graph.ttl \"key\"",
+	},
+	{
+		Name:        "graph.remedge",
+		Description: "删除边, 无向边的两个方向同时删除",
+		Usage:       "graph.remedge \"key\" \"from\" \"to\"",
+	},
+	{
+		Name:        "graph.remnode",
+		Description: "删除节点及与其相连的全部出边和入边",
+		Usage:       "graph.remnode \"key\" \"node\"",
+	},
+	{
+		Name:        "graph.nodes",
+		Description: "列出图中全部节点",
+		Usage:       "graph.nodes \"key\"",
+	},
+	{
+		Name:        "graph.edges",
+		Description: "列出图中全部边及其权重与属性",
+		Usage:       "graph.edges \"key\"",
+	},
+	{
+		Name:        "graph.degree",
+		Description: "获取节点的入度与出度",
+		Usage:       "graph.degree \"key\" \"node\"",
+	},
+	{
+		Name:        "graph.dijkstra",
+		Description: "使用 Dijkstra 算法求最短路径及其代价, 边权不能为负",
+		Usage:       "graph.dijkstra \"key\" \"from\" \"to\" [maxexplored n]",
+	},
+	{
+		Name:        "graph.astar",
+		Description: "以节点坐标的欧氏距离为启发值, 使用 A* 算法求最短路径及其代价",
+		Usage:       "graph.astar \"key\" \"from\" \"to\" [maxexplored n]",
+	},
+	{
+		Name:        "graph.kshortest",
+		Description: "使用 Yen 算法求前k条无环最短路径",
+		Usage:       "graph.kshortest \"key\" \"from\" \"to\" k [maxexplored n]",
+	},
+	{
+		Name:        "graph.components",
+		Description: "忽略边的方向求连通分量",
+		Usage:       "graph.components \"key\"",
+	},
+	{
+		Name:        "graph.scc",
+		Description: "使用 Tarjan 算法求强连通分量",
+		Usage:       "graph.scc \"key\"",
+	},
+	{
+		Name:        "graph.cycle",
+		Description: "查找图中的一个环, 单条无向边不构成环",
+		Usage:       "graph.cycle \"key\"",
+	},
+	{
+		Name:        "graph.toposort",
+		Description: "求有向无环图的拓扑序",
+		Usage:       "graph.toposort \"key\"",
+	},
+	{
+		Name:        "graph.mst",
+		Description: "使用 Kruskal 算法求最小生成树(森林), 有向边视为无向边",
+		Usage:       "graph.mst \"key\"",
+	},
+	{
+		Name:        "graph.pagerank",
+		Description: "迭代计算 PageRank 并按分值降序输出",
+		Usage:       "graph.pagerank \"key\" [damping d] [tolerance t] [maxiter n] [limit n]",
+	},
+	{
+		Name:        "graph.nearest",
+		Description: "使用空间索引查找距离给定坐标最近的k个节点及其距离",
+		Usage:       "graph.nearest \"key\" x y k",
+	},
+	{
+		Name:        "graph.radius",
+		Description: "使用空间索引查找与给定坐标距离不超过半径的节点",
+		Usage:       "graph.radius \"key\" x y radius",
+	},
+	{
+		Name:        "graph.rect",
+		Description: "使用空间索引查找位于矩形内的节点, 距离为到矩形中心的距离",
+		Usage:       "graph.rect \"key\" minx miny maxx maxy",
+	},
+	{
+		Name:        "graph.addlabel",
+		Description: "为节点添加标签, 节点不存在时创建, 返回新增的标签数",
+		Usage:       "graph.addlabel \"key\" \"node\" \"label\" [\"label\" ...]",
+	},
+	{
+		Name:        "graph.remlabel",
+		Description: "删除节点的标签, 返回删除的标签数",
+		Usage:       "graph.remlabel \"key\" \"node\" \"label\" [\"label\" ...]",
+	},
+	{
+		Name:        "graph.setprop",
+		Description: "设置节点属性, 节点不存在时创建",
+		Usage:       "graph.setprop \"key\" \"node\" \"name\" \"value\" [\"name\" \"value\" ...]",
+	},
+	{
+		Name:        "graph.remprop",
+		Description: "删除节点属性, 返回删除的属性数",
+		Usage:       "graph.remprop \"key\" \"node\" \"name\" [\"name\" ...]",
+	},
+	{
+		Name:        "graph.node",
+		Description: "查看节点的坐标、标签与属性",
+		Usage:       "graph.node \"key\" \"node\"",
+	},
+	{
+		Name:        "graph.query",
+		Description: "执行 Cypher 风格的查询, 如 MATCH (a:Person)-[:KNOWS]->(b) WHERE b.city = 'X' RETURN b, 支持 WHERE、DISTINCT、ORDER BY、SKIP 与 LIMIT",
+		Usage:       "graph.query \"key\" \"MATCH ... [WHERE ...] RETURN ... [ORDER BY ...] [SKIP n] [LIMIT n]\"",
+	},
+	{
+		Name:        "geoadd",
+		Description: "以 52 位 geohash 作为分数向有序集合添加经纬度成员, 返回新增的数量",
+		Usage:       "geoadd \"key\" [nx|xx] [ch] longitude latitude \"member\" [longitude latitude \"member\" ...]",
+	},
+	{
+		Name:        "geopos",
+		Description: "获取成员的经纬度",
+		Usage:       "geopos \"key\" \"member\" [\"member\" ...]",
+	},
+	{
+		Name:        "geodist",
+		Description: "计算两个成员间的球面距离, 默认单位为米",
+		Usage:       "geodist \"key\" \"member\" \"member\" [m|km|ft|mi]",
+	},
+	{
+		Name:        "geohash",
+		Description: "获取成员的 11 位标准 geohash 字符串",
+		Usage:       "geohash \"key\" \"member\" [\"member\" ...]",
+	},
+	{
+		Name:        "geosearch",
+		Description: "查询以成员或经纬度为中心的圆形或矩形范围内的成员",
+		Usage:       "geosearch \"key\" (frommember \"member\" | fromlonlat longitude latitude) (byradius radius m|km|ft|mi | bybox width height m|km|ft|mi) [asc|desc] [count n [any]] [withcoord] [withdist] [withhash]",
+	},
+	{
+		Name:        "geosearchstore",
+		Description: "将 geosearch 的结果写入目标有序集合, storedist 时以距离作为分数",
+		Usage:       "geosearchstore \"destination\" \"source\" (frommember \"member\" | fromlonlat longitude latitude) (byradius radius m|km|ft|mi | bybox width height m|km|ft|mi) [asc|desc] [count n [any]] [storedist]",
+	},
+	{
+		Name:        "xadd",
+		Description: "向流追加条目, 可按长度或最小ID裁剪",
+		Usage:       "xadd \"key\" [nomkstream] [maxlen|minid [=|~] threshold] *|id \"field\" \"value\" [\"field\" \"value\" ...]",
+	},
+	{
+		Name:        "xlen",
+		Description: "获取流中的条目数",
+		Usage:       "xlen \"key\"",
+	},
+	{
+		Name:        "xrange",
+		Description: "按ID区间读取条目, 支持 - + 与 ( 开区间",
+		Usage:       "xrange \"key\" start end [count n]",
+	},
+	{
+		Name:        "xrevrange",
+		Description: "按ID区间倒序读取条目",
+		Usage:       "xrevrange \"key\" end start [count n]",
+	},
+	{
+		Name:        "xtrim",
+		Description: "裁剪流",
+		Usage:       "xtrim \"key\" maxlen|minid [=|~] threshold",
+	},
+	{
+		Name:        "xdel",
+		Description: "删除指定ID的条目",
+		Usage:       "xdel \"key\" id [id ...]",
+	},
+	{
+		Name:        "xread",
+		Description: "从多个流读取新条目, 可阻塞等待, $ 表示只读取之后追加的条目",
+		Usage:       "xread [count n] [block milliseconds] streams \"key\" [\"key\" ...] id [id ...]",
+	},
+	{
+		Name:        "xgroup",
+		Description: "创建/删除消费者组, 创建/删除消费者, 修改组的最后投递ID",
+		Usage:       "xgroup create \"key\" \"group\" id|$ [mkstream] | destroy \"key\" \"group\" | createconsumer \"key\" \"group\" \"consumer\" | delconsumer \"key\" \"group\" \"consumer\" | setid \"key\" \"group\" id|$",
+	},
+	{
+		Name:        "xreadgroup",
+		Description: "以消费者组的身份读取, > 读取新条目, 其他ID读取自己待确认的条目",
+		Usage:       "xreadgroup group \"group\" \"consumer\" [count n] [block milliseconds] [noack] streams \"key\" [\"key\" ...] id [id ...]",
+	},
+	{
+		Name:        "xack",
+		Description: "确认条目, 将其移出待确认列表",
+		Usage:       "xack \"key\" \"group\" id [id ...]",
+	},
+	{
+		Name:        "xpending",
+		Description: "查看消费者组的待确认列表概要或明细",
+		Usage:       "xpending \"key\" \"group\" [[idle min-idle-time] start end count [\"consumer\"]]",
+	},
+	{
+		Name:        "xclaim",
+		Description: "将空闲的待确认条目转移给消费者",
+		Usage:       "xclaim \"key\" \"group\" \"consumer\" min-idle-time id [id ...] [idle ms] [time unix-ms] [retrycount n] [force] [justid]",
+	},
+	{
+		Name:        "xautoclaim",
+		Description: "扫描并认领空闲的待确认条目",
+		Usage:       "xautoclaim \"key\" \"group\" \"consumer\" min-idle-time start [count n] [justid]",
+	},
+	{
+		Name:        "stream.del",
+		Description: "删除整个流",
+		Usage:       "stream.del \"key\"",
+	},
+	{
+		Name:        "stream.settime",
+		Description: "设置流的过期时间(毫秒)",
+		Usage:       "stream.settime \"key\" (milliseconds)",
+	},
+	{
+		Name:        "stream.ttl",
+		Description: "获取流的剩余生存时间(毫秒)",
+		Usage:       "stream.ttl \"key\"",
+	},
+	{
+		Name:        "subscribe",
+		Description: "订阅频道, 收到的消息在下一次输入提示前打印",
+		Usage:       "subscribe \"channel\" [\"channel\" ...]",
+	},
+	{
+		Name:        "unsubscribe",
+		Description: "取消订阅频道, 不指定时取消全部",
+		Usage:       "unsubscribe [\"channel\" ...]",
+	},
+	{
+		Name:        "psubscribe",
+		Description: "按 glob 模式订阅频道",
+		Usage:       "psubscribe \"pattern\" [\"pattern\" ...]",
+	},
+	{
+		Name:        "punsubscribe",
+		Description: "取消模式订阅, 不指定时取消全部",
+		Usage:       "punsubscribe [\"pattern\" ...]",
+	},
+	{
+		Name:        "publish",
+		Description: "向频道发布消息, 返回收到消息的订阅次数",
+		Usage:       "publish \"channel\" \"message\"",
+	},
+	{
+		Name:        "pubsub",
+		Description: "查看活跃频道、频道订阅者数量与模式订阅数量",
+		Usage:       "pubsub channels [\"pattern\"] | numsub [\"channel\" ...] | numpat",
+	},
 	{
 		Name:        "help",
 		Description: "显示帮助信息",
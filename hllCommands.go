@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"gopherkv/data"
+	"strings"
+)
+
+// execHLLCommand 执行 HyperLogLog 相关命令
+// @param fields []string 拆分后的命令
+// @return bool 是否为 HyperLogLog 命令
+func execHLLCommand(fields []string) bool {
+	switch strings.ToLower(fields[0]) {
+	case "pfadd":
+		if len(fields) < 2 {
+			usageError("pfadd \"key\" [\"element\" ...]")
+			return true
+		}
+		fmt.Println(boolToInt(data.DataGkvHyperLoglog.Add(fields[1], fields[2:]...)))
+	case "pfcount":
+		if len(fields) < 2 {
+			usageError("pfcount \"key\" [\"key\" ...]")
+			return true
+		}
+		fmt.Println(data.DataGkvHyperLoglog.Count(fields[1:]...))
+	case "pfmerge":
+		if len(fields) < 2 {
+			usageError("pfmerge \"destkey\" [\"sourcekey\" ...]")
+			return true
+		}
+		data.DataGkvHyperLoglog.Merge(fields[1], fields[2:]...)
+		fmt.Println("OK")
+	default:
+		return false
+	}
+	return true
+}
@@ -14,6 +14,16 @@ type Config struct {
 	Port     int    `json:"port"`
 	DataDir  string `json:"data_dir"`
 	LogLevel string `json:"log_level"`
+	// 紧凑编码阈值
+	Encoding data.EncodingLimits `json:"encoding"`
+	// 新建 HyperLogLog 的精度, 0 表示使用默认值14
+	HLLPrecision uint8 `json:"hll_precision"`
+	// 单次最短路径查询最多展开的节点数, 0 表示使用默认值100000
+	GraphMaxExplored int `json:"graph_max_explored"`
+	// 每个订阅者最多缓冲的消息数, 超过时断开该订阅者, 0 表示使用默认值1024
+	PubSubBufferLimit int `json:"pubsub_buffer_limit"`
+	// 开启的键空间通知类别, g$lshzxetdKE 风格, 为空表示关闭
+	NotifyKeyspaceEvents string `json:"notify_keyspace_events"`
 }
 
 func loadConfig(path string) (*Config, error) {
@@ -37,6 +47,19 @@ func main() {
 		return
 	}
 	fmt.Printf("配置文件加载成功: %+v\n", cfg)
+	data.SetEncodingLimits(cfg.Encoding)
+	if cfg.HLLPrecision != 0 {
+		if err := data.DataGkvHyperLoglog.SetPrecision(cfg.HLLPrecision); err != nil {
+			fmt.Printf("配置 hll_precision 无效: %v\n", err)
+		}
+	}
+	data.DataGkvGraph.SetMaxExplored(cfg.GraphMaxExplored)
+	data.DataPubSub.SetBufferLimit(cfg.PubSubBufferLimit)
+	if flags, err := data.ParseNotifyFlags(cfg.NotifyKeyspaceEvents); err != nil {
+		fmt.Printf("配置 notify_keyspace_events 无效: %v\n", err)
+	} else {
+		data.SetNotifyFlags(flags)
+	}
 	inputHandler := NewInputHandler()
 	fmt.Println("-------------------------------------------------------")
 	fmt.Println("   _____             _                 _  ____      __")
@@ -52,6 +75,8 @@ func main() {
 	fmt.Println("-------------------------------------------------------")
 
 	for {
+		data.ActiveExpireCycle()
+		printPubSubMessages()
 		line, err := inputHandler.ReadLine("gkv> ")
 		if err != nil {
 			if err.Error() == "用户中断" {
@@ -77,7 +102,7 @@ func main() {
 				fmt.Println("用法: set \"key\" \"value\"")
 				continue
 			}
-			data.DataGkvString.Set(fields[1], []byte(fields[2]))
+			data.SetString(fields[1], []byte(fields[2]))
 			fmt.Println("OK")
 		case "get":
 			if len(fields) != 2 {
@@ -85,7 +110,7 @@ func main() {
 				fmt.Println("用法: get \"key\"")
 				continue
 			}
-			v, ok := data.DataGkvString.Get(fields[1])
+			v, ok := data.GetString(fields[1])
 			if ok {
 				fmt.Println(string(v))
 			} else {
@@ -122,23 +147,65 @@ func main() {
 			data.DataGkvString.Delete(fields[1])
 			fmt.Println("OK")
 		case "keys":
-			if len(fields) != 1 {
+			if len(fields) > 2 {
 				fmt.Println("参数错误!")
-				fmt.Println("用法: keys")
+				fmt.Println("用法: keys [\"pattern\"]")
 				continue
 			}
-			keys := data.DataGkvString.GetAllKeys()
-			if len(keys) == 0 {
-				fmt.Println("(empty list or set)")
-			} else {
-				for i, key := range keys {
-					if i > 0 {
-						fmt.Print(" ")
-					}
-					fmt.Printf("\"%s\"", key)
+			pattern := "*"
+			if len(fields) == 2 {
+				pattern = fields[1]
+			}
+			printList(data.Keys(pattern))
+		case "scan":
+			if len(fields) < 2 || len(fields)%2 != 0 {
+				fmt.Println("参数错误!")
+				fmt.Println("用法: scan cursor [match \"pattern\"] [count n]")
+				continue
+			}
+			pattern, count, ok := "", 10, true
+			var err error
+			for i := 2; i < len(fields); i += 2 {
+				switch strings.ToLower(fields[i]) {
+				case "match":
+					pattern = fields[i+1]
+				case "count":
+					count, err = strconv.Atoi(fields[i+1])
+					ok = ok && err == nil && count > 0
+				default:
+					ok = false
 				}
-				fmt.Println()
 			}
+			if !ok {
+				fmt.Println("参数错误!")
+				fmt.Println("用法: scan cursor [match \"pattern\"] [count n]")
+				continue
+			}
+			next, keys, err := data.Scan(fields[1], pattern, count)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(next)
+			printList(keys)
+		case "object":
+			if len(fields) != 3 || strings.ToLower(fields[1]) != "encoding" {
+				fmt.Println("参数错误!")
+				fmt.Println("用法: object encoding \"key\"")
+				continue
+			}
+			if enc, ok := data.ObjectEncoding(fields[2]); ok {
+				fmt.Printf("\"%s\"\n", enc)
+			} else {
+				fmt.Println("(nil)")
+			}
+		case "delmatch":
+			if len(fields) != 2 {
+				fmt.Println("参数错误!")
+				fmt.Println("用法: delmatch \"pattern\"")
+				continue
+			}
+			fmt.Println(data.DeleteMatch(fields[1]))
 		case "kvs":
 			if len(fields) != 1 {
 				fmt.Println("参数错误!")
@@ -182,6 +249,9 @@ func main() {
 			fmt.Println("再见! :D")
 			return
 		default:
+			if dispatchCommand(fields) {
+				continue
+			}
 			fmt.Println("未知命令: ", fields[0])
 			showSimilarCommands(fields[0])
 		}
@@ -50,6 +50,46 @@ func LoadGkvStringFromFile(filename string) error {
 	return nil
 }
 
+// SaveGkvGraphToFile 将DataGkvGraph的数据持久化到文件
+func SaveGkvGraphToFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return data.DataGkvGraph.SaveSnapshot(file)
+}
+
+// LoadGkvGraphFromFile 从文件加载数据到DataGkvGraph
+func LoadGkvGraphFromFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return data.DataGkvGraph.LoadSnapshot(file)
+}
+
+// SaveGkvStreamToFile 将DataGkvStream的数据持久化到文件
+func SaveGkvStreamToFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return data.DataGkvStream.SaveSnapshot(file)
+}
+
+// LoadGkvStreamFromFile 从文件加载数据到DataGkvStream
+func LoadGkvStreamFromFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return data.DataGkvStream.LoadSnapshot(file)
+}
+
 // DataCopy 返回data的深拷贝
 func (g *data.GkvString) DataCopy() map[string][]byte {
 	result := make(map[string][]byte, len(g.data))
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"gopherkv/data"
+	"strings"
+)
+
+// replSubscriber 命令行会话的订阅者, 第一次订阅时创建
+// 命令行是单线程的, 收到的消息在每次输入提示前由 printPubSubMessages 打印
+var replSubscriber *data.Subscriber
+
+// execPubSubCommand 执行发布/订阅相关命令
+// @param fields []string 拆分后的命令
+// @return bool 是否为发布/订阅命令
+func execPubSubCommand(fields []string) bool {
+	switch name := strings.ToLower(fields[0]); name {
+	case "subscribe", "psubscribe":
+		if len(fields) < 2 {
+			usageError(name + " \"channel\" [\"channel\" ...]")
+			return true
+		}
+		if replSubscriber == nil {
+			replSubscriber = data.DataPubSub.NewSubscriber()
+		}
+		subscribe := replSubscriber.Subscribe
+		if name == "psubscribe" {
+			subscribe = replSubscriber.PSubscribe
+		}
+		counts, err := subscribe(fields[1:]...)
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		for i, count := range counts {
+			fmt.Printf("%d) %s %s %d\n", i+1, name, fields[i+1], count)
+		}
+	case "unsubscribe", "punsubscribe":
+		if replSubscriber == nil {
+			fmt.Printf("1) %s (nil) 0\n", name)
+			return true
+		}
+		unsubscribe := replSubscriber.Unsubscribe
+		if name == "punsubscribe" {
+			unsubscribe = replSubscriber.PUnsubscribe
+		}
+		names, counts := unsubscribe(fields[1:]...)
+		if len(names) == 0 {
+			fmt.Printf("1) %s (nil) 0\n", name)
+			return true
+		}
+		for i, n := range names {
+			fmt.Printf("%d) %s %s %d\n", i+1, name, n, counts[i])
+		}
+	case "publish":
+		if len(fields) != 3 {
+			usageError("publish \"channel\" \"message\"")
+			return true
+		}
+		fmt.Println(data.DataPubSub.Publish(fields[1], fields[2]))
+	case "pubsub":
+		execPubSub(fields)
+	default:
+		return false
+	}
+	return true
+}
+
+// execPubSub 执行 pubsub 的各个子命令
+// @param fields []string 拆分后的命令
+func execPubSub(fields []string) {
+	const usage = "pubsub channels [\"pattern\"] | numsub [\"channel\" ...] | numpat"
+	if len(fields) < 2 {
+		usageError(usage)
+		return
+	}
+	switch sub := strings.ToLower(fields[1]); {
+	case sub == "channels" && len(fields) <= 3:
+		pattern := ""
+		if len(fields) == 3 {
+			pattern = fields[2]
+		}
+		printList(data.DataPubSub.ActiveChannels(pattern))
+	case sub == "numsub":
+		counts := data.DataPubSub.NumSub(fields[2:]...)
+		if len(counts) == 0 {
+			fmt.Println("(empty list or set)")
+			return
+		}
+		for i, count := range counts {
+			fmt.Printf("%d) %s %d\n", i+1, fields[i+2], count)
+		}
+	case sub == "numpat" && len(fields) == 2:
+		fmt.Println(data.DataPubSub.NumPat())
+	default:
+		usageError(usage)
+	}
+}
+
+// printPubSubMessages 打印会话订阅者已收到的消息, 订阅者因输出缓冲区已满被断开时打印原因
+func printPubSubMessages() {
+	if replSubscriber == nil {
+		return
+	}
+	for {
+		select {
+		case msg, ok := <-replSubscriber.Messages():
+			if !ok {
+				fmt.Println(replSubscriber.Err())
+				replSubscriber = nil
+				return
+			}
+			if msg.Pattern != "" {
+				fmt.Printf("pmessage %s %s %s\n", msg.Pattern, msg.Channel, msg.Payload)
+			} else {
+				fmt.Printf("message %s %s\n", msg.Channel, msg.Payload)
+			}
+		default:
+			return
+		}
+	}
+}
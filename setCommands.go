@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"gopherkv/data"
+	"strconv"
+	"strings"
+)
+
+// execSetCommand 执行集合相关命令
+// @param fields []string 拆分后的命令
+// @return bool 是否为集合命令
+func execSetCommand(fields []string) bool {
+	switch strings.ToLower(fields[0]) {
+	case "sadd":
+		if len(fields) < 3 {
+			usageError("sadd \"key\" \"member\" [\"member\" ...]")
+			return true
+		}
+		fmt.Println(data.DataGkvSet.Add(fields[1], fields[2:]...))
+	case "srem":
+		if len(fields) < 3 {
+			usageError("srem \"key\" \"member\" [\"member\" ...]")
+			return true
+		}
+		fmt.Println(data.DataGkvSet.Remove(fields[1], fields[2:]...))
+	case "sismember":
+		if len(fields) != 3 {
+			usageError("sismember \"key\" \"member\"")
+			return true
+		}
+		fmt.Println(boolToInt(data.DataGkvSet.IsMember(fields[1], fields[2])))
+	case "smismember":
+		if len(fields) < 3 {
+			usageError("smismember \"key\" \"member\" [\"member\" ...]")
+			return true
+		}
+		for _, ok := range data.DataGkvSet.MIsMember(fields[1], fields[2:]...) {
+			fmt.Println(boolToInt(ok))
+		}
+	case "smembers":
+		if len(fields) != 2 {
+			usageError("smembers \"key\"")
+			return true
+		}
+		printList(data.DataGkvSet.GetAllMembers(fields[1]))
+	case "scard":
+		if len(fields) != 2 {
+			usageError("scard \"key\"")
+			return true
+		}
+		fmt.Println(data.DataGkvSet.Cardinality(fields[1]))
+	case "spop", "srandmember":
+		name := strings.ToLower(fields[0])
+		if len(fields) != 2 && len(fields) != 3 {
+			usageError(name + " \"key\" [count]")
+			return true
+		}
+		if len(fields) == 2 {
+			var members []string
+			if name == "spop" {
+				members = data.DataGkvSet.Pop(fields[1], 1)
+			} else {
+				members, _ = data.DataGkvSet.RandMember(fields[1], 1)
+			}
+			if len(members) == 0 {
+				fmt.Println("(nil)")
+			} else {
+				fmt.Printf("\"%s\"\n", members[0])
+			}
+			return true
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil || (name == "spop" && count < 0) {
+			usageError(name + " \"key\" [count]")
+			return true
+		}
+		if name == "spop" {
+			printList(data.DataGkvSet.Pop(fields[1], count))
+		} else {
+			members, err := data.DataGkvSet.RandMember(fields[1], count)
+			if err != nil {
+				fmt.Println(err)
+				return true
+			}
+			printList(members)
+		}
+	case "smove":
+		if len(fields) != 4 {
+			usageError("smove \"source\" \"destination\" \"member\"")
+			return true
+		}
+		fmt.Println(boolToInt(data.DataGkvSet.Move(fields[1], fields[2], fields[3])))
+	case "sinter", "sunion", "sdiff":
+		name := strings.ToLower(fields[0])
+		if len(fields) < 2 {
+			usageError(name + " \"key\" [\"key\" ...]")
+			return true
+		}
+		switch name {
+		case "sinter":
+			printList(data.DataGkvSet.Inter(fields[1:]...))
+		case "sunion":
+			printList(data.DataGkvSet.Union(fields[1:]...))
+		default:
+			printList(data.DataGkvSet.Diff(fields[1:]...))
+		}
+	case "sintercard":
+		keys, limit, ok := parseInterCardArgs(fields)
+		if !ok {
+			usageError("sintercard numkeys \"key\" [\"key\" ...] [limit n]")
+			return true
+		}
+		fmt.Println(data.DataGkvSet.InterCard(limit, keys...))
+	case "sinterstore", "sunionstore", "sdiffstore":
+		name := strings.ToLower(fields[0])
+		if len(fields) < 3 {
+			usageError(name + " \"destination\" \"key\" [\"key\" ...]")
+			return true
+		}
+		switch name {
+		case "sinterstore":
+			fmt.Println(data.DataGkvSet.InterStore(fields[1], fields[2:]...))
+		case "sunionstore":
+			fmt.Println(data.DataGkvSet.UnionStore(fields[1], fields[2:]...))
+		default:
+			fmt.Println(data.DataGkvSet.DiffStore(fields[1], fields[2:]...))
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// parseInterCardArgs 解析 numkeys key [key ...] [limit n] 形式的参数
+// @param fields []string 拆分后的命令
+// @return []string 键
+// @return int 计数上限, 0表示不限制
+// @return bool 参数是否合法
+func parseInterCardArgs(fields []string) ([]string, int, bool) {
+	if len(fields) < 3 {
+		return nil, 0, false
+	}
+	numKeys, err := strconv.Atoi(fields[1])
+	if err != nil || numKeys <= 0 || len(fields) < 2+numKeys {
+		return nil, 0, false
+	}
+	keys := fields[2 : 2+numKeys]
+	rest := fields[2+numKeys:]
+	limit := 0
+	switch {
+	case len(rest) == 0:
+	case len(rest) == 2 && strings.ToLower(rest[0]) == "limit":
+		limit, err = strconv.Atoi(rest[1])
+		if err != nil || limit < 0 {
+			return nil, 0, false
+		}
+	default:
+		return nil, 0, false
+	}
+	return keys, limit, true
+}
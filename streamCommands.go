@@ -0,0 +1,667 @@
+package main
+
+import (
+	"fmt"
+	"gopherkv/data"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const xAddUsage = "xadd \"key\" [nomkstream] [maxlen|minid [=|~] threshold] *|id \"field\" \"value\" [\"field\" \"value\" ...]"
+
+const xReadUsage = "xread [count n] [block milliseconds] streams \"key\" [\"key\" ...] id [id ...]"
+
+const xReadGroupUsage = "xreadgroup group \"group\" \"consumer\" [count n] [block milliseconds] [noack] streams \"key\" [\"key\" ...] id [id ...]"
+
+const xClaimUsage = "xclaim \"key\" \"group\" \"consumer\" min-idle-time id [id ...] [idle ms] [time unix-ms] [retrycount n] [force] [justid]"
+
+const xAutoClaimUsage = "xautoclaim \"key\" \"group\" \"consumer\" min-idle-time start [count n] [justid]"
+
+const xPendingUsage = "xpending \"key\" \"group\" [[idle min-idle-time] start end count [\"consumer\"]]"
+
+// execStreamCommand 执行流相关命令
+// @param fields []string 拆分后的命令
+// @return bool 是否为流命令
+func execStreamCommand(fields []string) bool {
+	switch strings.ToLower(fields[0]) {
+	case "xadd":
+		if len(fields) < 5 {
+			usageError(xAddUsage)
+			return true
+		}
+		execXAdd(fields[1], fields[2:])
+	case "xlen":
+		if len(fields) != 2 {
+			usageError("xlen \"key\"")
+			return true
+		}
+		fmt.Println(data.DataGkvStream.Len(fields[1]))
+	case "xrange", "xrevrange":
+		name := strings.ToLower(fields[0])
+		usage := name + " \"key\" start end [count n]"
+		if name == "xrevrange" {
+			usage = name + " \"key\" end start [count n]"
+		}
+		if len(fields) != 4 && len(fields) != 6 {
+			usageError(usage)
+			return true
+		}
+		lo, hi := fields[2], fields[3]
+		if name == "xrevrange" {
+			lo, hi = hi, lo
+		}
+		start, okStart, err := data.ParseStreamRangeID(lo, false)
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		end, okEnd, err := data.ParseStreamRangeID(hi, true)
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		count := 0
+		if len(fields) == 6 {
+			if strings.ToLower(fields[4]) != "count" {
+				usageError(usage)
+				return true
+			}
+			if count, err = strconv.Atoi(fields[5]); err != nil || count < 0 {
+				usageError(usage)
+				return true
+			}
+			if count == 0 {
+				fmt.Println("(empty list or set)")
+				return true
+			}
+		}
+		if !okStart || !okEnd {
+			fmt.Println("(empty list or set)")
+			return true
+		}
+		printStreamEntries(data.DataGkvStream.Range(fields[1], start, end, count, name == "xrevrange"), "")
+	case "xtrim":
+		const usage = "xtrim \"key\" maxlen|minid [=|~] threshold"
+		if len(fields) < 4 {
+			usageError(usage)
+			return true
+		}
+		trim, n, err := parseStreamTrim(fields[2:])
+		if err != nil || n != len(fields)-2 {
+			usageError(usage)
+			return true
+		}
+		fmt.Println(data.DataGkvStream.Trim(fields[1], trim))
+	case "xdel":
+		if len(fields) < 3 {
+			usageError("xdel \"key\" id [id ...]")
+			return true
+		}
+		ids, err := parseStreamIDs(fields[2:])
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		fmt.Println(data.DataGkvStream.Remove(fields[1], ids...))
+	case "xread":
+		execXRead(fields[1:])
+	case "xgroup":
+		execXGroup(fields)
+	case "xreadgroup":
+		execXReadGroup(fields[1:])
+	case "xack":
+		if len(fields) < 4 {
+			usageError("xack \"key\" \"group\" id [id ...]")
+			return true
+		}
+		ids, err := parseStreamIDs(fields[3:])
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		fmt.Println(data.DataGkvStream.Ack(fields[1], fields[2], ids...))
+	case "xpending":
+		execXPending(fields)
+	case "xclaim":
+		execXClaim(fields)
+	case "xautoclaim":
+		execXAutoClaim(fields)
+	case "stream.del":
+		if len(fields) != 2 {
+			usageError("stream.del \"key\"")
+			return true
+		}
+		fmt.Println(boolToInt(data.DataGkvStream.Delete(fields[1])))
+	case "stream.settime":
+		if len(fields) != 3 {
+			usageError("stream.settime \"key\" (milliseconds)")
+			return true
+		}
+		ms, err := strconv.Atoi(fields[2])
+		if err != nil {
+			usageError("stream.settime \"key\" (milliseconds)")
+			return true
+		}
+		fmt.Println(boolToInt(data.DataGkvStream.SetTime(fields[1], ms)))
+	case "stream.ttl":
+		if len(fields) != 2 {
+			usageError("stream.ttl \"key\"")
+			return true
+		}
+		switch ttl := data.DataGkvStream.GetTTL(fields[1]); ttl {
+		case -1:
+			fmt.Println("(nil)")
+		case -2:
+			fmt.Println("未设置过期时间")
+		default:
+			fmt.Println(ttl)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// execXAdd 解析并执行 xadd 的选项、ID与字段
+// @param key string 流名
+// @param args []string key 之后的参数
+func execXAdd(key string, args []string) {
+	opts := data.StreamAddOptions{}
+	i := 0
+	for i < len(args) {
+		switch strings.ToLower(args[i]) {
+		case "nomkstream":
+			opts.NoMkStream = true
+			i++
+			continue
+		case "maxlen", "minid":
+			trim, n, err := parseStreamTrim(args[i:])
+			if err != nil {
+				fmt.Println(err)
+				usageError(xAddUsage)
+				return
+			}
+			opts.Trim = trim
+			i += n
+			continue
+		}
+		break
+	}
+	if len(args)-i < 3 || (len(args)-i)%2 != 1 {
+		usageError(xAddUsage)
+		return
+	}
+	id, added, err := data.DataGkvStream.Add(key, args[i], args[i+1:], opts)
+	switch {
+	case err != nil:
+		fmt.Println(err)
+	case !added:
+		fmt.Println("(nil)")
+	default:
+		fmt.Println(id)
+	}
+}
+
+// parseStreamTrim 解析 maxlen|minid [=|~] threshold, "~" 按精确裁剪处理
+// @param args []string 以 maxlen 或 minid 开头的参数
+// @return data.StreamTrim
+// @return int 消耗的参数个数
+// @return error
+func parseStreamTrim(args []string) (data.StreamTrim, int, error) {
+	trim := data.StreamTrim{}
+	n := 1
+	if n < len(args) && (args[n] == "=" || args[n] == "~") {
+		n++
+	}
+	if n >= len(args) {
+		return trim, 0, fmt.Errorf("%s 缺少参数", args[0])
+	}
+	threshold := args[n]
+	if strings.ToLower(args[0]) == "maxlen" {
+		maxLen, err := strconv.Atoi(threshold)
+		if err != nil || maxLen < 0 {
+			return trim, 0, fmt.Errorf("maxlen 必须为非负整数")
+		}
+		trim.Strategy, trim.MaxLen = data.StreamTrimMaxLen, maxLen
+	} else {
+		minID, err := data.ParseStreamID(threshold)
+		if err != nil {
+			return trim, 0, err
+		}
+		trim.Strategy, trim.MinID = data.StreamTrimMinID, minID
+	}
+	return trim, n + 1, nil
+}
+
+// parseStreamIDs 解析一组ID
+// @param args []string
+// @return []data.StreamID
+// @return error
+func parseStreamIDs(args []string) ([]data.StreamID, error) {
+	ids := make([]data.StreamID, len(args))
+	for i, arg := range args {
+		id, err := data.ParseStreamID(arg)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// streamReadArgs xread 与 xreadgroup 共同的参数
+type streamReadArgs struct {
+	count   int
+	block   bool
+	timeout time.Duration
+	noAck   bool
+	keys    []string
+	ids     []string
+}
+
+// maxMilliseconds time.Duration 能表示的最大毫秒数
+const maxMilliseconds = math.MaxInt64 / int64(time.Millisecond)
+
+// parseMilliseconds 解析以毫秒为单位的非负时长, 超出 time.Duration 表示范围的值不合法
+// @param s string
+// @return time.Duration
+// @return bool 是否合法
+func parseMilliseconds(s string) (time.Duration, bool) {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || ms < 0 || ms > maxMilliseconds {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// parseStreamReadArgs 解析 [count n] [block ms] [noack] streams key... id...
+// @param args []string
+// @param allowNoAck bool 是否接受 noack(只有 xreadgroup 接受)
+// @return streamReadArgs
+// @return bool 参数是否合法
+func parseStreamReadArgs(args []string, allowNoAck bool) (streamReadArgs, bool) {
+	r := streamReadArgs{}
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "count":
+			if i+1 >= len(args) {
+				return r, false
+			}
+			count, err := strconv.Atoi(args[i+1])
+			if err != nil || count < 0 {
+				return r, false
+			}
+			r.count = count
+			i++
+		case "block":
+			if i+1 >= len(args) {
+				return r, false
+			}
+			timeout, ok := parseMilliseconds(args[i+1])
+			if !ok {
+				return r, false
+			}
+			r.block, r.timeout = true, timeout
+			i++
+		case "noack":
+			if !allowNoAck {
+				return r, false
+			}
+			r.noAck = true
+		case "streams":
+			rest := args[i+1:]
+			if len(rest) == 0 || len(rest)%2 != 0 {
+				return r, false
+			}
+			r.keys, r.ids = rest[:len(rest)/2], rest[len(rest)/2:]
+			return r, true
+		default:
+			return r, false
+		}
+	}
+	return r, false
+}
+
+// execXRead 执行 xread
+// @param args []string 命令名之后的参数
+func execXRead(args []string) {
+	r, ok := parseStreamReadArgs(args, false)
+	if !ok {
+		usageError(xReadUsage)
+		return
+	}
+	var results []data.StreamReadResult
+	var err error
+	if r.block && r.timeout == 0 {
+		fmt.Println(blockForeverMsg)
+		return
+	}
+	if r.block {
+		results, err = data.DataGkvStream.BlockingRead(r.keys, r.ids, r.count, r.timeout)
+	} else {
+		results, err = data.DataGkvStream.Read(r.keys, r.ids, r.count)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	printStreamReadResults(results)
+}
+
+// execXReadGroup 执行 xreadgroup
+// @param args []string 命令名之后的参数
+func execXReadGroup(args []string) {
+	if len(args) < 3 || strings.ToLower(args[0]) != "group" {
+		usageError(xReadGroupUsage)
+		return
+	}
+	group, consumer := args[1], args[2]
+	r, ok := parseStreamReadArgs(args[3:], true)
+	if !ok {
+		usageError(xReadGroupUsage)
+		return
+	}
+	var results []data.StreamReadResult
+	var err error
+	if r.block && r.timeout == 0 {
+		fmt.Println(blockForeverMsg)
+		return
+	}
+	if r.block {
+		results, err = data.DataGkvStream.BlockingReadGroup(group, consumer, r.keys, r.ids, r.count, r.noAck, r.timeout)
+	} else {
+		results, err = data.DataGkvStream.ReadGroup(group, consumer, r.keys, r.ids, r.count, r.noAck)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	printStreamReadResults(results)
+}
+
+// execXGroup 执行 xgroup 的各个子命令
+// @param fields []string 拆分后的命令
+func execXGroup(fields []string) {
+	const usage = "xgroup create \"key\" \"group\" id|$ [mkstream] | destroy \"key\" \"group\" | createconsumer \"key\" \"group\" \"consumer\" | delconsumer \"key\" \"group\" \"consumer\" | setid \"key\" \"group\" id|$"
+	if len(fields) < 4 {
+		usageError(usage)
+		return
+	}
+	key, group := fields[2], fields[3]
+	switch sub := strings.ToLower(fields[1]); {
+	case sub == "create" && (len(fields) == 5 || len(fields) == 6):
+		mkStream := len(fields) == 6
+		if mkStream && strings.ToLower(fields[5]) != "mkstream" {
+			usageError(usage)
+			return
+		}
+		if err := data.DataGkvStream.CreateGroup(key, group, fields[4], mkStream); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println("OK")
+	case sub == "destroy" && len(fields) == 4:
+		fmt.Println(boolToInt(data.DataGkvStream.DestroyGroup(key, group)))
+	case sub == "createconsumer" && len(fields) == 5:
+		created, err := data.DataGkvStream.CreateConsumer(key, group, fields[4])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(boolToInt(created))
+	case sub == "delconsumer" && len(fields) == 5:
+		n, err := data.DataGkvStream.DeleteConsumer(key, group, fields[4])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(n)
+	case sub == "setid" && len(fields) == 5:
+		if err := data.DataGkvStream.SetGroupID(key, group, fields[4]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println("OK")
+	default:
+		usageError(usage)
+	}
+}
+
+// execXPending 执行 xpending, 只有 key 与 group 时输出概要, 否则按区间列出待确认的条目
+// @param fields []string 拆分后的命令
+func execXPending(fields []string) {
+	if len(fields) == 3 {
+		summary, err := data.DataGkvStream.Pending(fields[1], fields[2])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if summary.Count == 0 {
+			printList([]string{"0", "(nil)", "(nil)", "(nil)"})
+			return
+		}
+		fmt.Printf("1) %d\n2) %s\n3) %s\n4)", summary.Count, summary.Min, summary.Max)
+		for _, c := range summary.Consumers {
+			fmt.Printf(" %s:%d", c.Name, c.Pending)
+		}
+		fmt.Println()
+		return
+	}
+	args := fields[3:]
+	opts := data.StreamPendingOptions{}
+	if len(args) > 0 && strings.ToLower(args[0]) == "idle" {
+		if len(args) < 2 {
+			usageError(xPendingUsage)
+			return
+		}
+		minIdle, ok := parseMilliseconds(args[1])
+		if !ok {
+			usageError(xPendingUsage)
+			return
+		}
+		opts.MinIdle = minIdle
+		args = args[2:]
+	}
+	if len(args) != 3 && len(args) != 4 {
+		usageError(xPendingUsage)
+		return
+	}
+	start, okStart, err := data.ParseStreamRangeID(args[0], false)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	end, okEnd, err := data.ParseStreamRangeID(args[1], true)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if opts.Count, err = strconv.Atoi(args[2]); err != nil || opts.Count < 0 {
+		usageError(xPendingUsage)
+		return
+	}
+	if len(args) == 4 {
+		opts.Consumer = args[3]
+	}
+	opts.Start, opts.End = start, end
+	entries, err := data.DataGkvStream.PendingRange(fields[1], fields[2], opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !okStart || !okEnd || len(entries) == 0 {
+		fmt.Println("(empty list or set)")
+		return
+	}
+	now := time.Now()
+	for i, p := range entries {
+		fmt.Printf("%d) %s %s %d %d\n", i+1, p.ID, p.Consumer, now.Sub(p.DeliveredAt).Milliseconds(), p.DeliveryCount)
+	}
+}
+
+// execXClaim 执行 xclaim
+// @param fields []string 拆分后的命令
+func execXClaim(fields []string) {
+	if len(fields) < 6 {
+		usageError(xClaimUsage)
+		return
+	}
+	minIdle, ok := parseMilliseconds(fields[4])
+	if !ok {
+		usageError(xClaimUsage)
+		return
+	}
+	args := fields[5:]
+	ids := []data.StreamID{}
+	for len(args) > 0 {
+		id, err := data.ParseStreamID(args[0])
+		if err != nil {
+			break
+		}
+		ids = append(ids, id)
+		args = args[1:]
+	}
+	if len(ids) == 0 {
+		usageError(xClaimUsage)
+		return
+	}
+	opts := data.StreamClaimOptions{}
+	for i := 0; i < len(args); i++ {
+		opt := strings.ToLower(args[i])
+		switch opt {
+		case "idle", "time", "retrycount":
+			if i+1 >= len(args) {
+				usageError(xClaimUsage)
+				return
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n < 0 || (opt == "idle" && n > maxMilliseconds) {
+				usageError(xClaimUsage)
+				return
+			}
+			switch opt {
+			case "idle":
+				opts.DeliveredAt = time.Now().Add(-time.Duration(n) * time.Millisecond)
+			case "time":
+				opts.DeliveredAt = time.UnixMilli(n)
+			default:
+				opts.SetRetryCount, opts.RetryCount = true, int(n)
+			}
+			i++
+		case "force":
+			opts.Force = true
+		case "justid":
+			opts.JustID = true
+		default:
+			usageError(xClaimUsage)
+			return
+		}
+	}
+	entries, err := data.DataGkvStream.Claim(fields[1], fields[2], fields[3], minIdle, ids, opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if opts.JustID {
+		printStreamIDs(entries)
+		return
+	}
+	printStreamEntries(entries, "")
+}
+
+// execXAutoClaim 执行 xautoclaim, 依次输出下次扫描的起点、被认领的条目与已删除的ID
+// @param fields []string 拆分后的命令
+func execXAutoClaim(fields []string) {
+	if len(fields) < 6 {
+		usageError(xAutoClaimUsage)
+		return
+	}
+	minIdle, ok := parseMilliseconds(fields[4])
+	if !ok {
+		usageError(xAutoClaimUsage)
+		return
+	}
+	start, ok, err := data.ParseStreamRangeID(fields[5], false)
+	if err != nil || !ok {
+		usageError(xAutoClaimUsage)
+		return
+	}
+	count, justID := 100, false
+	args := fields[6:]
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "count":
+			if i+1 >= len(args) {
+				usageError(xAutoClaimUsage)
+				return
+			}
+			if count, err = strconv.Atoi(args[i+1]); err != nil || count <= 0 || count > data.MaxAutoClaimCount {
+				usageError(xAutoClaimUsage)
+				return
+			}
+			i++
+		case "justid":
+			justID = true
+		default:
+			usageError(xAutoClaimUsage)
+			return
+		}
+	}
+	next, claimed, deleted, err := data.DataGkvStream.AutoClaim(fields[1], fields[2], fields[3], minIdle, start, count, justID)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("1) %s\n2)\n", next)
+	if justID {
+		printStreamIDs(claimed)
+	} else {
+		printStreamEntries(claimed, "   ")
+	}
+	fmt.Println("3)")
+	for i, id := range deleted {
+		fmt.Printf("   %d) %s\n", i+1, id)
+	}
+}
+
+// printStreamEntries 打印条目, 每行一个条目: ID 后跟字段名与字段值, 已删除的条目字段显示为 (nil)
+// @param entries []data.StreamEntry
+// @param indent string 行首缩进
+func printStreamEntries(entries []data.StreamEntry, indent string) {
+	if len(entries) == 0 {
+		fmt.Println(indent + "(empty list or set)")
+		return
+	}
+	for i, e := range entries {
+		if e.Fields == nil {
+			fmt.Printf("%s%d) %s (nil)\n", indent, i+1, e.ID)
+			continue
+		}
+		fmt.Printf("%s%d) %s %s\n", indent, i+1, e.ID, strings.Join(e.Fields, " "))
+	}
+}
+
+// printStreamIDs 只打印条目的ID
+// @param entries []data.StreamEntry
+func printStreamIDs(entries []data.StreamEntry) {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID.String()
+	}
+	printList(ids)
+}
+
+// printStreamReadResults 打印 xread 与 xreadgroup 的结果, 按流分组, 没有结果时为 (nil)
+// @param results []data.StreamReadResult
+func printStreamReadResults(results []data.StreamReadResult) {
+	if len(results) == 0 {
+		fmt.Println("(nil)")
+		return
+	}
+	for i, r := range results {
+		fmt.Printf("%d) %s\n", i+1, r.Key)
+		printStreamEntries(r.Entries, "   ")
+	}
+}
@@ -0,0 +1,574 @@
+package main
+
+import (
+	"fmt"
+	"gopherkv/data"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// execZSetCommand 执行有序集合相关命令
+// @param fields []string 拆分后的命令
+// @return bool 是否为有序集合命令
+func execZSetCommand(fields []string) bool {
+	switch strings.ToLower(fields[0]) {
+	case "zadd":
+		if len(fields) < 4 {
+			usageError(zaddUsage)
+			return true
+		}
+		execZAdd(fields[1], fields[2:])
+	case "zincrby":
+		if len(fields) != 4 {
+			usageError("zincrby \"key\" increment \"member\"")
+			return true
+		}
+		delta, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || math.IsNaN(delta) {
+			fmt.Println("分数必须为数字")
+			return true
+		}
+		score, _, err := data.DataGkvZSet.IncrBy(fields[1], fields[3], delta, data.ZAddFlags{})
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		fmt.Println(formatScore(score))
+	case "zmscore":
+		if len(fields) < 3 {
+			usageError("zmscore \"key\" \"member\" [\"member\" ...]")
+			return true
+		}
+		scores, found := data.DataGkvZSet.MScore(fields[1], fields[2:]...)
+		for i := range scores {
+			if found[i] {
+				fmt.Println(formatScore(scores[i]))
+			} else {
+				fmt.Println("(nil)")
+			}
+		}
+	case "zrandmember":
+		usage := "zrandmember \"key\" [count [withscores]]"
+		if len(fields) < 2 || len(fields) > 4 {
+			usageError(usage)
+			return true
+		}
+		if len(fields) == 2 {
+			if entries, _ := data.DataGkvZSet.RandMember(fields[1], 1); len(entries) > 0 {
+				fmt.Println(entries[0].Member)
+			} else {
+				fmt.Println("(nil)")
+			}
+			return true
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil || (len(fields) == 4 && strings.ToLower(fields[3]) != "withscores") {
+			usageError(usage)
+			return true
+		}
+		entries, err := data.DataGkvZSet.RandMember(fields[1], count)
+		if err != nil {
+			fmt.Println(err)
+			return true
+		}
+		printEntries(entries, len(fields) == 4)
+	case "zpopmin", "zpopmax":
+		name := strings.ToLower(fields[0])
+		if len(fields) != 2 && len(fields) != 3 {
+			usageError(name + " \"key\" [count]")
+			return true
+		}
+		count := 1
+		if len(fields) == 3 {
+			n, err := strconv.Atoi(fields[2])
+			if err != nil || n < 0 {
+				usageError(name + " \"key\" [count]")
+				return true
+			}
+			count = n
+		}
+		printEntries(data.DataGkvZSet.Pop(fields[1], count, name == "zpopmax"), true)
+	case "zrem":
+		if len(fields) < 3 {
+			usageError("zrem \"key\" \"member\" [\"member\" ...]")
+			return true
+		}
+		fmt.Println(data.DataGkvZSet.Remove(fields[1], fields[2:]...))
+	case "zscore":
+		if len(fields) != 3 {
+			usageError("zscore \"key\" \"member\"")
+			return true
+		}
+		if score, ok := data.DataGkvZSet.Score(fields[1], fields[2]); ok {
+			fmt.Println(formatScore(score))
+		} else {
+			fmt.Println("(nil)")
+		}
+	case "zrank", "zrevrank":
+		name := strings.ToLower(fields[0])
+		if len(fields) != 3 {
+			usageError(name + " \"key\" \"member\"")
+			return true
+		}
+		rank := data.DataGkvZSet.Rank(fields[1], fields[2])
+		if name == "zrevrank" {
+			rank = data.DataGkvZSet.RevRank(fields[1], fields[2])
+		}
+		if rank < 0 {
+			fmt.Println("(nil)")
+		} else {
+			fmt.Println(rank)
+		}
+	case "zcard":
+		if len(fields) != 2 {
+			usageError("zcard \"key\"")
+			return true
+		}
+		fmt.Println(data.DataGkvZSet.Cardinality(fields[1]))
+	case "zrange":
+		if len(fields) < 4 {
+			usageError(zrangeUsage)
+			return true
+		}
+		opts, withScores, err := parseZRangeArgs(fields[2:], true)
+		if err != nil {
+			fmt.Println(err)
+			usageError(zrangeUsage)
+			return true
+		}
+		printEntries(data.DataGkvZSet.Range(fields[1], opts), withScores)
+	case "zrangestore":
+		if len(fields) < 5 {
+			usageError(zrangestoreUsage)
+			return true
+		}
+		opts, _, err := parseZRangeArgs(fields[3:], false)
+		if err != nil {
+			fmt.Println(err)
+			usageError(zrangestoreUsage)
+			return true
+		}
+		fmt.Println(data.DataGkvZSet.RangeStore(fields[1], fields[2], opts))
+	case "zrangebyscore":
+		if len(fields) < 4 {
+			usageError(zrangebyscoreUsage)
+			return true
+		}
+		args := append([]string{fields[2], fields[3], "byscore"}, fields[4:]...)
+		opts, withScores, err := parseZRangeArgs(args, true)
+		if err != nil {
+			fmt.Println(err)
+			usageError(zrangebyscoreUsage)
+			return true
+		}
+		printEntries(data.DataGkvZSet.Range(fields[1], opts), withScores)
+	case "zcount", "zlexcount", "zremrangebyscore", "zremrangebylex", "zremrangebyrank":
+		name := strings.ToLower(fields[0])
+		usage := name + " \"key\" min max"
+		if name == "zremrangebyrank" {
+			usage = name + " \"key\" start stop"
+		}
+		if len(fields) != 4 {
+			usageError(usage)
+			return true
+		}
+		var by string
+		switch name {
+		case "zcount", "zremrangebyscore":
+			by = "byscore"
+		case "zlexcount", "zremrangebylex":
+			by = "bylex"
+		}
+		args := []string{fields[2], fields[3]}
+		if by != "" {
+			args = append(args, by)
+		}
+		opts, _, err := parseZRangeArgs(args, false)
+		if err != nil {
+			fmt.Println(err)
+			usageError(usage)
+			return true
+		}
+		if strings.HasPrefix(name, "zrem") {
+			fmt.Println(data.DataGkvZSet.RemoveRange(fields[1], opts))
+		} else {
+			fmt.Println(data.DataGkvZSet.Count(fields[1], opts))
+		}
+	case "bzpopmin", "bzpopmax":
+		name := strings.ToLower(fields[0])
+		if len(fields) < 3 {
+			usageError(name + " \"key\" [\"key\" ...] timeout")
+			return true
+		}
+		timeout, ok := parseBlockTimeout(fields[len(fields)-1])
+		if !ok {
+			usageError(name + " \"key\" [\"key\" ...] timeout")
+			return true
+		}
+		if timeout == 0 {
+			fmt.Println(blockForeverMsg)
+			return true
+		}
+		res, ok := data.DataGkvZSet.BlockingPop(fields[1:len(fields)-1], 1, name == "bzpopmax", timeout)
+		if !ok {
+			fmt.Println("(nil)")
+			return true
+		}
+		printList([]string{res.Key, res.Entries[0].Member, formatScore(res.Entries[0].Score)})
+	case "bzmpop":
+		keys, max, count, timeout, ok := parseBZMPopArgs(fields)
+		if !ok {
+			usageError(bzmpopUsage)
+			return true
+		}
+		if timeout == 0 {
+			fmt.Println(blockForeverMsg)
+			return true
+		}
+		res, ok := data.DataGkvZSet.BlockingPop(keys, count, max, timeout)
+		if !ok {
+			fmt.Println("(nil)")
+			return true
+		}
+		fmt.Println(res.Key)
+		printEntries(res.Entries, true)
+	case "zunion", "zinter", "zdiff":
+		name := strings.ToLower(fields[0])
+		keys, opts, withScores, ok := parseZCombineArgs(fields[1:], name != "zdiff", true)
+		if !ok {
+			usageError(zcombineUsage(name))
+			return true
+		}
+		switch name {
+		case "zunion":
+			printEntries(data.DataGkvZSet.Union(opts, keys...), withScores)
+		case "zinter":
+			printEntries(data.DataGkvZSet.Inter(opts, keys...), withScores)
+		default:
+			printEntries(data.DataGkvZSet.Diff(keys...), withScores)
+		}
+	case "zunionstore", "zinterstore", "zdiffstore":
+		name := strings.ToLower(fields[0])
+		if len(fields) < 4 {
+			usageError(zcombineUsage(name))
+			return true
+		}
+		keys, opts, _, ok := parseZCombineArgs(fields[2:], name != "zdiffstore", false)
+		if !ok {
+			usageError(zcombineUsage(name))
+			return true
+		}
+		switch name {
+		case "zunionstore":
+			fmt.Println(data.DataGkvZSet.UnionStore(fields[1], opts, keys...))
+		case "zinterstore":
+			fmt.Println(data.DataGkvZSet.InterStore(fields[1], opts, keys...))
+		default:
+			fmt.Println(data.DataGkvZSet.DiffStore(fields[1], keys...))
+		}
+	case "zintercard":
+		keys, limit, ok := parseInterCardArgs(fields)
+		if !ok {
+			usageError("zintercard numkeys \"key\" [\"key\" ...] [limit n]")
+			return true
+		}
+		fmt.Println(data.DataGkvZSet.InterCard(limit, keys...))
+	default:
+		return false
+	}
+	return true
+}
+
+const (
+	bzmpopUsage        = "bzmpop timeout numkeys \"key\" [\"key\" ...] min|max [count n]"
+	zaddUsage          = "zadd \"key\" [nx|xx] [gt|lt] [ch] [incr] score \"member\" [score \"member\" ...]"
+	zrangeUsage        = "zrange \"key\" start stop [byscore|bylex] [rev] [limit offset count] [withscores]"
+	zrangestoreUsage   = "zrangestore \"dst\" \"src\" start stop [byscore|bylex] [rev] [limit offset count]"
+	zrangebyscoreUsage = "zrangebyscore \"key\" min max [withscores] [limit offset count]"
+)
+
+// execZAdd 解析并执行 zadd 的选项与成员分数对
+// @param key string 集合名
+// @param args []string key 之后的参数
+func execZAdd(key string, args []string) {
+	flags := data.ZAddFlags{}
+	incr := false
+	i := 0
+loop:
+	for ; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "nx":
+			flags.NX = true
+		case "xx":
+			flags.XX = true
+		case "gt":
+			flags.GT = true
+		case "lt":
+			flags.LT = true
+		case "ch":
+			flags.CH = true
+		case "incr":
+			incr = true
+		default:
+			break loop
+		}
+	}
+	pairs := args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 || (incr && len(pairs) != 2) {
+		usageError(zaddUsage)
+		return
+	}
+	entries := make([]data.ZSetEntry, 0, len(pairs)/2)
+	for j := 0; j < len(pairs); j += 2 {
+		score, err := strconv.ParseFloat(pairs[j], 64)
+		if err != nil || math.IsNaN(score) {
+			fmt.Println("分数必须为数字")
+			return
+		}
+		entries = append(entries, data.ZSetEntry{Member: pairs[j+1], Score: score})
+	}
+	if incr {
+		score, ok, err := data.DataGkvZSet.IncrBy(key, entries[0].Member, entries[0].Score, flags)
+		switch {
+		case err != nil:
+			fmt.Println(err)
+		case !ok:
+			fmt.Println("(nil)")
+		default:
+			fmt.Println(formatScore(score))
+		}
+		return
+	}
+	count, err := data.DataGkvZSet.AddWithFlags(key, flags, entries...)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(count)
+}
+
+// blockForeverMsg 命令行是唯一的客户端, 没有其他客户端能唤醒一直等待的阻塞命令
+const blockForeverMsg = "命令行中阻塞超时时间不能为0, 没有其他客户端能唤醒一直等待的命令"
+
+// parseBlockTimeout 解析以秒为单位的阻塞超时时间, 支持小数, 超出 time.Duration 表示范围的值不合法
+// @param s string
+// @return time.Duration 0表示一直等待
+// @return bool 是否合法
+func parseBlockTimeout(s string) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil || seconds < 0 || math.IsNaN(seconds) || seconds >= math.MaxInt64/float64(time.Second) {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// parseBZMPopArgs 解析 bzmpop timeout numkeys key [key ...] min|max [count n]
+// @param fields []string 拆分后的命令
+// @return []string 集合名
+// @return bool 是否弹出最高分
+// @return int 弹出数量
+// @return time.Duration 超时时间
+// @return bool 参数是否合法
+func parseBZMPopArgs(fields []string) ([]string, bool, int, time.Duration, bool) {
+	if len(fields) < 5 {
+		return nil, false, 0, 0, false
+	}
+	timeout, ok := parseBlockTimeout(fields[1])
+	numKeys, err := strconv.Atoi(fields[2])
+	if !ok || err != nil || numKeys <= 0 || len(fields) < 4+numKeys {
+		return nil, false, 0, 0, false
+	}
+	keys := fields[3 : 3+numKeys]
+	var max bool
+	switch strings.ToLower(fields[3+numKeys]) {
+	case "min":
+	case "max":
+		max = true
+	default:
+		return nil, false, 0, 0, false
+	}
+	count := 1
+	rest := fields[4+numKeys:]
+	switch {
+	case len(rest) == 0:
+	case len(rest) == 2 && strings.ToLower(rest[0]) == "count":
+		count, err = strconv.Atoi(rest[1])
+		if err != nil || count <= 0 {
+			return nil, false, 0, 0, false
+		}
+	default:
+		return nil, false, 0, 0, false
+	}
+	return keys, max, count, timeout, true
+}
+
+// zcombineUsage 集合运算命令的用法
+// @param name string 命令名
+// @return string
+func zcombineUsage(name string) string {
+	usage := name
+	if strings.HasSuffix(name, "store") {
+		usage += " \"destination\""
+	}
+	usage += " numkeys \"key\" [\"key\" ...]"
+	if !strings.HasPrefix(name, "zdiff") {
+		usage += " [weights weight [weight ...]] [aggregate sum|min|max]"
+	}
+	if !strings.HasSuffix(name, "store") {
+		usage += " [withscores]"
+	}
+	return usage
+}
+
+// parseZCombineArgs 解析 numkeys key [key ...] [weights w ...] [aggregate sum|min|max] [withscores] 形式的参数
+// @param args []string 从 numkeys 开始的参数
+// @param allowWeights bool 是否允许 weights 与 aggregate
+// @param allowWithScores bool 是否允许 withscores
+// @return []string 输入集合
+// @return data.ZCombineOptions 权重与合并方式
+// @return bool 是否返回分数
+// @return bool 参数是否合法
+func parseZCombineArgs(args []string, allowWeights, allowWithScores bool) ([]string, data.ZCombineOptions, bool, bool) {
+	opts := data.ZCombineOptions{}
+	if len(args) < 2 {
+		return nil, opts, false, false
+	}
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil || numKeys <= 0 || len(args) < 1+numKeys {
+		return nil, opts, false, false
+	}
+	keys := args[1 : 1+numKeys]
+	withScores := false
+	for i := 1 + numKeys; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "weights":
+			if !allowWeights || i+numKeys >= len(args) {
+				return nil, opts, false, false
+			}
+			opts.Weights = make([]float64, numKeys)
+			for j := range numKeys {
+				w, err := strconv.ParseFloat(args[i+1+j], 64)
+				if err != nil || math.IsNaN(w) {
+					return nil, opts, false, false
+				}
+				opts.Weights[j] = w
+			}
+			i += numKeys
+		case "aggregate":
+			if !allowWeights || i+1 >= len(args) {
+				return nil, opts, false, false
+			}
+			switch strings.ToLower(args[i+1]) {
+			case "sum":
+				opts.Aggregate = data.ZAggregateSum
+			case "min":
+				opts.Aggregate = data.ZAggregateMin
+			case "max":
+				opts.Aggregate = data.ZAggregateMax
+			default:
+				return nil, opts, false, false
+			}
+			i++
+		case "withscores":
+			if !allowWithScores {
+				return nil, opts, false, false
+			}
+			withScores = true
+		default:
+			return nil, opts, false, false
+		}
+	}
+	return keys, opts, withScores, true
+}
+
+// parseZRangeArgs 解析 start stop [byscore|bylex] [rev] [limit offset count] [withscores] 形式的参数
+// byscore/bylex 配合 rev 使用时, 与 Redis 一致先写上界再写下界
+// @param args []string 区间及选项
+// @param allowWithScores bool 是否允许 withscores
+// @return data.ZRangeOptions 查询参数
+// @return bool 是否返回分数
+// @return error 参数错误
+func parseZRangeArgs(args []string, allowWithScores bool) (data.ZRangeOptions, bool, error) {
+	opts := data.ZRangeOptions{}
+	withScores := false
+	for i := 2; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "byscore":
+			opts.By = data.ZRangeByScore
+		case "bylex":
+			opts.By = data.ZRangeByLex
+		case "rev":
+			opts.Rev = true
+		case "withscores":
+			if !allowWithScores {
+				return opts, false, fmt.Errorf("不支持 withscores")
+			}
+			withScores = true
+		case "limit":
+			if i+2 >= len(args) {
+				return opts, false, fmt.Errorf("limit 需要 offset 与 count")
+			}
+			offset, err1 := strconv.Atoi(args[i+1])
+			count, err2 := strconv.Atoi(args[i+2])
+			if err1 != nil || err2 != nil {
+				return opts, false, fmt.Errorf("limit 的 offset 与 count 必须为整数")
+			}
+			opts.Limit, opts.Offset, opts.Count = true, offset, count
+			i += 2
+		default:
+			return opts, false, fmt.Errorf("未知选项: %s", args[i])
+		}
+	}
+	if opts.Limit && opts.By == data.ZRangeByRank {
+		return opts, false, fmt.Errorf("limit 只能与 byscore 或 bylex 一起使用")
+	}
+	if withScores && opts.By == data.ZRangeByLex {
+		return opts, false, fmt.Errorf("withscores 不能与 bylex 一起使用")
+	}
+	lo, hi := args[0], args[1]
+	if opts.Rev && opts.By != data.ZRangeByRank {
+		lo, hi = hi, lo
+	}
+	var err error
+	switch opts.By {
+	case data.ZRangeByScore:
+		if opts.Min, err = data.ParseScoreBound(lo); err == nil {
+			opts.Max, err = data.ParseScoreBound(hi)
+		}
+	case data.ZRangeByLex:
+		if opts.LexMin, err = data.ParseLexBound(lo); err == nil {
+			opts.LexMax, err = data.ParseLexBound(hi)
+		}
+	default:
+		if opts.Start, err = strconv.Atoi(lo); err == nil {
+			opts.Stop, err = strconv.Atoi(hi)
+		}
+		if err != nil {
+			err = fmt.Errorf("排名必须为整数")
+		}
+	}
+	return opts, withScores, err
+}
+
+// printEntries 打印有序集合成员, withScores 为 true 时每个成员后跟分数
+// @param entries []data.ZSetEntry
+// @param withScores bool
+func printEntries(entries []data.ZSetEntry, withScores bool) {
+	items := make([]string, 0, 2*len(entries))
+	for _, e := range entries {
+		items = append(items, e.Member)
+		if withScores {
+			items = append(items, formatScore(e.Score))
+		}
+	}
+	printList(items)
+}
+
+// formatScore 格式化分数输出
+// @param score float64
+// @return string
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}